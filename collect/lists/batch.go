@@ -1,21 +1,44 @@
 package lists
 
 import (
+	"context"
 	"errors"
 	"sync"
 
 	lynxSync "github.com/Tangerg/lynx/pkg/sync"
+	"github.com/karosown/katool-go/pool"
 )
 
 type Batch[T any, RT ~[]T] struct {
 	SplitData []RT
 }
 
+// AvgPartition 平均分成 totalPage 片，各片大小最多相差 1；totalPage 大于元素数时每片一个元素，不大于 0 时整体作为一片
+// AvgPartition splits datas into totalPage parts whose sizes differ by at most one; with totalPage larger
+// than len(datas) every part holds one element, and a non-positive totalPage yields a single part
 func AvgPartition[T any](datas []T, totalPage int) Batch[T, []T] {
 	total := len(datas)
-	return Partition(datas, total/totalPage)
+	totalPage = min(max(totalPage, 1), total)
+	splitData := make([][]T, 0, totalPage)
+	for i, start := 0, 0; i < totalPage; i++ {
+		size := total / totalPage
+		if i < total%totalPage {
+			size++
+		}
+		splitData = append(splitData, datas[start:start+size])
+		start += size
+	}
+	return Batch[T, []T]{
+		SplitData: splitData,
+	}
 }
+
+// Partition 按 pageSize 分片，pageSize 不大于 0 时整体作为一片
+// Partition splits datas into parts of pageSize, a non-positive pageSize yields a single part
 func Partition[T any](datas []T, pageSize int) Batch[T, []T] {
+	if pageSize <= 0 {
+		pageSize = max(len(datas), 1)
+	}
 	splitData := make([][]T, 0)
 	for i := 0; i < len(datas); i += pageSize {
 		splitData = append(splitData, datas[i:min(i+pageSize, len(datas))])
@@ -26,21 +49,27 @@ func Partition[T any](datas []T, pageSize int) Batch[T, []T] {
 	}
 }
 
+// ForEach 处理每个分片，async 时并发度由 limiter 限制；所有错误用 errors.Join 合并。
+// 需要取消、快速失败、重试或有序结果时使用 ForEachContext 或 MapBatches
+// ForEach processes every batch, concurrently bounded by limiter when async; all errors are combined with
+// errors.Join. Use ForEachContext or MapBatches for cancellation, fail-fast, retries or ordered results
 func (b Batch[T, RT]) ForEach(solve func(pos int, automicDatas []T) error, async bool, limiter *lynxSync.Limiter) error {
 	errs := make([]error, 0)
 	if async {
+		var mu sync.Mutex
 		countDownLatch := &sync.WaitGroup{}
-		//countDownLatch.Add(len(b.SplitData))
 		for i, data := range b.SplitData {
 			limiter.Acquire()
 			countDownLatch.Add(1)
 			go func(limit *lynxSync.Limiter, datas []T, pos int) {
 				defer countDownLatch.Done()
+				defer limit.Release()
 				err := solve(pos, datas)
 				if err != nil {
+					mu.Lock()
 					errs = append(errs, err)
+					mu.Unlock()
 				}
-				defer limit.Release()
 			}(limiter, data, i)
 		}
 		countDownLatch.Wait()
@@ -55,3 +84,48 @@ func (b Batch[T, RT]) ForEach(solve func(pos int, automicDatas []T) error, async
 	err := errors.Join(errs...)
 	return err
 }
+
+// ForEachWithPool 在协程池中并发处理每个分片并等待全部完成；池已满时在当前协程执行该分片，
+// 因此可以在池内任务中安全调用。panic 被恢复为 *pool.PanicError，所有错误用 errors.Join 合并
+// ForEachWithPool processes every batch on the pool and waits for all of them; when the pool is full
+// the batch runs on the calling goroutine, so it is safe to call from inside a pool task. Panics are
+// recovered as *pool.PanicError and all errors are combined with errors.Join
+func (b Batch[T, RT]) ForEachWithPool(ctx context.Context, p *pool.Pool, solve func(pos int, automicDatas []T) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	errs := make([]error, 0)
+	futures := make([]*pool.Future[struct{}], 0, len(b.SplitData))
+	for i, data := range b.SplitData {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		run := func(context.Context) (struct{}, error) {
+			return struct{}{}, solve(i, data)
+		}
+		if f, ok := pool.TrySubmit(ctx, p, run); ok {
+			futures = append(futures, f)
+			continue
+		}
+		if err := callBatch(run); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, f := range futures {
+		if _, err := f.Wait(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func callBatch(run func(context.Context) (struct{}, error)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = pool.NewPanicError(r)
+		}
+	}()
+	_, err = run(context.Background())
+	return err
+}
@@ -0,0 +1,232 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/karosown/katool-go/pool"
+)
+
+// BatchError 某个分片的错误
+// BatchError is the error of one batch
+type BatchError struct {
+	Pos int
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch %d: %v", e.Pos, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Progress 进度，每个分片结束（成功或最终失败）后回调一次
+// Progress is reported once every time a batch finishes, successfully or after its last attempt
+type Progress struct {
+	// Pos 刚结束的分片 / the batch that just finished
+	Pos int
+	// Err 该分片的错误 / the batch's error
+	Err    error
+	Done   int
+	Failed int
+	Total  int
+}
+
+// BatchOption 分片执行选项
+// BatchOption configures batch execution
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	pool        *pool.Pool
+	failFast    bool
+	attempts    int
+	backoff     func(attempt int) time.Duration
+	progress    func(Progress)
+}
+
+// WithConcurrency 最多同时处理的分片数，默认 1 即顺序执行
+// WithConcurrency sets how many batches run at once, 1 by default which runs them in order
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) { o.concurrency = max(n, 1) }
+}
+
+// WithBatchPool 在协程池中处理分片，池已满时在当前协程执行；设置后 WithConcurrency 不再生效
+// WithBatchPool runs batches on the pool, falling back to the calling goroutine when it is full;
+// WithConcurrency no longer applies
+func WithBatchPool(p *pool.Pool) BatchOption {
+	return func(o *batchOptions) { o.pool = p }
+}
+
+// WithFailFast 任一分片失败后取消其余分片并返回该错误；默认处理全部分片并合并所有错误
+// WithFailFast cancels the remaining batches on the first failure and returns that error; by default every
+// batch runs and all errors are combined
+func WithFailFast() BatchOption {
+	return func(o *batchOptions) { o.failFast = true }
+}
+
+// WithBatchRetry 分片失败时重试，maxAttempts 含第一次；backoff 为第几次失败后的等待时间，可直接使用 resilience 包的退避策略
+// WithBatchRetry retries failed batches, maxAttempts includes the first; backoff returns the wait after the
+// n-th failure, and the backoffs of the resilience package can be passed directly
+func WithBatchRetry(maxAttempts int, backoff func(attempt int) time.Duration) BatchOption {
+	return func(o *batchOptions) {
+		o.attempts = max(maxAttempts, 1)
+		o.backoff = backoff
+	}
+}
+
+// WithProgress 进度回调，串行调用
+// WithProgress sets a progress callback, calls are serialized
+func WithProgress(progress func(Progress)) BatchOption {
+	return func(o *batchOptions) { o.progress = progress }
+}
+
+// MapBatches 处理每个分片并按分片顺序返回结果。ctx 取消后不再开始新的分片；
+// 分片的错误包装为 *BatchError，panic 恢复为 *pool.PanicError。快速失败模式下返回第一个错误，
+// 否则返回所有错误的合并；未执行或失败的分片对应零值
+// MapBatches processes every batch and returns the results in batch order. No new batch starts once ctx is
+// done. Batch errors are wrapped in *BatchError and panics recovered as *pool.PanicError. In fail-fast mode
+// the first error is returned, otherwise all errors are combined; batches that failed or never ran yield zero values
+func MapBatches[T any, R any](ctx context.Context, b Batch[T, []T], fn func(ctx context.Context, pos int, part []T) (R, error), opts ...BatchOption) ([]R, error) {
+	o := batchOptions{concurrency: 1, attempts: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	total := len(b.SplitData)
+	results := make([]R, total)
+	errs := make([]error, total)
+	var (
+		mu       sync.Mutex
+		done     int
+		failed   int
+		firstErr error
+	)
+	run := func(pos int) {
+		r, err := callWithRetry(ctx, &o, fn, pos, b.SplitData[pos])
+		if err != nil {
+			err = &BatchError{Pos: pos, Err: err}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if err == nil {
+			results[pos] = r
+		} else {
+			errs[pos] = err
+			failed++
+			if firstErr == nil {
+				firstErr = err
+				if o.failFast {
+					cancel(err)
+				}
+			}
+		}
+		if o.progress != nil {
+			o.progress(Progress{Pos: pos, Err: err, Done: done, Failed: failed, Total: total})
+		}
+	}
+
+	var wg sync.WaitGroup
+	futures := make([]*pool.Future[struct{}], 0)
+	sem := make(chan struct{}, o.concurrency)
+	for pos := range b.SplitData {
+		if ctx.Err() != nil {
+			break
+		}
+		if o.pool != nil {
+			f, ok := pool.TrySubmit(ctx, o.pool, func(context.Context) (struct{}, error) {
+				run(pos)
+				return struct{}{}, nil
+			})
+			if ok {
+				futures = append(futures, f)
+			} else {
+				run(pos)
+			}
+			continue
+		}
+		if o.concurrency == 1 {
+			run(pos)
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			run(pos)
+		}()
+	}
+	for _, f := range futures {
+		_, _ = f.Wait()
+	}
+	wg.Wait()
+
+	if o.failFast && firstErr != nil {
+		return results, firstErr
+	}
+	err := errors.Join(errs...)
+	if parent.Err() != nil && done < total {
+		err = errors.Join(err, parent.Err())
+	}
+	return results, err
+}
+
+// callWithRetry 带重试地处理一个分片，panic 恢复为 *pool.PanicError 且不重试
+// callWithRetry processes one batch with retries; a panic is recovered as *pool.PanicError and not retried
+func callWithRetry[T any, R any](ctx context.Context, o *batchOptions, fn func(ctx context.Context, pos int, part []T) (R, error), pos int, part []T) (R, error) {
+	for attempt := 1; ; attempt++ {
+		r, err := callPart(ctx, fn, pos, part)
+		var pe *pool.PanicError
+		if err == nil || attempt >= o.attempts || ctx.Err() != nil || errors.As(err, &pe) {
+			return r, err
+		}
+		if o.backoff != nil {
+			timer := time.NewTimer(o.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return r, err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+func callPart[T any, R any](ctx context.Context, fn func(ctx context.Context, pos int, part []T) (R, error), pos int, part []T) (r R, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = pool.NewPanicError(v)
+		}
+	}()
+	return fn(ctx, pos, part)
+}
+
+// ForEachContext 处理每个分片，选项与错误语义同 MapBatches
+// ForEachContext processes every batch with the same options and error semantics as MapBatches
+func (b Batch[T, RT]) ForEachContext(ctx context.Context, solve func(ctx context.Context, pos int, part []T) error, opts ...BatchOption) error {
+	parts := make([][]T, len(b.SplitData))
+	for i, part := range b.SplitData {
+		parts[i] = part
+	}
+	_, err := MapBatches(ctx, Batch[T, []T]{SplitData: parts}, func(ctx context.Context, pos int, part []T) (struct{}, error) {
+		return struct{}{}, solve(ctx, pos, part)
+	}, opts...)
+	return err
+}
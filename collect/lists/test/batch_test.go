@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	lynx "github.com/Tangerg/lynx/pkg/sync"
+	"github.com/karosown/katool-go/collect/lists"
+	"github.com/karosown/katool-go/pool"
+	"github.com/karosown/katool-go/resilience"
+	"github.com/stretchr/testify/assert"
+)
+
+func ints(n int) []int {
+	datas := make([]int, n)
+	for i := range datas {
+		datas[i] = i
+	}
+	return datas
+}
+
+func sizes(b lists.Batch[int, []int]) []int {
+	res := make([]int, 0, len(b.SplitData))
+	for _, part := range b.SplitData {
+		res = append(res, len(part))
+	}
+	return res
+}
+
+// 测试平均分片的边界情况
+func TestAvgPartition(t *testing.T) {
+	assert.Equal(t, []int{4, 3, 3}, sizes(lists.AvgPartition(ints(10), 3)))
+	assert.Equal(t, []int{1, 1, 1}, sizes(lists.AvgPartition(ints(3), 10)))
+	assert.Equal(t, []int{5}, sizes(lists.AvgPartition(ints(5), 0)))
+	assert.Equal(t, []int{5}, sizes(lists.AvgPartition(ints(5), -1)))
+	assert.Empty(t, lists.AvgPartition(ints(0), 4).SplitData)
+	assert.Equal(t, []int{5}, sizes(lists.Partition(ints(5), 0)))
+	assert.Equal(t, ints(10), append(append(lists.AvgPartition(ints(10), 3).SplitData[0],
+		lists.AvgPartition(ints(10), 3).SplitData[1]...), lists.AvgPartition(ints(10), 3).SplitData[2]...))
+}
+
+// 测试 ForEach 并发收集错误（配合 -race）
+func TestForEachErrors(t *testing.T) {
+	err := lists.Partition(ints(100), 1).ForEach(func(pos int, part []int) error {
+		return errors.New("fail")
+	}, true, lynx.NewLimiter(8))
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 100)
+}
+
+// 测试结果按分片顺序返回
+func TestMapBatchesOrdered(t *testing.T) {
+	b := lists.Partition(ints(100), 10)
+	var progress []lists.Progress
+	var mu sync.Mutex
+	sums, err := lists.MapBatches(context.Background(), b, func(ctx context.Context, pos int, part []int) (int, error) {
+		time.Sleep(time.Duration(10-pos) * time.Millisecond)
+		sum := 0
+		for _, v := range part {
+			sum += v
+		}
+		return sum, nil
+	}, lists.WithConcurrency(4), lists.WithProgress(func(p lists.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, p)
+	}))
+	assert.NoError(t, err)
+	for i, sum := range sums {
+		assert.Equal(t, 100*i+45, sum)
+	}
+	assert.Len(t, progress, 10)
+	for i, p := range progress {
+		assert.Equal(t, i+1, p.Done)
+		assert.Equal(t, 10, p.Total)
+	}
+}
+
+// 测试收集全部错误与快速失败
+func TestMapBatchesErrors(t *testing.T) {
+	b := lists.Partition(ints(10), 1)
+	boom := errors.New("boom")
+	fn := func(ctx context.Context, pos int, part []int) (int, error) {
+		if pos%3 == 0 {
+			return 0, boom
+		}
+		return pos, nil
+	}
+	res, err := lists.MapBatches(context.Background(), b, fn)
+	assert.ErrorIs(t, err, boom)
+	var be *lists.BatchError
+	assert.ErrorAs(t, err, &be)
+	assert.Equal(t, 0, be.Pos)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+	assert.Equal(t, []int{0, 1, 2, 0, 4, 5, 0, 7, 8, 0}, res)
+
+	var ran atomic.Int32
+	_, err = lists.MapBatches(context.Background(), b, func(ctx context.Context, pos int, part []int) (int, error) {
+		ran.Add(1)
+		return fn(ctx, pos, part)
+	}, lists.WithFailFast())
+	assert.EqualError(t, err, "batch 0: boom")
+	assert.Equal(t, int32(1), ran.Load())
+
+	// 并发快速失败：其余分片的ctx被取消 / concurrent fail-fast cancels the other batches' ctx
+	var cancelled atomic.Int32
+	_, err = lists.MapBatches(context.Background(), lists.Partition(ints(4), 1), func(ctx context.Context, pos int, part []int) (int, error) {
+		if pos == 0 {
+			time.Sleep(10 * time.Millisecond)
+			return 0, boom
+		}
+		<-ctx.Done()
+		cancelled.Add(1)
+		return 0, ctx.Err()
+	}, lists.WithConcurrency(4), lists.WithFailFast())
+	assert.EqualError(t, err, "batch 0: boom")
+	assert.Equal(t, int32(3), cancelled.Load())
+
+	_, err = lists.MapBatches(context.Background(), b, func(ctx context.Context, pos int, part []int) (int, error) {
+		panic("oops")
+	}, lists.WithFailFast())
+	var pe *pool.PanicError
+	assert.ErrorAs(t, err, &pe)
+}
+
+// 测试分片重试与取消
+func TestMapBatchesRetryAndCancel(t *testing.T) {
+	attempts := make([]int, 3)
+	res, err := lists.MapBatches(context.Background(), lists.Partition(ints(3), 1), func(ctx context.Context, pos int, part []int) (int, error) {
+		attempts[pos]++
+		if attempts[pos] <= pos {
+			return 0, errors.New("flaky")
+		}
+		return part[0] * 10, nil
+	}, lists.WithBatchRetry(3, resilience.Constant(time.Millisecond)))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 10, 20}, res)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var ran atomic.Int32
+	err = lists.Partition(ints(10), 1).ForEachContext(ctx, func(ctx context.Context, pos int, part []int) error {
+		if ran.Add(1) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(3), ran.Load())
+}
+
+// 测试在协程池中执行
+func TestMapBatchesWithPool(t *testing.T) {
+	p := pool.New(pool.WithWorkers(2), pool.WithQueueSize(1))
+	defer p.Shutdown(context.Background())
+	res, err := lists.MapBatches(context.Background(), lists.AvgPartition(ints(100), 8), func(ctx context.Context, pos int, part []int) (int, error) {
+		return len(part), nil
+	}, lists.WithBatchPool(p))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{13, 13, 13, 13, 12, 12, 12, 12}, res)
+}
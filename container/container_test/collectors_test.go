@@ -0,0 +1,52 @@
+package container_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/container/stream/collectors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectors(t *testing.T) {
+	users := make([]user, 0, 100)
+	for i := 0; i < 100; i++ {
+		users = append(users, user{Name: "u" + strconv.Itoa(i), Age: i, Class: "c" + strconv.Itoa(i%3)})
+	}
+	for _, s := range []*stream.Stream[user, []user]{stream.ToStream(&users), stream.ToStream(&users).ParallelWithSetting(func(int) int { return 7 }, 8)} {
+		names := collectors.Collect(stream.Map(s.Sub(0, 3), func(u user) string { return u.Name }), collectors.Joining(",", "[", "]"))
+		assert.Equal(t, "[u0,u1,u2]", names)
+
+		assert.Equal(t, int64(100), collectors.Collect(s, collectors.Counting[user]()))
+		assert.Equal(t, 4950, collectors.Collect(s, collectors.Summing(func(u user) int { return u.Age })))
+		assert.Equal(t, 49.5, collectors.Collect(s, collectors.Averaging(func(u user) int { return u.Age })))
+
+		stats := collectors.Collect(s, collectors.SummaryStatistics(func(u user) int { return u.Age }))
+		assert.Equal(t, int64(100), stats.Count)
+		assert.Equal(t, 0.0, stats.Min)
+		assert.Equal(t, 99.0, stats.Max)
+		assert.InDelta(t, 49.5, stats.Median(), 1e-9)
+		assert.InDelta(t, 89.1, stats.Percentile(90), 1e-9)
+
+		parts := collectors.Collect(s, collectors.PartitioningBy(func(u user) bool { return u.Age >= 50 }, collectors.Counting[user]()))
+		assert.Equal(t, map[bool]int64{true: 50, false: 50}, parts)
+
+		groups := collectors.Collect(s, collectors.GroupingBy(func(u user) string { return u.Class },
+			collectors.Mapping(func(u user) int { return u.Age }, collectors.ToList[int]())))
+		assert.Equal(t, 34, len(groups["c0"]))
+		assert.Equal(t, []int{1, 4, 7}, groups["c1"][:3])
+
+		sorted := collectors.Collect(s, collectors.ToSortedMap(func(u user) string { return u.Class },
+			func(u user) int { return 1 }, func(a, b int) int { return a + b }))
+		assert.Equal(t, []string{"c0", "c1", "c2"}, sorted.SortedKeys())
+		count, _ := sorted.Get("c2")
+		assert.Equal(t, 33, count)
+
+		minMax := collectors.Collect(s, collectors.Teeing(collectors.Counting[user](),
+			collectors.Summing(func(u user) int { return u.Age }), func(c int64, sum int) float64 { return float64(sum) / float64(c) }))
+		assert.Equal(t, 49.5, minMax)
+	}
+	lazy := stream.ToLazyStream(&users).Limit(10)
+	assert.Equal(t, int64(10), collectors.CollectLazy(lazy, collectors.Counting[user]()))
+}
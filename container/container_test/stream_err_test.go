@@ -0,0 +1,65 @@
+package container_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapErrFailFast(t *testing.T) {
+	arr := make([]int, 200)
+	for i := range arr {
+		arr[i] = i
+	}
+	boom := errors.New("boom")
+	var calls atomic.Int64
+	s := stream.ToStream(&arr).ParallelWithSetting(func(int) int { return 10 }, 4)
+	_, err := stream.MapErr(context.Background(), s, func(ctx context.Context, i int) (int, error) {
+		calls.Add(1)
+		if i == 5 {
+			return 0, boom
+		}
+		time.Sleep(time.Millisecond)
+		return i * 2, nil
+	})
+	assert.ErrorIs(t, err, boom)
+	var elementErr *stream.ElementError
+	assert.True(t, errors.As(err, &elementErr))
+	assert.Equal(t, 5, elementErr.Index)
+	assert.Less(t, calls.Load(), int64(200))
+
+	res, err := stream.MapErr(context.Background(), s, func(ctx context.Context, i int) (int, error) {
+		return i * 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 398, res.ToList()[199])
+}
+
+func TestErrCollectAllAndPanic(t *testing.T) {
+	arr := []int{1, 2, 3, 4, 5, 6}
+	s := stream.ToStream(&arr).Parallel()
+	filtered, err := s.FilterErr(context.Background(), func(ctx context.Context, i int) (bool, error) {
+		if i == 3 {
+			panic("bad element")
+		}
+		if i == 4 {
+			return false, errors.New("four")
+		}
+		return i%2 == 0, nil
+	}, stream.WithCollectAllErrors())
+	assert.Error(t, err)
+	var panicErr *stream.PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Contains(t, err.Error(), "four")
+	assert.Equal(t, []int{2, 6}, filtered.ToList())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = stream.ToStream(&arr).ForEachErr(ctx, func(ctx context.Context, i int) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,130 @@
+package container_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazyShortCircuit(t *testing.T) {
+	evaluated := 0
+	res := stream.Iterate(1, func(i int) int { return i + 1 }).
+		Peek(func(int) { evaluated++ }).
+		Filter(func(i int) bool { return i%2 == 0 }).
+		Limit(3).
+		ToList()
+	assert.Equal(t, []int{2, 4, 6}, res)
+	assert.Equal(t, 6, evaluated)
+
+	evaluated = 0
+	arr := make([]int, 1000000)
+	for i := range arr {
+		arr[i] = i
+	}
+	first := stream.ToLazyStream(&arr).Peek(func(int) { evaluated++ }).
+		Filter(func(i int) bool { return i > 10 }).FindFirst()
+	assert.Equal(t, 11, first.Get())
+	assert.Equal(t, 12, evaluated)
+	assert.True(t, stream.ToLazyStream(&arr).AnyMatch(func(i int) bool { return i == 5 }))
+	assert.False(t, stream.ToLazyStream(&arr).AllMatch(func(i int) bool { return i < 5 }))
+}
+
+func TestLazySources(t *testing.T) {
+	ch := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+	doubled := stream.LazyMap(stream.FromChan(ch), func(i int) string { return strings.Repeat("a", i) }).Skip(1).ToList()
+	assert.Equal(t, []string{"a", "aa", "aaa", "aaaa"}, doubled)
+
+	lines := stream.FromLines(strings.NewReader("a\nb\nc")).Count()
+	assert.Equal(t, int64(3), lines)
+
+	arr := []int{1, 2, 3}
+	sum := stream.ToStream(&arr).Lazy().Reduce(0, func(acc, i int) int { return acc + i })
+	assert.Equal(t, 6, sum)
+	flat := stream.LazyFlatMap(stream.ToLazyStream(&arr), func(i int) *stream.LazyStream[int] {
+		return stream.Iterate(i, func(x int) int { return x }).Limit(i)
+	}).Limit(4).ToList()
+	assert.Equal(t, []int{1, 2, 2, 3}, flat)
+	assert.Equal(t, int64(3), stream.ToLazyStream(&arr).Collect().Count())
+}
+
+// errReader 读完数据后返回错误
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err == io.EOF {
+		return n, e.err
+	}
+	return n, err
+}
+
+// sliceCursor 遍历 JSON 文档，结束后返回 err
+type sliceCursor struct {
+	docs   []string
+	cur    string
+	err    error
+	closed bool
+}
+
+func (c *sliceCursor) Next(context.Context) bool {
+	if len(c.docs) == 0 {
+		return false
+	}
+	c.cur, c.docs = c.docs[0], c.docs[1:]
+	return true
+}
+
+func (c *sliceCursor) Decode(val any) error { return json.Unmarshal([]byte(c.cur), val) }
+func (c *sliceCursor) Err() error           { return c.err }
+func (c *sliceCursor) Close(context.Context) error {
+	c.closed = true
+	return nil
+}
+
+func TestLazySourceErrors(t *testing.T) {
+	readErr := errors.New("read failed")
+	lines := stream.FromLines(&errReader{r: strings.NewReader("a\nb\n"), err: readErr})
+	upper := lines.Map(strings.ToUpper).Filter(func(s string) bool { return s != "" })
+	assert.Equal(t, []string{"A", "B"}, upper.ToList())
+	assert.ErrorIs(t, upper.Err(), readErr)
+	assert.ErrorIs(t, lines.Err(), readErr)
+
+	ok := stream.FromLines(strings.NewReader("a\nb"))
+	assert.Equal(t, int64(2), ok.Count())
+	assert.NoError(t, ok.Err())
+
+	cursorErr := errors.New("cursor failed")
+	cursor := &sliceCursor{docs: []string{"1", "x", "3"}, err: cursorErr}
+	nums := stream.FromCursor[int](context.Background(), cursor)
+	assert.Equal(t, []int{1, 3}, nums.ToList())
+	assert.True(t, cursor.closed)
+	// 解码错误先于游标错误 / the decode error came before the cursor error
+	var syntaxErr *json.SyntaxError
+	assert.ErrorAs(t, nums.Err(), &syntaxErr)
+
+	cursor = &sliceCursor{docs: []string{"1", "2"}, err: cursorErr}
+	nums = stream.FromCursor[int](context.Background(), cursor)
+	assert.Equal(t, int64(2), nums.Count())
+	assert.ErrorIs(t, nums.Err(), cursorErr)
+
+	// 子流的错误同样可见 / errors of inner streams are visible as well
+	arr := []int{1, 2}
+	flat := stream.LazyFlatMap(stream.ToLazyStream(&arr), func(i int) *stream.LazyStream[string] {
+		return stream.FromLines(&errReader{r: strings.NewReader("x"), err: readErr})
+	})
+	assert.Equal(t, []string{"x", "x"}, flat.ToList())
+	assert.ErrorIs(t, flat.Err(), readErr)
+}
@@ -0,0 +1,39 @@
+package container_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedMap(t *testing.T) {
+	arr := make([]int, 1000)
+	for i := range arr {
+		arr[i] = i
+	}
+	for _, s := range []*stream.Stream[int, []int]{stream.ToStream(&arr), stream.ToStream(&arr).Parallel()} {
+		strs := stream.Map(s, strconv.Itoa).ToList()
+		assert.Equal(t, 1000, len(strs))
+		assert.Equal(t, "999", strs[999])
+		assert.Equal(t, "500", strs[500])
+
+		sum := stream.Reduce(s, 10, func(acc, i int) int { return acc + i }, func(a, b int) int { return a + b })
+		assert.Equal(t, 10+999*1000/2, sum)
+
+		groups := stream.GroupBy(s, func(i int) bool { return i%2 == 0 })
+		assert.Equal(t, 500, len(groups[true]))
+		assert.Equal(t, []int{1, 3, 5}, groups[false][:3])
+
+		m := stream.ToMap(s, func(i int) int { return i % 10 }, func(i int) int { return i })
+		assert.Equal(t, 10, len(m))
+		assert.Equal(t, 999, m[9])
+
+		flat := stream.FlatMap(s.Sub(0, 3), func(i int) *stream.Stream[string, []string] {
+			list := []string{strconv.Itoa(i), strconv.Itoa(i)}
+			return stream.ToStream(&list)
+		}).ToList()
+		assert.Equal(t, []string{"0", "0", "1", "1", "2", "2"}, flat)
+	}
+}
@@ -0,0 +1,34 @@
+package container_test
+
+import (
+	"testing"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowOperators(t *testing.T) {
+	arr := []int{1, 2, 3, 4, 5, 6, 7}
+	for _, s := range []*stream.Stream[int, []int]{stream.ToStream(&arr), stream.ToStream(&arr).Parallel()} {
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}, stream.Chunk(s, 3).ToList())
+		assert.Equal(t, [][]int{{1, 2, 3}, {3, 4, 5}, {5, 6, 7}}, stream.SlidingWindow(s, 3, 2).ToList())
+		assert.Equal(t, []int{1, 2}, s.TakeWhile(func(i int) bool { return i < 3 }).ToList())
+		assert.Equal(t, []int{3, 4, 5, 6, 7}, s.DropWhile(func(i int) bool { return i < 3 }).ToList())
+		assert.Equal(t, []int{1, 3, 6, 10, 15, 21, 28}, stream.Scan(s, 0, func(acc, i int) int { return acc + i }).ToList())
+
+		other := []string{"a", "b", "c"}
+		zipped := stream.Zip(s, stream.ToStream(&other)).ToList()
+		assert.Equal(t, 3, len(zipped))
+		assert.Equal(t, stream.Pair[int, string]{First: 3, Second: "c"}, zipped[2])
+
+		short := []int{10, 20}
+		assert.Equal(t, []int{1, 10, 2, 20, 3, 4, 5, 6, 7}, s.Interleave(stream.ToStream(&short)).ToList())
+		enumerated := stream.Enumerate(s).ToList()
+		assert.Equal(t, stream.Indexed[int]{Index: 6, Value: 7}, enumerated[6])
+
+		sum := 0
+		s.Sub(0, 3).UnParallel().Peek(func(i int) { sum += i })
+		assert.Equal(t, 6, sum)
+	}
+	assert.Equal(t, []int{1, 2}, stream.Iterate(1, func(i int) int { return i + 1 }).TakeWhile(func(i int) bool { return i < 3 }).ToList())
+}
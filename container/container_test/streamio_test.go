@@ -0,0 +1,198 @@
+package container_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/container/stream/streamio"
+	"github.com/karosown/katool-go/mq"
+	"github.com/karosown/katool-go/mq/cmq"
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	Name string `csv:"name" json:"name"`
+	Age  int    `csv:"age" json:"age"`
+}
+
+func TestStreamIOFiles(t *testing.T) {
+	records := make([]record, 0, 25)
+	for i := 0; i < 25; i++ {
+		records = append(records, record{Name: "r" + string(rune('a'+i)), Age: i})
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "records.csv")
+	n, err := streamio.WriteCSVFile(ctx, stream.ToLazyStream(&records), csvPath, streamio.WithBatchSize(4))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25), n)
+	assert.Equal(t, records, streamio.ReadCSVFile[record](csvPath).ToList())
+
+	jsonlPath := filepath.Join(dir, "records.jsonl")
+	n, err = streamio.WriteJSONLFile(ctx, stream.ToLazyStream(&records), jsonlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25), n)
+	assert.Equal(t, records[:3], streamio.ReadJSONLFile[record](jsonlPath).Limit(3).ToList())
+
+	skipped := 0
+	input := "{\"name\":\"a\",\"age\":1}\n\nnot json\n{\"name\":\"b\",\"age\":2}\n"
+	got := streamio.ReadJSONL[record](strings.NewReader(input), streamio.WithErrorHandler(func(error) { skipped++ })).ToList()
+	assert.Equal(t, []record{{"a", 1}, {"b", 2}}, got)
+	assert.Equal(t, 1, skipped)
+
+	got = streamio.ReadCSV[record](strings.NewReader("name,age\na,1\nb,x\nc,3\n"), streamio.WithErrorHandler(func(error) { skipped++ })).ToList()
+	assert.Equal(t, []string{"a", "c"}, stream.LazyMap(stream.ToLazyStream(&got), func(r record) string { return r.Name }).ToList())
+	assert.Equal(t, 2, skipped)
+}
+
+func TestStreamIODrain(t *testing.T) {
+	nums := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		nums = append(nums, i)
+	}
+	sizes := make(chan int, 100)
+	n, err := streamio.Drain(context.Background(), stream.ToLazyStream(&nums), func(ctx context.Context, batch []int) error {
+		sizes <- len(batch)
+		return nil
+	}, streamio.WithBatchSize(30), streamio.WithConcurrency(3))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), n)
+	close(sizes)
+	total := 0
+	for size := range sizes {
+		total += size
+	}
+	assert.Equal(t, 100, total)
+
+	boom := errors.New("boom")
+	pulled := 0
+	n, err = streamio.Drain(context.Background(), stream.Iterate(0, func(i int) int { return i + 1 }).Peek(func(int) { pulled++ }),
+		func(ctx context.Context, batch []int) error {
+			if batch[0] >= 20 {
+				return boom
+			}
+			return nil
+		}, streamio.WithBatchSize(10))
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, int64(20), n)
+	assert.Equal(t, 30, pulled)
+
+	var buf bytes.Buffer
+	_, err = streamio.WriteJSONL(context.Background(), stream.ToLazyStream(&nums).Limit(3), &buf, streamio.WithFlushInterval(time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n1\n2\n", buf.String())
+}
+
+// 测试数据源中途失败时错误由流的 Err 与 Drain 返回
+func TestStreamIOSourceErrors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.csv")
+	skipped := 0
+	onErr := streamio.WithErrorHandler(func(error) { skipped++ })
+
+	lines := streamio.ReadLinesFile(missing)
+	assert.Empty(t, lines.ToList())
+	assert.ErrorIs(t, lines.Err(), fs.ErrNotExist)
+
+	n, err := streamio.WriteJSONLFile(ctx, streamio.ReadCSVFile[record](missing, onErr), filepath.Join(dir, "out.jsonl"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, int64(0), n)
+	assert.Equal(t, 0, skipped)
+
+	// 被跳过的行报告给 OnError，读取失败由 Drain 返回 / skipped lines go to OnError, the read failure to Drain
+	readErr := errors.New("read failed")
+	src := streamio.ReadJSONL[record](&errReader{r: strings.NewReader("{\"name\":\"a\",\"age\":1}\nbad\n"), err: readErr}, onErr)
+	n, err = streamio.WriteCSVFile(ctx, src, filepath.Join(dir, "out.csv"))
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, 1, skipped)
+
+	n, err = streamio.Drain(ctx, stream.FromLines(&errReader{r: strings.NewReader("a\nb\n"), err: readErr}),
+		func(context.Context, []string) error { return nil }, streamio.WithFlushInterval(time.Millisecond))
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestStreamIOMQ(t *testing.T) {
+	broker := cmq.NewChanBroker()
+	defer broker.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	messages := streamio.Subscribe(ctx, broker, "records", streamio.WithBuffer(1))
+	done := make(chan []record)
+	go func() {
+		done <- stream.LazyMap(messages, func(msg mq.Message) record {
+			var r record
+			_ = json.Unmarshal(msg.Payload(), &r)
+			return r
+		}).Limit(5).ToList()
+	}()
+	// 等待订阅建立 / wait for the subscription
+	time.Sleep(50 * time.Millisecond)
+
+	records := []record{{"a", 1}, {"b", 2}, {"c", 3}, {"d", 4}, {"e", 5}}
+	n, err := streamio.Publish(ctx, stream.ToLazyStream(&records), broker, "records", nil, streamio.WithBatchSize(2))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	got := <-done
+	assert.ElementsMatch(t, records, got)
+}
+
+type ackMessage string
+
+func (m ackMessage) Payload() []byte          { return []byte(m) }
+func (m ackMessage) GetMetadata() mq.Metadata { return mq.Metadata{} }
+func (m ackMessage) Ack() error               { return nil }
+func (m ackMessage) Nack(bool) error          { return nil }
+
+// ackClient 按顺序投递消息并记录处理函数的结果，处理函数返回 nil 视为确认
+type ackClient struct {
+	messages []string
+	acked    chan string
+	failed   chan string
+}
+
+func (c *ackClient) Publish(context.Context, string, []byte, ...mq.PublishOption) error { return nil }
+func (c *ackClient) Close() error                                                       { return nil }
+
+func (c *ackClient) Subscribe(ctx context.Context, _ string, handler mq.Handler, _ ...mq.SubscribeOption) error {
+	go func() {
+		for _, m := range c.messages {
+			if handler(ctx, ackMessage(m)) != nil {
+				c.failed <- m
+				return
+			}
+			c.acked <- m
+		}
+	}()
+	return nil
+}
+
+// 测试订阅只确认已被消费的消息
+func TestStreamIOSubscribeAck(t *testing.T) {
+	client := &ackClient{messages: []string{"a", "b", "c", "d"}, acked: make(chan string, 4), failed: make(chan string, 4)}
+	got := stream.LazyMap(streamio.Subscribe(context.Background(), client, "t", streamio.WithBuffer(4)),
+		func(msg mq.Message) string { return string(msg.Payload()) }).Limit(2).ToList()
+	assert.Equal(t, []string{"a", "b"}, got)
+
+	assert.Equal(t, "a", <-client.acked)
+	assert.Equal(t, "b", <-client.acked)
+	select {
+	case m := <-client.failed:
+		assert.Equal(t, "c", m, "未消费的消息不应被确认")
+	case <-time.After(time.Second):
+		t.Fatal("the unconsumed message was not rejected")
+	}
+	assert.Empty(t, client.acked)
+}
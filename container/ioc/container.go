@@ -0,0 +1,685 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNotFound 没有可用的提供者 / no provider is registered for a dependency
+	ErrNotFound = errors.New("ioc: no provider")
+	// ErrCycle 依赖成环 / the dependency graph has a cycle
+	ErrCycle = errors.New("ioc: dependency cycle")
+	// ErrDuplicate 同一类型与名称重复注册 / a type and name pair is registered twice
+	ErrDuplicate = errors.New("ioc: duplicate provider")
+	// ErrScopeRequired 在作用域外（根容器或单例中）解析 Scoped 组件 / a scoped component resolved outside a scope, at the root or from a singleton
+	ErrScopeRequired = errors.New("ioc: scoped component resolved outside a scope")
+	// ErrClosed 容器已关闭 / the container is closed
+	ErrClosed = errors.New("ioc: container closed")
+	// ErrInvalidProvider 构造函数、选项或注入目标不合法 / a constructor, option or injection target is malformed
+	ErrInvalidProvider = errors.New("ioc: invalid provider")
+)
+
+// In 嵌入到构造函数的结构体参数中，该结构体的每个导出字段按类型及 inject 标签单独解析
+// In is embedded in a constructor's struct parameter, whose exported fields are then resolved one by one
+// by type and inject tag
+//
+//	type Params struct {
+//		ioc.In
+//		DB       *sql.DB
+//		Cache    Cache           `inject:"name=redis"`
+//		Handlers []Handler       `inject:"group=http"`
+//		Metrics  *Metrics        `inject:"optional"`
+//	}
+type In struct{}
+
+var (
+	inType        = reflect.TypeFor[In]()
+	errType       = reflect.TypeFor[error]()
+	containerType = reflect.TypeFor[*Container]()
+)
+
+// key 提供者的索引：类型加名称，或类型（分组元素类型）加分组
+// key indexes providers by type and name, or by element type and group
+type key struct {
+	t     reflect.Type
+	name  string
+	group string
+}
+
+func (k key) String() string {
+	switch {
+	case k.group != "":
+		return fmt.Sprintf("%v[group=%s]", k.t, k.group)
+	case k.name != "":
+		return fmt.Sprintf("%v[name=%s]", k.t, k.name)
+	}
+	return k.t.String()
+}
+
+// dep 一个待解析的依赖
+// dep is one dependency to resolve
+type dep struct {
+	t        reflect.Type
+	name     string
+	group    string
+	optional bool
+}
+
+func (d dep) key() key {
+	if d.group != "" {
+		return key{t: d.t.Elem(), group: d.group}
+	}
+	return key{t: d.t, name: d.name}
+}
+
+// param 构造函数的一个参数：普通依赖、In 结构体或容器本身
+// param is one constructor parameter: a plain dependency, an In struct or the container itself
+type param struct {
+	dep    dep
+	in     reflect.Type
+	fields []field
+	self   bool
+}
+
+type field struct {
+	index int
+	dep   dep
+}
+
+type provider struct {
+	label  string
+	t      reflect.Type
+	ctor   reflect.Value
+	value  reflect.Value
+	params []param
+	hasErr bool
+	opts   provideOptions
+}
+
+func (p *provider) deps() []dep {
+	var deps []dep
+	for _, pa := range p.params {
+		switch {
+		case pa.self:
+		case pa.in != nil:
+			for _, f := range pa.fields {
+				deps = append(deps, f.dep)
+			}
+		default:
+			deps = append(deps, pa.dep)
+		}
+	}
+	return deps
+}
+
+type slot struct {
+	mu   sync.Mutex
+	done bool
+	v    reflect.Value
+}
+
+// Container 类型安全的依赖注入容器。组件在首次解析时才构造；解析前会先校验依赖图，缺失依赖与循环依赖
+// 以完整的依赖链报错，如 "ioc: dependency cycle: *A -> *B -> *A"。注册总是作用于根容器，作用域只持有自己的 Scoped 实例
+// Container is a typed dependency injection container. Components are built lazily on first resolution, and the
+// dependency graph is validated beforehand so missing and cyclic dependencies are reported with the whole chain,
+// such as "ioc: dependency cycle: *A -> *B -> *A". Registration always goes to the root; a scope only holds
+// its own scoped instances
+type Container struct {
+	root   *Container
+	parent *Container
+	// view 不为 nil 时本容器是交给构造函数的视图 / set when this container is the view handed to a constructor
+	view *view
+
+	mu        sync.Mutex
+	providers map[key]*provider
+	groups    map[key][]*provider
+	all       []*provider
+	slots     map[*provider]*slot
+	order     []*instance
+	started   bool
+	startCtx  context.Context
+	closed    bool
+}
+
+// view 交给构造函数的容器视图，构造函数执行期间经它的解析沿用进行中的解析链，因此能发现经由 *Container 的循环依赖；
+// 构造完成后视图等同于背后的容器
+// view is the container handed to a constructor. While the constructor runs, resolutions through it continue the
+// resolution in progress so cycles through *Container are detected; once built the view acts as the container
+type view struct {
+	base *Container
+	path []*provider
+	done atomic.Bool
+}
+
+// unwrap 返回视图背后的容器与进行中的解析链
+// unwrap returns the container behind a view and the resolution in progress
+func (c *Container) unwrap() (*Container, []*provider) {
+	if c.view == nil {
+		return c, nil
+	}
+	if c.view.done.Load() {
+		return c.view.base, nil
+	}
+	return c.view.base, c.view.path
+}
+
+// New 创建根容器
+// New creates a root container
+func New() *Container {
+	c := &Container{
+		providers: make(map[key]*provider),
+		groups:    make(map[key][]*provider),
+		slots:     make(map[*provider]*slot),
+	}
+	c.root = c
+	return c
+}
+
+// Scope 创建子作用域，Scoped 组件在每个作用域中各有一个实例，随作用域的 Close 关闭
+// Scope creates a child scope; every scope has its own instance of each scoped component, closed with the scope
+func (c *Container) Scope() *Container {
+	c, _ = c.unwrap()
+	return &Container{root: c.root, parent: c, slots: make(map[*provider]*slot)}
+}
+
+// Provide 注册构造函数。构造函数返回 T 或 (T, error)，参数按类型自动解析；*Container 参数得到当前解析所在的容器，
+// 构造期间经它的解析属于同一条解析链，成环时返回 ErrCycle；嵌入 In 的结构体参数按字段解析
+// Provide registers a constructor returning T or (T, error), whose parameters are resolved by type. A
+// *Container parameter receives the resolving container, and resolutions through it during construction belong to
+// the same chain, so a cycle returns ErrCycle; struct parameters embedding In are resolved field by field
+func (c *Container) Provide(ctor any, opts ...ProvideOption) error {
+	v := reflect.ValueOf(ctor)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("%w: constructor must be a function, got %T", ErrInvalidProvider, ctor)
+	}
+	ft := v.Type()
+	if ft.IsVariadic() || ft.NumOut() == 0 || ft.NumOut() > 2 || ft.Out(0) == errType ||
+		(ft.NumOut() == 2 && ft.Out(1) != errType) {
+		return fmt.Errorf("%w: constructor %v must return T or (T, error)", ErrInvalidProvider, ft)
+	}
+	params, err := parseParams(ft)
+	if err != nil {
+		return err
+	}
+	return c.register(&provider{t: ft.Out(0), ctor: v, params: params, hasErr: ft.NumOut() == 2}, opts)
+}
+
+// MustProvide 同 Provide，出错时 panic
+// MustProvide is like Provide but panics on error
+func (c *Container) MustProvide(ctor any, opts ...ProvideOption) {
+	if err := c.Provide(ctor, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// ProvideValue 注册已构造好的值
+// ProvideValue registers an already built value
+func ProvideValue[T any](c *Container, v T, opts ...ProvideOption) error {
+	return c.register(&provider{t: reflect.TypeFor[T](), value: reflect.ValueOf(&v).Elem()}, opts)
+}
+
+func (c *Container) register(p *provider, opts []ProvideOption) error {
+	for _, opt := range opts {
+		if err := opt(&p.opts); err != nil {
+			return err
+		}
+	}
+	o := p.opts
+	if o.lifetime < Singleton || o.lifetime > Scoped {
+		return fmt.Errorf("%w: unknown lifetime %d", ErrInvalidProvider, o.lifetime)
+	}
+	if o.name != "" && o.group != "" {
+		return fmt.Errorf("%w: %v cannot be both named and grouped", ErrInvalidProvider, p.t)
+	}
+	for _, h := range slices.Concat(o.onStart, o.onClose) {
+		if !p.t.AssignableTo(h.t) {
+			return fmt.Errorf("%w: hook for %v cannot accept %v", ErrInvalidProvider, h.t, p.t)
+		}
+	}
+	keys := []key{{t: p.t, name: o.name, group: o.group}}
+	for _, it := range o.as {
+		if !p.t.Implements(it) {
+			return fmt.Errorf("%w: %v does not implement %v", ErrInvalidProvider, p.t, it)
+		}
+		keys = append(keys, key{t: it, name: o.name, group: o.group})
+	}
+	p.label = keys[0].String()
+
+	r := c.root
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	if o.group == "" {
+		for _, k := range keys {
+			if _, ok := r.providers[k]; ok {
+				return fmt.Errorf("%w: %v", ErrDuplicate, k)
+			}
+		}
+	}
+	for _, k := range keys {
+		if o.group != "" {
+			r.groups[k] = append(r.groups[k], p)
+		} else {
+			r.providers[k] = p
+		}
+	}
+	r.all = append(r.all, p)
+	return nil
+}
+
+// Resolve 解析类型为 T 的组件
+// Resolve resolves the component of type T
+func Resolve[T any](c *Container) (T, error) {
+	return resolveAs[T](c, dep{t: reflect.TypeFor[T]()})
+}
+
+// ResolveNamed 解析以 name 注册的类型为 T 的组件
+// ResolveNamed resolves the component of type T registered under name
+func ResolveNamed[T any](c *Container, name string) (T, error) {
+	return resolveAs[T](c, dep{t: reflect.TypeFor[T](), name: name})
+}
+
+// ResolveGroup 按注册顺序解析分组内的全部组件，空分组返回空切片
+// ResolveGroup resolves every component of the group in registration order; an empty group yields an empty slice
+func ResolveGroup[T any](c *Container, group string) ([]T, error) {
+	return resolveAs[[]T](c, dep{t: reflect.TypeFor[[]T](), group: group})
+}
+
+// MustResolve 同 Resolve，出错时 panic
+// MustResolve is like Resolve but panics on error
+func MustResolve[T any](c *Container) T {
+	v, err := Resolve[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func resolveAs[T any](c *Container, d dep) (T, error) {
+	var res T
+	c, path := c.unwrap()
+	if err := c.check(d); err != nil {
+		return res, err
+	}
+	v, err := c.resolve(d, path)
+	if err != nil {
+		return res, err
+	}
+	reflect.ValueOf(&res).Elem().Set(v)
+	return res, nil
+}
+
+// Invoke 解析 fn 的参数并调用它，fn 可以返回 error
+// Invoke resolves fn's parameters and calls it; fn may return an error
+func (c *Container) Invoke(fn any) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("%w: Invoke expects a function, got %T", ErrInvalidProvider, fn)
+	}
+	ft := v.Type()
+	if ft.IsVariadic() || ft.NumOut() > 1 || (ft.NumOut() == 1 && ft.Out(0) != errType) {
+		return fmt.Errorf("%w: Invoke expects a function returning nothing or an error, got %v", ErrInvalidProvider, ft)
+	}
+	params, err := parseParams(ft)
+	if err != nil {
+		return err
+	}
+	base, path := c.unwrap()
+	for _, d := range (&provider{params: params}).deps() {
+		if err := base.check(d); err != nil {
+			return err
+		}
+	}
+	args, err := base.args(params, path, c)
+	if err != nil {
+		return err
+	}
+	if out := v.Call(args); len(out) == 1 && !out[0].IsNil() {
+		return out[0].Interface().(error)
+	}
+	return nil
+}
+
+// Inject 为结构体指针中带 inject 标签的导出字段注入依赖，标签为逗号分隔的 name=..., group=..., optional，可为空
+// Inject fills the exported fields of a struct pointer that carry an inject tag, a comma separated list of
+// name=..., group=... and optional, possibly empty
+func (c *Container) Inject(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: Inject expects a non-nil struct pointer, got %T", ErrInvalidProvider, target)
+	}
+	fields, err := parseFields(v.Elem().Type(), true)
+	if err != nil {
+		return err
+	}
+	c, path := c.unwrap()
+	for _, f := range fields {
+		if err := c.check(f.dep); err != nil {
+			return err
+		}
+	}
+	for _, f := range fields {
+		fv, err := c.resolve(f.dep, path)
+		if err != nil {
+			return err
+		}
+		v.Elem().Field(f.index).Set(fv)
+	}
+	return nil
+}
+
+func parseParams(ft reflect.Type) ([]param, error) {
+	params := make([]param, 0, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		t := ft.In(i)
+		switch {
+		case t == containerType:
+			params = append(params, param{self: true})
+		case isIn(t):
+			fields, err := parseFields(t, false)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param{in: t, fields: fields})
+		default:
+			params = append(params, param{dep: dep{t: t}})
+		}
+	}
+	return params, nil
+}
+
+func isIn(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Anonymous && f.Type == inType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFields 解析结构体字段，tagged 为 true 时只处理带 inject 标签的字段
+// parseFields parses struct fields, only those with an inject tag when tagged is true
+func parseFields(t reflect.Type, tagged bool) ([]field, error) {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == inType {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("inject")
+		if tagged && !ok {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("%w: field %v.%s is unexported", ErrInvalidProvider, t, f.Name)
+		}
+		d, err := parseTag(f.Type, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w (field %v.%s)", err, t, f.Name)
+		}
+		fields = append(fields, field{index: i, dep: d})
+	}
+	return fields, nil
+}
+
+func parseTag(t reflect.Type, tag string) (dep, error) {
+	d := dep{t: t}
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+		case part == "optional":
+			d.optional = true
+		case strings.HasPrefix(part, "name="):
+			d.name = strings.TrimPrefix(part, "name=")
+		case strings.HasPrefix(part, "group="):
+			d.group = strings.TrimPrefix(part, "group=")
+		default:
+			return d, fmt.Errorf("%w: unknown inject option %q", ErrInvalidProvider, part)
+		}
+	}
+	if d.group != "" && (d.name != "" || t.Kind() != reflect.Slice) {
+		return d, fmt.Errorf("%w: a group must be injected into an unnamed slice, got %v", ErrInvalidProvider, t)
+	}
+	return d, nil
+}
+
+// lookup 查找依赖的提供者；可选依赖缺失时返回空
+// lookup finds the providers of a dependency, none for a missing optional one
+func (c *Container) lookup(d dep, path []*provider) ([]*provider, error) {
+	r := c.root
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d.group != "" {
+		return slices.Clone(r.groups[d.key()]), nil
+	}
+	if p, ok := r.providers[d.key()]; ok {
+		return []*provider{p}, nil
+	}
+	if d.optional {
+		return nil, nil
+	}
+	if len(path) > 0 {
+		return nil, fmt.Errorf("%w for %v (required by %s)", ErrNotFound, d.key(), chain(path))
+	}
+	return nil, fmt.Errorf("%w for %v", ErrNotFound, d.key())
+}
+
+func chain(path []*provider) string {
+	labels := make([]string, len(path))
+	for i, p := range path {
+		labels[i] = p.label
+	}
+	return strings.Join(labels, " -> ")
+}
+
+// visit 校验状态的键，pinned 表示处于根容器或单例之下，此时不能依赖 Scoped 组件
+// visit keys the validation state; pinned means under the root or a singleton, where scoped components are unavailable
+type visit struct {
+	p      *provider
+	pinned bool
+}
+
+const (
+	visiting = iota + 1
+	visited
+)
+
+// check 在构造前校验依赖图
+// check validates the dependency graph before anything is built
+func (c *Container) check(d dep) error {
+	return c.walk(d, nil, c.parent == nil, make(map[visit]int))
+}
+
+func (c *Container) walk(d dep, path []*provider, pinned bool, state map[visit]int) error {
+	ps, err := c.lookup(d, path)
+	if err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if err := c.walkProvider(p, path, pinned, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Container) walkProvider(p *provider, path []*provider, pinned bool, state map[visit]int) error {
+	next := append(path[:len(path):len(path)], p)
+	if p.opts.lifetime == Scoped && pinned {
+		return fmt.Errorf("%w: %s", ErrScopeRequired, chain(next))
+	}
+	pinned = pinned || p.opts.lifetime == Singleton
+	v := visit{p: p, pinned: pinned}
+	switch state[v] {
+	case visiting:
+		return fmt.Errorf("%w: %s", ErrCycle, chain(next[slices.Index(path, p):]))
+	case visited:
+		return nil
+	}
+	state[v] = visiting
+	for _, d := range p.deps() {
+		if err := c.walk(d, next, pinned, state); err != nil {
+			return err
+		}
+	}
+	state[v] = visited
+	return nil
+}
+
+func (c *Container) resolve(d dep, path []*provider) (reflect.Value, error) {
+	ps, err := c.lookup(d, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if d.group != "" {
+		res := reflect.MakeSlice(d.t, 0, len(ps))
+		for _, p := range ps {
+			v, err := c.instance(p, path)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			res = reflect.Append(res, v)
+		}
+		return res, nil
+	}
+	if len(ps) == 0 {
+		return reflect.Zero(d.t), nil
+	}
+	v, err := c.instance(ps[0], path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	res := reflect.New(d.t).Elem()
+	res.Set(v)
+	return res, nil
+}
+
+// instance 按生命周期取得或构造实例；容器已启动时新建的实例立即启动
+// instance gets or builds an instance according to its lifetime; instances built after Start are started at once
+func (c *Container) instance(p *provider, path []*provider) (reflect.Value, error) {
+	if slices.Contains(path, p) {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrCycle, chain(append(path[slices.Index(path, p):], p)))
+	}
+	path = append(path[:len(path):len(path)], p)
+	owner := c
+	switch p.opts.lifetime {
+	case Transient:
+		return c.build(p, path)
+	case Singleton:
+		owner = c.root
+	case Scoped:
+		if c.parent == nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrScopeRequired, chain(path))
+		}
+	}
+
+	owner.mu.Lock()
+	if owner.closed {
+		owner.mu.Unlock()
+		return reflect.Value{}, ErrClosed
+	}
+	s, ok := owner.slots[p]
+	if !ok {
+		s = &slot{}
+		owner.slots[p] = s
+	}
+	owner.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return s.v, nil
+	}
+	v, err := owner.build(p, path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	s.v, s.done = v, true
+
+	inst := &instance{p: p, v: v.Interface()}
+	ctx, started := owner.track(inst)
+	if started {
+		if err := inst.start(ctx); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return v, nil
+}
+
+// track 记录需要管理生命周期的实例，返回容器是否已启动
+// track records an instance whose lifecycle is managed and reports whether the container is started
+func (c *Container) track(inst *instance) (context.Context, bool) {
+	r := c.root
+	if c != r {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order = append(c.order, inst)
+	return r.startCtx, r.started
+}
+
+func (c *Container) build(p *provider, path []*provider) (v reflect.Value, err error) {
+	if p.value.IsValid() {
+		return p.value, nil
+	}
+	self := &Container{root: c.root, parent: c.parent, view: &view{base: c, path: path}}
+	defer self.view.done.Store(true)
+	args, err := c.args(p.params, path, self)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("ioc: construct %s: panic: %v", chain(path), r)
+		}
+	}()
+	out := p.ctor.Call(args)
+	if p.hasErr && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("ioc: construct %s: %w", chain(path), out[1].Interface().(error))
+	}
+	return out[0], nil
+}
+
+// args 解析参数，self 为 *Container 参数得到的容器
+// args resolves the parameters, self is what a *Container parameter receives
+func (c *Container) args(params []param, path []*provider, self *Container) ([]reflect.Value, error) {
+	args := make([]reflect.Value, len(params))
+	for i, pa := range params {
+		switch {
+		case pa.self:
+			args[i] = reflect.ValueOf(self)
+		case pa.in != nil:
+			sv := reflect.New(pa.in).Elem()
+			for _, f := range pa.fields {
+				v, err := c.resolve(f.dep, path)
+				if err != nil {
+					return nil, err
+				}
+				sv.Field(f.index).Set(v)
+			}
+			args[i] = sv
+		default:
+			v, err := c.resolve(pa.dep, path)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+	}
+	return args, nil
+}
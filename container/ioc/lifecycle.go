@@ -0,0 +1,130 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Starter 实现该接口的组件在 Start 时被自动启动
+// Starter is implemented by components the container starts automatically
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// instance 一个需要管理生命周期的实例（单例或 Scoped）
+// instance is a singleton or scoped instance whose lifecycle is managed
+type instance struct {
+	p *provider
+	v any
+}
+
+func (i *instance) start(ctx context.Context) error {
+	if i.v == nil {
+		return nil
+	}
+	for _, h := range i.p.opts.onStart {
+		if err := h.fn(ctx, i.v); err != nil {
+			return fmt.Errorf("ioc: start %s: %w", i.p.label, err)
+		}
+	}
+	if s, ok := i.v.(Starter); ok && !i.p.opts.noLifecycle {
+		if err := s.Start(ctx); err != nil {
+			return fmt.Errorf("ioc: start %s: %w", i.p.label, err)
+		}
+	}
+	return nil
+}
+
+// close 依次调用 OnClose 回调与组件自身的 Close(ctx) error、Close() error、Close() 或 Disconnect(ctx) error
+// close runs the OnClose hooks, then the component's own Close(ctx) error, Close() error, Close() or
+// Disconnect(ctx) error, the last one covering Mongo clients
+func (i *instance) close(ctx context.Context) error {
+	if i.v == nil {
+		return nil
+	}
+	var errs []error
+	for _, h := range i.p.opts.onClose {
+		errs = append(errs, h.fn(ctx, i.v))
+	}
+	if !i.p.opts.noLifecycle {
+		switch x := i.v.(type) {
+		case interface{ Close(context.Context) error }:
+			errs = append(errs, x.Close(ctx))
+		case interface{ Close() error }:
+			errs = append(errs, x.Close())
+		case interface{ Close() }:
+			x.Close()
+		case interface{ Disconnect(context.Context) error }:
+			errs = append(errs, x.Disconnect(ctx))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("ioc: close %s: %w", i.p.label, err)
+	}
+	return nil
+}
+
+// Start 构造全部单例，并按构造顺序（依赖先于使用者）调用 OnStart 回调与 Starter；之后新构造的单例与 Scoped 实例在创建时启动。
+// 启动失败时返回错误，已构造的组件仍由 Close 释放。在作用域上调用等同于在根容器上调用
+// Start builds every singleton and runs the OnStart hooks and Starter in construction order, dependencies before
+// their users; singletons and scoped instances built afterwards are started on creation. On failure the error is
+// returned and whatever was built is still released by Close. Calling it on a scope starts the root
+func (c *Container) Start(ctx context.Context) error {
+	r := c.root
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return ErrClosed
+	}
+	if r.started {
+		r.mu.Unlock()
+		return nil
+	}
+	r.started = true
+	r.startCtx = context.WithoutCancel(ctx)
+	built := slices.Clone(r.order)
+	all := slices.Clone(r.all)
+	r.mu.Unlock()
+
+	for _, inst := range built {
+		if err := inst.start(ctx); err != nil {
+			return err
+		}
+	}
+	for _, p := range all {
+		if p.opts.lifetime != Singleton {
+			continue
+		}
+		if err := r.walkProvider(p, nil, true, make(map[visit]int)); err != nil {
+			return err
+		}
+		if _, err := r.instance(p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 按构造的逆序关闭本容器持有的实例并合并错误；根容器不会关闭仍在使用的子作用域，作用域需各自关闭。Transient 实例不受管理
+// Close closes the instances this container holds in reverse construction order and joins the errors. Closing
+// the root does not close child scopes, which must be closed on their own; transient instances are not managed
+func (c *Container) Close(ctx context.Context) error {
+	c, _ = c.unwrap()
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	order := c.order
+	c.order = nil
+	c.mu.Unlock()
+
+	errs := make([]error, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		errs = append(errs, order[i].close(ctx))
+	}
+	return errors.Join(errs...)
+}
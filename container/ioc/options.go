@@ -0,0 +1,124 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Lifetime 组件的生命周期
+// Lifetime is how long a constructed component lives
+type Lifetime int
+
+const (
+	// Singleton 整个容器共享一个实例（默认），首次解析时创建 / one instance per container (default), built on first use
+	Singleton Lifetime = iota
+	// Transient 每次解析都新建，容器不跟踪其生命周期 / a new instance per resolution, not tracked by the container
+	Transient
+	// Scoped 每个作用域一个实例，只能在 Scope 中解析 / one instance per scope, resolvable only inside a Scope
+	Scoped
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	}
+	return "unknown"
+}
+
+// hook 生命周期回调，t 为回调接收的类型
+// hook is a lifecycle callback, t is the type it accepts
+type hook struct {
+	t  reflect.Type
+	fn func(ctx context.Context, v any) error
+}
+
+type provideOptions struct {
+	name        string
+	group       string
+	lifetime    Lifetime
+	as          []reflect.Type
+	onStart     []hook
+	onClose     []hook
+	noLifecycle bool
+}
+
+// ProvideOption 注册选项
+// ProvideOption configures a registration
+type ProvideOption func(*provideOptions) error
+
+// Named 以名称注册，解析时需使用相同名称（ResolveNamed 或 inject:"name=..."）
+// Named registers under a name, which resolution must use too (ResolveNamed or inject:"name=...")
+func Named(name string) ProvideOption {
+	return func(o *provideOptions) error {
+		o.name = name
+		return nil
+	}
+}
+
+// InGroup 加入分组，分组只能整体以切片解析（ResolveGroup 或 inject:"group=..."）
+// InGroup adds the component to a group, which is only resolvable as a whole slice (ResolveGroup or inject:"group=...")
+func InGroup(group string) ProvideOption {
+	return func(o *provideOptions) error {
+		o.group = group
+		return nil
+	}
+}
+
+// WithLifetime 设置生命周期，默认 Singleton
+// WithLifetime sets the lifetime, Singleton by default
+func WithLifetime(l Lifetime) ProvideOption {
+	return func(o *provideOptions) error {
+		o.lifetime = l
+		return nil
+	}
+}
+
+// As 同时以接口类型注册，参数为接口指针，如 As(new(io.Reader))；解析接口与具体类型得到同一实例
+// As also registers the component under interfaces given as pointers, such as As(new(io.Reader)); resolving
+// an interface and the concrete type yields the same instance
+func As(ifaces ...any) ProvideOption {
+	return func(o *provideOptions) error {
+		for _, i := range ifaces {
+			t := reflect.TypeOf(i)
+			if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Interface {
+				return fmt.Errorf("%w: As expects pointers to interfaces, got %v", ErrInvalidProvider, t)
+			}
+			o.as = append(o.as, t.Elem())
+		}
+		return nil
+	}
+}
+
+// OnStart Start 时调用的回调，按依赖顺序（依赖先于使用者）执行
+// OnStart adds a callback run by Start in dependency order, dependencies before their users
+func OnStart[T any](fn func(ctx context.Context, v T) error) ProvideOption {
+	return func(o *provideOptions) error {
+		o.onStart = append(o.onStart, hook{t: reflect.TypeFor[T](), fn: func(ctx context.Context, v any) error { return fn(ctx, v.(T)) }})
+		return nil
+	}
+}
+
+// OnClose Close 时调用的回调，按依赖的逆序执行
+// OnClose adds a callback run by Close in reverse dependency order
+func OnClose[T any](fn func(ctx context.Context, v T) error) ProvideOption {
+	return func(o *provideOptions) error {
+		o.onClose = append(o.onClose, hook{t: reflect.TypeFor[T](), fn: func(ctx context.Context, v any) error { return fn(ctx, v.(T)) }})
+		return nil
+	}
+}
+
+// WithoutLifecycle 不自动调用组件自身的 Start/Close 方法，显式的 OnStart/OnClose 仍然生效
+// WithoutLifecycle stops the container from calling the component's own Start/Close methods; explicit
+// OnStart/OnClose hooks still run
+func WithoutLifecycle() ProvideOption {
+	return func(o *provideOptions) error {
+		o.noLifecycle = true
+		return nil
+	}
+}
@@ -0,0 +1,309 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/ioc"
+	"github.com/stretchr/testify/assert"
+)
+
+type Config struct{ DSN string }
+
+type DB struct {
+	cfg *Config
+	log *[]string
+}
+
+func (d *DB) Start(ctx context.Context) error {
+	*d.log = append(*d.log, "start db")
+	return nil
+}
+
+func (d *DB) Close() error {
+	*d.log = append(*d.log, "close db")
+	return nil
+}
+
+type Repo struct{ db *DB }
+
+type Service struct {
+	repo *Repo
+	log  *[]string
+}
+
+func (s *Service) Close(ctx context.Context) error {
+	*s.log = append(*s.log, "close service")
+	return nil
+}
+
+type Handler interface{ Name() string }
+
+type handler string
+
+func (h handler) Name() string { return string(h) }
+
+// 测试构造函数的自动解析与懒加载
+func TestProvideAndResolve(t *testing.T) {
+	c := ioc.New()
+	var built atomic.Int32
+	var log []string
+	assert.NoError(t, ioc.ProvideValue(c, &Config{DSN: "mem"}))
+	assert.NoError(t, c.Provide(func(cfg *Config) *DB {
+		built.Add(1)
+		return &DB{cfg: cfg, log: &log}
+	}))
+	assert.NoError(t, c.Provide(func(db *DB) (*Repo, error) { return &Repo{db: db}, nil }))
+	assert.Equal(t, int32(0), built.Load())
+
+	var wg sync.WaitGroup
+	repos := make([]*Repo, 10)
+	for i := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repos[i] = ioc.MustResolve[*Repo](c)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), built.Load())
+	for _, r := range repos {
+		assert.Same(t, repos[0], r)
+	}
+	assert.Equal(t, "mem", repos[0].db.cfg.DSN)
+
+	_, err := ioc.Resolve[*Service](c)
+	assert.ErrorIs(t, err, ioc.ErrNotFound)
+	assert.ErrorIs(t, c.Provide(func() *DB { return nil }), ioc.ErrDuplicate)
+	assert.ErrorIs(t, c.Provide(func() {}), ioc.ErrInvalidProvider)
+	assert.ErrorIs(t, c.Provide(func() (*DB, int) { return nil, 0 }), ioc.ErrInvalidProvider)
+}
+
+// 测试缺失依赖与循环依赖的可读错误
+func TestGraphErrors(t *testing.T) {
+	type A struct{}
+	type B struct{}
+	type C struct{}
+	c := ioc.New()
+	c.MustProvide(func(*B) *A { return &A{} })
+	c.MustProvide(func(*C) *B { return &B{} })
+	_, err := ioc.Resolve[*A](c)
+	assert.ErrorIs(t, err, ioc.ErrNotFound)
+	assert.Contains(t, err.Error(), "*test.C (required by *test.A -> *test.B)")
+
+	c.MustProvide(func(*A) *C { return &C{} })
+	_, err = ioc.Resolve[*A](c)
+	assert.ErrorIs(t, err, ioc.ErrCycle)
+	assert.EqualError(t, err, "ioc: dependency cycle: *test.A -> *test.B -> *test.C -> *test.A")
+
+	boom := errors.New("boom")
+	c2 := ioc.New()
+	c2.MustProvide(func() (*C, error) { return nil, boom })
+	c2.MustProvide(func(*C) *B { return &B{} })
+	_, err = ioc.Resolve[*B](c2)
+	assert.ErrorIs(t, err, boom)
+	assert.EqualError(t, err, "ioc: construct *test.B -> *test.C: boom")
+
+	c3 := ioc.New()
+	c3.MustProvide(func() *A { panic("oops") })
+	_, err = ioc.Resolve[*A](c3)
+	assert.ErrorContains(t, err, "panic: oops")
+}
+
+// 测试经由构造函数中 *Container 的循环依赖
+func TestCycleThroughContainer(t *testing.T) {
+	type A struct{}
+	type B struct{ c *ioc.Container }
+	c := ioc.New()
+	c.MustProvide(func(*B) *A { return &A{} })
+	c.MustProvide(func(c *ioc.Container) (*B, error) {
+		_, err := ioc.Resolve[*A](c)
+		return &B{c: c}, err
+	})
+	done := make(chan error, 1)
+	go func() {
+		_, err := ioc.Resolve[*A](c)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ioc.ErrCycle)
+		assert.ErrorContains(t, err, "*test.A -> *test.B -> *test.A")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Resolve deadlocked")
+	}
+
+	// 构造完成后保存的容器可以正常延迟解析 / a container kept after construction resolves lazily as usual
+	lazy := ioc.New()
+	lazy.MustProvide(func() *A { return &A{} })
+	lazy.MustProvide(func(c *ioc.Container) *B { return &B{c: c} })
+	b := ioc.MustResolve[*B](lazy)
+	a, err := ioc.Resolve[*A](b.c)
+	assert.NoError(t, err)
+	assert.Same(t, ioc.MustResolve[*A](lazy), a)
+}
+
+// 测试生命周期与作用域
+func TestLifetimes(t *testing.T) {
+	type Request struct{ id int }
+	type Handler struct{ req *Request }
+	c := ioc.New()
+	var n atomic.Int32
+	c.MustProvide(func() *Request { return &Request{id: int(n.Add(1))} }, ioc.WithLifetime(ioc.Scoped))
+	c.MustProvide(func(r *Request) *Handler { return &Handler{req: r} }, ioc.WithLifetime(ioc.Transient))
+	c.MustProvide(func(h *Handler) string { return "bad" })
+
+	_, err := ioc.Resolve[*Request](c)
+	assert.ErrorIs(t, err, ioc.ErrScopeRequired)
+	s1, s2 := c.Scope(), c.Scope()
+	h1, h2 := ioc.MustResolve[*Handler](s1), ioc.MustResolve[*Handler](s1)
+	assert.NotSame(t, h1, h2)
+	assert.Same(t, h1.req, h2.req)
+	assert.NotSame(t, h1.req, ioc.MustResolve[*Handler](s2).req)
+
+	// 单例不能依赖 Scoped，即使在作用域中解析 / a singleton cannot depend on a scoped component, even from a scope
+	_, err = ioc.Resolve[string](s1)
+	assert.ErrorIs(t, err, ioc.ErrScopeRequired)
+	assert.Contains(t, err.Error(), "string -> *test.Handler -> *test.Request")
+}
+
+// 测试命名、分组、接口绑定与字段注入
+func TestBindings(t *testing.T) {
+	type Params struct {
+		ioc.In
+		Primary  *Config   `inject:"name=primary"`
+		Replica  *Config   `inject:"name=replica"`
+		Handlers []Handler `inject:"group=http"`
+		Missing  *DB       `inject:"optional"`
+	}
+	type Server struct{ p Params }
+	c := ioc.New()
+	assert.NoError(t, ioc.ProvideValue(c, &Config{DSN: "a"}, ioc.Named("primary")))
+	assert.NoError(t, ioc.ProvideValue(c, &Config{DSN: "b"}, ioc.Named("replica")))
+	assert.NoError(t, ioc.ProvideValue(c, handler("users"), ioc.InGroup("http"), ioc.As(new(Handler))))
+	assert.NoError(t, ioc.ProvideValue(c, handler("orders"), ioc.InGroup("http"), ioc.As(new(Handler))))
+	c.MustProvide(func(p Params) *Server { return &Server{p: p} })
+	assert.ErrorIs(t, ioc.ProvideValue(c, 1, ioc.As(new(Handler))), ioc.ErrInvalidProvider)
+
+	srv := ioc.MustResolve[*Server](c)
+	assert.Equal(t, "a", srv.p.Primary.DSN)
+	assert.Equal(t, "b", srv.p.Replica.DSN)
+	assert.Nil(t, srv.p.Missing)
+	assert.Equal(t, []Handler{handler("users"), handler("orders")}, srv.p.Handlers)
+
+	hs, err := ioc.ResolveGroup[Handler](c, "http")
+	assert.NoError(t, err)
+	assert.Len(t, hs, 2)
+	empty, err := ioc.ResolveGroup[Handler](c, "grpc")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+	cfg, err := ioc.ResolveNamed[*Config](c, "replica")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", cfg.DSN)
+
+	var target struct {
+		Cfg      *Config   `inject:"name=primary"`
+		Handlers []Handler `inject:"group=http"`
+		Ignored  *DB
+	}
+	assert.NoError(t, c.Inject(&target))
+	assert.Equal(t, "a", target.Cfg.DSN)
+	assert.Len(t, target.Handlers, 2)
+	assert.Nil(t, target.Ignored)
+
+	var bad struct {
+		Cfg *Config `inject:"name=none"`
+	}
+	assert.ErrorIs(t, c.Inject(&bad), ioc.ErrNotFound)
+
+	assert.NoError(t, c.Invoke(func(s *Server, self *ioc.Container) {
+		assert.Same(t, c, self)
+		assert.Same(t, srv, s)
+	}))
+	assert.EqualError(t, c.Invoke(func(*Server) error { return errors.New("invoke") }), "invoke")
+}
+
+// 测试启动与关闭的顺序
+func TestStartClose(t *testing.T) {
+	c := ioc.New()
+	var log []string
+	c.MustProvide(func() *Config { return &Config{} }, ioc.OnStart(func(ctx context.Context, cfg *Config) error {
+		log = append(log, "start config")
+		return nil
+	}), ioc.OnClose(func(ctx context.Context, cfg *Config) error {
+		log = append(log, "close config")
+		return nil
+	}))
+	c.MustProvide(func(cfg *Config) *DB { return &DB{cfg: cfg, log: &log} })
+	c.MustProvide(func(db *DB) *Repo { return &Repo{db: db} })
+	c.MustProvide(func(r *Repo) *Service { return &Service{repo: r, log: &log} })
+	c.MustProvide(func() handler { return "lazy" }, ioc.OnStart(func(ctx context.Context, h handler) error {
+		log = append(log, "start "+string(h))
+		return nil
+	}), ioc.WithLifetime(ioc.Transient))
+
+	// 注册在后、先被解析的依赖仍然先启动 / dependencies start first whatever the registration order
+	ioc.MustResolve[*Service](c)
+	assert.NoError(t, c.Start(context.Background()))
+	assert.Equal(t, []string{"start config", "start db"}, log)
+	assert.NoError(t, c.Start(context.Background()))
+
+	log = nil
+	assert.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, []string{"close service", "close db", "close config"}, log)
+	_, err := ioc.Resolve[*Service](c)
+	assert.ErrorIs(t, err, ioc.ErrClosed)
+	assert.ErrorIs(t, c.Start(context.Background()), ioc.ErrClosed)
+}
+
+type closer struct {
+	name string
+	log  *[]string
+	err  error
+}
+
+func (c *closer) Close() {
+	*c.log = append(*c.log, c.name)
+}
+
+type failing struct{ err error }
+
+func (f *failing) Start(ctx context.Context) error { return f.err }
+func (f *failing) Close() error                    { return f.err }
+
+// 测试启动后懒加载的实例、作用域关闭与错误合并
+func TestLateStartAndErrors(t *testing.T) {
+	c := ioc.New()
+	var log []string
+	started := 0
+	c.MustProvide(func() *closer { return &closer{name: "scoped", log: &log} }, ioc.WithLifetime(ioc.Scoped),
+		ioc.OnStart(func(ctx context.Context, c *closer) error {
+			started++
+			return nil
+		}))
+	assert.NoError(t, c.Start(context.Background()))
+	s := c.Scope()
+	ioc.MustResolve[*closer](s)
+	assert.Equal(t, 1, started)
+	assert.NoError(t, s.Close(context.Background()))
+	assert.Equal(t, []string{"scoped"}, log)
+
+	boom := errors.New("boom")
+	c2 := ioc.New()
+	c2.MustProvide(func() *failing { return &failing{err: boom} })
+	c2.MustProvide(func() *closer { return &closer{name: "plain", log: &log} }, ioc.WithoutLifecycle())
+	err := c2.Start(context.Background())
+	assert.ErrorIs(t, err, boom)
+	assert.EqualError(t, err, "ioc: start *test.failing: boom")
+	log = nil
+	err = c2.Close(context.Background())
+	assert.ErrorIs(t, err, boom)
+	assert.Empty(t, log)
+	assert.True(t, strings.HasPrefix(err.Error(), "ioc: close *test.failing"), fmt.Sprint(err))
+}
@@ -73,3 +73,9 @@ func (t *OptSwitch[T]) Default(run ...func(res *T, err error) (*T, error)) *OptS
 func (t *OptSwitch[T]) Submit() (*T, error) {
 	return t.lastResult, t.lastError
 }
+
+// Result 提交并以Result返回结果
+// Result submits and returns the outcome as a Result
+func (t *OptSwitch[T]) Result() Result[*T] {
+	return ResultOf(t.lastResult, t.lastError)
+}
@@ -0,0 +1,242 @@
+package optional
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/karosown/katool-go/pool"
+)
+
+// ErrEmpty 由空 Optional 转换为 Result 且未指定错误时使用
+// ErrEmpty is used when an empty Optional is converted to a Result without an explicit error
+var ErrEmpty = errors.New("optional: empty")
+
+// PanicError Try 恢复的panic，与 pool.PanicError 为同一类型
+// PanicError is a panic recovered by Try, the same type as pool.PanicError
+type PanicError = pool.PanicError
+
+// Result 成功的值或失败的错误，二者只有其一，用于函数式地传递错误
+// Result holds either a value or an error, never both, so errors can be propagated functionally
+type Result[T any] struct {
+	value T     // 成功时的值 / Value on success
+	err   error // 失败时的错误 / Error on failure
+}
+
+// Ok 创建成功的Result
+// Ok creates a successful Result
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err 创建失败的Result，err 为 nil 时等同于 Ok 零值
+// Err creates a failed Result; a nil err yields Ok of the zero value
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// ResultOf 由 (T, error) 创建Result，err 非 nil 时丢弃值
+// ResultOf creates a Result from a (T, error) pair, dropping the value when err is not nil
+func ResultOf[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(value)
+}
+
+// FromOptional 由Optional创建Result，为空时使用 err，err 为 nil 时使用 ErrEmpty
+// FromOptional creates a Result from an Optional, failing with err when it is empty, or ErrEmpty if err is nil
+func FromOptional[T any](o Optional[T], err error) Result[T] {
+	if o.present {
+		return Ok(o.value)
+	}
+	if err == nil {
+		err = ErrEmpty
+	}
+	return Err[T](err)
+}
+
+// Try 调用函数并把返回值与panic都转换为Result，panic 以 *PanicError 作为错误
+// Try calls fn and turns both its results and a panic into a Result, the panic as a *PanicError
+func Try[T any](fn func() (T, error)) (res Result[T]) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = Err[T](pool.NewPanicError(r))
+		}
+	}()
+	return ResultOf(fn())
+}
+
+// TryValue 同 Try，用于不返回错误的函数
+// TryValue is like Try for functions that return no error
+func TryValue[T any](fn func() T) Result[T] {
+	return Try(func() (T, error) { return fn(), nil })
+}
+
+// IsOk 是否成功
+// IsOk reports whether the Result holds a value
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr 是否失败
+// IsErr reports whether the Result holds an error
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Get 返回 (T, error)
+// Get returns the (T, error) pair
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// Err 返回错误，成功时为 nil
+// Err returns the error, nil on success
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// MustGet 返回值，失败时以错误panic
+// MustGet returns the value and panics with the error on failure
+func (r Result[T]) MustGet() T {
+	if r.err != nil {
+		panic(r.err)
+	}
+	return r.value
+}
+
+// OrElse 失败时返回默认值
+// OrElse returns the default value on failure
+func (r Result[T]) OrElse(defaultValue T) T {
+	if r.err != nil {
+		return defaultValue
+	}
+	return r.value
+}
+
+// OrElseGet 失败时调用函数得到默认值
+// OrElseGet calls the function with the error to get a default value on failure
+func (r Result[T]) OrElseGet(supplier func(err error) T) T {
+	if r.err != nil {
+		return supplier(r.err)
+	}
+	return r.value
+}
+
+// Recover 失败时调用函数尝试恢复，函数仍可返回错误
+// Recover calls the function on failure to try to recover, it may fail again
+func (r Result[T]) Recover(fn func(err error) (T, error)) Result[T] {
+	if r.err == nil {
+		return r
+	}
+	return ResultOf(fn(r.err))
+}
+
+// MapErr 失败时转换错误，例如附加上下文
+// MapErr transforms the error on failure, for example to add context
+func (r Result[T]) MapErr(fn func(err error) error) Result[T] {
+	if r.err == nil {
+		return r
+	}
+	return Err[T](fn(r.err))
+}
+
+// Inspect 成功时以值调用函数，不改变Result
+// Inspect calls the function with the value on success and returns the Result unchanged
+func (r Result[T]) Inspect(consumer func(T)) Result[T] {
+	if r.err == nil {
+		consumer(r.value)
+	}
+	return r
+}
+
+// InspectErr 失败时以错误调用函数，不改变Result
+// InspectErr calls the function with the error on failure and returns the Result unchanged
+func (r Result[T]) InspectErr(consumer func(error)) Result[T] {
+	if r.err != nil {
+		consumer(r.err)
+	}
+	return r
+}
+
+// Filter 成功但不满足条件时以 err 失败
+// Filter fails with err when the value does not satisfy the predicate
+func (r Result[T]) Filter(predicate func(T) bool, err error) Result[T] {
+	if r.err == nil && !predicate(r.value) {
+		return Err[T](err)
+	}
+	return r
+}
+
+// ToOptional 转换为Optional，失败时为空
+// ToOptional converts to an Optional, empty on failure
+func (r Result[T]) ToOptional() Optional[T] {
+	if r.err != nil {
+		return Empty[T]()
+	}
+	return Of(r.value)
+}
+
+// String 返回Result的字符串表示
+// String returns string representation of Result
+func (r Result[T]) String() string {
+	if r.err != nil {
+		return "Err[" + r.err.Error() + "]"
+	}
+	return "Ok[" + fmt.Sprintf("%v", r.value) + "]"
+}
+
+// MapResult 成功时映射值，失败时原样传递错误
+// MapResult maps the value on success and passes the error through on failure
+func MapResult[T, R any](r Result[T], mapper func(T) R) Result[R] {
+	if r.err != nil {
+		return Err[R](r.err)
+	}
+	return Ok(mapper(r.value))
+}
+
+// FlatMapResult 成功时应用返回Result的映射函数
+// FlatMapResult applies a mapping function returning a Result on success
+func FlatMapResult[T, R any](r Result[T], mapper func(T) Result[R]) Result[R] {
+	if r.err != nil {
+		return Err[R](r.err)
+	}
+	return mapper(r.value)
+}
+
+// AndThen 成功时应用返回 (R, error) 的函数，便于串联普通的 Go 函数
+// AndThen applies a function returning (R, error) on success, which chains plain Go functions
+func AndThen[T, R any](r Result[T], fn func(T) (R, error)) Result[R] {
+	if r.err != nil {
+		return Err[R](r.err)
+	}
+	return ResultOf(fn(r.value))
+}
+
+// Collect 全部成功时返回所有值，否则返回第一个错误
+// Collect returns every value when all succeed, otherwise the first error
+func Collect[T any](results []Result[T]) Result[[]T] {
+	values := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			return Err[[]T](r.err)
+		}
+		values = append(values, r.value)
+	}
+	return Ok(values)
+}
+
+// CollectAll 返回所有成功的值与合并后的全部错误
+// CollectAll returns every successful value along with all the errors joined
+func CollectAll[T any](results []Result[T]) ([]T, error) {
+	values := make([]T, 0, len(results))
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		values = append(values, r.value)
+	}
+	return values, errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/karosown/katool-go/container/optional"
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试Result的创建与取值
+func TestResultBasics(t *testing.T) {
+	boom := errors.New("boom")
+	ok := optional.Ok(1)
+	bad := optional.Err[int](boom)
+	assert.True(t, ok.IsOk())
+	assert.True(t, bad.IsErr())
+	assert.Equal(t, "Ok[1]", ok.String())
+	assert.Equal(t, "Err[boom]", bad.String())
+	assert.Equal(t, 1, ok.MustGet())
+	assert.PanicsWithError(t, "boom", func() { bad.MustGet() })
+	assert.Equal(t, 2, bad.OrElse(2))
+	assert.Equal(t, 4, bad.OrElseGet(func(err error) int { return len(err.Error()) }))
+
+	v, err := optional.ResultOf(strconv.Atoi("x")).Get()
+	assert.Equal(t, 0, v)
+	assert.Error(t, err)
+	assert.True(t, optional.Err[int](nil).IsOk())
+
+	assert.Equal(t, optional.Of(1), ok.ToOptional())
+	assert.True(t, bad.ToOptional().IsEmpty())
+	assert.ErrorIs(t, optional.FromOptional(optional.Empty[int](), nil).Err(), optional.ErrEmpty)
+	assert.ErrorIs(t, optional.FromOptional(optional.Empty[int](), boom).Err(), boom)
+	assert.Equal(t, 3, optional.FromOptional(optional.Of(3), boom).MustGet())
+}
+
+// 测试组合子
+func TestResultCombinators(t *testing.T) {
+	boom := errors.New("boom")
+	parsed := optional.AndThen(optional.Ok("21"), strconv.Atoi)
+	doubled := optional.MapResult(parsed, func(v int) int { return v * 2 })
+	assert.Equal(t, 42, doubled.MustGet())
+
+	s := optional.FlatMapResult(doubled, func(v int) optional.Result[string] {
+		return optional.Ok(strconv.Itoa(v))
+	})
+	assert.Equal(t, "42", s.MustGet())
+
+	var seen []string
+	failed := optional.AndThen(optional.Ok("x"), strconv.Atoi).
+		Inspect(func(int) { seen = append(seen, "value") }).
+		InspectErr(func(error) { seen = append(seen, "error") }).
+		MapErr(func(err error) error { return errors.Join(boom, err) })
+	assert.Equal(t, []string{"error"}, seen)
+	assert.ErrorIs(t, failed.Err(), boom)
+	assert.True(t, optional.MapResult(failed, func(v int) int { return v + 1 }).IsErr())
+
+	assert.Equal(t, -1, failed.Recover(func(error) (int, error) { return -1, nil }).MustGet())
+	assert.ErrorIs(t, failed.Recover(func(err error) (int, error) { return 0, err }).Err(), boom)
+	assert.ErrorIs(t, optional.Ok(1).Filter(func(v int) bool { return v > 1 }, boom).Err(), boom)
+}
+
+// 测试汇总与panic捕获
+func TestResultCollectAndTry(t *testing.T) {
+	boom := errors.New("boom")
+	all := optional.Collect([]optional.Result[int]{optional.Ok(1), optional.Ok(2)})
+	assert.Equal(t, []int{1, 2}, all.MustGet())
+	assert.ErrorIs(t, optional.Collect([]optional.Result[int]{optional.Ok(1), optional.Err[int](boom)}).Err(), boom)
+
+	values, err := optional.CollectAll([]optional.Result[int]{optional.Err[int](boom), optional.Ok(1), optional.Err[int](boom)})
+	assert.Equal(t, []int{1}, values)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+
+	r := optional.Try(func() (int, error) { panic("oops") })
+	var pe *optional.PanicError
+	assert.ErrorAs(t, r.Err(), &pe)
+	assert.Equal(t, "oops", pe.Value)
+	// 与 pool 及 stream 共用同一类型 / the type is shared with pool and stream
+	var poolErr *pool.PanicError
+	assert.ErrorAs(t, r.Err(), &poolErr)
+	_, err = stream.MapErr(context.Background(), stream.ToStream(&[]int{1}), func(context.Context, int) (int, error) { panic("boom") })
+	assert.ErrorAs(t, err, &poolErr)
+	assert.Equal(t, "boom", poolErr.Value)
+	assert.Equal(t, 5, optional.TryValue(func() int { return 5 }).MustGet())
+
+	res := stream.MapResult(stream.ToStream(&[]string{"1", "x", "3"}), strconv.Atoi).ToList()
+	assert.Len(t, res, 3)
+	assert.True(t, res[1].IsErr())
+	values, err = optional.CollectAll(res)
+	assert.Equal(t, []int{1, 3}, values)
+	assert.Error(t, err)
+
+	one := 1
+	sw := (&optional.OptSwitch[int]{}).Case(true, func() (*int, error) { return &one, nil })
+	assert.Equal(t, &one, sw.Result().MustGet())
+}
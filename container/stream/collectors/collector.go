@@ -0,0 +1,205 @@
+package collectors
+
+// 模仿Java的Collectors，配合 Collect 在串行与并行流上使用
+// 并行模式下每个分片各自调用Supplier生成容器并累加，最后按分片顺序用Combiner合并，因此结果与串行一致
+//
+// Package collectors mimics Java's Collectors and is used together with Collect on sequential and parallel streams.
+// In parallel mode every partition accumulates into its own container created by Supplier, then the
+// containers are merged with Combiner in partition order, so the result matches the sequential one
+
+import (
+	"strings"
+
+	"github.com/karosown/katool-go/container/stream"
+	"golang.org/x/exp/constraints"
+)
+
+// Number 可求和的数值类型
+// Number is a numeric type that can be summed
+type Number interface {
+	constraints.Integer | constraints.Float
+}
+
+// Collector 收集器：T为元素类型，A为中间累加容器，R为最终结果
+// Collector collects elements of type T into an accumulation container A and finishes into R
+type Collector[T any, A any, R any] struct {
+	Supplier    func() A              // 创建新的累加容器 / creates a new accumulation container
+	Accumulator func(acc A, item T) A // 把元素累加进容器 / folds an element into the container
+	Combiner    func(a, b A) A        // 合并两个容器（并行模式）/ merges two containers (parallel mode)
+	Finisher    func(acc A) R         // 把容器转换为最终结果 / converts the container into the result
+}
+
+// Collect 使用收集器收集流，支持并行流
+// Collect collects the stream with the collector, parallel streams are supported
+func Collect[T any, A any, R any, Slice ~[]T](s *stream.Stream[T, Slice], c Collector[T, A, R]) R {
+	return c.Finisher(stream.ReduceWith(s, c.Supplier, c.Accumulator, c.Combiner))
+}
+
+// CollectLazy 使用收集器收集惰性流
+// CollectLazy collects the lazy stream with the collector
+func CollectLazy[T any, A any, R any](s *stream.LazyStream[T], c Collector[T, A, R]) R {
+	return c.Finisher(stream.LazyReduce(s, c.Supplier(), c.Accumulator))
+}
+
+// identity 恒等Finisher
+// identity is the identity finisher
+func identity[A any](acc A) A {
+	return acc
+}
+
+// ToList 收集为切片
+// ToList collects into a slice
+func ToList[T any]() Collector[T, []T, []T] {
+	return Collector[T, []T, []T]{
+		Supplier:    func() []T { return make([]T, 0) },
+		Accumulator: func(acc []T, item T) []T { return append(acc, item) },
+		Combiner:    func(a, b []T) []T { return append(a, b...) },
+		Finisher:    identity[[]T],
+	}
+}
+
+// ToSet 收集为集合
+// ToSet collects into a set
+func ToSet[T comparable]() Collector[T, map[T]struct{}, map[T]struct{}] {
+	return Collector[T, map[T]struct{}, map[T]struct{}]{
+		Supplier: func() map[T]struct{} { return make(map[T]struct{}) },
+		Accumulator: func(acc map[T]struct{}, item T) map[T]struct{} {
+			acc[item] = struct{}{}
+			return acc
+		},
+		Combiner: func(a, b map[T]struct{}) map[T]struct{} {
+			for k := range b {
+				a[k] = struct{}{}
+			}
+			return a
+		},
+		Finisher: identity[map[T]struct{}],
+	}
+}
+
+// Joining 使用分隔符拼接字符串，可选传入前缀与后缀
+// Joining joins strings with the separator, prefix and suffix are optional
+func Joining(sep string, prefixSuffix ...string) Collector[string, []string, string] {
+	prefix, suffix := "", ""
+	if len(prefixSuffix) > 0 {
+		prefix = prefixSuffix[0]
+	}
+	if len(prefixSuffix) > 1 {
+		suffix = prefixSuffix[1]
+	}
+	list := ToList[string]()
+	return Collector[string, []string, string]{
+		Supplier:    list.Supplier,
+		Accumulator: list.Accumulator,
+		Combiner:    list.Combiner,
+		Finisher: func(acc []string) string {
+			return prefix + strings.Join(acc, sep) + suffix
+		},
+	}
+}
+
+// Counting 计数
+// Counting counts the elements
+func Counting[T any]() Collector[T, int64, int64] {
+	return Collector[T, int64, int64]{
+		Supplier:    func() int64 { return 0 },
+		Accumulator: func(acc int64, _ T) int64 { return acc + 1 },
+		Combiner:    func(a, b int64) int64 { return a + b },
+		Finisher:    identity[int64],
+	}
+}
+
+// Summing 求和
+// Summing sums the mapped values
+func Summing[T any, N Number](mapper func(T) N) Collector[T, N, N] {
+	return Collector[T, N, N]{
+		Supplier:    func() N { return 0 },
+		Accumulator: func(acc N, item T) N { return acc + mapper(item) },
+		Combiner:    func(a, b N) N { return a + b },
+		Finisher:    identity[N],
+	}
+}
+
+// average 平均值累加器
+// average is the accumulator of Averaging
+type average struct {
+	count int64
+	sum   float64
+}
+
+// Averaging 求平均值，没有元素时返回0
+// Averaging averages the mapped values, returns 0 when there is no element
+func Averaging[T any, N Number](mapper func(T) N) Collector[T, average, float64] {
+	return Collector[T, average, float64]{
+		Supplier: func() average { return average{} },
+		Accumulator: func(acc average, item T) average {
+			acc.count++
+			acc.sum += float64(mapper(item))
+			return acc
+		},
+		Combiner: func(a, b average) average {
+			return average{count: a.count + b.count, sum: a.sum + b.sum}
+		},
+		Finisher: func(acc average) float64 {
+			if acc.count == 0 {
+				return 0
+			}
+			return acc.sum / float64(acc.count)
+		},
+	}
+}
+
+// Mapping 先映射再交给下游收集器
+// Mapping maps the elements before handing them to the downstream collector
+func Mapping[T any, U any, A any, R any](mapper func(T) U, downstream Collector[U, A, R]) Collector[T, A, R] {
+	return Collector[T, A, R]{
+		Supplier: downstream.Supplier,
+		Accumulator: func(acc A, item T) A {
+			return downstream.Accumulator(acc, mapper(item))
+		},
+		Combiner: downstream.Combiner,
+		Finisher: downstream.Finisher,
+	}
+}
+
+// Filtering 只把满足条件的元素交给下游收集器
+// Filtering only hands the matching elements to the downstream collector
+func Filtering[T any, A any, R any](predicate func(T) bool, downstream Collector[T, A, R]) Collector[T, A, R] {
+	return Collector[T, A, R]{
+		Supplier: downstream.Supplier,
+		Accumulator: func(acc A, item T) A {
+			if !predicate(item) {
+				return acc
+			}
+			return downstream.Accumulator(acc, item)
+		},
+		Combiner: downstream.Combiner,
+		Finisher: downstream.Finisher,
+	}
+}
+
+// teeing Teeing的累加器
+// teeing is the accumulator of Teeing
+type teeing[A1 any, A2 any] struct {
+	first  A1
+	second A2
+}
+
+// Teeing 同时交给两个收集器，再用merger合并两个结果
+// Teeing feeds both collectors and merges their results with merger
+func Teeing[T any, A1 any, R1 any, A2 any, R2 any, R any](c1 Collector[T, A1, R1], c2 Collector[T, A2, R2], merger func(R1, R2) R) Collector[T, teeing[A1, A2], R] {
+	return Collector[T, teeing[A1, A2], R]{
+		Supplier: func() teeing[A1, A2] {
+			return teeing[A1, A2]{first: c1.Supplier(), second: c2.Supplier()}
+		},
+		Accumulator: func(acc teeing[A1, A2], item T) teeing[A1, A2] {
+			return teeing[A1, A2]{first: c1.Accumulator(acc.first, item), second: c2.Accumulator(acc.second, item)}
+		},
+		Combiner: func(a, b teeing[A1, A2]) teeing[A1, A2] {
+			return teeing[A1, A2]{first: c1.Combiner(a.first, b.first), second: c2.Combiner(a.second, b.second)}
+		},
+		Finisher: func(acc teeing[A1, A2]) R {
+			return merger(c1.Finisher(acc.first), c2.Finisher(acc.second))
+		},
+	}
+}
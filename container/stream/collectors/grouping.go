@@ -0,0 +1,140 @@
+package collectors
+
+import (
+	"github.com/karosown/katool-go/container/xmap"
+	"golang.org/x/exp/constraints"
+)
+
+// partition PartitioningBy的累加器
+// partition is the accumulator of PartitioningBy
+type partition[A any] struct {
+	matched   A
+	unmatched A
+}
+
+// PartitioningBy 按条件分为true/false两组，每组交给下游收集器；两个键总是存在
+// PartitioningBy splits the elements into true/false groups collected by downstream; both keys are always present
+func PartitioningBy[T any, A any, R any](predicate func(T) bool, downstream Collector[T, A, R]) Collector[T, partition[A], map[bool]R] {
+	return Collector[T, partition[A], map[bool]R]{
+		Supplier: func() partition[A] {
+			return partition[A]{matched: downstream.Supplier(), unmatched: downstream.Supplier()}
+		},
+		Accumulator: func(acc partition[A], item T) partition[A] {
+			if predicate(item) {
+				acc.matched = downstream.Accumulator(acc.matched, item)
+			} else {
+				acc.unmatched = downstream.Accumulator(acc.unmatched, item)
+			}
+			return acc
+		},
+		Combiner: func(a, b partition[A]) partition[A] {
+			return partition[A]{
+				matched:   downstream.Combiner(a.matched, b.matched),
+				unmatched: downstream.Combiner(a.unmatched, b.unmatched),
+			}
+		},
+		Finisher: func(acc partition[A]) map[bool]R {
+			return map[bool]R{
+				true:  downstream.Finisher(acc.matched),
+				false: downstream.Finisher(acc.unmatched),
+			}
+		},
+	}
+}
+
+// GroupingBy 按key分组，每组交给下游收集器
+// GroupingBy groups the elements by key, every group is collected by downstream
+func GroupingBy[T any, K comparable, A any, R any](key func(T) K, downstream Collector[T, A, R]) Collector[T, map[K]A, map[K]R] {
+	return Collector[T, map[K]A, map[K]R]{
+		Supplier: func() map[K]A { return make(map[K]A) },
+		Accumulator: func(acc map[K]A, item T) map[K]A {
+			k := key(item)
+			group, ok := acc[k]
+			if !ok {
+				group = downstream.Supplier()
+			}
+			acc[k] = downstream.Accumulator(group, item)
+			return acc
+		},
+		Combiner: func(a, b map[K]A) map[K]A {
+			for k, group := range b {
+				if exist, ok := a[k]; ok {
+					a[k] = downstream.Combiner(exist, group)
+				} else {
+					a[k] = group
+				}
+			}
+			return a
+		},
+		Finisher: func(acc map[K]A) map[K]R {
+			res := make(map[K]R, len(acc))
+			for k, group := range acc {
+				res[k] = downstream.Finisher(group)
+			}
+			return res
+		},
+	}
+}
+
+// ToMap 收集为map，键重复时使用merge合并，未提供merge时保留靠后的元素
+// ToMap collects into a map, duplicate keys are merged with merge, the later element wins without it
+func ToMap[T any, K comparable, V any](key func(T) K, value func(T) V, merge ...func(a, b V) V) Collector[T, map[K]V, map[K]V] {
+	mergeFn := mergeOrReplace(merge)
+	return Collector[T, map[K]V, map[K]V]{
+		Supplier: func() map[K]V { return make(map[K]V) },
+		Accumulator: func(acc map[K]V, item T) map[K]V {
+			k, v := key(item), value(item)
+			if exist, ok := acc[k]; ok {
+				v = mergeFn(exist, v)
+			}
+			acc[k] = v
+			return acc
+		},
+		Combiner: func(a, b map[K]V) map[K]V {
+			for k, v := range b {
+				if exist, ok := a[k]; ok {
+					v = mergeFn(exist, v)
+				}
+				a[k] = v
+			}
+			return a
+		},
+		Finisher: identity[map[K]V],
+	}
+}
+
+// ToSortedMap 收集为按键有序的 xmap.SortedMap，键重复时使用merge合并，未提供merge时保留靠后的元素
+// ToSortedMap collects into a key-ordered xmap.SortedMap, duplicate keys are merged with merge, the later element wins without it
+func ToSortedMap[T any, K constraints.Ordered, V any](key func(T) K, value func(T) V, merge ...func(a, b V) V) Collector[T, *xmap.SortedMap[K, V], *xmap.SortedMap[K, V]] {
+	mergeFn := mergeOrReplace(merge)
+	put := func(m *xmap.SortedMap[K, V], k K, v V) {
+		if exist, ok := m.Get(k); ok {
+			v = mergeFn(exist, v)
+		}
+		m.Set(k, v)
+	}
+	return Collector[T, *xmap.SortedMap[K, V], *xmap.SortedMap[K, V]]{
+		Supplier: xmap.NewSortedMap[K, V],
+		Accumulator: func(acc *xmap.SortedMap[K, V], item T) *xmap.SortedMap[K, V] {
+			put(acc, key(item), value(item))
+			return acc
+		},
+		Combiner: func(a, b *xmap.SortedMap[K, V]) *xmap.SortedMap[K, V] {
+			for _, k := range b.SortedKeys() {
+				v, _ := b.Get(k)
+				put(a, k, v)
+			}
+			return a
+		},
+		Finisher: identity[*xmap.SortedMap[K, V]],
+	}
+}
+
+// mergeOrReplace 返回用户的merge函数，未提供时返回“后者覆盖前者”
+// mergeOrReplace returns the user merge function, or "later wins" when it is absent
+func mergeOrReplace[V any](merge []func(a, b V) V) func(a, b V) V {
+	if len(merge) > 0 && merge[0] != nil {
+		return merge[0]
+	}
+	return func(_, b V) V { return b }
+}
@@ -0,0 +1,73 @@
+package collectors
+
+import (
+	"math"
+	"sort"
+)
+
+// Statistics 汇总统计结果
+// Statistics is the result of SummaryStatistics
+type Statistics struct {
+	Count   int64
+	Sum     float64
+	Min     float64
+	Max     float64
+	Average float64
+	sorted  []float64
+}
+
+// Percentile 计算百分位数（p取值0~100，线性插值），没有元素时返回NaN
+// Percentile returns the p-th percentile (p in 0~100, linear interpolation), NaN when there is no element
+func (s Statistics) Percentile(p float64) float64 {
+	n := len(s.sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	if p <= 0 {
+		return s.sorted[0]
+	}
+	if p >= 100 {
+		return s.sorted[n-1]
+	}
+	rank := p / 100 * float64(n-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return s.sorted[lower] + (s.sorted[upper]-s.sorted[lower])*(rank-float64(lower))
+}
+
+// Median 中位数
+// Median returns the 50th percentile
+func (s Statistics) Median() float64 {
+	return s.Percentile(50)
+}
+
+// SummaryStatistics 汇总统计：数量、总和、最小值、最大值、平均值与百分位数；
+// 计算百分位数需要保留全部数值，数据量很大时请考虑采样
+// SummaryStatistics summarizes count, sum, min, max, average and percentiles;
+// percentiles keep every value in memory, consider sampling for very large inputs
+func SummaryStatistics[T any, N Number](mapper func(T) N) Collector[T, []float64, Statistics] {
+	list := ToList[float64]()
+	return Collector[T, []float64, Statistics]{
+		Supplier: list.Supplier,
+		Accumulator: func(acc []float64, item T) []float64 {
+			return append(acc, float64(mapper(item)))
+		},
+		Combiner: list.Combiner,
+		Finisher: func(acc []float64) Statistics {
+			stats := Statistics{Min: math.NaN(), Max: math.NaN(), Average: math.NaN()}
+			if len(acc) == 0 {
+				return stats
+			}
+			sorted := append([]float64(nil), acc...)
+			sort.Float64s(sorted)
+			stats.sorted = sorted
+			stats.Count = int64(len(sorted))
+			stats.Min, stats.Max = sorted[0], sorted[len(sorted)-1]
+			for _, v := range sorted {
+				stats.Sum += v
+			}
+			stats.Average = stats.Sum / float64(stats.Count)
+			return stats
+		},
+	}
+}
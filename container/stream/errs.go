@@ -0,0 +1,199 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/karosown/katool-go/algorithm"
+	"github.com/karosown/katool-go/container/optional"
+	"github.com/karosown/katool-go/pool"
+)
+
+// ElementError 处理某个元素时产生的错误，Index为该元素在流中的下标
+// ElementError is the error produced while processing an element, Index is its position in the stream
+type ElementError struct {
+	Index int
+	Err   error
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("stream: element %d: %v", e.Index, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError 处理元素时发生的panic，会被恢复并作为错误返回，与 pool.PanicError 为同一类型
+// PanicError is a panic raised while processing an element, it is recovered and returned as an error; it is
+// the same type as pool.PanicError
+type PanicError = pool.PanicError
+
+// ErrOption 错误处理选项
+// ErrOption customizes error handling of the *Err operators
+type ErrOption func(*errConfig)
+
+type errConfig struct {
+	collectAll bool
+}
+
+// WithCollectAllErrors 收集所有错误后再返回（errors.Join），默认遇到第一个错误即取消剩余任务
+// WithCollectAllErrors keeps going and returns every error joined, by default the first error cancels the remaining work
+func WithCollectAllErrors() ErrOption {
+	return func(c *errConfig) {
+		c.collectAll = true
+	}
+}
+
+// MapErr 可返回错误、可取消的映射；结果只包含成功处理的元素且保持原有顺序
+// MapErr maps with error and cancellation support; the result only holds the successfully mapped elements, in order
+func MapErr[T any, R any, Slice ~[]T](ctx context.Context, s *Stream[T, Slice], fn func(ctx context.Context, item T) (R, error), opts ...ErrOption) (*Stream[R, []R], error) {
+	options := *s.options
+	res := make([]R, len(options))
+	ok := make([]bool, len(options))
+	err := goRunCtx(ctx, s, opts, func(ctx context.Context, index int, item T) error {
+		r, err := fn(ctx, item)
+		if err != nil {
+			return err
+		}
+		res[index], ok[index] = r, true
+		return nil
+	})
+	list := make([]R, 0, len(res))
+	for i := range res {
+		if ok[i] {
+			list = append(list, res[i])
+		}
+	}
+	return inherit(s, ToStream(&list)), err
+}
+
+// FilterErr 可返回错误、可取消的过滤；结果只包含判定为true的元素且保持原有顺序
+// FilterErr filters with error and cancellation support; the result holds the accepted elements, in order
+func (s *Stream[T, Slice]) FilterErr(ctx context.Context, fn func(ctx context.Context, item T) (bool, error), opts ...ErrOption) (*Stream[T, Slice], error) {
+	options := *s.options
+	keep := make([]bool, len(options))
+	err := goRunCtx(ctx, s, opts, func(ctx context.Context, index int, item T) error {
+		k, err := fn(ctx, item)
+		if err != nil {
+			return err
+		}
+		keep[index] = k
+		return nil
+	})
+	list := make(Slice, 0)
+	for i := range options {
+		if keep[i] {
+			list = append(list, options[i].opt)
+		}
+	}
+	return inherit(s, ToStream(&list)), err
+}
+
+// ForEachErr 可返回错误、可取消的遍历
+// ForEachErr iterates with error and cancellation support
+func (s *Stream[T, Slice]) ForEachErr(ctx context.Context, fn func(ctx context.Context, item T) error, opts ...ErrOption) error {
+	return goRunCtx(ctx, s, opts, func(ctx context.Context, _ int, item T) error {
+		return fn(ctx, item)
+	})
+}
+
+// goRunCtx goRun 的错误感知版本：每个元素单独恢复panic，默认第一个错误会取消ctx并停止剩余任务
+// goRunCtx is the error-aware goRun: panics are recovered per element and, by default, the first error
+// cancels the context so the remaining work stops
+func goRunCtx[T any, Slice ~[]T](ctx context.Context, s *Stream[T, Slice], opts []ErrOption, solve func(ctx context.Context, index int, item T) error) error {
+	cfg := &errConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	options := *s.options
+	size := len(options)
+	pageSize := partitionSize(s.getPageSize, size, s.parallel)
+	goNum := optional.IsTrue(s.maxGoroutineNum <= 0, algorithm.NumOfTwoMultiply(size), s.maxGoroutineNum)
+	if !s.parallel || goNum < 1 {
+		goNum = 1
+	}
+
+	mu := sync.Mutex{}
+	errs := make([]error, 0)
+	fail := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+		if !cfg.collectAll {
+			cancel()
+		}
+	}
+	runPart := func(begin, end int) {
+		for i := begin; i < end; i++ {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := safeSolve(ctx, i, options[i].opt, solve); err != nil {
+				fail(err)
+			}
+		}
+	}
+
+	if goNum == 1 {
+		runPart(0, size)
+	} else {
+		wg := sync.WaitGroup{}
+		limiter := make(chan struct{}, goNum)
+	launch:
+		for begin := 0; begin < size; begin += pageSize {
+			select {
+			case <-ctx.Done():
+				break launch
+			case limiter <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(begin, end int) {
+				defer wg.Done()
+				defer func() { <-limiter }()
+				runPart(begin, end)
+			}(begin, min(begin+pageSize, size))
+		}
+		wg.Wait()
+	}
+
+	if len(errs) > 0 {
+		if cfg.collectAll {
+			return errors.Join(errs...)
+		}
+		return errs[0]
+	}
+	return context.Cause(ctx)
+}
+
+// safeSolve 执行单个元素的处理函数，把panic恢复为错误并附加元素下标
+// safeSolve runs the solve function of one element, turning panics into errors annotated with the element index
+func safeSolve[T any](ctx context.Context, index int, item T, solve func(ctx context.Context, index int, item T) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ElementError{Index: index, Err: pool.NewPanicError(r)}
+		}
+	}()
+	if err = solve(ctx, index, item); err != nil {
+		return &ElementError{Index: index, Err: err}
+	}
+	return nil
+}
+
+// MapResult 把每个元素映射为 optional.Result，失败与panic都保留在对应元素上而不中断流，
+// 之后可用 optional.Collect 或 optional.CollectAll 汇总
+// MapResult maps every element to an optional.Result, keeping failures and panics on their element instead of
+// stopping the stream; optional.Collect or optional.CollectAll can gather them afterwards
+func MapResult[T any, R any, Slice ~[]T](s *Stream[T, Slice], fn func(T) (R, error)) *Stream[optional.Result[R], []optional.Result[R]] {
+	return Map(s, func(item T) optional.Result[R] {
+		return optional.Try(func() (R, error) { return fn(item) })
+	})
+}
@@ -1,6 +1,10 @@
 package stream
 
-import "github.com/karosown/katool-go/algorithm"
+import (
+	"context"
+
+	"github.com/karosown/katool-go/algorithm"
+)
 
 type AbstarctStream[T any, R any, Slice ~[]T, RSlice ~[]R] interface {
 	Sub(begin, end int) *Stream[T, Slice]
@@ -33,4 +37,11 @@ type AbstarctStream[T any, R any, Slice ~[]T, RSlice ~[]R] interface {
 	Parallel() *Stream[T, Slice]
 	ParallelWithSetting(pageSizeGetter func(size int) int, maxGoroutineNum int) *Stream[T, Slice]
 	UnParallel() *Stream[T, Slice]
+	Lazy() *LazyStream[T]
+	FilterErr(ctx context.Context, fn func(ctx context.Context, item T) (bool, error), opts ...ErrOption) (*Stream[T, Slice], error)
+	ForEachErr(ctx context.Context, fn func(ctx context.Context, item T) error, opts ...ErrOption) error
+	TakeWhile(fn func(T) bool) *Stream[T, Slice]
+	DropWhile(fn func(T) bool) *Stream[T, Slice]
+	Peek(fn func(T)) *Stream[T, Slice]
+	Interleave(other *Stream[T, Slice]) *Stream[T, Slice]
 }
@@ -0,0 +1,424 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"iter"
+	"sync"
+
+	"github.com/karosown/katool-go/container/optional"
+)
+
+// LazyStream 基于 iter.Seq 的惰性流，中间操作只组装迭代器，直到终止操作才真正拉取数据
+// 相邻算子会被融合在同一次遍历中，Limit/FindFirst/AnyMatch 等操作会提前终止上游，
+// 因此可以处理无限序列或无法一次性装入内存的数据源（channel、reader、数据库游标）
+// LazyStream is a lazy stream backed by iter.Seq, intermediate operations only compose iterators
+// until a terminal operation pulls the data. Operators are fused into a single pass and
+// Limit/FindFirst/AnyMatch short-circuit the upstream, so infinite sequences and sources
+// that do not fit in memory (channels, readers, database cursors) are supported.
+type LazyStream[T any] struct {
+	seq  iter.Seq[T]
+	errs *sourceErr
+}
+
+// sourceErr 记录数据源在遍历中遇到的第一个错误，由同一数据源派生的流共享
+// sourceErr records the first error a source hit while iterating, shared by the streams derived from it
+type sourceErr struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (e *sourceErr) set(err error) {
+	if e == nil || err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *sourceErr) get() error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// derive 创建与s共享错误的下游流
+// derive creates a downstream stream sharing s's error
+func derive[T any, R any](s *LazyStream[T], seq iter.Seq[R]) *LazyStream[R] {
+	return &LazyStream[R]{seq: seq, errs: s.errs}
+}
+
+// Cursor 数据库游标接口，*mongo.Cursor 满足该接口
+// Cursor is a database cursor, satisfied by *mongo.Cursor
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(val any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// FromSeq 从 iter.Seq 创建惰性流
+// FromSeq creates a lazy stream from an iter.Seq
+func FromSeq[T any](seq iter.Seq[T]) *LazyStream[T] {
+	if seq == nil {
+		seq = func(yield func(T) bool) {}
+	}
+	return &LazyStream[T]{seq: seq, errs: &sourceErr{}}
+}
+
+// FromSeqErr 从 iter.Seq 创建惰性流，seq 通过 fail 报告的第一个错误可在遍历后由 Err 取得
+// FromSeqErr creates a lazy stream from an iter.Seq; the first error seq reports through fail is
+// available from Err after the walk
+func FromSeqErr[T any](seq func(yield func(T) bool, fail func(error))) *LazyStream[T] {
+	errs := &sourceErr{}
+	return &LazyStream[T]{seq: func(yield func(T) bool) { seq(yield, errs.set) }, errs: errs}
+}
+
+// ToLazyStream 从切片创建惰性流
+// ToLazyStream creates a lazy stream from a slice
+func ToLazyStream[T any, Slice ~[]T](source *Slice) *LazyStream[T] {
+	return FromSeq(func(yield func(T) bool) {
+		if source == nil {
+			return
+		}
+		for _, item := range *source {
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// FromChan 从channel创建惰性流，channel关闭时流结束
+// FromChan creates a lazy stream from a channel, the stream ends when the channel is closed
+func FromChan[T any](ch <-chan T) *LazyStream[T] {
+	return FromSeq(func(yield func(T) bool) {
+		for item := range ch {
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// FromLines 按行读取reader创建惰性流（不包含换行符），读取错误在遍历后由 Err 返回
+// FromLines creates a lazy stream reading the reader line by line (without line breaks), a read error
+// is returned by Err after the walk
+func FromLines(reader io.Reader) *LazyStream[string] {
+	return FromSeqErr(func(yield func(string) bool, fail func(error)) {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			if !yield(scanner.Text()) {
+				return
+			}
+		}
+		fail(scanner.Err())
+	})
+}
+
+// FromCursor 从数据库游标创建惰性流，遍历结束或提前终止时关闭游标；解码失败的记录会被跳过，
+// 第一个解码错误或游标错误在遍历后由 Err 返回
+// FromCursor creates a lazy stream from a database cursor, the cursor is closed when the walk ends
+// or stops early. Records that fail to decode are skipped; the first decode or cursor error is
+// returned by Err after the walk
+func FromCursor[T any](ctx context.Context, cursor Cursor) *LazyStream[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return FromSeqErr(func(yield func(T) bool, fail func(error)) {
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var item T
+			if err := cursor.Decode(&item); err != nil {
+				fail(err)
+				continue
+			}
+			if !yield(item) {
+				return
+			}
+		}
+		fail(cursor.Err())
+	})
+}
+
+// Iterate 以seed为起点，不断应用next生成无限流
+// Iterate creates an infinite stream by repeatedly applying next starting from seed
+func Iterate[T any](seed T, next func(T) T) *LazyStream[T] {
+	return FromSeq(func(yield func(T) bool) {
+		for cur := seed; ; cur = next(cur) {
+			if !yield(cur) {
+				return
+			}
+		}
+	})
+}
+
+// Generate 不断调用supplier生成无限流
+// Generate creates an infinite stream by repeatedly calling supplier
+func Generate[T any](supplier func() T) *LazyStream[T] {
+	return FromSeq(func(yield func(T) bool) {
+		for {
+			if !yield(supplier()) {
+				return
+			}
+		}
+	})
+}
+
+// Lazy 转换为惰性流
+// Lazy converts to a lazy stream
+func (s *Stream[T, Slice]) Lazy() *LazyStream[T] {
+	options := *s.options
+	return FromSeq(func(yield func(T) bool) {
+		for i := 0; i < len(options); i++ {
+			if !yield(options[i].opt) {
+				return
+			}
+		}
+	})
+}
+
+// LazyMap 惰性映射转换元素（类型安全）
+// LazyMap lazily transforms elements (type safe)
+func LazyMap[T any, R any](s *LazyStream[T], fn func(T) R) *LazyStream[R] {
+	return derive(s, func(yield func(R) bool) {
+		s.seq(func(item T) bool {
+			return yield(fn(item))
+		})
+	})
+}
+
+// LazyFlatMap 惰性扁平化处理，子流的错误同样由 Err 返回
+// LazyFlatMap lazily flattens the stream, errors of the inner streams are returned by Err as well
+func LazyFlatMap[T any, R any](s *LazyStream[T], fn func(T) *LazyStream[R]) *LazyStream[R] {
+	return derive(s, func(yield func(R) bool) {
+		stop := false
+		s.seq(func(item T) bool {
+			inner := fn(item)
+			inner.seq(func(r R) bool {
+				stop = !yield(r)
+				return !stop
+			})
+			s.errs.set(inner.Err())
+			return !stop
+		})
+	})
+}
+
+// Seq 返回底层迭代器，可直接用于 for range
+// Seq returns the underlying iterator, usable with for range
+func (s *LazyStream[T]) Seq() iter.Seq[T] {
+	return s.seq
+}
+
+// Err 数据源在遍历中遇到的第一个错误，应在终止操作之后调用；由同一数据源派生的流返回相同的错误
+// Err returns the first error the source hit while iterating and should be called after a terminal
+// operation; streams derived from the same source return the same error
+func (s *LazyStream[T]) Err() error {
+	return s.errs.get()
+}
+
+// Map 惰性映射转换元素（同类型），跨类型映射请使用 LazyMap
+// Map lazily transforms elements of the same type, use LazyMap for other types
+func (s *LazyStream[T]) Map(fn func(T) T) *LazyStream[T] {
+	return LazyMap(s, fn)
+}
+
+// Filter 惰性过滤元素
+// Filter lazily filters elements
+func (s *LazyStream[T]) Filter(fn func(T) bool) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		s.seq(func(item T) bool {
+			if !fn(item) {
+				return true
+			}
+			return yield(item)
+		})
+	})
+}
+
+// Peek 在元素流过时执行fn，不改变元素
+// Peek runs fn on each element as it flows through, without changing it
+func (s *LazyStream[T]) Peek(fn func(T)) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		s.seq(func(item T) bool {
+			fn(item)
+			return yield(item)
+		})
+	})
+}
+
+// TakeWhile 获取元素直到第一个不满足条件的元素，随即停止上游
+// TakeWhile takes elements until the first one that does not match, then stops the upstream
+func (s *LazyStream[T]) TakeWhile(fn func(T) bool) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		s.seq(func(item T) bool {
+			return fn(item) && yield(item)
+		})
+	})
+}
+
+// DropWhile 丢弃元素直到第一个不满足条件的元素
+// DropWhile drops elements until the first one that does not match
+func (s *LazyStream[T]) DropWhile(fn func(T) bool) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		dropping := true
+		s.seq(func(item T) bool {
+			if dropping && fn(item) {
+				return true
+			}
+			dropping = false
+			return yield(item)
+		})
+	})
+}
+
+// Limit 只保留前n个元素，达到数量后立即停止上游
+// Limit keeps the first n elements and stops the upstream as soon as they are taken
+func (s *LazyStream[T]) Limit(n int) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		count := 0
+		s.seq(func(item T) bool {
+			count++
+			if !yield(item) {
+				return false
+			}
+			return count < n
+		})
+	})
+}
+
+// Skip 跳过前n个元素
+// Skip skips the first n elements
+func (s *LazyStream[T]) Skip(n int) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		count := 0
+		s.seq(func(item T) bool {
+			if count < n {
+				count++
+				return true
+			}
+			return yield(item)
+		})
+	})
+}
+
+// Sub 获取[begin, end)区间的元素，不支持负数索引
+// Sub takes the elements in [begin, end), negative indexes are not supported
+func (s *LazyStream[T]) Sub(begin, end int) *LazyStream[T] {
+	return s.Skip(begin).Limit(end - begin)
+}
+
+// DistinctBy 按照key去重，已出现的key保存在内存中
+// DistinctBy removes duplicates by key, seen keys are kept in memory
+func (s *LazyStream[T]) DistinctBy(key func(T) any) *LazyStream[T] {
+	return derive(s, func(yield func(T) bool) {
+		seen := make(map[any]struct{})
+		s.seq(func(item T) bool {
+			k := key(item)
+			if _, ok := seen[k]; ok {
+				return true
+			}
+			seen[k] = struct{}{}
+			return yield(item)
+		})
+	})
+}
+
+// ForEach 遍历每个元素（终止操作）
+// ForEach iterates over each element (terminal operation)
+func (s *LazyStream[T]) ForEach(fn func(T)) {
+	s.seq(func(item T) bool {
+		fn(item)
+		return true
+	})
+}
+
+// ToList 收集为切片（终止操作）
+// ToList collects into a slice (terminal operation)
+func (s *LazyStream[T]) ToList() []T {
+	res := make([]T, 0)
+	s.seq(func(item T) bool {
+		res = append(res, item)
+		return true
+	})
+	return res
+}
+
+// Collect 收集为急切流，之后可使用 Stream 的全部能力（包括并行）
+// Collect collects into an eager Stream so every Stream operation (including parallel) is available
+func (s *LazyStream[T]) Collect() *Stream[T, []T] {
+	list := s.ToList()
+	return ToStream(&list)
+}
+
+// FindFirst 返回第一个元素，取到后立即停止
+// FindFirst returns the first element and stops right after it
+func (s *LazyStream[T]) FindFirst() optional.Optional[T] {
+	res := optional.Empty[T]()
+	s.seq(func(item T) bool {
+		res = optional.Of(item)
+		return false
+	})
+	return res
+}
+
+// AnyMatch 是否存在满足条件的元素，命中后立即停止
+// AnyMatch reports whether any element matches, stops at the first match
+func (s *LazyStream[T]) AnyMatch(fn func(T) bool) bool {
+	return s.Filter(fn).FindFirst().IsPresent()
+}
+
+// AllMatch 是否所有元素都满足条件，遇到不满足的元素立即停止
+// AllMatch reports whether all elements match, stops at the first mismatch
+func (s *LazyStream[T]) AllMatch(fn func(T) bool) bool {
+	return !s.AnyMatch(func(item T) bool { return !fn(item) })
+}
+
+// NoneMatch 是否没有元素满足条件
+// NoneMatch reports whether no element matches
+func (s *LazyStream[T]) NoneMatch(fn func(T) bool) bool {
+	return !s.AnyMatch(fn)
+}
+
+// Count 计算元素数量（终止操作）
+// Count counts the elements (terminal operation)
+func (s *LazyStream[T]) Count() int64 {
+	var count int64
+	s.seq(func(T) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// Reduce 归约（终止操作）
+// Reduce reduces the elements (terminal operation)
+func (s *LazyStream[T]) Reduce(begin T, fn func(acc T, item T) T) T {
+	s.seq(func(item T) bool {
+		begin = fn(begin, item)
+		return true
+	})
+	return begin
+}
+
+// LazyReduce 类型安全的归约（终止操作）
+// LazyReduce is a type safe reduction (terminal operation)
+func LazyReduce[T any, A any](s *LazyStream[T], begin A, fn func(acc A, item T) A) A {
+	s.seq(func(item T) bool {
+		begin = fn(begin, item)
+		return true
+	})
+	return begin
+}
@@ -1,9 +1,5 @@
 package stream
 
-import (
-	"github.com/karosown/katool-go/convert"
-)
-
 // Entry 键值对条目
 // Entry represents a key-value pair entry
 type Entry[K comparable, V any] struct {
@@ -38,19 +34,17 @@ func (e Entries[K, V]) Identity() *[]Entry[K, V] {
 // KeySet 获取所有键的集合
 // KeySet gets a set of all keys
 func (e Entries[K, V]) KeySet() []K {
-	keyset := e.ToStream().Map(func(i Entry[K, V]) any {
+	return Map(e.ToStream(), func(i Entry[K, V]) K {
 		return i.Key
 	}).ToList()
-	return convert.FromAnySlice[K](keyset)
 }
 
 // Values 获取所有值的集合
 // Values gets a set of all values
 func (e Entries[K, V]) Values() []V {
-	keyset := e.ToStream().Map(func(i Entry[K, V]) any {
+	return Map(e.ToStream(), func(i Entry[K, V]) V {
 		return i.Value
 	}).ToList()
-	return convert.FromAnySlice[V](keyset)
 }
 
 // KeySetStream 获取键的流
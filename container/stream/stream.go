@@ -296,18 +296,17 @@ func (s *Stream[T, Slice]) ToOptionList() Options[T] {
 // ToList 转换为列表
 // ToList converts to list
 func (s *Stream[T, Slice]) ToList() Slice {
-	res := make([]T, 0)
 	size := len(*s.options)
-	resChan := make(chan T, size)
+	res := make([]T, size)
+	// 按下标写回，并行模式下同样保持原有顺序
+	// write back by index so the order is kept in parallel mode as well
+	pageSize := partitionSize(s.getPageSize, size, s.parallel)
 	goRun[Option[T]](s.getPageSize, s.maxGoroutineNum, *s.options, s.parallel, func(pos int, options []Option[T]) error {
 		for i := 0; i < len(options); i++ {
-			resChan <- (options)[i].opt
+			res[pos*pageSize+i] = (options)[i].opt
 		}
 		return nil
 	})
-	for i := 0; i < size; i++ {
-		res = append(res, <-resChan)
-	}
 	return res
 }
 
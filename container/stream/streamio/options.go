@@ -0,0 +1,119 @@
+package streamio
+
+// 基于 I/O 的流数据源与终止写出（CSV、JSONL、行文本、mq 订阅与发布、Mongo 批量插入）
+// 数据源返回惰性流，按需逐条读取；写出端按批次消费，并通过有界的在途批次数对上游形成背压，
+// 因此大文件导出、消息转存等场景无需先把全部数据装入内存
+//
+// Package streamio provides I/O-backed stream sources and sinks (CSV, JSONL, text lines,
+// mq subscriptions and publishing, Mongo bulk inserts). Sources return lazy streams that read
+// one record at a time; sinks consume in batches and apply back-pressure to the upstream through
+// a bounded number of in-flight batches, so large exports or message dumps never need to hold
+// all the data in memory
+
+import (
+	"time"
+
+	"github.com/karosown/katool-go/mq"
+)
+
+const (
+	// DefaultBatchSize 默认批次大小
+	// DefaultBatchSize is the default batch size
+	DefaultBatchSize = 500
+	// DefaultBuffer 订阅数据源默认的缓冲消息数
+	// DefaultBuffer is the default number of buffered messages of a subscription source
+	DefaultBuffer = 64
+)
+
+// Options 数据源与写出端的配置
+// Options configures sources and sinks
+type Options struct {
+	BatchSize     int                  // 每批元素数 / elements per batch
+	Concurrency   int                  // 同时在途的批次数 / batches in flight at the same time
+	FlushInterval time.Duration        // 不足一批时的最长等待 / max wait before a partial batch is flushed
+	Buffer        int                  // 订阅缓冲区大小 / subscription buffer size
+	OnError       func(err error)      // 数据源中被跳过的记录的错误 / errors of records skipped by sources
+	Subscribe     []mq.SubscribeOption // 订阅选项 / subscribe options
+	Publish       []mq.PublishOption   // 发布选项 / publish options
+}
+
+// Option 函数式选项
+// Option is a functional option
+type Option func(*Options)
+
+// NewOptions 应用默认值与选项
+// NewOptions applies the defaults and the options
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		BatchSize:   DefaultBatchSize,
+		Concurrency: 1,
+		Buffer:      DefaultBuffer,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	if options.Buffer < 0 {
+		options.Buffer = 0
+	}
+	return options
+}
+
+// onError 报告被跳过的错误
+// onError reports a skipped error
+func (o Options) onError(err error) {
+	if err != nil && o.OnError != nil {
+		o.OnError(err)
+	}
+}
+
+// WithBatchSize 设置批次大小
+// WithBatchSize sets the batch size
+func WithBatchSize(size int) Option {
+	return func(o *Options) { o.BatchSize = size }
+}
+
+// WithConcurrency 设置同时在途的批次数，达到上限时上游会被阻塞；大于1时批次的写出顺序不保证
+// 文件类写出端总是串行写出，忽略该选项
+// WithConcurrency sets how many batches may be in flight, the upstream blocks when the limit is reached;
+// with more than one the write order of batches is not guaranteed. File sinks always write sequentially
+func WithConcurrency(n int) Option {
+	return func(o *Options) { o.Concurrency = n }
+}
+
+// WithFlushInterval 不足一批的数据最多等待d后写出，适合消息订阅等长时间运行的数据源
+// WithFlushInterval flushes a partial batch after at most d, useful for long-running sources such as subscriptions
+func WithFlushInterval(d time.Duration) Option {
+	return func(o *Options) { o.FlushInterval = d }
+}
+
+// WithBuffer 设置订阅缓冲区大小，即已交付但尚未消费的消息数上限，达到上限时消息处理函数阻塞，从而对 broker 形成背压
+// WithBuffer sets the subscription buffer size, the bound on messages handed over but not yet consumed;
+// the message handler blocks at that bound, which applies back-pressure to the broker
+func WithBuffer(n int) Option {
+	return func(o *Options) { o.Buffer = n }
+}
+
+// WithErrorHandler 设置数据源错误回调，解析失败的记录会被跳过并报告给fn；导致遍历停止的错误由流的 Err 返回
+// WithErrorHandler sets the source error callback, records that fail to parse are skipped and reported to fn;
+// errors that stop the walk are returned by the stream's Err instead
+func WithErrorHandler(fn func(err error)) Option {
+	return func(o *Options) { o.OnError = fn }
+}
+
+// WithSubscribeOptions 设置 mq 订阅选项
+// WithSubscribeOptions sets the mq subscribe options
+func WithSubscribeOptions(opts ...mq.SubscribeOption) Option {
+	return func(o *Options) { o.Subscribe = append(o.Subscribe, opts...) }
+}
+
+// WithPublishOptions 设置 mq 发布选项
+// WithPublishOptions sets the mq publish options
+func WithPublishOptions(opts ...mq.PublishOption) Option {
+	return func(o *Options) { o.Publish = append(o.Publish, opts...) }
+}
@@ -0,0 +1,241 @@
+package streamio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/db/xmongo/coll"
+	"github.com/karosown/katool-go/file/file_serialize/csv"
+	"github.com/karosown/katool-go/mq"
+)
+
+// Sink 批次写出函数
+// Sink writes one batch
+type Sink[T any] func(ctx context.Context, batch []T) error
+
+// Drain 按批次消费流并交给sink写出（终止操作），返回成功写出的元素数
+// 同时在途的批次数达到 Concurrency 时上游被阻塞；任一批次失败或ctx取消时停止拉取上游，等待在途批次结束后返回错误；
+// 数据源中途失败（流的 Err）时同样返回该错误，不会当作写出完成
+// Drain consumes the stream in batches and hands them to sink (terminal operation), returning how many
+// elements were written. The upstream blocks while Concurrency batches are in flight; when a batch fails
+// or ctx is cancelled the upstream is no longer pulled and the error is returned once in-flight batches finish.
+// A source that fails partway (the stream's Err) is reported as well rather than as a complete write
+func Drain[T any](ctx context.Context, s *stream.LazyStream[T], sink Sink[T], opts ...Option) (int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	o := NewOptions(opts...)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var written atomic.Int64
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.Concurrency)
+	run := func(batch []T) {
+		defer func() { <-sem }()
+		if err := sink(ctx, batch); err != nil {
+			cancel(err)
+			return
+		}
+		written.Add(int64(len(batch)))
+	}
+	batches(ctx, s, o, func(batch []T) bool {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		}
+		if o.Concurrency == 1 {
+			run(batch)
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(batch)
+			}()
+		}
+		return ctx.Err() == nil
+	})
+	wg.Wait()
+	return written.Load(), errors.Join(context.Cause(ctx), s.Err())
+}
+
+// batches 把流切分为批次交给fn，fn返回false时停止；设置了 FlushInterval 时由独立的协程拉取上游，
+// 不足一批的数据最多等待 FlushInterval 后交给fn
+// batches splits the stream into batches for fn and stops when fn returns false; with FlushInterval
+// the upstream is pulled by a separate goroutine and a partial batch waits at most FlushInterval
+func batches[T any](ctx context.Context, s *stream.LazyStream[T], o Options, fn func(batch []T) bool) {
+	batch := make([]T, 0, o.BatchSize)
+	if o.FlushInterval <= 0 {
+		s.Seq()(func(item T) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			batch = append(batch, item)
+			if len(batch) < o.BatchSize {
+				return true
+			}
+			full := batch
+			batch = make([]T, 0, o.BatchSize)
+			return fn(full)
+		})
+		if len(batch) > 0 && ctx.Err() == nil {
+			fn(batch)
+		}
+		return
+	}
+
+	ch := make(chan T, o.BatchSize)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(ch)
+		s.Seq()(func(item T) bool {
+			select {
+			case ch <- item:
+				return true
+			case <-done:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	ticker := time.NewTicker(o.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item, ok := <-ch:
+			if !ok {
+				if len(batch) > 0 {
+					fn(batch)
+				}
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) < o.BatchSize {
+				continue
+			}
+			full := batch
+			batch = make([]T, 0, o.BatchSize)
+			if !fn(full) {
+				return
+			}
+			ticker.Reset(o.FlushInterval)
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+			partial := batch
+			batch = make([]T, 0, o.BatchSize)
+			if !fn(partial) {
+				return
+			}
+		}
+	}
+}
+
+// sequential 文件类写出端只能串行写出
+// sequential forces file sinks to write sequentially
+func sequential(opts []Option) []Option {
+	return append(opts, WithConcurrency(1))
+}
+
+// createFile 创建文件并在写出结束后关闭
+// createFile creates the file and closes it after write
+func createFile(path string, write func(w io.Writer) (int64, error)) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := write(f)
+	return n, errors.Join(err, f.Close())
+}
+
+// WriteCSV 逐批把流写为 CSV，第一批写出时输出标题行，每批写完后刷新
+// WriteCSV writes the stream as CSV batch by batch, the header is written with the first batch and
+// the writer is flushed after every batch
+func WriteCSV[T any](ctx context.Context, s *stream.LazyStream[T], w io.Writer, opts ...Option) (int64, error) {
+	encoder := csv.NewEncoder[T](w)
+	return Drain(ctx, s, func(ctx context.Context, batch []T) error {
+		for _, item := range batch {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return encoder.Flush()
+	}, sequential(opts)...)
+}
+
+// WriteCSVFile 逐批把流写入 CSV 文件
+// WriteCSVFile writes the stream into the CSV file batch by batch
+func WriteCSVFile[T any](ctx context.Context, s *stream.LazyStream[T], path string, opts ...Option) (int64, error) {
+	return createFile(path, func(w io.Writer) (int64, error) {
+		return WriteCSV(ctx, s, w, opts...)
+	})
+}
+
+// WriteJSONL 逐批把流写为 JSON Lines，每批写完后刷新
+// WriteJSONL writes the stream as JSON Lines batch by batch, the writer is flushed after every batch
+func WriteJSONL[T any](ctx context.Context, s *stream.LazyStream[T], w io.Writer, opts ...Option) (int64, error) {
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	return Drain(ctx, s, func(ctx context.Context, batch []T) error {
+		for _, item := range batch {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return buf.Flush()
+	}, sequential(opts)...)
+}
+
+// WriteJSONLFile 逐批把流写入 JSON Lines 文件
+// WriteJSONLFile writes the stream into the JSON Lines file batch by batch
+func WriteJSONLFile[T any](ctx context.Context, s *stream.LazyStream[T], path string, opts ...Option) (int64, error) {
+	return createFile(path, func(w io.Writer) (int64, error) {
+		return WriteJSONL(ctx, s, w, opts...)
+	})
+}
+
+// Publish 把流中的元素逐条发布到 mq 主题，encode 为空时使用 JSON 编码
+// 批次内按顺序发布，Concurrency 控制同时发布的批次数
+// Publish publishes every element to the mq topic, JSON is used when encode is nil.
+// Elements of a batch are published in order and Concurrency controls how many batches publish at once
+func Publish[T any](ctx context.Context, s *stream.LazyStream[T], client mq.Client, topic string, encode func(T) ([]byte, error), opts ...Option) (int64, error) {
+	if encode == nil {
+		encode = func(item T) ([]byte, error) { return json.Marshal(item) }
+	}
+	o := NewOptions(opts...)
+	return Drain(ctx, s, func(ctx context.Context, batch []T) error {
+		for _, item := range batch {
+			payload, err := encode(item)
+			if err != nil {
+				return err
+			}
+			if err := client.Publish(ctx, topic, payload, o.Publish...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// InsertMongo 把流按批次批量插入 Mongo 集合
+// InsertMongo bulk inserts the stream into the Mongo collection batch by batch
+func InsertMongo[T any](ctx context.Context, s *stream.LazyStream[T], collection *coll.Collection[T], opts ...Option) (int64, error) {
+	return Drain(ctx, s, func(ctx context.Context, batch []T) error {
+		_, err := collection.InsertMany(ctx, batch)
+		return err
+	}, opts...)
+}
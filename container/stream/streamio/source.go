@@ -0,0 +1,206 @@
+package streamio
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/file/file_serialize/csv"
+	"github.com/karosown/katool-go/mq"
+)
+
+// maxLineSize 单行最大长度
+// maxLineSize is the max length of a single line
+const maxLineSize = 16 * 1024 * 1024
+
+// 数据源中导致遍历停止的错误（文件打开失败、读取失败等）在遍历后由流的 Err 返回，
+// 被跳过的记录报告给 OnError
+//
+// Errors that stop a source's walk, such as a file that cannot be opened or a failed read, are returned by
+// the stream's Err after the walk; skipped records are reported to OnError
+
+// fromFile 遍历时才打开文件，遍历结束或提前终止时关闭
+// fromFile opens the file when the walk starts and closes it when the walk ends or stops early
+func fromFile[T any](path string, read func(r io.Reader, yield func(T) bool, fail func(error))) *stream.LazyStream[T] {
+	return stream.FromSeqErr(func(yield func(T) bool, fail func(error)) {
+		f, err := os.Open(path)
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer f.Close()
+		read(f, yield, fail)
+	})
+}
+
+// ReadLines 按行读取reader（不包含换行符），读取错误由流的 Err 返回
+// ReadLines reads the reader line by line (without line breaks), a read error is returned by the stream's Err
+func ReadLines(r io.Reader, opts ...Option) *stream.LazyStream[string] {
+	return stream.FromSeqErr(func(yield func(string) bool, fail func(error)) {
+		readLines(r, yield, fail)
+	})
+}
+
+// ReadLinesFile 按行读取文件
+// ReadLinesFile reads the file line by line
+func ReadLinesFile(path string, opts ...Option) *stream.LazyStream[string] {
+	return fromFile(path, readLines)
+}
+
+func readLines(r io.Reader, yield func(string) bool, fail func(error)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if !yield(scanner.Text()) {
+			return
+		}
+	}
+	fail(scanner.Err())
+}
+
+// ReadCSV 逐行读取 CSV 并解码为 T，字段映射规则与 file_serialize/csv 一致，解析失败的行会被跳过
+// ReadCSV reads CSV rows one by one into T using the file_serialize/csv field mapping, rows that fail to parse are skipped
+func ReadCSV[T any](r io.Reader, opts ...Option) *stream.LazyStream[T] {
+	o := NewOptions(opts...)
+	return stream.FromSeqErr(func(yield func(T) bool, fail func(error)) {
+		readCSV(r, o, yield, fail)
+	})
+}
+
+// ReadCSVFile 逐行读取 CSV 文件
+// ReadCSVFile reads the CSV file row by row
+func ReadCSVFile[T any](path string, opts ...Option) *stream.LazyStream[T] {
+	o := NewOptions(opts...)
+	return fromFile(path, func(r io.Reader, yield func(T) bool, fail func(error)) {
+		readCSV(r, o, yield, fail)
+	})
+}
+
+func readCSV[T any](r io.Reader, o Options, yield func(T) bool, fail func(error)) {
+	decoder := csv.NewDecoder[T](r)
+	for {
+		item, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			// 只有单行错误可以跳过，表头缺失、类型不支持或读取失败时停止
+			// only row errors can be skipped, a missing header, an unsupported type or a read failure stops the walk
+			var rowErr *csv.RowError
+			if errors.As(err, &rowErr) {
+				o.onError(err)
+				continue
+			}
+			fail(err)
+			return
+		}
+		if !yield(item) {
+			return
+		}
+	}
+}
+
+// ReadJSONL 逐行读取 JSON Lines 并解码为 T，空行会被忽略，解析失败的行会被跳过
+// ReadJSONL reads JSON Lines into T one by one, blank lines are ignored and lines that fail to parse are skipped
+func ReadJSONL[T any](r io.Reader, opts ...Option) *stream.LazyStream[T] {
+	o := NewOptions(opts...)
+	return stream.FromSeqErr(func(yield func(T) bool, fail func(error)) {
+		readJSONL(r, o, yield, fail)
+	})
+}
+
+// ReadJSONLFile 逐行读取 JSON Lines 文件
+// ReadJSONLFile reads the JSON Lines file line by line
+func ReadJSONLFile[T any](path string, opts ...Option) *stream.LazyStream[T] {
+	o := NewOptions(opts...)
+	return fromFile(path, func(r io.Reader, yield func(T) bool, fail func(error)) {
+		readJSONL(r, o, yield, fail)
+	})
+}
+
+func readJSONL[T any](r io.Reader, o Options, yield func(T) bool, fail func(error)) {
+	line := 0
+	readLines(r, func(text string) bool {
+		line++
+		if strings.TrimSpace(text) == "" {
+			return true
+		}
+		var item T
+		if err := json.Unmarshal([]byte(text), &item); err != nil {
+			o.onError(fmt.Errorf("jsonl line %d: %w", line, err))
+			return true
+		}
+		return yield(item)
+	}, fail)
+}
+
+// Subscribe 订阅 mq 主题并返回消息流，流在ctx取消或下游提前终止时结束并退订
+// 消息处理函数把消息交给流后阻塞，直到下游处理完该消息才返回成功，因此 broker 只会确认已被消费的消息；
+// 流提前终止或ctx取消时尚未消费的消息以错误返回，由 broker 重新投递。Buffer 为已交付但尚未消费的
+// 消息数上限，达到上限时消息处理函数阻塞，从而对 broker 形成背压
+// Subscribe subscribes to the mq topic and returns a message stream, the stream ends and unsubscribes
+// when ctx is cancelled or the downstream stops early. The handler hands each message to the stream and
+// blocks until the downstream has processed it before reporting success, so the broker only acknowledges
+// consumed messages; messages not consumed when the stream stops early or ctx is cancelled are reported
+// as errors and redelivered by the broker. Buffer bounds the messages handed over but not yet consumed,
+// and the handler blocks at that bound, which applies back-pressure to the broker
+func Subscribe(ctx context.Context, client mq.Client, topic string, opts ...Option) *stream.LazyStream[mq.Message] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	o := NewOptions(opts...)
+	return stream.FromSeq(func(yield func(mq.Message) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch := make(chan delivery, o.Buffer)
+		handler := func(hctx context.Context, msg mq.Message) error {
+			d := delivery{msg: msg, consumed: make(chan struct{})}
+			select {
+			case ch <- d:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-hctx.Done():
+				return hctx.Err()
+			}
+			select {
+			case <-d.consumed:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-hctx.Done():
+				return hctx.Err()
+			}
+		}
+		if err := client.Subscribe(ctx, topic, handler, o.Subscribe...); err != nil {
+			o.onError(err)
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case d := <-ch:
+				// yield 返回时下游已处理完该消息，返回false也表示该消息已被消费
+				// the downstream has processed the message once yield returns, false included
+				more := yield(d.msg)
+				close(d.consumed)
+				if !more {
+					return
+				}
+			}
+		}
+	})
+}
+
+// delivery 交给流的一条消息，consumed 在下游处理完后关闭
+// delivery is a message handed to the stream, consumed is closed once the downstream has processed it
+type delivery struct {
+	msg      mq.Message
+	consumed chan struct{}
+}
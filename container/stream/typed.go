@@ -0,0 +1,146 @@
+package stream
+
+import "github.com/karosown/katool-go/sys"
+
+// 类型安全的流转换函数
+// Go 的方法不能声明额外的类型参数，因此 Stream.Map/FlatMap/Reduce/ToMap/GroupBy 只能经过 any；
+// 这里以包级泛型函数的形式提供全程静态类型的版本，串行与并行模式下结果顺序一致
+//
+// Type safe stream transformations
+// Go methods cannot declare extra type parameters, so Stream.Map/FlatMap/Reduce/ToMap/GroupBy go through any;
+// the package-level generic functions below keep static types end to end, and produce results
+// in the same order in both sequential and parallel modes
+
+// inherit 继承源流的并行配置
+// inherit copies the parallel settings of the source stream
+func inherit[T any, R any, Slice ~[]T, RSlice ~[]R](from *Stream[T, Slice], to *Stream[R, RSlice]) *Stream[R, RSlice] {
+	to.getPageSize = from.getPageSize
+	to.maxGoroutineNum = from.maxGoroutineNum
+	to.parallel = from.parallel
+	return to
+}
+
+// Map 类型安全的映射转换
+// Map transforms elements with static types
+func Map[T any, R any, Slice ~[]T](s *Stream[T, Slice], fn func(T) R) *Stream[R, []R] {
+	res := mapSlice(s, fn)
+	return inherit(s, ToStream(&res))
+}
+
+// mapSlice 按原有顺序映射为切片，并行模式下各分片写入各自的下标区间
+// mapSlice maps into a slice in the original order, partitions write their own index range in parallel mode
+func mapSlice[T any, R any, Slice ~[]T](s *Stream[T, Slice], fn func(T) R) []R {
+	options := *s.options
+	size := len(options)
+	pageSize := partitionSize(s.getPageSize, size, s.parallel)
+	res := make([]R, size)
+	goRun[Option[T]](s.getPageSize, s.maxGoroutineNum, options, s.parallel, func(pos int, part []Option[T]) error {
+		for i := 0; i < len(part); i++ {
+			res[pos*pageSize+i] = fn(part[i].opt)
+		}
+		return nil
+	})
+	return res
+}
+
+// FlatMap 类型安全的扁平化处理
+// FlatMap flattens the stream with static types
+func FlatMap[T any, R any, Slice ~[]T, RSlice ~[]R](s *Stream[T, Slice], fn func(T) *Stream[R, RSlice]) *Stream[R, []R] {
+	parts := mapSlice(s, func(item T) RSlice {
+		return fn(item).ToList()
+	})
+	res := make([]R, 0, len(parts))
+	for _, part := range parts {
+		res = append(res, part...)
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// Reduce 类型安全的归约；并行模式下每个分片从A的零值开始累加，再按分片顺序用combine合并到begin
+// Reduce reduces with static types; in parallel mode every partition starts from the zero value of A
+// and the partial results are merged into begin with combine in partition order
+func Reduce[T any, A any, Slice ~[]T](s *Stream[T, Slice], begin A, accumulate func(acc A, item T) A, combine func(a, b A) A) A {
+	options := *s.options
+	if !s.parallel {
+		for i := 0; i < len(options); i++ {
+			begin = accumulate(begin, options[i].opt)
+		}
+		return begin
+	}
+	if combine == nil {
+		sys.Panic("combine must not be nil in parallel mode")
+	}
+	pageSize := partitionSize(s.getPageSize, len(options), s.parallel)
+	partials := make([]A, (len(options)+pageSize-1)/pageSize)
+	goRun[Option[T]](s.getPageSize, s.maxGoroutineNum, options, s.parallel, func(pos int, part []Option[T]) error {
+		var acc A
+		for i := 0; i < len(part); i++ {
+			acc = accumulate(acc, part[i].opt)
+		}
+		partials[pos] = acc
+		return nil
+	})
+	for _, partial := range partials {
+		begin = combine(begin, partial)
+	}
+	return begin
+}
+
+// GroupBy 类型安全的分组，组内元素保持原有顺序
+// GroupBy groups elements with static types, elements keep their order inside each group
+func GroupBy[T any, K comparable, Slice ~[]T](s *Stream[T, Slice], key func(T) K) map[K][]T {
+	keys := mapSlice(s, key)
+	options := *s.options
+	res := make(map[K][]T)
+	for i, k := range keys {
+		res[k] = append(res[k], options[i].opt)
+	}
+	return res
+}
+
+// ToMap 类型安全的映射收集，键重复时保留流中靠后的元素
+// ToMap collects into a map with static types, later elements win on duplicate keys
+func ToMap[T any, K comparable, V any, Slice ~[]T](s *Stream[T, Slice], key func(T) K, value func(T) V) map[K]V {
+	entries := mapSlice(s, func(item T) Entry[K, V] {
+		return Entry[K, V]{Key: key(item), Value: value(item)}
+	})
+	res := make(map[K]V, len(entries))
+	for _, entry := range entries {
+		res[entry.Key] = entry.Value
+	}
+	return res
+}
+
+// ReduceWith 带初始值生成器的归约：每个分片都从supplier()开始累加，再按分片顺序用combine合并；
+// 适用于累加器是可变容器（map、切片、结构体指针）的场景，是 collectors 包的基础
+// ReduceWith reduces with an identity supplier: every partition starts from supplier(), and the partial
+// results are merged with combine in partition order; it suits mutable accumulators (maps, slices,
+// struct pointers) and backs the collectors package
+func ReduceWith[T any, A any, Slice ~[]T](s *Stream[T, Slice], supplier func() A, accumulate func(acc A, item T) A, combine func(a, b A) A) A {
+	options := *s.options
+	if !s.parallel || len(options) == 0 {
+		acc := supplier()
+		for i := 0; i < len(options); i++ {
+			acc = accumulate(acc, options[i].opt)
+		}
+		return acc
+	}
+	if combine == nil {
+		sys.Panic("combine must not be nil in parallel mode")
+	}
+	pageSize := partitionSize(s.getPageSize, len(options), s.parallel)
+	partials := make([]A, (len(options)+pageSize-1)/pageSize)
+	goRun[Option[T]](s.getPageSize, s.maxGoroutineNum, options, s.parallel, func(pos int, part []Option[T]) error {
+		acc := supplier()
+		for i := 0; i < len(part); i++ {
+			acc = accumulate(acc, part[i].opt)
+		}
+		partials[pos] = acc
+		return nil
+	})
+	acc := partials[0]
+	for _, partial := range partials[1:] {
+		acc = combine(acc, partial)
+	}
+	return acc
+}
@@ -1,11 +1,15 @@
 package stream
 
 import (
-	"fmt"
+	"context"
+	"sync/atomic"
+
 	lynx "github.com/Tangerg/lynx/pkg/sync"
 	"github.com/karosown/katool-go/algorithm"
 	"github.com/karosown/katool-go/collect/lists"
 	"github.com/karosown/katool-go/container/optional"
+	"github.com/karosown/katool-go/pool"
+	"github.com/karosown/katool-go/sys"
 )
 
 // fromAnySlice 从any切片转换为指定类型切片
@@ -101,15 +105,43 @@ func ToParallelStream[T any, Slice ~[]T](source *Slice) *Stream[T, Slice] {
 	}
 }
 
+// commonPool 并行流共用的协程池，见 SetCommonPool
+// commonPool is the pool shared by parallel streams, see SetCommonPool
+var commonPool atomic.Pointer[pool.Pool]
+
+// SetCommonPool 设置并行流共用的协程池，设置后并发度由协程池决定而不再为每次操作创建协程；
+// 传入 nil 恢复默认行为，协程池关闭后同样回退到默认行为
+// SetCommonPool sets the pool shared by parallel streams, concurrency is then bounded by the pool instead
+// of spawning goroutines per operation; nil restores the default, which is also used once the pool is closed
+func SetCommonPool(p *pool.Pool) {
+	commonPool.Store(p)
+}
+
 // goRun 并行执行辅助函数
 // goRun is a helper function for parallel execution
 func goRun[T any](getPageSize func(int) int, maxGoroutineNum int, datas []T, parallel bool, solve func(pos int, automicDatas []T) error) {
 	size := len(datas)
-	pageSize := optional.IsTrue((getPageSize(size)) == 0, 1, getPageSize(size))
 	goNum := optional.IsTrue(maxGoroutineNum == 0, algorithm.NumOfTwoMultiply(size), maxGoroutineNum)
-	err := lists.Partition(datas, optional.IsTrue(parallel, pageSize, 1)).ForEach(solve, parallel, lynx.NewLimiter(optional.IsTrue(parallel, goNum, 1)))
+	batch := lists.Partition(datas, partitionSize(getPageSize, size, parallel))
+	var err error
+	if p := commonPool.Load(); parallel && p != nil && !p.Closed() {
+		err = batch.ForEachWithPool(context.Background(), p, solve)
+	} else {
+		err = batch.ForEach(solve, parallel, lynx.NewLimiter(optional.IsTrue(parallel, goNum, 1)))
+	}
 	if err != nil {
-		fmt.Println(err)
+		// 这里的回调均不返回错误，需要错误处理请使用 MapErr/FilterErr/ForEachErr
+		// the callbacks here never return errors, use MapErr/FilterErr/ForEachErr for error handling
+		sys.Warn(err.Error())
 	}
 	return
 }
+
+// partitionSize goRun 实际使用的分片大小，pos*partitionSize+i 即为元素下标
+// partitionSize is the partition size used by goRun, pos*partitionSize+i is the element index
+func partitionSize(getPageSize func(int) int, size int, parallel bool) int {
+	if !parallel {
+		return 1
+	}
+	return optional.IsTrue((getPageSize(size)) == 0, 1, getPageSize(size))
+}
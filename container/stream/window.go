@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"github.com/karosown/katool-go/collect/lists"
+)
+
+// 位置相关的算子（分块、滑动窗口、拉链、扫描、TakeWhile/DropWhile、交错、编号）
+// 这些算子的结果依赖元素的先后顺序，因此在并行流上同样按遇到顺序计算，得到的结果与串行一致；
+// 返回的新流继承原流的并行配置，后续算子仍按并行方式执行。Peek 是唯一例外：并行流上回调会并发执行，调用顺序不保证
+//
+// Positional operators (chunk, sliding window, zip, scan, take/drop while, interleave, enumerate)
+// Their results depend on element order, so on parallel streams they are still evaluated in encounter
+// order and produce the same result as on sequential streams; the returned stream inherits the
+// parallel settings so the following operators keep running in parallel. Peek is the only exception:
+// on parallel streams its callback runs concurrently and the call order is not guaranteed.
+// Chunk/SlidingWindow/Enumerate/Zip/Scan change the element type, so they are package-level functions
+// to avoid a generic instantiation cycle
+
+// Pair 二元组
+// Pair is a two-element tuple
+type Pair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// Indexed 带下标的元素
+// Indexed is an element with its index
+type Indexed[T any] struct {
+	Index int
+	Value T
+}
+
+// Chunk 每n个元素分为一块，最后一块可能不足n个
+// Chunk splits the stream into blocks of n elements, the last block may be shorter
+func Chunk[T any, Slice ~[]T](s *Stream[T, Slice], n int) *Stream[Slice, []Slice] {
+	res := make([]Slice, 0)
+	if n > 0 {
+		for _, part := range lists.Partition(s.ToList(), n).SplitData {
+			res = append(res, Slice(part))
+		}
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// SlidingWindow 大小为size、步长为step的滑动窗口，只输出完整的窗口
+// SlidingWindow emits windows of size elements moving by step, only complete windows are emitted
+func SlidingWindow[T any, Slice ~[]T](s *Stream[T, Slice], size, step int) *Stream[Slice, []Slice] {
+	res := make([]Slice, 0)
+	list := s.ToList()
+	if size > 0 && step > 0 {
+		for begin := 0; begin+size <= len(list); begin += step {
+			window := make(Slice, size)
+			copy(window, list[begin:begin+size])
+			res = append(res, window)
+		}
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// TakeWhile 从头开始获取元素，直到第一个不满足条件的元素为止
+// TakeWhile takes elements from the head until the first one that does not match
+func (s *Stream[T, Slice]) TakeWhile(fn func(T) bool) *Stream[T, Slice] {
+	list := s.ToList()
+	end := 0
+	for end < len(list) && fn(list[end]) {
+		end++
+	}
+	res := list[:end:end]
+	return inherit(s, ToStream(&res))
+}
+
+// DropWhile 从头开始丢弃元素，直到第一个不满足条件的元素为止
+// DropWhile drops elements from the head until the first one that does not match
+func (s *Stream[T, Slice]) DropWhile(fn func(T) bool) *Stream[T, Slice] {
+	list := s.ToList()
+	begin := 0
+	for begin < len(list) && fn(list[begin]) {
+		begin++
+	}
+	res := list[begin:]
+	return inherit(s, ToStream(&res))
+}
+
+// Peek 对每个元素执行fn后原样返回，通常用于调试
+// Peek runs fn on every element and returns them unchanged, mostly for debugging
+func (s *Stream[T, Slice]) Peek(fn func(T)) *Stream[T, Slice] {
+	goRun[Option[T]](s.getPageSize, s.maxGoroutineNum, *s.options, s.parallel, func(pos int, options []Option[T]) error {
+		for i := 0; i < len(options); i++ {
+			fn(options[i].opt)
+		}
+		return nil
+	})
+	list := s.ToList()
+	return inherit(s, ToStream(&list))
+}
+
+// Interleave 与另一个流交错合并（a1 b1 a2 b2 ...），较长一方的剩余元素追加在末尾
+// Interleave merges with another stream alternately (a1 b1 a2 b2 ...), the rest of the longer one is appended
+func (s *Stream[T, Slice]) Interleave(other *Stream[T, Slice]) *Stream[T, Slice] {
+	a, b := s.ToList(), other.ToList()
+	res := make(Slice, 0, len(a)+len(b))
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if i < len(a) {
+			res = append(res, a[i])
+		}
+		if i < len(b) {
+			res = append(res, b[i])
+		}
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// Enumerate 为每个元素附加从0开始的下标
+// Enumerate attaches a zero-based index to every element
+func Enumerate[T any, Slice ~[]T](s *Stream[T, Slice]) *Stream[Indexed[T], []Indexed[T]] {
+	list := s.ToList()
+	res := make([]Indexed[T], len(list))
+	for i, item := range list {
+		res[i] = Indexed[T]{Index: i, Value: item}
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// Zip 与另一个流按位置配对，长度取两者较短者
+// Zip pairs elements by position with another stream, the length is the shorter one
+func Zip[T any, U any, Slice ~[]T, USlice ~[]U](s *Stream[T, Slice], other *Stream[U, USlice]) *Stream[Pair[T, U], []Pair[T, U]] {
+	return ZipWith(s, other, func(a T, b U) Pair[T, U] {
+		return Pair[T, U]{First: a, Second: b}
+	})
+}
+
+// ZipWith 与另一个流按位置使用fn合并，长度取两者较短者
+// ZipWith combines elements by position with another stream using fn, the length is the shorter one
+func ZipWith[T any, U any, R any, Slice ~[]T, USlice ~[]U](s *Stream[T, Slice], other *Stream[U, USlice], fn func(T, U) R) *Stream[R, []R] {
+	a, b := s.ToList(), other.ToList()
+	size := min(len(a), len(b))
+	res := make([]R, size)
+	for i := 0; i < size; i++ {
+		res[i] = fn(a[i], b[i])
+	}
+	return inherit(s, ToStream(&res))
+}
+
+// Scan 运行中的累加：输出每一步累加后的结果（不包含初始值）
+// Scan emits the running accumulation after every element (the initial value is not emitted)
+func Scan[T any, A any, Slice ~[]T](s *Stream[T, Slice], begin A, fn func(acc A, item T) A) *Stream[A, []A] {
+	list := s.ToList()
+	res := make([]A, len(list))
+	for i, item := range list {
+		begin = fn(begin, item)
+		res[i] = begin
+	}
+	return inherit(s, ToStream(&res))
+}
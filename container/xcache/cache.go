@@ -0,0 +1,296 @@
+package xcache
+
+// 泛型本地缓存：支持 W-TinyLFU/LRU/LFU 淘汰、条目数与权重上限、默认与单条目 TTL（写入后/访问后过期）、
+// 单飞加载、提前异步刷新、移除监听与命中统计。过期条目在访问时惰性清理，也可以调用 CleanUp 主动清理
+//
+// Package xcache is a generic local cache with W-TinyLFU/LRU/LFU eviction, entry and weight limits,
+// default and per-entry TTL (expire after write/access), single-flight loading, refresh-ahead,
+// removal listeners and hit statistics. Expired entries are removed lazily on access or by CleanUp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoLoader 没有可用的加载函数
+// ErrNoLoader is returned when no loader is available
+var ErrNoLoader = errors.New("xcache: no loader")
+
+// removal 待通知的移除事件
+// removal is a pending removal notification
+type removal[K comparable, V any] struct {
+	key   K
+	value V
+	cause RemovalCause
+}
+
+// Cache 并发安全的泛型本地缓存
+// Cache is a concurrency-safe generic local cache
+type Cache[K comparable, V any] struct {
+	mu     sync.Mutex
+	data   map[K]*entry[K, V]
+	policy policy[K, V]
+	weight int64
+	opts   Options[K, V]
+	stats  statsCounter
+
+	loadMu sync.Mutex
+	calls  map[K]*call[V]
+}
+
+// New 创建缓存
+// New creates a cache
+func New[K comparable, V any](opts ...Option[K, V]) *Cache[K, V] {
+	o := Options[K, V]{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Weigher == nil {
+		o.Weigher = func(K, V) int64 { return 1 }
+	}
+	return &Cache[K, V]{
+		data:   make(map[K]*entry[K, V]),
+		policy: newPolicy(o),
+		opts:   o,
+		calls:  make(map[K]*call[V]),
+	}
+}
+
+// Get 获取未过期的值
+// Get returns the value when present and not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	value, ok, refresh := c.lookup(key)
+	if refresh {
+		go c.refresh(key)
+	}
+	if ok {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return value, ok
+}
+
+// Has 键是否存在且未过期，不影响淘汰顺序与统计
+// Has reports whether the key is present and not expired without touching eviction order or stats
+func (c *Cache[K, V]) Has(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data[key]
+	return ok && !c.expired(e, time.Now())
+}
+
+// lookup 查找条目并记录访问，同时判断是否需要提前刷新
+// lookup finds the entry, records the access and tells whether a refresh-ahead is due
+func (c *Cache[K, V]) lookup(key K) (value V, ok bool, refresh bool) {
+	now := time.Now()
+	var removed []removal[K, V]
+	defer func() { c.notify(removed) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data[key]
+	if !ok {
+		return value, false, false
+	}
+	if c.expired(e, now) {
+		removed = append(removed, c.removeLocked(e, RemovalExpired))
+		return value, false, false
+	}
+	c.policy.access(e)
+	if e.ttl == 0 && c.opts.ExpireAfterAccess > 0 {
+		e.expireAt = c.expireAt(e, now)
+	}
+	if c.opts.RefreshAfterWrite > 0 && c.opts.Loader != nil && !e.refreshing && now.Sub(e.writeTime) >= c.opts.RefreshAfterWrite {
+		e.refreshing = true
+		refresh = true
+	}
+	return e.value, true, refresh
+}
+
+// Set 写入，使用默认过期时间
+// Set stores the value with the default expiration
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.set(key, value, 0)
+}
+
+// SetWithTTL 写入并单独指定写入后过期时间，ttl<=0 时使用默认过期时间
+// SetWithTTL stores the value with its own expire-after-write duration, the default applies when ttl <= 0
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.set(key, value, max(ttl, 0))
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	now := time.Now()
+	weight := c.opts.Weigher(key, value)
+	if ttl == 0 && c.opts.Expiry != nil {
+		ttl = max(c.opts.Expiry(key, value), 0)
+	}
+	var removed []removal[K, V]
+	defer func() { c.notify(removed) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.data[key]; ok {
+		removed = append(removed, removal[K, V]{key, e.value, RemovalReplaced})
+		c.weight += weight - e.weight
+		if e.list != nil {
+			e.list.weight += weight - e.weight
+		}
+		e.value, e.weight, e.ttl, e.writeTime, e.refreshing = value, weight, ttl, now, false
+		e.expireAt = c.expireAt(e, now)
+		c.policy.access(e)
+	} else {
+		e := &entry[K, V]{key: key, value: value, weight: weight, ttl: ttl, writeTime: now}
+		e.expireAt = c.expireAt(e, now)
+		c.data[key] = e
+		c.weight += weight
+		c.policy.add(e)
+	}
+	removed = append(removed, c.evictLocked()...)
+}
+
+// Delete 删除
+// Delete removes the key
+func (c *Cache[K, V]) Delete(key K) {
+	var removed []removal[K, V]
+	defer func() { c.notify(removed) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.data[key]; ok {
+		removed = append(removed, c.removeLocked(e, RemovalExplicit))
+	}
+}
+
+// Clear 清空缓存，每个条目都会以 RemovalExplicit 通知监听器
+// Clear removes every entry, the listener is notified with RemovalExplicit for each of them
+func (c *Cache[K, V]) Clear() {
+	var removed []removal[K, V]
+	defer func() { c.notify(removed) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.data {
+		removed = append(removed, c.removeLocked(e, RemovalExplicit))
+	}
+}
+
+// CleanUp 清理所有过期条目，返回清理数量
+// CleanUp removes every expired entry and returns how many were removed
+func (c *Cache[K, V]) CleanUp() int {
+	now := time.Now()
+	var removed []removal[K, V]
+	defer func() { c.notify(removed) }()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.data {
+		if c.expired(e, now) {
+			removed = append(removed, c.removeLocked(e, RemovalExpired))
+		}
+	}
+	return len(removed)
+}
+
+// Len 条目数，可能包含尚未清理的过期条目
+// Len returns the number of entries, which may include expired entries not cleaned up yet
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// Weight 当前总权重
+// Weight returns the current total weight
+func (c *Cache[K, V]) Weight() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.weight
+}
+
+// Keys 返回所有未过期的键，顺序不保证
+// Keys returns the keys that are not expired in no particular order
+func (c *Cache[K, V]) Keys() []K {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]K, 0, len(c.data))
+	for k, e := range c.data {
+		if !c.expired(e, now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Stats 返回统计快照
+// Stats returns a snapshot of the statistics
+func (c *Cache[K, V]) Stats() Stats {
+	return c.stats.snapshot()
+}
+
+// expireAt 计算条目的过期时间：单独指定的 TTL 优先，否则取默认写入后/访问后过期中较早者
+// expireAt computes the expiration: a per-entry TTL wins, otherwise the earlier of the default
+// expire-after-write and expire-after-access
+func (c *Cache[K, V]) expireAt(e *entry[K, V], now time.Time) time.Time {
+	if e.ttl > 0 {
+		return e.writeTime.Add(e.ttl)
+	}
+	var at time.Time
+	if c.opts.ExpireAfterWrite > 0 {
+		at = e.writeTime.Add(c.opts.ExpireAfterWrite)
+	}
+	if c.opts.ExpireAfterAccess > 0 {
+		if access := now.Add(c.opts.ExpireAfterAccess); at.IsZero() || access.Before(at) {
+			at = access
+		}
+	}
+	return at
+}
+
+func (c *Cache[K, V]) expired(e *entry[K, V], now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// over 是否超出条目数或权重上限
+// over reports whether the entry or weight limit is exceeded
+func (c *Cache[K, V]) over() bool {
+	return (c.opts.MaxEntries > 0 && len(c.data) > c.opts.MaxEntries) ||
+		(c.opts.MaxWeight > 0 && c.weight > c.opts.MaxWeight)
+}
+
+// evictLocked 淘汰条目直到满足上限
+// evictLocked evicts entries until the limits are met
+func (c *Cache[K, V]) evictLocked() []removal[K, V] {
+	var removed []removal[K, V]
+	for c.over() {
+		victim := c.policy.victim()
+		if victim == nil {
+			break
+		}
+		c.stats.evictions.Add(1)
+		c.stats.evictionWeight.Add(victim.weight)
+		removed = append(removed, c.removeLocked(victim, RemovalSize))
+	}
+	return removed
+}
+
+func (c *Cache[K, V]) removeLocked(e *entry[K, V], cause RemovalCause) removal[K, V] {
+	delete(c.data, e.key)
+	c.weight -= e.weight
+	c.policy.remove(e)
+	return removal[K, V]{e.key, e.value, cause}
+}
+
+// notify 在锁外通知监听器
+// notify calls the listener outside the lock
+func (c *Cache[K, V]) notify(removed []removal[K, V]) {
+	if c.opts.RemovalListener == nil {
+		return
+	}
+	for _, r := range removed {
+		c.opts.RemovalListener(r.key, r.value, r.cause)
+	}
+}
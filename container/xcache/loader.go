@@ -0,0 +1,119 @@
+package xcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// call 一次进行中的加载
+// call is an in-flight load
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// GetOrLoad 命中时直接返回，否则调用loader加载并写入缓存；loader为空时使用 WithLoader 配置的默认加载函数
+// 同一个键的并发加载只会执行一次（单飞），其余调用者等待同一结果或在各自的ctx取消时返回；
+// 加载使用第一个调用者的ctx执行，loader中的 panic 会被转换为错误
+// GetOrLoad returns the cached value or loads it with loader and stores it; the default loader from
+// WithLoader is used when loader is nil. Concurrent loads of the same key run once (single flight),
+// the other callers wait for the same result or return when their own ctx is cancelled; the load runs
+// with the first caller's ctx and a panic in loader is turned into an error
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader ...Loader[K, V]) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	load := c.opts.Loader
+	if len(loader) > 0 && loader[0] != nil {
+		load = loader[0]
+	}
+	if load == nil {
+		return *new(V), ErrNoLoader
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.load(ctx, key, load, true)
+}
+
+// Refresh 立即重新加载键（单飞），失败时保留旧值
+// Refresh reloads the key right away (single flight), the old value is kept on failure
+func (c *Cache[K, V]) Refresh(ctx context.Context, key K) error {
+	if c.opts.Loader == nil {
+		return ErrNoLoader
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, err := c.load(ctx, key, c.opts.Loader, true)
+	if err != nil {
+		c.endRefresh(key)
+	}
+	return err
+}
+
+// refresh 提前异步刷新
+// refresh is the asynchronous refresh-ahead
+func (c *Cache[K, V]) refresh(key K) {
+	_ = c.Refresh(context.Background(), key)
+}
+
+// endRefresh 刷新失败后允许再次刷新
+// endRefresh allows another refresh after a failed one
+func (c *Cache[K, V]) endRefresh(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.data[key]; ok {
+		e.refreshing = false
+	}
+}
+
+// load 单飞加载，store 为true时成功后写入缓存，否则由loader自行决定是否写入
+// load loads with single flight; with store it stores the value on success, otherwise the loader decides
+// whether to store it
+func (c *Cache[K, V]) load(ctx context.Context, key K, loader Loader[K, V], store bool) (V, error) {
+	c.loadMu.Lock()
+	if cl, ok := c.calls[key]; ok {
+		c.loadMu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, cl.err
+		case <-ctx.Done():
+			return *new(V), ctx.Err()
+		}
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.loadMu.Unlock()
+
+	start := time.Now()
+	cl.value, cl.err = safeLoad(ctx, key, loader)
+	c.stats.loadTime.Add(int64(time.Since(start)))
+	if cl.err != nil {
+		c.stats.loadFailures.Add(1)
+	} else {
+		c.stats.loadSuccesses.Add(1)
+		if store {
+			c.Set(key, cl.value)
+		}
+	}
+
+	c.loadMu.Lock()
+	delete(c.calls, key)
+	c.loadMu.Unlock()
+	close(cl.done)
+	return cl.value, cl.err
+}
+
+// safeLoad 调用loader并把 panic 转换为错误
+// safeLoad calls loader and turns a panic into an error
+func safeLoad[K comparable, V any](ctx context.Context, key K, loader Loader[K, V]) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("xcache: loader panic: %v", r)
+		}
+	}()
+	return loader(ctx, key)
+}
@@ -0,0 +1,485 @@
+package xcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound 近端缓存的 Loader 用它表示数据源中不存在该键
+	// ErrNotFound is returned by a near cache Loader when the key does not exist in the source
+	ErrNotFound = errors.New("xcache: not found")
+	// ErrClosed 近端缓存已关闭
+	// ErrClosed is returned after the near cache is closed
+	ErrClosed = errors.New("xcache: near cache closed")
+)
+
+// WriteMode 写入模式
+// WriteMode is the write consistency mode
+type WriteMode int
+
+const (
+	// WriteThrough 同步写远端，成功后更新本地并广播失效，写入返回时远端已是最新值
+	// WriteThrough writes the remote synchronously, then updates the local copy and broadcasts;
+	// the remote is up to date when the write returns
+	WriteThrough WriteMode = iota
+	// WriteBehind 立即更新本地，远端写入与失效广播由后台按顺序异步完成，队列满时写入阻塞
+	// WriteBehind updates the local copy right away while a background worker writes the remote and
+	// broadcasts in order; writes block while the queue is full
+	WriteBehind
+)
+
+// NearOptions 近端缓存配置
+// NearOptions configures the near cache
+type NearOptions[K comparable, V any] struct {
+	LocalMaxEntries int           // 本地最大条目数 / max local entries
+	LocalTTL        time.Duration // 本地副本有效期，兜底失效消息丢失，默认5分钟 / local copy TTL, a safety net for lost invalidations, 5 minutes by default
+	Jitter          float64       // 本地TTL随机抖动比例，避免集中过期 / random TTL jitter ratio to spread expirations
+	NullTTL         time.Duration // 空值缓存时间，0表示不缓存空值 / how long misses are cached, 0 disables it
+	Loader          Loader[K, V]  // 远端未命中时的数据源 / source of truth when the remote misses
+	Invalidator     Invalidator
+	WriteMode       WriteMode
+	QueueSize       int                    // 异步写队列长度 / write-behind queue size
+	OnError         func(key K, err error) // 异步写入、回填与广播的错误 / errors of write-behind, backfill and broadcast
+}
+
+// NearOption 近端缓存选项
+// NearOption is a near cache option
+type NearOption[K comparable, V any] func(*NearOptions[K, V])
+
+// WithLocalCache 设置本地副本的最大条目数与有效期
+// WithLocalCache sets the max local entries and the local copy TTL
+func WithLocalCache[K comparable, V any](maxEntries int, ttl time.Duration) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) {
+		o.LocalMaxEntries = maxEntries
+		o.LocalTTL = ttl
+	}
+}
+
+// WithJitter 设置本地TTL的随机抖动比例（0~1）
+// WithJitter sets the random jitter ratio (0~1) of the local TTL
+func WithJitter[K comparable, V any](ratio float64) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) { o.Jitter = ratio }
+}
+
+// WithNullTTL 缓存不存在的键d时间，防止缓存穿透
+// WithNullTTL caches missing keys for d to prevent cache penetration
+func WithNullTTL[K comparable, V any](d time.Duration) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) { o.NullTTL = d }
+}
+
+// WithSource 远端未命中时从loader加载并回填远端，loader返回 ErrNotFound 表示不存在
+// WithSource loads from loader when the remote misses and backfills the remote, loader returns
+// ErrNotFound when the key does not exist
+func WithSource[K comparable, V any](loader Loader[K, V]) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) { o.Loader = loader }
+}
+
+// WithInvalidator 设置失效广播通道
+// WithInvalidator sets the invalidation channel
+func WithInvalidator[K comparable, V any](invalidator Invalidator) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) { o.Invalidator = invalidator }
+}
+
+// WithWriteBehind 使用异步写入，queueSize为队列长度
+// WithWriteBehind switches to write-behind with a queue of queueSize operations
+func WithWriteBehind[K comparable, V any](queueSize int) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) {
+		o.WriteMode = WriteBehind
+		o.QueueSize = queueSize
+	}
+}
+
+// WithErrorHandler 设置后台错误回调
+// WithErrorHandler sets the background error callback
+func WithErrorHandler[K comparable, V any](fn func(key K, err error)) NearOption[K, V] {
+	return func(o *NearOptions[K, V]) { o.OnError = fn }
+}
+
+// nearValue 本地副本，present 为false时表示缓存的空值
+// nearValue is a local copy, present == false marks a cached miss
+type nearValue[V any] struct {
+	value   V
+	present bool
+}
+
+// invalidation 失效消息
+// invalidation is an invalidation message
+type invalidation[K any] struct {
+	Origin string `json:"origin"`
+	Keys   []K    `json:"keys,omitempty"`
+	All    bool   `json:"all,omitempty"`
+}
+
+// writeOp 异步写操作，done 非空时为 Flush 的标记
+// writeOp is a write-behind operation, a non-nil done marks a Flush
+type writeOp[K comparable, V any] struct {
+	key    K
+	value  V
+	delete bool
+	done   chan struct{}
+}
+
+// NearCache 二级缓存：进程内本地缓存 + 远端（RedisMap/RedisTemplate），写入与删除通过 Redis pub/sub 或 mq
+// 广播失效消息，其他实例收到后移除本地副本。同一实例内对同一键的回源是单飞的，本地TTL带随机抖动，
+// 不存在的键可以缓存为空值，从而防止缓存击穿、雪崩与穿透
+// NearCache is a two-level cache: an in-process cache in front of a remote store (RedisMap/RedisTemplate).
+// Writes and deletes broadcast invalidations over Redis pub/sub or mq so other instances drop their local
+// copies. Loads of the same key are single-flight within an instance, local TTLs are jittered and missing
+// keys can be cached as null values, which protects against stampedes, avalanches and penetration
+type NearCache[K comparable, V any] struct {
+	local  *Cache[K, nearValue[V]]
+	remote Remote[K, V]
+	opts   NearOptions[K, V]
+	id     string
+
+	// gens 有回源进行中的键的代数，写入、删除与失效时递增，回源结束时代数未变才写入本地
+	// gens holds the generation of keys with a load in flight; Set, Delete and invalidations bump it and a
+	// finished load only stores its value locally when the generation is unchanged
+	genMu sync.Mutex
+	gens  map[K]*generation
+
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+	closed bool
+	queue  chan writeOp[K, V]
+	worker sync.WaitGroup
+}
+
+// generation 一个键的代数与进行中的回源数
+// generation is a key's generation and the number of loads in flight for it
+type generation struct {
+	gen   uint64
+	loads int
+}
+
+// NewNearCache 创建近端缓存，配置了 Invalidator 时会立即订阅失效消息
+// NewNearCache creates a near cache and subscribes to invalidations right away when an Invalidator is set
+func NewNearCache[K comparable, V any](remote Remote[K, V], opts ...NearOption[K, V]) (*NearCache[K, V], error) {
+	o := NearOptions[K, V]{LocalTTL: 5 * time.Minute, Jitter: 0.1, QueueSize: 1024}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &NearCache[K, V]{remote: remote, opts: o, id: uuid.NewString(), cancel: cancel, gens: make(map[K]*generation)}
+	n.local = New(
+		WithMaxEntries[K, nearValue[V]](o.LocalMaxEntries),
+		WithExpiry[K, nearValue[V]](n.expiry),
+	)
+	if o.Invalidator != nil {
+		if err := o.Invalidator.Subscribe(ctx, n.onInvalidation); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	if o.WriteMode == WriteBehind {
+		n.queue = make(chan writeOp[K, V], max(o.QueueSize, 1))
+		n.worker.Add(1)
+		go n.writeBehind()
+	}
+	return n, nil
+}
+
+// expiry 本地副本的过期时间：空值使用 NullTTL，其余使用带抖动的 LocalTTL
+// expiry is the local TTL: NullTTL for null values, the jittered LocalTTL otherwise
+func (n *NearCache[K, V]) expiry(_ K, v nearValue[V]) time.Duration {
+	if !v.present {
+		return n.opts.NullTTL
+	}
+	ttl := n.opts.LocalTTL
+	if ttl > 0 && n.opts.Jitter > 0 {
+		ttl += time.Duration(rand.Float64() * n.opts.Jitter * float64(ttl))
+	}
+	return ttl
+}
+
+// Get 依次查询本地、远端与数据源，不存在时返回false
+// Get looks up the local copy, the remote and the source in turn, false means the key does not exist
+func (n *NearCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if v, ok := n.local.Get(key); ok {
+		return v.value, v.present, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v, err := n.local.load(ctx, key, n.load, false)
+	if errors.Is(err, ErrNotFound) {
+		return *new(V), false, nil
+	}
+	if err != nil {
+		return *new(V), false, err
+	}
+	return v.value, v.present, nil
+}
+
+// load 回源：远端未命中时从数据源加载并回填远端；加载期间该键被写入、删除或失效时不写入本地，
+// 避免旧值在本地长期存活
+// load goes to the remote and, when it misses, to the source and backfills the remote. The value is not
+// stored locally when the key was written, deleted or invalidated during the load, so a stale value never
+// outlives it in the local tier
+func (n *NearCache[K, V]) load(ctx context.Context, key K) (v nearValue[V], err error) {
+	gen := n.beginLoad(key)
+	defer func() { n.endLoad(key, gen, v, err) }()
+	return n.fetch(ctx, key)
+}
+
+// fetch 依次查询远端与数据源
+// fetch queries the remote and then the source
+func (n *NearCache[K, V]) fetch(ctx context.Context, key K) (nearValue[V], error) {
+	value, ok, err := n.remote.Get(ctx, key)
+	if err != nil {
+		return nearValue[V]{}, err
+	}
+	if !ok && n.opts.Loader != nil {
+		value, err = n.opts.Loader(ctx, key)
+		switch {
+		case errors.Is(err, ErrNotFound):
+		case err != nil:
+			return nearValue[V]{}, err
+		default:
+			ok = true
+			n.report(key, n.remote.Set(ctx, key, value))
+		}
+	}
+	if ok {
+		return nearValue[V]{value: value, present: true}, nil
+	}
+	if n.opts.NullTTL <= 0 {
+		return nearValue[V]{}, ErrNotFound
+	}
+	return nearValue[V]{}, nil
+}
+
+// beginLoad 登记一次回源并返回键当前的代数
+// beginLoad registers a load and returns the key's current generation
+func (n *NearCache[K, V]) beginLoad(key K) uint64 {
+	n.genMu.Lock()
+	defer n.genMu.Unlock()
+	g, ok := n.gens[key]
+	if !ok {
+		g = &generation{}
+		n.gens[key] = g
+	}
+	g.loads++
+	return g.gen
+}
+
+// endLoad 结束回源，代数未变且加载成功时写入本地
+// endLoad finishes a load and stores the value locally when it succeeded and the generation is unchanged
+func (n *NearCache[K, V]) endLoad(key K, gen uint64, v nearValue[V], err error) {
+	n.genMu.Lock()
+	defer n.genMu.Unlock()
+	g := n.gens[key]
+	if err == nil && g.gen == gen {
+		n.local.Set(key, v)
+	}
+	if g.loads--; g.loads == 0 {
+		delete(n.gens, key)
+	}
+}
+
+// bump 使进行中的回源结果作废，没有进行中的回源时无需记录
+// bump voids the results of loads in flight, nothing is recorded when there are none
+func (n *NearCache[K, V]) bump(keys ...K) {
+	n.genMu.Lock()
+	defer n.genMu.Unlock()
+	for _, key := range keys {
+		if g, ok := n.gens[key]; ok {
+			g.gen++
+		}
+	}
+}
+
+// bumpAll 使所有进行中的回源结果作废
+// bumpAll voids the results of every load in flight
+func (n *NearCache[K, V]) bumpAll() {
+	n.genMu.Lock()
+	defer n.genMu.Unlock()
+	for _, g := range n.gens {
+		g.gen++
+	}
+}
+
+// Set 写入，一致性取决于 WriteMode
+// Set writes the value, the consistency depends on WriteMode
+func (n *NearCache[K, V]) Set(ctx context.Context, key K, value V) error {
+	if n.opts.WriteMode == WriteBehind {
+		n.bump(key)
+		n.local.Set(key, nearValue[V]{value: value, present: true})
+		return n.enqueue(ctx, writeOp[K, V]{key: key, value: value})
+	}
+	err := n.remote.Set(ctx, key, value)
+	n.bump(key)
+	if err != nil {
+		n.local.Delete(key)
+		return err
+	}
+	n.local.Set(key, nearValue[V]{value: value, present: true})
+	n.report(key, n.broadcast(ctx, invalidation[K]{Keys: []K{key}}))
+	return nil
+}
+
+// Delete 删除，一致性取决于 WriteMode
+// Delete removes the key, the consistency depends on WriteMode
+func (n *NearCache[K, V]) Delete(ctx context.Context, key K) error {
+	if n.opts.WriteMode == WriteBehind {
+		// 远端删除完成前用空值占位，避免从远端读回旧值
+		// a null value stands in until the remote delete completes so the stale remote value is not read back
+		n.bump(key)
+		n.local.Set(key, nearValue[V]{})
+		return n.enqueue(ctx, writeOp[K, V]{key: key, delete: true})
+	}
+	if err := n.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	n.bump(key)
+	n.local.Delete(key)
+	n.report(key, n.broadcast(ctx, invalidation[K]{Keys: []K{key}}))
+	return nil
+}
+
+// InvalidateLocal 只移除本实例的本地副本
+// InvalidateLocal drops the local copies of this instance only
+func (n *NearCache[K, V]) InvalidateLocal(keys ...K) {
+	n.bump(keys...)
+	for _, key := range keys {
+		n.local.Delete(key)
+	}
+}
+
+// InvalidateAll 清空所有实例的本地副本，远端数据不受影响
+// InvalidateAll drops the local copies of every instance, the remote is left untouched
+func (n *NearCache[K, V]) InvalidateAll(ctx context.Context) error {
+	n.bumpAll()
+	n.local.Clear()
+	return n.broadcast(ctx, invalidation[K]{All: true})
+}
+
+// Flush 等待此前所有异步写入完成
+// Flush waits until every earlier write-behind operation is done
+func (n *NearCache[K, V]) Flush(ctx context.Context) error {
+	if n.opts.WriteMode != WriteBehind {
+		return nil
+	}
+	done := make(chan struct{})
+	if err := n.enqueue(ctx, writeOp[K, V]{done: done}); err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LocalStats 本地缓存统计
+// LocalStats returns the local cache statistics
+func (n *NearCache[K, V]) LocalStats() Stats {
+	return n.local.Stats()
+}
+
+// Close 退订失效消息，并在写完异步队列后返回
+// Close unsubscribes from invalidations and returns after the write-behind queue is drained
+func (n *NearCache[K, V]) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	if n.queue != nil {
+		close(n.queue)
+	}
+	n.mu.Unlock()
+	n.worker.Wait()
+	n.cancel()
+	return nil
+}
+
+// enqueue 放入异步写队列，队列满时阻塞直到有空位或ctx取消
+// enqueue puts the operation into the write-behind queue, blocking while it is full until ctx is cancelled
+func (n *NearCache[K, V]) enqueue(ctx context.Context, op writeOp[K, V]) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return ErrClosed
+	}
+	select {
+	case n.queue <- op:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writeBehind 按顺序执行异步写入并广播失效
+// writeBehind performs the queued writes in order and broadcasts the invalidations
+func (n *NearCache[K, V]) writeBehind() {
+	defer n.worker.Done()
+	ctx := context.Background()
+	for op := range n.queue {
+		if op.done != nil {
+			close(op.done)
+			continue
+		}
+		var err error
+		if op.delete {
+			err = n.remote.Delete(ctx, op.key)
+		} else {
+			err = n.remote.Set(ctx, op.key, op.value)
+		}
+		if err != nil {
+			n.report(op.key, err)
+			continue
+		}
+		n.report(op.key, n.broadcast(ctx, invalidation[K]{Keys: []K{op.key}}))
+	}
+}
+
+// broadcast 广播失效消息，未配置 Invalidator 时忽略
+// broadcast publishes an invalidation, ignored without an Invalidator
+func (n *NearCache[K, V]) broadcast(ctx context.Context, msg invalidation[K]) error {
+	if n.opts.Invalidator == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	msg.Origin = n.id
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return n.opts.Invalidator.Publish(ctx, payload)
+}
+
+// onInvalidation 处理其他实例的失效消息
+// onInvalidation handles invalidations from other instances
+func (n *NearCache[K, V]) onInvalidation(payload []byte) {
+	var msg invalidation[K]
+	if err := json.Unmarshal(payload, &msg); err != nil || msg.Origin == n.id {
+		return
+	}
+	if msg.All {
+		n.bumpAll()
+		n.local.Clear()
+		return
+	}
+	n.InvalidateLocal(msg.Keys...)
+}
+
+func (n *NearCache[K, V]) report(key K, err error) {
+	if err != nil && n.opts.OnError != nil {
+		n.opts.OnError(key, err)
+	}
+}
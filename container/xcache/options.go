@@ -0,0 +1,154 @@
+package xcache
+
+import (
+	"context"
+	"time"
+)
+
+// Policy 淘汰策略
+// Policy is the eviction policy
+type Policy int
+
+const (
+	// PolicyTinyLFU W-TinyLFU：新数据先进入窗口 LRU，淘汰时由频率草图决定窗口候选者能否替换主区的受害者，
+	// 兼顾突发流量与长期热点，是默认策略
+	// PolicyTinyLFU is W-TinyLFU: new entries enter a window LRU and a frequency sketch decides whether a
+	// window candidate replaces the victim of the main area, handling both bursts and long-term hot keys;
+	// it is the default
+	PolicyTinyLFU Policy = iota
+	// PolicyLRU 最近最少使用
+	// PolicyLRU evicts the least recently used entry
+	PolicyLRU
+	// PolicyLFU 最不经常使用，频率相同时淘汰最久未使用的
+	// PolicyLFU evicts the least frequently used entry, the least recently used among equal frequencies
+	PolicyLFU
+)
+
+// RemovalCause 移除原因
+// RemovalCause is why an entry was removed
+type RemovalCause int
+
+const (
+	// RemovalExplicit 被 Delete/Clear 显式删除
+	// RemovalExplicit means removed by Delete/Clear
+	RemovalExplicit RemovalCause = iota
+	// RemovalReplaced 值被新值覆盖
+	// RemovalReplaced means the value was replaced
+	RemovalReplaced
+	// RemovalExpired 过期
+	// RemovalExpired means the entry expired
+	RemovalExpired
+	// RemovalSize 超出数量或权重上限被淘汰
+	// RemovalSize means evicted because of the entry or weight limit
+	RemovalSize
+)
+
+// String 返回移除原因的名称
+// String returns the name of the cause
+func (c RemovalCause) String() string {
+	switch c {
+	case RemovalExplicit:
+		return "explicit"
+	case RemovalReplaced:
+		return "replaced"
+	case RemovalExpired:
+		return "expired"
+	case RemovalSize:
+		return "size"
+	default:
+		return "unknown"
+	}
+}
+
+// Loader 加载函数
+// Loader loads the value of a key
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Weigher 计算条目权重
+// Weigher computes the weight of an entry
+type Weigher[K comparable, V any] func(key K, value V) int64
+
+// RemovalListener 移除监听器，在锁外同步调用
+// RemovalListener is notified synchronously outside the lock
+type RemovalListener[K comparable, V any] func(key K, value V, cause RemovalCause)
+
+// Expiry 根据键值计算条目的写入后过期时间，返回值<=0时使用默认过期时间
+// Expiry computes the expire-after-write duration of an entry, the default applies when it returns <= 0
+type Expiry[K comparable, V any] func(key K, value V) time.Duration
+
+// Options 缓存配置
+// Options configures the cache
+type Options[K comparable, V any] struct {
+	Policy            Policy
+	MaxEntries        int           // 最大条目数，0表示不限制 / max entries, 0 means unlimited
+	MaxWeight         int64         // 最大总权重，0表示不限制 / max total weight, 0 means unlimited
+	Weigher           Weigher[K, V] // 权重函数，默认每个条目为1 / weigher, every entry weighs 1 by default
+	ExpireAfterWrite  time.Duration // 写入后过期 / expire after write
+	ExpireAfterAccess time.Duration // 访问后过期 / expire after access
+	RefreshAfterWrite time.Duration // 写入后多久在访问时异步刷新 / refresh asynchronously on access after this long
+	Expiry            Expiry[K, V]  // 按条目计算过期时间 / per-entry expiration
+	Loader            Loader[K, V]
+	RemovalListener   RemovalListener[K, V]
+}
+
+// Option 函数式选项
+// Option is a functional option
+type Option[K comparable, V any] func(*Options[K, V])
+
+// WithPolicy 设置淘汰策略
+// WithPolicy sets the eviction policy
+func WithPolicy[K comparable, V any](policy Policy) Option[K, V] {
+	return func(o *Options[K, V]) { o.Policy = policy }
+}
+
+// WithMaxEntries 设置最大条目数
+// WithMaxEntries sets the max number of entries
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(o *Options[K, V]) { o.MaxEntries = n }
+}
+
+// WithMaxWeight 设置最大总权重与权重函数
+// WithMaxWeight sets the max total weight and the weigher
+func WithMaxWeight[K comparable, V any](max int64, weigher Weigher[K, V]) Option[K, V] {
+	return func(o *Options[K, V]) {
+		o.MaxWeight = max
+		o.Weigher = weigher
+	}
+}
+
+// WithExpireAfterWrite 设置默认的写入后过期时间
+// WithExpireAfterWrite sets the default expire-after-write duration
+func WithExpireAfterWrite[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *Options[K, V]) { o.ExpireAfterWrite = d }
+}
+
+// WithExpireAfterAccess 设置默认的访问后过期时间
+// WithExpireAfterAccess sets the default expire-after-access duration
+func WithExpireAfterAccess[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *Options[K, V]) { o.ExpireAfterAccess = d }
+}
+
+// WithExpiry 按条目计算写入后过期时间，SetWithTTL 指定的 TTL 优先
+// WithExpiry computes the expire-after-write duration per entry, a TTL passed to SetWithTTL wins
+func WithExpiry[K comparable, V any](expiry Expiry[K, V]) Option[K, V] {
+	return func(o *Options[K, V]) { o.Expiry = expiry }
+}
+
+// WithRefreshAfterWrite 写入超过d后的访问会触发异步刷新，刷新期间仍返回旧值（需要配置 Loader）
+// WithRefreshAfterWrite triggers an asynchronous reload on access once d has passed since the write,
+// the old value is served while refreshing (requires a Loader)
+func WithRefreshAfterWrite[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(o *Options[K, V]) { o.RefreshAfterWrite = d }
+}
+
+// WithLoader 设置默认加载函数
+// WithLoader sets the default loader
+func WithLoader[K comparable, V any](loader Loader[K, V]) Option[K, V] {
+	return func(o *Options[K, V]) { o.Loader = loader }
+}
+
+// WithRemovalListener 设置移除监听器
+// WithRemovalListener sets the removal listener
+func WithRemovalListener[K comparable, V any](listener RemovalListener[K, V]) Option[K, V] {
+	return func(o *Options[K, V]) { o.RemovalListener = listener }
+}
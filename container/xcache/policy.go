@@ -0,0 +1,195 @@
+package xcache
+
+import (
+	"time"
+)
+
+// entry 缓存条目，同时是淘汰策略链表的节点
+// entry is a cache entry and a node of the eviction policy lists
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	weight     int64
+	ttl        time.Duration // 单独指定的写入后过期时间 / per-entry expire-after-write
+	writeTime  time.Time
+	expireAt   time.Time // 零值表示不过期 / zero means never
+	refreshing bool
+
+	prev, next *entry[K, V]
+	list       *entryList[K, V]
+	bucket     *freqBucket[K, V]
+}
+
+// entryList 带哨兵的侵入式双向链表，头部为最近使用
+// entryList is an intrusive doubly linked list with a sentinel, the front is the most recently used
+type entryList[K comparable, V any] struct {
+	root   entry[K, V]
+	len    int
+	weight int64
+}
+
+func newEntryList[K comparable, V any]() *entryList[K, V] {
+	l := &entryList[K, V]{}
+	l.root.prev = &l.root
+	l.root.next = &l.root
+	return l
+}
+
+func (l *entryList[K, V]) pushFront(e *entry[K, V]) {
+	e.prev = &l.root
+	e.next = l.root.next
+	l.root.next.prev = e
+	l.root.next = e
+	e.list = l
+	l.len++
+	l.weight += e.weight
+}
+
+func (l *entryList[K, V]) remove(e *entry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next, e.list = nil, nil, nil
+	l.len--
+	l.weight -= e.weight
+}
+
+func (l *entryList[K, V]) moveToFront(e *entry[K, V]) {
+	l.remove(e)
+	l.pushFront(e)
+}
+
+// back 返回最久未使用的条目，链表为空时返回nil
+// back returns the least recently used entry, nil when the list is empty
+func (l *entryList[K, V]) back() *entry[K, V] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// policy 淘汰策略，所有方法都在缓存锁内调用
+// policy is an eviction policy, all methods are called under the cache lock
+type policy[K comparable, V any] interface {
+	// add 新条目加入 / a new entry is added
+	add(e *entry[K, V])
+	// access 条目被读取或覆盖 / an entry is read or replaced
+	access(e *entry[K, V])
+	// remove 条目被移除 / an entry is removed
+	remove(e *entry[K, V])
+	// victim 返回下一个应被淘汰的条目 / returns the next entry to evict
+	victim() *entry[K, V]
+}
+
+func newPolicy[K comparable, V any](o Options[K, V]) policy[K, V] {
+	switch o.Policy {
+	case PolicyLRU:
+		return &lruPolicy[K, V]{list: newEntryList[K, V]()}
+	case PolicyLFU:
+		return newLFUPolicy[K, V]()
+	default:
+		return newTinyLFUPolicy(o)
+	}
+}
+
+// lruPolicy 最近最少使用
+// lruPolicy evicts the least recently used entry
+type lruPolicy[K comparable, V any] struct {
+	list *entryList[K, V]
+}
+
+func (p *lruPolicy[K, V]) add(e *entry[K, V])    { p.list.pushFront(e) }
+func (p *lruPolicy[K, V]) access(e *entry[K, V]) { p.list.moveToFront(e) }
+func (p *lruPolicy[K, V]) remove(e *entry[K, V]) { p.list.remove(e) }
+func (p *lruPolicy[K, V]) victim() *entry[K, V]  { return p.list.back() }
+
+// freqBucket 同一访问频率的条目，按频率升序串成链表
+// freqBucket holds the entries of one frequency, buckets are linked in ascending frequency
+type freqBucket[K comparable, V any] struct {
+	freq       int
+	entries    *entryList[K, V]
+	prev, next *freqBucket[K, V]
+}
+
+// lfuPolicy O(1) 的 LFU，频率相同的条目中淘汰最久未使用的；刚加入的条目只有在别无选择时才会被淘汰
+// lfuPolicy is an O(1) LFU that evicts the least recently used among the least frequent entries;
+// the entry just added is only evicted when there is no other choice
+type lfuPolicy[K comparable, V any] struct {
+	head   freqBucket[K, V]
+	newest *entry[K, V]
+}
+
+func newLFUPolicy[K comparable, V any]() *lfuPolicy[K, V] {
+	p := &lfuPolicy[K, V]{}
+	p.head.prev = &p.head
+	p.head.next = &p.head
+	return p
+}
+
+// insertAfter 在b之后插入频率为freq的新桶
+// insertAfter inserts a new bucket of freq after b
+func (p *lfuPolicy[K, V]) insertAfter(b *freqBucket[K, V], freq int) *freqBucket[K, V] {
+	nb := &freqBucket[K, V]{freq: freq, entries: newEntryList[K, V](), prev: b, next: b.next}
+	b.next.prev = nb
+	b.next = nb
+	return nb
+}
+
+// detach 把条目移出所在的桶，桶为空时删除桶
+// detach takes the entry out of its bucket and drops the bucket when it becomes empty
+func (p *lfuPolicy[K, V]) detach(e *entry[K, V]) {
+	b := e.bucket
+	b.entries.remove(e)
+	e.bucket = nil
+	if b.entries.len == 0 {
+		b.prev.next = b.next
+		b.next.prev = b.prev
+	}
+}
+
+func (p *lfuPolicy[K, V]) add(e *entry[K, V]) {
+	b := p.head.next
+	if b == &p.head || b.freq != 1 {
+		b = p.insertAfter(&p.head, 1)
+	}
+	b.entries.pushFront(e)
+	e.bucket = b
+	p.newest = e
+}
+
+func (p *lfuPolicy[K, V]) access(e *entry[K, V]) {
+	if p.newest == e {
+		p.newest = nil
+	}
+	b := e.bucket
+	nb := b.next
+	if nb == &p.head || nb.freq != b.freq+1 {
+		nb = p.insertAfter(b, b.freq+1)
+	}
+	p.detach(e)
+	nb.entries.pushFront(e)
+	e.bucket = nb
+}
+
+func (p *lfuPolicy[K, V]) remove(e *entry[K, V]) {
+	if p.newest == e {
+		p.newest = nil
+	}
+	p.detach(e)
+}
+
+func (p *lfuPolicy[K, V]) victim() *entry[K, V] {
+	first := p.head.next
+	if first == &p.head {
+		return nil
+	}
+	victim := first.entries.back()
+	if victim != p.newest {
+		return victim
+	}
+	// 新条目位于最低频率桶的末尾说明它是该桶唯一的条目，改为淘汰下一个桶中的条目
+	// the new entry at the back of the lowest bucket is its only entry, evict from the next bucket instead
+	if next := first.next; next != &p.head {
+		return next.entries.back()
+	}
+	return victim
+}
@@ -0,0 +1,127 @@
+package xcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/karosown/katool-go/db/xredis"
+	"github.com/karosown/katool-go/mq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Remote 二级缓存的远端存储，*xmap.RedisMap 满足该接口，RedisTemplate 可以通过 TemplateRemote 适配
+// Remote is the remote store of the near cache; *xmap.RedisMap satisfies it and a RedisTemplate can be
+// adapted with TemplateRemote
+type Remote[K comparable, V any] interface {
+	Get(ctx context.Context, key K) (V, bool, error)
+	Set(ctx context.Context, key K, value V) error
+	Delete(ctx context.Context, key K) error
+}
+
+// templateRemote 基于 RedisTemplate 的远端存储，每个键对应一个 Redis 字符串键
+// templateRemote is a remote store on top of RedisTemplate, one Redis string key per key
+type templateRemote[V any] struct {
+	template *xredis.RedisTemplate
+	ttl      time.Duration
+}
+
+// TemplateRemote 把 RedisTemplate 适配为远端存储，ttl 为 Redis 键的过期时间，0表示不过期
+// TemplateRemote adapts a RedisTemplate as the remote store, ttl is the Redis key TTL and 0 means none
+func TemplateRemote[V any](template *xredis.RedisTemplate, ttl time.Duration) Remote[string, V] {
+	return &templateRemote[V]{template: template, ttl: ttl}
+}
+
+func (r *templateRemote[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var value V
+	ok, err := r.template.Get(ctx, key, &value)
+	return value, ok, err
+}
+
+func (r *templateRemote[V]) Set(ctx context.Context, key string, value V) error {
+	return r.template.Set(ctx, key, value, r.ttl)
+}
+
+func (r *templateRemote[V]) Delete(ctx context.Context, key string) error {
+	_, err := r.template.Del(ctx, key)
+	return err
+}
+
+// Invalidator 失效广播通道
+// Invalidator broadcasts invalidation messages
+type Invalidator interface {
+	// Publish 广播消息 / broadcasts a message
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe 在ctx取消前持续接收消息 / receives messages until ctx is cancelled
+	Subscribe(ctx context.Context, handler func(payload []byte)) error
+}
+
+// redisInvalidator 基于 Redis pub/sub 的失效广播
+// redisInvalidator broadcasts over Redis pub/sub
+type redisInvalidator struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+// RedisInvalidator 使用 Redis pub/sub 频道广播失效消息，*xredis.Client 可传入其内嵌的 *redis.Client
+// RedisInvalidator broadcasts over a Redis pub/sub channel, pass the embedded *redis.Client of an *xredis.Client
+func RedisInvalidator(client redis.UniversalClient, channel string) Invalidator {
+	return &redisInvalidator{client: client, channel: channel}
+}
+
+func (r *redisInvalidator) Publish(ctx context.Context, payload []byte) error {
+	return r.client.Publish(ctx, r.channel, payload).Err()
+}
+
+func (r *redisInvalidator) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	sub := r.client.Subscribe(ctx, r.channel)
+	// 等待订阅确认，确保返回后不会漏掉消息
+	// wait for the confirmation so no message is missed after returning
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return err
+	}
+	go func() {
+		defer sub.Close()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler([]byte(msg.Payload))
+			}
+		}
+	}()
+	return nil
+}
+
+// mqInvalidator 基于 mq 主题的失效广播
+// mqInvalidator broadcasts over an mq topic
+type mqInvalidator struct {
+	client mq.Client
+	topic  string
+	opts   []mq.SubscribeOption
+}
+
+// MQInvalidator 使用 mq 主题广播失效消息，每个实例默认使用独立的消费组以便都能收到全部消息
+// MQInvalidator broadcasts over an mq topic, every instance uses its own consumer group by default
+// so all of them receive every message
+func MQInvalidator(client mq.Client, topic string, opts ...mq.SubscribeOption) Invalidator {
+	return &mqInvalidator{client: client, topic: topic, opts: opts}
+}
+
+func (m *mqInvalidator) Publish(ctx context.Context, payload []byte) error {
+	return m.client.Publish(ctx, m.topic, payload)
+}
+
+func (m *mqInvalidator) Subscribe(ctx context.Context, handler func(payload []byte)) error {
+	opts := append([]mq.SubscribeOption{mq.WithGroup("near_cache_" + uuid.NewString())}, m.opts...)
+	return m.client.Subscribe(ctx, m.topic, func(ctx context.Context, msg mq.Message) error {
+		handler(msg.Payload())
+		return nil
+	}, opts...)
+}
@@ -0,0 +1,67 @@
+package xcache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats 缓存统计
+// Stats holds the cache statistics
+type Stats struct {
+	Hits           uint64
+	Misses         uint64
+	LoadSuccesses  uint64
+	LoadFailures   uint64
+	TotalLoadTime  time.Duration
+	Evictions      uint64
+	EvictionWeight int64
+}
+
+// Requests 请求总数
+// Requests returns the number of requests
+func (s Stats) Requests() uint64 {
+	return s.Hits + s.Misses
+}
+
+// HitRate 命中率，没有请求时为1
+// HitRate returns the hit rate, 1 when there is no request
+func (s Stats) HitRate() float64 {
+	if s.Requests() == 0 {
+		return 1
+	}
+	return float64(s.Hits) / float64(s.Requests())
+}
+
+// AverageLoadPenalty 平均加载耗时
+// AverageLoadPenalty returns the average load time
+func (s Stats) AverageLoadPenalty() time.Duration {
+	loads := s.LoadSuccesses + s.LoadFailures
+	if loads == 0 {
+		return 0
+	}
+	return s.TotalLoadTime / time.Duration(loads)
+}
+
+// statsCounter 原子计数器
+// statsCounter holds the atomic counters
+type statsCounter struct {
+	hits           atomic.Uint64
+	misses         atomic.Uint64
+	loadSuccesses  atomic.Uint64
+	loadFailures   atomic.Uint64
+	loadTime       atomic.Int64
+	evictions      atomic.Uint64
+	evictionWeight atomic.Int64
+}
+
+func (s *statsCounter) snapshot() Stats {
+	return Stats{
+		Hits:           s.hits.Load(),
+		Misses:         s.misses.Load(),
+		LoadSuccesses:  s.loadSuccesses.Load(),
+		LoadFailures:   s.loadFailures.Load(),
+		TotalLoadTime:  time.Duration(s.loadTime.Load()),
+		Evictions:      s.evictions.Load(),
+		EvictionWeight: s.evictionWeight.Load(),
+	}
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/xcache"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试 LRU 与 LFU 的淘汰顺序
+func TestCacheEvictionPolicies(t *testing.T) {
+	lru := xcache.New(xcache.WithPolicy[string, int](xcache.PolicyLRU), xcache.WithMaxEntries[string, int](2))
+	lru.Set("a", 1)
+	lru.Set("b", 2)
+	lru.Get("a")
+	lru.Set("c", 3)
+	assert.True(t, lru.Has("a"))
+	assert.False(t, lru.Has("b"), "最久未使用的 b 应被淘汰")
+
+	lfu := xcache.New(xcache.WithPolicy[string, int](xcache.PolicyLFU), xcache.WithMaxEntries[string, int](2))
+	lfu.Set("a", 1)
+	lfu.Set("b", 2)
+	lfu.Get("a")
+	lfu.Get("a")
+	lfu.Get("b")
+	lfu.Set("c", 3)
+	assert.True(t, lfu.Has("a"))
+	assert.False(t, lfu.Has("b"), "频率较低的 b 应被淘汰")
+	assert.Equal(t, uint64(1), lfu.Stats().Evictions)
+}
+
+// 测试 W-TinyLFU 保留热点数据
+func TestCacheTinyLFU(t *testing.T) {
+	c := xcache.New(xcache.WithMaxEntries[int, int](100))
+	for i := 0; i < 50; i++ {
+		c.Set(i, i)
+		for j := 0; j < 5; j++ {
+			c.Get(i)
+		}
+	}
+	// 大量只访问一次的扫描数据不应冲掉仍在被访问的热点
+	for i := 1000; i < 5000; i++ {
+		c.Set(i, i)
+		c.Get(i % 50)
+	}
+	hot := 0
+	for i := 0; i < 50; i++ {
+		if c.Has(i) {
+			hot++
+		}
+	}
+	assert.Equal(t, 100, c.Len())
+	assert.Greater(t, hot, 45, "热点数据应基本保留")
+}
+
+// 测试权重上限与移除监听器
+func TestCacheWeightAndListener(t *testing.T) {
+	var mu sync.Mutex
+	causes := make(map[string]xcache.RemovalCause)
+	c := xcache.New(
+		xcache.WithPolicy[string, string](xcache.PolicyLRU),
+		xcache.WithMaxWeight[string, string](10, func(k string, v string) int64 { return int64(len(v)) }),
+		xcache.WithRemovalListener[string, string](func(k string, v string, cause xcache.RemovalCause) {
+			mu.Lock()
+			defer mu.Unlock()
+			causes[k] = cause
+		}),
+	)
+	c.Set("a", "12345")
+	c.Set("b", "1234")
+	c.Set("b", "12345")
+	assert.Equal(t, int64(10), c.Weight())
+	c.Set("c", "1")
+	assert.False(t, c.Has("a"))
+	c.Delete("c")
+	assert.Equal(t, xcache.RemovalSize, causes["a"])
+	assert.Equal(t, xcache.RemovalExplicit, causes["c"])
+	assert.Equal(t, int64(5), c.Weight())
+}
+
+// 测试写入后过期、访问后过期与单条目 TTL
+func TestCacheExpiration(t *testing.T) {
+	c := xcache.New(xcache.WithExpireAfterWrite[string, int](50 * time.Millisecond))
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, time.Hour)
+	time.Sleep(80 * time.Millisecond)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	assert.True(t, ok, "单独指定的 TTL 应覆盖默认值")
+
+	access := xcache.New(xcache.WithExpireAfterAccess[string, int](60 * time.Millisecond))
+	access.Set("a", 1)
+	access.Set("b", 2)
+	for i := 0; i < 4; i++ {
+		time.Sleep(25 * time.Millisecond)
+		access.Get("a")
+	}
+	assert.True(t, access.Has("a"), "持续访问的条目不应过期")
+	assert.Equal(t, 1, access.CleanUp())
+	assert.Equal(t, []string{"a"}, access.Keys())
+}
+
+// 测试单飞加载、错误与 panic
+func TestCacheGetOrLoad(t *testing.T) {
+	var calls atomic.Int32
+	c := xcache.New(xcache.WithLoader[string, int](func(ctx context.Context, key string) (int, error) {
+		calls.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		return strconv.Atoi(key)
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "42")
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load(), "并发加载应只执行一次")
+
+	_, err := c.GetOrLoad(context.Background(), "x")
+	assert.Error(t, err)
+	assert.False(t, c.Has("x"), "加载失败不应写入缓存")
+
+	_, err = c.GetOrLoad(context.Background(), "p", func(ctx context.Context, key string) (int, error) { panic("boom") })
+	assert.ErrorContains(t, err, "boom")
+
+	_, err = xcache.New[string, int]().GetOrLoad(context.Background(), "a")
+	assert.True(t, errors.Is(err, xcache.ErrNoLoader))
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.LoadSuccesses)
+	assert.Equal(t, uint64(2), stats.LoadFailures)
+}
+
+// 测试提前刷新：刷新期间返回旧值，刷新完成后返回新值
+func TestCacheRefreshAhead(t *testing.T) {
+	var version atomic.Int32
+	c := xcache.New(
+		xcache.WithRefreshAfterWrite[string, int32](30*time.Millisecond),
+		xcache.WithLoader[string, int32](func(ctx context.Context, key string) (int32, error) {
+			return version.Add(1), nil
+		}),
+	)
+	v, err := c.GetOrLoad(context.Background(), "k")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), v)
+	time.Sleep(50 * time.Millisecond)
+	v, _ = c.Get("k")
+	assert.Equal(t, int32(1), v, "刷新期间应返回旧值")
+	assert.Eventually(t, func() bool {
+		v, _ := c.Get("k")
+		return v == 2
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/xcache"
+	"github.com/karosown/katool-go/container/xmap"
+	"github.com/karosown/katool-go/mq/cmq"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ xcache.Remote[string, int] = (*xmap.RedisMap[string, int])(nil)
+
+// memoryRemote 内存版远端存储
+type memoryRemote struct {
+	mu   sync.Mutex
+	data map[string]int
+	gets atomic.Int32
+}
+
+func (r *memoryRemote) Get(ctx context.Context, key string) (int, bool, error) {
+	r.gets.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.data[key]
+	return v, ok, nil
+}
+
+func (r *memoryRemote) Set(ctx context.Context, key string, value int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data[key] = value
+	return nil
+}
+
+func (r *memoryRemote) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.data, key)
+	return nil
+}
+
+// 测试跨实例失效与空值缓存
+func TestNearCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	broker := cmq.NewChanBroker()
+	defer broker.Close()
+	remote := &memoryRemote{data: map[string]int{"a": 1}}
+
+	newNear := func() *xcache.NearCache[string, int] {
+		n, err := xcache.NewNearCache[string, int](remote,
+			xcache.WithInvalidator[string, int](xcache.MQInvalidator(broker, "near")),
+			xcache.WithNullTTL[string, int](time.Minute))
+		assert.NoError(t, err)
+		return n
+	}
+	n1, n2 := newNear(), newNear()
+	defer n1.Close()
+	defer n2.Close()
+
+	v, ok, err := n2.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	assert.NoError(t, n1.Set(ctx, "a", 2))
+	assert.Eventually(t, func() bool {
+		v, _, _ := n2.Get(ctx, "a")
+		return v == 2
+	}, time.Second, 5*time.Millisecond, "其他实例应收到失效消息")
+
+	gets := remote.gets.Load()
+	for i := 0; i < 5; i++ {
+		_, ok, err = n1.Get(ctx, "missing")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, gets+1, remote.gets.Load(), "不存在的键应只回源一次")
+}
+
+// 测试数据源回填、单飞与异步写入
+func TestNearCacheSourceAndWriteBehind(t *testing.T) {
+	ctx := context.Background()
+	remote := &memoryRemote{data: map[string]int{}}
+	var loads atomic.Int32
+	n, err := xcache.NewNearCache[string, int](remote,
+		xcache.WithWriteBehind[string, int](4),
+		xcache.WithSource[string, int](func(ctx context.Context, key string) (int, error) {
+			loads.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			if key == "none" {
+				return 0, xcache.ErrNotFound
+			}
+			return len(key), nil
+		}))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, ok, err := n.Get(ctx, "hello")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, 5, v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load(), "并发回源应只执行一次")
+	remoteValue, ok, _ := remote.Get(ctx, "hello")
+	assert.True(t, ok, "数据源的值应回填远端")
+	assert.Equal(t, 5, remoteValue)
+
+	_, ok, err = n.Get(ctx, "none")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, n.Set(ctx, "k", 7))
+	v, ok, _ := n.Get(ctx, "k")
+	assert.True(t, ok, "异步写入应立即对本实例可见")
+	assert.Equal(t, 7, v)
+	assert.NoError(t, n.Delete(ctx, "hello"))
+	_, ok, _ = n.Get(ctx, "hello")
+	assert.False(t, ok, "删除应立即对本实例可见")
+	assert.NoError(t, n.Flush(ctx))
+	remoteValue, _, _ = remote.Get(ctx, "k")
+	assert.Equal(t, 7, remoteValue)
+	_, ok, _ = remote.Get(ctx, "hello")
+	assert.False(t, ok)
+
+	assert.NoError(t, n.Close())
+	assert.ErrorIs(t, n.Set(ctx, "x", 1), xcache.ErrClosed)
+}
+
+// blockingRemote 读取后阻塞的远端，用于让回源与写入或失效交错
+type blockingRemote struct {
+	*memoryRemote
+	read chan int
+	gate chan struct{}
+}
+
+func (r *blockingRemote) Get(ctx context.Context, key string) (int, bool, error) {
+	v, ok, err := r.memoryRemote.Get(ctx, key)
+	r.read <- v
+	<-r.gate
+	return v, ok, err
+}
+
+// 测试回源期间的写入与失效不会被回源的旧值覆盖
+func TestNearCacheLoadRace(t *testing.T) {
+	ctx := context.Background()
+	for name, change := range map[string]func(n *xcache.NearCache[string, int], remote *memoryRemote){
+		"set": func(n *xcache.NearCache[string, int], _ *memoryRemote) {
+			assert.NoError(t, n.Set(ctx, "a", 2))
+		},
+		"invalidate": func(n *xcache.NearCache[string, int], remote *memoryRemote) {
+			// 其他实例写入远端后广播的失效 / another instance wrote the remote and broadcast the invalidation
+			assert.NoError(t, remote.Set(ctx, "a", 2))
+			n.InvalidateLocal("a")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mem := &memoryRemote{data: map[string]int{"a": 1}}
+			remote := &blockingRemote{memoryRemote: mem, read: make(chan int, 1), gate: make(chan struct{})}
+			n, err := xcache.NewNearCache[string, int](remote)
+			assert.NoError(t, err)
+			defer n.Close()
+
+			loaded := make(chan int)
+			go func() {
+				v, _, _ := n.Get(ctx, "a")
+				loaded <- v
+			}()
+			assert.Equal(t, 1, <-remote.read)
+			change(n, mem)
+			close(remote.gate)
+			assert.Equal(t, 1, <-loaded, "进行中的回源返回其读到的值")
+
+			go func() { <-remote.read }()
+			v, ok, err := n.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, 2, v, "回源的旧值不应写入本地")
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package xcache
+
+import (
+	"hash/maphash"
+)
+
+const (
+	sketchDepth    = 4
+	sketchMaxWidth = 1 << 18
+	counterMax     = 15
+)
+
+// sketch 带衰减的 Count-Min 频率草图，计数上限为15，累计次数达到采样上限时全部减半
+// sketch is a Count-Min frequency sketch with aging: counters saturate at 15 and are all halved when
+// the number of increments reaches the sample size
+type sketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	sample    int
+}
+
+func newSketch(capacity int64) *sketch {
+	width := uint64(16)
+	for width < uint64(capacity) && width < sketchMaxWidth {
+		width <<= 1
+	}
+	s := &sketch{mask: width - 1, sample: int(width) * 10}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *sketch) index(h uint64, i int) uint64 {
+	h = (h + uint64(i)*0x9E3779B97F4A7C15) * 0xBF58476D1CE4E5B9
+	h ^= h >> 31
+	return h & s.mask
+}
+
+func (s *sketch) increment(h uint64) {
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c < counterMax {
+			*c++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sample {
+		s.reset()
+	}
+}
+
+func (s *sketch) frequency(h uint64) uint8 {
+	freq := uint8(counterMax)
+	for i := range s.rows {
+		freq = min(freq, s.rows[i][s.index(h, i)])
+	}
+	return freq
+}
+
+// reset 所有计数减半，使过去的热点逐渐冷却
+// reset halves every counter so past hot keys cool down
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// tinyLFUPolicy W-TinyLFU：窗口区（约1%容量，LRU）+ 主区（分段 LRU：试用区与保护区，保护区约占主区80%）
+// tinyLFUPolicy is W-TinyLFU: a window (about 1% of the capacity, LRU) in front of a main area made of
+// a segmented LRU with a probation and a protected segment (about 80% of the main area)
+type tinyLFUPolicy[K comparable, V any] struct {
+	window, probation, protected *entryList[K, V]
+	sketch                       *sketch
+	seed                         maphash.Seed
+	byWeight                     bool
+	windowMax, protectedMax      int64
+}
+
+func newTinyLFUPolicy[K comparable, V any](o Options[K, V]) *tinyLFUPolicy[K, V] {
+	capacity := int64(o.MaxEntries)
+	byWeight := o.MaxWeight > 0
+	if byWeight {
+		capacity = o.MaxWeight
+	}
+	windowMax := max(1, capacity/100)
+	return &tinyLFUPolicy[K, V]{
+		window:       newEntryList[K, V](),
+		probation:    newEntryList[K, V](),
+		protected:    newEntryList[K, V](),
+		sketch:       newSketch(capacity),
+		seed:         maphash.MakeSeed(),
+		byWeight:     byWeight,
+		windowMax:    windowMax,
+		protectedMax: max(0, capacity-windowMax) * 8 / 10,
+	}
+}
+
+// size 按权重或条目数计算链表大小
+// size measures a list by weight or by entries
+func (p *tinyLFUPolicy[K, V]) size(l *entryList[K, V]) int64 {
+	if p.byWeight {
+		return l.weight
+	}
+	return int64(l.len)
+}
+
+func (p *tinyLFUPolicy[K, V]) touch(e *entry[K, V]) {
+	p.sketch.increment(maphash.Comparable(p.seed, e.key))
+}
+
+func (p *tinyLFUPolicy[K, V]) frequency(e *entry[K, V]) uint8 {
+	return p.sketch.frequency(maphash.Comparable(p.seed, e.key))
+}
+
+func (p *tinyLFUPolicy[K, V]) add(e *entry[K, V]) {
+	p.touch(e)
+	p.window.pushFront(e)
+}
+
+func (p *tinyLFUPolicy[K, V]) access(e *entry[K, V]) {
+	p.touch(e)
+	switch e.list {
+	case p.window, p.protected:
+		e.list.moveToFront(e)
+	case p.probation:
+		// 试用区被再次访问的条目晋升到保护区，保护区溢出的条目降回试用区
+		// probation entries accessed again are promoted, the overflow of the protected segment is demoted
+		p.probation.remove(e)
+		p.protected.pushFront(e)
+		for p.size(p.protected) > p.protectedMax {
+			demoted := p.protected.back()
+			p.protected.remove(demoted)
+			p.probation.pushFront(demoted)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy[K, V]) remove(e *entry[K, V]) {
+	e.list.remove(e)
+}
+
+// victim 窗口区超出上限时，窗口中最久未使用的候选者与主区的受害者比较频率，频率更高者留在主区；
+// 否则直接淘汰主区（或窗口区）最久未使用的条目
+// victim: when the window is over its limit, its least recently used candidate competes with the main
+// area's victim by frequency and the more frequent one stays in the main area; otherwise the least
+// recently used entry of the main area (or the window) is evicted
+func (p *tinyLFUPolicy[K, V]) victim() *entry[K, V] {
+	for {
+		if p.size(p.window) > p.windowMax {
+			candidate := p.window.back()
+			mainVictim := p.probation.back()
+			if mainVictim == nil {
+				mainVictim = p.protected.back()
+			}
+			if mainVictim == nil {
+				p.window.remove(candidate)
+				p.probation.pushFront(candidate)
+				continue
+			}
+			if p.frequency(candidate) > p.frequency(mainVictim) {
+				p.window.remove(candidate)
+				p.probation.pushFront(candidate)
+				return mainVictim
+			}
+			return candidate
+		}
+		for _, l := range []*entryList[K, V]{p.probation, p.protected, p.window} {
+			if e := l.back(); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package xheap
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// delayed 延迟元素，seq 保证到期时间相同的元素按入队顺序出队
+type delayed[T any] struct {
+	value T
+	at    time.Time
+	seq   uint64
+}
+
+// DelayQueue 基于 SafeHeap 的阻塞延迟队列：元素到期前不可取出，Take 会一直等到最早到期的元素，
+// 适用于超时处理与重试调度。并发安全。
+type DelayQueue[T any] struct {
+	heap *SafeHeap[delayed[T]]
+	seq  atomic.Uint64
+
+	mu      sync.Mutex
+	changed chan struct{} // 入队时关闭并替换，唤醒所有等待者
+}
+
+// NewDelayQueue 创建延迟队列
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{
+		heap: NewSafeHeap(func(a, b delayed[T]) bool {
+			if a.at.Equal(b.at) {
+				return a.seq < b.seq
+			}
+			return a.at.Before(b.at)
+		}),
+		changed: make(chan struct{}),
+	}
+}
+
+// Put 入队，delay 后到期
+func (q *DelayQueue[T]) Put(value T, delay time.Duration) {
+	q.PutAt(value, time.Now().Add(delay))
+}
+
+// PutAt 入队，在 at 时刻到期
+func (q *DelayQueue[T]) PutAt(value T, at time.Time) {
+	q.heap.PushVal(delayed[T]{value: value, at: at, seq: q.seq.Add(1)})
+	q.mu.Lock()
+	close(q.changed)
+	q.changed = make(chan struct{})
+	q.mu.Unlock()
+}
+
+// Poll 非阻塞地取出一个已到期的元素
+func (q *DelayQueue[T]) Poll() (T, bool) {
+	now := time.Now()
+	d, ok := q.heap.PopIf(func(top delayed[T]) bool { return !top.at.After(now) })
+	return d.value, ok
+}
+
+// Take 阻塞直到有元素到期并取出，ctx 结束时返回 ctx 的错误
+func (q *DelayQueue[T]) Take(ctx context.Context) (T, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		// 先拿到通知通道再检查堆顶，避免检查之后的入队被错过
+		q.mu.Lock()
+		changed := q.changed
+		q.mu.Unlock()
+
+		if v, ok := q.Poll(); ok {
+			return v, nil
+		}
+		var wait <-chan time.Time
+		if top, ok := q.heap.Peek(); ok {
+			d := time.Until(top.at)
+			if timer == nil {
+				timer = time.NewTimer(d)
+			} else {
+				timer.Reset(d)
+			}
+			wait = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-changed:
+		case <-wait:
+		}
+	}
+}
+
+// Peek 查看最早到期的元素及其到期时间
+func (q *DelayQueue[T]) Peek() (T, time.Time, bool) {
+	top, ok := q.heap.Peek()
+	return top.value, top.at, ok
+}
+
+// Len 队列中的元素数量（含未到期的）
+func (q *DelayQueue[T]) Len() int {
+	return q.heap.Len()
+}
@@ -0,0 +1,154 @@
+package xheap
+
+import (
+	"cmp"
+	"container/heap"
+
+	"github.com/karosown/katool-go/sys"
+)
+
+// indexedItem 带位置信息的堆元素，index 随上浮下沉同步更新
+type indexedItem[K comparable, P any] struct {
+	key      K
+	priority P
+	index    int
+}
+
+// indexedData 实现 heap.Interface，交换时维护每个元素的下标
+type indexedData[K comparable, P any] struct {
+	items []*indexedItem[K, P]
+	less  func(a, b P) bool
+}
+
+func (d *indexedData[K, P]) Len() int { return len(d.items) }
+func (d *indexedData[K, P]) Less(i, j int) bool {
+	return d.less(d.items[i].priority, d.items[j].priority)
+}
+func (d *indexedData[K, P]) Swap(i, j int) {
+	d.items[i], d.items[j] = d.items[j], d.items[i]
+	d.items[i].index = i
+	d.items[j].index = j
+}
+func (d *indexedData[K, P]) Push(x any) {
+	it := x.(*indexedItem[K, P])
+	it.index = len(d.items)
+	d.items = append(d.items, it)
+}
+func (d *indexedData[K, P]) Pop() any {
+	n := len(d.items) - 1
+	it := d.items[n]
+	d.items[n] = nil
+	d.items = d.items[:n]
+	it.index = -1
+	return it
+}
+
+// IndexedHeap 索引优先队列：以键作为句柄，可以 O(log n) 按键修改优先级或删除元素，
+// 无需关心元素在堆中的下标，适用于超时管理、重试调度与 Dijkstra 等算法。非并发安全。
+type IndexedHeap[K comparable, P any] struct {
+	data  indexedData[K, P]
+	index map[K]*indexedItem[K, P]
+}
+
+// NewIndexedHeap 创建索引优先队列，less(a, b) 为 true 表示优先级 a 更靠近堆顶
+func NewIndexedHeap[K comparable, P any](less func(a, b P) bool) *IndexedHeap[K, P] {
+	if less == nil {
+		sys.Panic("xheap.IndexedHeap: less function must not be nil")
+	}
+	return &IndexedHeap[K, P]{
+		data:  indexedData[K, P]{less: less},
+		index: make(map[K]*indexedItem[K, P]),
+	}
+}
+
+// NewIndexedMinHeap 优先级最小者在堆顶
+func NewIndexedMinHeap[K comparable, P cmp.Ordered]() *IndexedHeap[K, P] {
+	return NewIndexedHeap[K](func(a, b P) bool { return a < b })
+}
+
+// NewIndexedMaxHeap 优先级最大者在堆顶
+func NewIndexedMaxHeap[K comparable, P cmp.Ordered]() *IndexedHeap[K, P] {
+	return NewIndexedHeap[K](func(a, b P) bool { return a > b })
+}
+
+// Len 元素数量
+func (h *IndexedHeap[K, P]) Len() int { return len(h.data.items) }
+
+// Push 插入元素；键已存在时更新其优先级
+func (h *IndexedHeap[K, P]) Push(key K, priority P) {
+	if it, ok := h.index[key]; ok {
+		it.priority = priority
+		heap.Fix(&h.data, it.index)
+		return
+	}
+	it := &indexedItem[K, P]{key: key, priority: priority}
+	h.index[key] = it
+	heap.Push(&h.data, it)
+}
+
+// Update 修改已存在键的优先级，键不存在时返回 false
+func (h *IndexedHeap[K, P]) Update(key K, priority P) bool {
+	it, ok := h.index[key]
+	if !ok {
+		return false
+	}
+	it.priority = priority
+	heap.Fix(&h.data, it.index)
+	return true
+}
+
+// Remove 按键删除并返回其优先级
+func (h *IndexedHeap[K, P]) Remove(key K) (P, bool) {
+	it, ok := h.index[key]
+	if !ok {
+		var zero P
+		return zero, false
+	}
+	heap.Remove(&h.data, it.index)
+	delete(h.index, key)
+	return it.priority, true
+}
+
+// Pop 弹出堆顶；堆空时第三个返回值为 false
+func (h *IndexedHeap[K, P]) Pop() (K, P, bool) {
+	if len(h.data.items) == 0 {
+		var k K
+		var p P
+		return k, p, false
+	}
+	it := heap.Pop(&h.data).(*indexedItem[K, P])
+	delete(h.index, it.key)
+	return it.key, it.priority, true
+}
+
+// Peek 查看堆顶但不移除
+func (h *IndexedHeap[K, P]) Peek() (K, P, bool) {
+	if len(h.data.items) == 0 {
+		var k K
+		var p P
+		return k, p, false
+	}
+	it := h.data.items[0]
+	return it.key, it.priority, true
+}
+
+// Priority 查询键当前的优先级
+func (h *IndexedHeap[K, P]) Priority(key K) (P, bool) {
+	if it, ok := h.index[key]; ok {
+		return it.priority, true
+	}
+	var zero P
+	return zero, false
+}
+
+// Contains 键是否在队列中
+func (h *IndexedHeap[K, P]) Contains(key K) bool {
+	_, ok := h.index[key]
+	return ok
+}
+
+// Clear 清空队列
+func (h *IndexedHeap[K, P]) Clear() {
+	h.data.items = nil
+	clear(h.index)
+}
@@ -78,3 +78,20 @@ func (h *SafeHeap[T]) RemoveIdx(i int) T {
 	})
 	return idx
 }
+
+// TryPop 弹出堆顶；堆空时第二个返回值为 false，不会 panic
+func (h *SafeHeap[T]) TryPop() (v T, ok bool) {
+	return h.PopIf(func(T) bool { return true })
+}
+
+// PopIf 在同一把锁内检查堆顶，满足 pred 时弹出，避免“先 Peek 再 Pop”之间被其他协程抢先
+func (h *SafeHeap[T]) PopIf(pred func(top T) bool) (v T, ok bool) {
+	lock.Synchronized(&h.mu, func() {
+		top, exists := h.raw.Peek()
+		if !exists || !pred(top) {
+			return
+		}
+		v, ok = h.raw.PopVal(), true
+	})
+	return v, ok
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/xheap"
+)
+
+// ------- 索引优先队列 --------------------------------------------------------
+
+func TestIndexedHeap_UpdateRemove(t *testing.T) {
+	h := xheap.NewIndexedMinHeap[string, int]()
+	h.Push("a", 5)
+	h.Push("b", 3)
+	h.Push("c", 9)
+	h.Push("d", 1)
+
+	if !h.Update("c", 0) {
+		t.Fatal("update of existing key should succeed")
+	}
+	if h.Update("missing", 0) {
+		t.Fatal("update of missing key should fail")
+	}
+	if p, ok := h.Remove("d"); !ok || p != 1 {
+		t.Fatalf("remove d: got %v %v", p, ok)
+	}
+	h.Push("a", 2) // 已存在的键更新优先级
+
+	var got []string
+	for h.Len() > 0 {
+		k, _, _ := h.Pop()
+		got = append(got, k)
+	}
+	if want := []string{"c", "a", "b"}; !slices.Equal(want, got) {
+		t.Errorf("indexed heap order wrong: want %v, got %v", want, got)
+	}
+	if h.Contains("a") {
+		t.Error("popped key should be gone")
+	}
+}
+
+// Dijkstra 最短路
+func TestIndexedHeap_Dijkstra(t *testing.T) {
+	graph := map[int]map[int]int{
+		0: {1: 4, 2: 1},
+		2: {1: 2, 3: 5},
+		1: {3: 1},
+	}
+	dist := map[int]int{0: 0}
+	pq := xheap.NewIndexedMinHeap[int, int]()
+	pq.Push(0, 0)
+	for pq.Len() > 0 {
+		u, d, _ := pq.Pop()
+		for v, w := range graph[u] {
+			if old, ok := dist[v]; !ok || d+w < old {
+				dist[v] = d + w
+				pq.Push(v, d+w)
+			}
+		}
+	}
+	if dist[3] != 4 || dist[1] != 3 {
+		t.Errorf("unexpected distances: %v", dist)
+	}
+}
+
+// ------- 延迟队列 ------------------------------------------------------------
+
+func TestDelayQueue_Order(t *testing.T) {
+	q := xheap.NewDelayQueue[string]()
+	q.Put("late", 60*time.Millisecond)
+	q.Put("early", 20*time.Millisecond)
+	q.Put("now", 0)
+
+	if v, ok := q.Poll(); !ok || v != "now" {
+		t.Fatalf("poll should return the due element, got %q %v", v, ok)
+	}
+	if _, ok := q.Poll(); ok {
+		t.Fatal("poll should not return elements that are not due")
+	}
+
+	start := time.Now()
+	ctx := context.Background()
+	for _, want := range []string{"early", "late"} {
+		v, err := q.Take(ctx)
+		if err != nil || v != want {
+			t.Fatalf("take: want %q, got %q %v", want, v, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("take returned before the deadline: %v", elapsed)
+	}
+}
+
+func TestDelayQueue_WakeAndCancel(t *testing.T) {
+	q := xheap.NewDelayQueue[int]()
+	q.Put(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := q.Take(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("take should stop with ctx, got %v", err)
+	}
+
+	// 新的更早元素应唤醒等待中的 Take
+	var wg sync.WaitGroup
+	results := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			v, err := q.Take(ctx)
+			if err == nil {
+				results <- v
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	q.Put(2, 10*time.Millisecond)
+	q.Put(3, 0)
+	wg.Wait()
+	close(results)
+	var got []int
+	for v := range results {
+		got = append(got, v)
+	}
+	slices.Sort(got)
+	if !slices.Equal([]int{2, 3}, got) {
+		t.Errorf("each waiter should get one element, got %v", got)
+	}
+	if q.Len() != 1 {
+		t.Errorf("the hour-long element should remain, len %d", q.Len())
+	}
+}
@@ -0,0 +1,240 @@
+package xmap
+
+import (
+	"iter"
+	"sync"
+
+	"github.com/karosown/katool-go/container/stream"
+	"golang.org/x/exp/constraints"
+)
+
+// ConcurrentSortedMap 并发安全的 SortedMap，读写由读写锁保护；遍历与区间操作基于加锁时的快照，
+// 因此在遍历回调中修改映射不会死锁
+// ConcurrentSortedMap is a concurrency-safe SortedMap guarded by a read-write lock. Iteration and range
+// operations work on a snapshot taken under the lock, so modifying the map inside a loop cannot deadlock
+type ConcurrentSortedMap[K constraints.Ordered, V any] struct {
+	mu sync.RWMutex
+	m  SortedMap[K, V]
+}
+
+// NewConcurrentSortedMap 创建并发安全的有序映射
+// NewConcurrentSortedMap creates a concurrency-safe ordered map
+func NewConcurrentSortedMap[K constraints.Ordered, V any]() *ConcurrentSortedMap[K, V] {
+	return &ConcurrentSortedMap[K, V]{}
+}
+
+// Set 添加或更新 / Set adds or updates a pair
+func (c *ConcurrentSortedMap[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m.Set(key, value)
+}
+
+// Get 获取值 / Get returns the value of a key
+func (c *ConcurrentSortedMap[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Get(key)
+}
+
+// GetOrSet 键存在时返回已有值，否则写入v；第二个返回值表示是否已存在
+// GetOrSet returns the existing value when present, otherwise stores v; the bool reports whether it existed
+func (c *ConcurrentSortedMap[K, V]) GetOrSet(key K, value V) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.m.Get(key); ok {
+		return old, true
+	}
+	c.m.Set(key, value)
+	return value, false
+}
+
+// Compute 在锁内根据旧值计算新值，keep 为 false 时删除该键
+// Compute derives the new value from the old one under the lock, the key is deleted when keep is false
+func (c *ConcurrentSortedMap[K, V]) Compute(key K, fn func(old V, exists bool) (value V, keep bool)) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old, exists := c.m.Get(key)
+	value, keep := fn(old, exists)
+	if !keep {
+		c.m.Delete(key)
+		var zero V
+		return zero, false
+	}
+	c.m.Set(key, value)
+	return value, true
+}
+
+// Delete 删除 / Delete removes a key
+func (c *ConcurrentSortedMap[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m.Delete(key)
+}
+
+// Remove 删除并返回旧值 / Remove deletes a key and returns the removed value
+func (c *ConcurrentSortedMap[K, V]) Remove(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m.Remove(key)
+}
+
+// Has 键是否存在 / Has reports whether the key is present
+func (c *ConcurrentSortedMap[K, V]) Has(key K) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Len 元素数量 / Len returns the number of pairs
+func (c *ConcurrentSortedMap[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Len()
+}
+
+// Clear 清空 / Clear removes every pair
+func (c *ConcurrentSortedMap[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m.Clear()
+}
+
+// Floor 小于等于key的最大键值对 / Floor returns the greatest pair whose key is <= key
+func (c *ConcurrentSortedMap[K, V]) Floor(key K) (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Floor(key)
+}
+
+// Ceiling 大于等于key的最小键值对 / Ceiling returns the least pair whose key is >= key
+func (c *ConcurrentSortedMap[K, V]) Ceiling(key K) (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Ceiling(key)
+}
+
+// Lower 严格小于key的最大键值对 / Lower returns the greatest pair whose key is strictly < key
+func (c *ConcurrentSortedMap[K, V]) Lower(key K) (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Lower(key)
+}
+
+// Higher 严格大于key的最小键值对 / Higher returns the least pair whose key is strictly > key
+func (c *ConcurrentSortedMap[K, V]) Higher(key K) (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Higher(key)
+}
+
+// First 最小的键值对 / First returns the pair with the least key
+func (c *ConcurrentSortedMap[K, V]) First() (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.First()
+}
+
+// Last 最大的键值对 / Last returns the pair with the greatest key
+func (c *ConcurrentSortedMap[K, V]) Last() (K, V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.Last()
+}
+
+// PollFirst 删除并返回最小的键值对 / PollFirst removes and returns the pair with the least key
+func (c *ConcurrentSortedMap[K, V]) PollFirst() (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m.PollFirst()
+}
+
+// PollLast 删除并返回最大的键值对 / PollLast removes and returns the pair with the greatest key
+func (c *ConcurrentSortedMap[K, V]) PollLast() (K, V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m.PollLast()
+}
+
+// snapshot 复制一段有序序列 / snapshot copies an ordered sequence
+func (c *ConcurrentSortedMap[K, V]) snapshot(seq func(m *SortedMap[K, V]) iter.Seq2[K, V]) stream.Entries[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var entries stream.Entries[K, V]
+	for k, v := range seq(&c.m) {
+		entries = append(entries, stream.Entry[K, V]{Key: k, Value: v})
+	}
+	return entries
+}
+
+func entriesSeq[K comparable, V any](entries stream.Entries[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range entries {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// All 按键升序遍历快照 / All iterates a snapshot in ascending key order
+func (c *ConcurrentSortedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		entriesSeq(c.snapshot((*SortedMap[K, V]).All))(yield)
+	}
+}
+
+// Backward 按键降序遍历快照 / Backward iterates a snapshot in descending key order
+func (c *ConcurrentSortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		entriesSeq(c.snapshot((*SortedMap[K, V]).Backward))(yield)
+	}
+}
+
+// Range 按升序遍历键在 [from, to) 区间内的快照
+// Range iterates a snapshot of the pairs whose keys are in [from, to) in ascending order
+func (c *ConcurrentSortedMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		entriesSeq(c.snapshot(func(m *SortedMap[K, V]) iter.Seq2[K, V] { return m.Range(from, to) }))(yield)
+	}
+}
+
+// HeadMap 复制键小于to（inclusive 时小于等于）的部分
+// HeadMap copies the pairs whose keys are < to, or <= to when inclusive
+func (c *ConcurrentSortedMap[K, V]) HeadMap(to K, inclusive bool) *SortedMap[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.HeadMap(to, inclusive).ToSortedMap()
+}
+
+// TailMap 复制键大于from（inclusive 时大于等于）的部分
+// TailMap copies the pairs whose keys are > from, or >= from when inclusive
+func (c *ConcurrentSortedMap[K, V]) TailMap(from K, inclusive bool) *SortedMap[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.TailMap(from, inclusive).ToSortedMap()
+}
+
+// SubMap 复制键在 from 与 to 之间的部分
+// SubMap copies the pairs whose keys are between from and to
+func (c *ConcurrentSortedMap[K, V]) SubMap(from K, fromInclusive bool, to K, toInclusive bool) *SortedMap[K, V] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.SubMap(from, fromInclusive, to, toInclusive).ToSortedMap()
+}
+
+// SortedKeys 按升序返回所有键 / SortedKeys returns every key in ascending order
+func (c *ConcurrentSortedMap[K, V]) SortedKeys() []K {
+	return c.snapshot((*SortedMap[K, V]).All).KeySet()
+}
+
+// ToStream 按键升序把快照转换为流 / ToStream converts a snapshot to a stream in ascending key order
+func (c *ConcurrentSortedMap[K, V]) ToStream() *stream.Stream[stream.Entry[K, V], []stream.Entry[K, V]] {
+	return c.snapshot((*SortedMap[K, V]).All).ToStream()
+}
+
+// MarshalJSON 按键升序序列化 / MarshalJSON encodes the pairs in ascending key order
+func (c *ConcurrentSortedMap[K, V]) MarshalJSON() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.m.MarshalJSON()
+}
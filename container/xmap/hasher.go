@@ -0,0 +1,138 @@
+package xmap
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// Hasher 计算键的哈希值，相等的键必须得到相同的哈希值
+// Hasher hashes a key, equal keys must produce the same hash
+type Hasher[K any] func(key K) uint64
+
+// Equal 判断两个键是否相等
+// Equal reports whether two keys are equal
+type Equal[K any] func(a, b K) bool
+
+// StructuralHasher 按值递归计算哈希的 Hasher，支持切片、map、结构体（含未导出字段）、指针与接口等不可比较类型
+// 指针按指向的值计算哈希，map 的哈希与遍历顺序无关；chan 与 func 按地址计算。与 reflect.DeepEqual 配合使用
+// StructuralHasher returns a Hasher that hashes by value recursively, supporting non-comparable types
+// such as slices, maps, structs (including unexported fields), pointers and interfaces. Pointers hash
+// the value they point to and map hashes do not depend on iteration order; chans and funcs hash by
+// address. It pairs with reflect.DeepEqual
+func StructuralHasher[K any]() Hasher[K] {
+	seed := maphash.MakeSeed()
+	return func(key K) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		v := reflect.ValueOf(&key).Elem()
+		hashValue(&h, seed, v, make(map[uintptr]struct{}))
+		return h.Sum64()
+	}
+}
+
+// DeepEqual 基于 reflect.DeepEqual 的 Equal
+// DeepEqual is an Equal based on reflect.DeepEqual
+func DeepEqual[K any](a, b K) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// hashValue 把v按值写入h，visited 用于打断指针环
+// hashValue writes v into h by value, visited breaks pointer cycles
+func hashValue(h *maphash.Hash, seed maphash.Seed, v reflect.Value, visited map[uintptr]struct{}) {
+	if !v.IsValid() {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(byte(v.Kind()))
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(h, real(v.Complex()))
+		writeFloat(h, imag(v.Complex()))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, seed, v.Index(i), visited)
+		}
+	case reflect.Slice:
+		writeUint64(h, uint64(v.Len()))
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			h.Write(v.Bytes())
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, seed, v.Index(i), visited)
+		}
+	case reflect.Map:
+		// 每个键值对单独计算哈希后相加，与遍历顺序无关
+		// every entry is hashed on its own and the results are summed, independent of iteration order
+		writeUint64(h, uint64(v.Len()))
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry maphash.Hash
+			entry.SetSeed(seed)
+			hashValue(&entry, seed, iter.Key(), visited)
+			hashValue(&entry, seed, iter.Value(), visited)
+			sum += entry.Sum64()
+		}
+		writeUint64(h, sum)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, seed, v.Field(i), visited)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		addr := v.Pointer()
+		if _, ok := visited[addr]; ok {
+			return
+		}
+		visited[addr] = struct{}{}
+		hashValue(h, seed, v.Elem(), visited)
+		delete(visited, addr)
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		h.WriteString(v.Elem().Type().String())
+		hashValue(h, seed, v.Elem(), visited)
+	default:
+		// chan、func、unsafe.Pointer 按地址计算
+		// chan, func and unsafe.Pointer hash by address
+		writeUint64(h, uint64(v.Pointer()))
+	}
+}
+
+func writeUint64(h *maphash.Hash, u uint64) {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(u >> (8 * i))
+	}
+	h.Write(buf[:])
+}
+
+func writeFloat(h *maphash.Hash, f float64) {
+	// +0 与 -0 相等，必须得到相同的哈希
+	// +0 and -0 are equal and must hash the same
+	if f == 0 {
+		f = 0
+	}
+	writeUint64(h, math.Float64bits(f))
+}
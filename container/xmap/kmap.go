@@ -1,88 +1,213 @@
 package xmap
 
 import (
-	"fmt"
-
-	"github.com/karosown/katool-go/container/optional"
 	"github.com/karosown/katool-go/container/stream"
-	"github.com/spf13/cast"
 )
 
+// KV 键值对
+// KV is a key-value pair
 type KV[K any, V any] struct {
 	Key   K
 	Value V
 }
+
+// KMap 可以使用任意类型（包括切片、map、含切片的结构体等不可比较类型）作为键的哈希表
+// 默认按值计算哈希（StructuralHasher）并用 reflect.DeepEqual 比较，也可以通过 WithHasher/WithEqual 自定义；
+// 哈希冲突的键值对以链表方式存放在同一个桶中。非并发安全，并发场景请使用 ShardedKMap
+// KMap is a hash map that accepts any key type, including non-comparable ones such as slices, maps and
+// structs holding slices. Keys are hashed by value (StructuralHasher) and compared with reflect.DeepEqual
+// by default, both can be replaced with WithHasher/WithEqual; colliding entries are chained in the same
+// bucket. It is not safe for concurrent use, see ShardedKMap
 type KMap[K any, V any] struct {
-	// 使用这个Map来做Hash映射
-	internalMap Map[uint32, *KV[K, V]]
+	buckets map[uint64][]*KV[K, V]
+	size    int
+	hasher  Hasher[K]
+	equal   Equal[K]
+}
+
+// KMapOption 自定义 KMap
+// KMapOption customizes KMap
+type KMapOption[K any, V any] func(*KMap[K, V])
+
+// WithHasher 自定义哈希函数，相等的键必须得到相同的哈希值
+// WithHasher sets the hash function, equal keys must produce the same hash
+func WithHasher[K any, V any](hasher Hasher[K]) KMapOption[K, V] {
+	return func(m *KMap[K, V]) {
+		if hasher != nil {
+			m.hasher = hasher
+		}
+	}
+}
+
+// WithEqual 自定义键的相等判断
+// WithEqual sets the key equality
+func WithEqual[K any, V any](equal Equal[K]) KMapOption[K, V] {
+	return func(m *KMap[K, V]) {
+		if equal != nil {
+			m.equal = equal
+		}
+	}
+}
+
+// NewKMap 创建 KMap
+// NewKMap creates a KMap
+func NewKMap[K any, V any](opts ...KMapOption[K, V]) *KMap[K, V] {
+	m := &KMap[K, V]{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m.init()
 }
 
-func NewKMap[K any, V any]() *KMap[K, V] {
-	return &KMap[K, V]{}
+// init 补齐默认值，使零值 KMap 也可以直接使用
+// init fills in the defaults so the zero value is ready to use
+func (m *KMap[K, V]) init() *KMap[K, V] {
+	if m.buckets == nil {
+		m.buckets = make(map[uint64][]*KV[K, V])
+	}
+	if m.hasher == nil {
+		m.hasher = StructuralHasher[K]()
+	}
+	if m.equal == nil {
+		m.equal = DeepEqual[K]
+	}
+	return m
 }
-func hash[K any](key *K) uint32 {
-	return cast.ToUint32(fmt.Sprintf("%p", key))
+
+// find 在桶中查找键
+// find looks up the key in its bucket
+func (m *KMap[K, V]) find(h uint64, k K) (int, *KV[K, V]) {
+	for i, kv := range m.buckets[h] {
+		if m.equal(kv.Key, k) {
+			return i, kv
+		}
+	}
+	return -1, nil
+}
+
+func (m *KMap[K, V]) hash(k K) uint64 {
+	m.init()
+	return m.hasher(k)
 }
+
+// Set 设置键值对
+// Set sets a key-value pair
 func (m *KMap[K, V]) Set(k K, v V) {
-	u := hash(&k)
-	m.internalMap.Set(u, &KV[K, V]{k, v})
+	m.set(m.hash(k), k, v)
 }
 
+func (m *KMap[K, V]) set(h uint64, k K, v V) {
+	if _, kv := m.find(h, k); kv != nil {
+		kv.Value = v
+		return
+	}
+	m.buckets[h] = append(m.buckets[h], &KV[K, V]{k, v})
+	m.size++
+}
+
+// Get 获取指定键的值
+// Get retrieves the value for the key
 func (m *KMap[K, V]) Get(k K) (V, bool) {
-	u, ok := m.internalMap.Get(hash(&k))
-	if !ok {
-		return *new(V), false
+	return m.get(m.hash(k), k)
+}
+
+func (m *KMap[K, V]) get(h uint64, k K) (V, bool) {
+	if _, kv := m.find(h, k); kv != nil {
+		return kv.Value, true
 	}
-	return u.Value, ok
+	return *new(V), false
 }
 
+// Delete 删除指定键
+// Delete removes the key
 func (m *KMap[K, V]) Delete(k K) {
-	u, ok := m.internalMap.Get(hash(&k))
-	if !ok || nil == optional.ToNillable(u) {
-		return
-	}
-	m.internalMap.Delete(hash(&k))
+	m.delete(m.hash(k), k)
 }
 
-func (m *KMap[K, V]) Has(k K) bool {
-	u, ok := m.internalMap.Get(hash(&k))
-	if !ok {
+func (m *KMap[K, V]) delete(h uint64, k K) bool {
+	i, _ := m.find(h, k)
+	if i < 0 {
 		return false
 	}
-	return nil != u
+	bucket := m.buckets[h]
+	if len(bucket) == 1 {
+		delete(m.buckets, h)
+	} else {
+		bucket[i] = bucket[len(bucket)-1]
+		bucket[len(bucket)-1] = nil
+		m.buckets[h] = bucket[:len(bucket)-1]
+	}
+	m.size--
+	return true
 }
+
+// Has 检查键是否存在
+// Has reports whether the key exists
+func (m *KMap[K, V]) Has(k K) bool {
+	_, ok := m.Get(k)
+	return ok
+}
+
+// Len 返回键值对数量
+// Len returns the number of entries
 func (m *KMap[K, V]) Len() int {
-	return len(m.internalMap)
+	return m.size
+}
+
+// Entries 返回所有键值对，顺序不保证
+// Entries returns all the entries in no particular order
+func (m *KMap[K, V]) Entries() []*KV[K, V] {
+	entries := make([]*KV[K, V], 0, m.size)
+	for _, bucket := range m.buckets {
+		entries = append(entries, bucket...)
+	}
+	return entries
 }
+
+// ToStream 转换为键值对流
+// ToStream converts to a stream of entries
 func (m *KMap[K, V]) ToStream() *stream.Stream[*KV[K, V], []*KV[K, V]] {
-	values := m.internalMap.Values()
-	return stream.Of(&values)
+	entries := m.Entries()
+	return stream.Of(&entries)
 }
 
+// Foreach 遍历所有键值对
+// Foreach iterates over all the entries
 func (m *KMap[K, V]) Foreach(f func(k K, v V)) {
-	m.ToStream().ForEach(func(item *KV[K, V]) {
-		f(item.Key, item.Value)
-	})
+	for _, bucket := range m.buckets {
+		for _, kv := range bucket {
+			f(kv.Key, kv.Value)
+		}
+	}
 }
 
+// Keys 返回所有键
+// Keys returns all the keys
 func (m *KMap[K, V]) Keys() []K {
-	return stream.Cast[K](m.ToStream().Map(func(i *KV[K, V]) any {
+	return stream.Map(m.ToStream(), func(i *KV[K, V]) K {
 		return i.Key
-	})).ToList()
+	}).ToList()
 }
 
+// Values 返回所有值
+// Values returns all the values
 func (m *KMap[K, V]) Values() []V {
-	return stream.Cast[V](m.ToStream().Map(func(i *KV[K, V]) any {
+	return stream.Map(m.ToStream(), func(i *KV[K, V]) V {
 		return i.Value
-	})).ToList()
+	}).ToList()
 }
 
+// Clear 清空所有键值对
+// Clear removes all the entries
 func (m *KMap[K, V]) Clear() {
-	for k := range m.internalMap {
-		delete(m.internalMap, k)
-	}
+	clear(m.buckets)
+	m.size = 0
 }
+
+// Reset 重置为空表并返回自身
+// Reset empties the map and returns it
 func (m *KMap[K, V]) Reset() *KMap[K, V] {
-	m.internalMap = NewMap[uint32, *KV[K, V]]()
-	return m
+	m.buckets = make(map[uint64][]*KV[K, V])
+	m.size = 0
+	return m.init()
 }
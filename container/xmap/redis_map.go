@@ -69,6 +69,8 @@ type RedisMap[K comparable, V any] struct {
 	ttl      time.Duration
 	codec    Codec[V]
 	keyCodec KeyCodec[K]
+
+	computeRetries int
 }
 
 // NewRedisMap creates a Redis-backed map stored as a hash key.
@@ -163,71 +165,65 @@ func (m *RedisMap[K, V]) Len(ctx context.Context) (int64, error) {
 }
 
 // Keys returns all keys when a key decoder is available.
+// It iterates with HSCAN so large hashes do not block the server.
 func (m *RedisMap[K, V]) Keys(ctx context.Context) ([]K, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
 	if m.keyCodec == nil {
 		return nil, errors.New("xmap: key codec not set")
 	}
-	fields, err := m.client.HKeys(ctx, m.key).Result()
-	if err != nil {
-		return nil, err
-	}
-	keys := make([]K, 0, len(fields))
-	for _, field := range fields {
+	var keys []K
+	err := m.scanFields(ctx, "", 0, true, func(field string, _ []byte) error {
 		k, err := m.keyCodec.Decode(field)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return keys, nil
 }
 
 // Values returns all values.
+// It iterates with HSCAN so large hashes do not block the server.
 func (m *RedisMap[K, V]) Values(ctx context.Context) ([]V, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-	vals, err := m.client.HVals(ctx, m.key).Result()
-	if err != nil {
-		return nil, err
-	}
-	out := make([]V, 0, len(vals))
-	for _, v := range vals {
+	var out []V
+	err := m.scanFields(ctx, "", 0, true, func(_ string, b []byte) error {
 		var decoded V
-		if err := m.codec.Unmarshal([]byte(v), &decoded); err != nil {
-			return nil, err
+		if err := m.codec.Unmarshal(b, &decoded); err != nil {
+			return err
 		}
 		out = append(out, decoded)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return out, nil
 }
 
 // GetAll loads all key-value pairs.
+// It iterates with HSCAN so large hashes do not block the server.
 func (m *RedisMap[K, V]) GetAll(ctx context.Context) (map[K]V, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
 	if m.keyCodec == nil {
 		return nil, errors.New("xmap: key codec not set")
 	}
-	all, err := m.client.HGetAll(ctx, m.key).Result()
-	if err != nil {
-		return nil, err
-	}
-	out := make(map[K]V, len(all))
-	for field, v := range all {
+	out := make(map[K]V)
+	err := m.scanFields(ctx, "", 0, true, func(field string, b []byte) error {
 		k, err := m.keyCodec.Decode(field)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		var decoded V
-		if err := m.codec.Unmarshal([]byte(v), &decoded); err != nil {
-			return nil, err
+		if err := m.codec.Unmarshal(b, &decoded); err != nil {
+			return err
 		}
 		out[k] = decoded
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return out, nil
 }
@@ -0,0 +1,375 @@
+package xmap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrComputeConflict is returned by Compute when the field kept changing concurrently.
+var ErrComputeConflict = errors.New("xmap: compute retries exhausted")
+
+// ErrFieldTTLUnsupported is returned when the server does not support per-field expiry (Redis < 7.4).
+var ErrFieldTTLUnsupported = errors.New("xmap: per-field TTL requires Redis 7.4+")
+
+// defaultComputeRetries bounds the optimistic retries of Compute.
+const defaultComputeRetries = 16
+
+// casScript atomically compares a field with an expected value and then sets or deletes it.
+// ARGV: field, expect ("1" present with value ARGV[3], "0" absent), expected, op ("1" set, "0" delete), value, ttl ms.
+var casScript = redis.NewScript(`
+local cur = redis.call('HGET', KEYS[1], ARGV[1])
+if ARGV[2] == '1' then
+	if cur ~= ARGV[3] then return 0 end
+elseif cur then
+	return 0
+end
+if ARGV[4] == '1' then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[5])
+	if tonumber(ARGV[6]) > 0 then redis.call('PEXPIRE', KEYS[1], ARGV[6]) end
+else
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// WithRedisMapComputeRetries sets how many times Compute retries after a concurrent change.
+func WithRedisMapComputeRetries[K comparable, V any](n int) RedisMapOption[K, V] {
+	return func(m *RedisMap[K, V]) {
+		if n > 0 {
+			m.computeRetries = n
+		}
+	}
+}
+
+// MGet loads several keys with a single HMGET. Missing keys are absent from the result.
+func (m *RedisMap[K, V]) MGet(ctx context.Context, keys ...K) (map[K]V, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := make(map[K]V, len(keys))
+	if len(keys) == 0 {
+		return out, nil
+	}
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		field, err := m.encodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = field
+	}
+	vals, err := m.client.HMGet(ctx, m.key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var decoded V
+		if err := m.codec.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, err
+		}
+		out[keys[i]] = decoded
+	}
+	return out, nil
+}
+
+// MSet stores several key-value pairs with a single HSET.
+func (m *RedisMap[K, V]) MSet(ctx context.Context, entries map[K]V) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(entries)*2)
+	for k, v := range entries {
+		field, err := m.encodeKey(k)
+		if err != nil {
+			return err
+		}
+		b, err := m.codec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		args = append(args, field, b)
+	}
+	if err := m.client.HSet(ctx, m.key, args...).Err(); err != nil {
+		return err
+	}
+	if m.ttl > 0 {
+		_ = m.client.Expire(ctx, m.key, m.ttl).Err()
+	}
+	return nil
+}
+
+// SetIfAbsent stores the value only when the key is absent and reports whether it was stored.
+func (m *RedisMap[K, V]) SetIfAbsent(ctx context.Context, key K, value V) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	b, err := m.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	ok, err := m.client.HSetNX(ctx, m.key, field, b).Result()
+	if err != nil {
+		return false, err
+	}
+	if ok && m.ttl > 0 {
+		_ = m.client.Expire(ctx, m.key, m.ttl).Err()
+	}
+	return ok, nil
+}
+
+// CompareAndSet replaces the value only when the stored value equals expected.
+// Values are compared by their encoded bytes, so the codec must be deterministic.
+func (m *RedisMap[K, V]) CompareAndSet(ctx context.Context, key K, expected, value V) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	old, err := m.codec.Marshal(expected)
+	if err != nil {
+		return false, err
+	}
+	b, err := m.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return m.cas(ctx, field, old, true, b, true)
+}
+
+// CompareAndDelete removes the key only when the stored value equals expected.
+func (m *RedisMap[K, V]) CompareAndDelete(ctx context.Context, key K, expected V) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	old, err := m.codec.Marshal(expected)
+	if err != nil {
+		return false, err
+	}
+	return m.cas(ctx, field, old, true, nil, false)
+}
+
+// Compute atomically updates a key: fn receives the current value and returns the new value and
+// whether to keep it (false deletes the key). The update is applied with a Lua compare-and-set and
+// fn is called again when the value changed in between, so fn must be free of side effects.
+// It returns the resulting value and whether the key is present afterwards.
+func (m *RedisMap[K, V]) Compute(ctx context.Context, key K, fn func(old V, exists bool) (V, bool)) (V, bool, error) {
+	var zero V
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return zero, false, err
+	}
+	retries := m.computeRetries
+	if retries <= 0 {
+		retries = defaultComputeRetries
+	}
+	for range retries {
+		raw, err := m.client.HGet(ctx, m.key, field).Bytes()
+		exists := err == nil
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return zero, false, err
+		}
+		var old V
+		if exists {
+			if err := m.codec.Unmarshal(raw, &old); err != nil {
+				return zero, false, err
+			}
+		}
+		value, keep := fn(old, exists)
+		var b []byte
+		if keep {
+			if b, err = m.codec.Marshal(value); err != nil {
+				return zero, false, err
+			}
+		} else if !exists {
+			return zero, false, nil
+		}
+		ok, err := m.cas(ctx, field, raw, exists, b, keep)
+		if err != nil {
+			return zero, false, err
+		}
+		if ok {
+			if !keep {
+				return zero, false, nil
+			}
+			return value, true, nil
+		}
+	}
+	return zero, false, ErrComputeConflict
+}
+
+// cas runs casScript for the field.
+func (m *RedisMap[K, V]) cas(ctx context.Context, field string, expected []byte, present bool, value []byte, set bool) (bool, error) {
+	n, err := casScript.Run(ctx, m.client, []string{m.key},
+		field, flag(present), expected, flag(set), value, m.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Incr adds delta to an integer value with HINCRBY and returns the new value.
+// The codec must store integers as decimal text, which the default JSON codec does.
+func (m *RedisMap[K, V]) Incr(ctx context.Context, key K, delta int64) (int64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := m.client.HIncrBy(ctx, m.key, field, delta).Result()
+	if err != nil {
+		return 0, err
+	}
+	if m.ttl > 0 {
+		_ = m.client.Expire(ctx, m.key, m.ttl).Err()
+	}
+	return n, nil
+}
+
+// IncrFloat adds delta to a numeric value with HINCRBYFLOAT and returns the new value.
+func (m *RedisMap[K, V]) IncrFloat(ctx context.Context, key K, delta float64) (float64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := m.client.HIncrByFloat(ctx, m.key, field, delta).Result()
+	if err != nil {
+		return 0, err
+	}
+	if m.ttl > 0 {
+		_ = m.client.Expire(ctx, m.key, m.ttl).Err()
+	}
+	return n, nil
+}
+
+// SetWithTTL stores a key-value pair that expires on its own after ttl, using HPEXPIRE.
+// Both commands run in a MULTI block, so nothing is written when the server lacks
+// per-field expiry; ErrFieldTTLUnsupported is returned in that case.
+func (m *RedisMap[K, V]) SetWithTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ttl <= 0 {
+		return m.Set(ctx, key, value)
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return err
+	}
+	b, err := m.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = m.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, m.key, field, b)
+		p.HPExpire(ctx, m.key, ttl, field)
+		if m.ttl > 0 {
+			p.Expire(ctx, m.key, m.ttl)
+		}
+		return nil
+	})
+	return fieldTTLError(err)
+}
+
+// Expire sets the expiry of a single key with HPEXPIRE and reports whether the key exists.
+func (m *RedisMap[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	codes, err := m.client.HPExpire(ctx, m.key, ttl, field).Result()
+	if err != nil {
+		return false, fieldTTLError(err)
+	}
+	// -2 means the field does not exist
+	return len(codes) == 1 && codes[0] != -2, nil
+}
+
+// Persist removes the expiry of a single key and reports whether an expiry was removed.
+func (m *RedisMap[K, V]) Persist(ctx context.Context, key K) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	codes, err := m.client.HPersist(ctx, m.key, field).Result()
+	if err != nil {
+		return false, fieldTTLError(err)
+	}
+	return len(codes) == 1 && codes[0] == 1, nil
+}
+
+// TTL returns the remaining time to live of a single key. A zero duration with exists=true
+// means the key has no expiry of its own.
+func (m *RedisMap[K, V]) TTL(ctx context.Context, key K) (ttl time.Duration, exists bool, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	field, err := m.encodeKey(key)
+	if err != nil {
+		return 0, false, err
+	}
+	ms, err := m.client.HPTTL(ctx, m.key, field).Result()
+	if err != nil {
+		return 0, false, fieldTTLError(err)
+	}
+	if len(ms) != 1 || ms[0] == -2 {
+		return 0, false, nil
+	}
+	if ms[0] == -1 {
+		return 0, true, nil
+	}
+	return time.Duration(ms[0]) * time.Millisecond, true, nil
+}
+
+// fieldTTLError maps the "unknown command" reply of older servers to ErrFieldTTLUnsupported.
+func fieldTTLError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "unknown command") {
+		return errors.Join(ErrFieldTTLUnsupported, err)
+	}
+	return err
+}
+
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
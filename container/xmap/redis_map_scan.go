@@ -0,0 +1,129 @@
+package xmap
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// defaultScanCount is the HSCAN COUNT hint used when none is given.
+const defaultScanCount = 100
+
+// errStopScan stops a scan early without reporting an error.
+var errStopScan = errors.New("xmap: stop scan")
+
+// RedisMapScanner iterates a RedisMap with HSCAN instead of whole-hash commands.
+// Like every SCAN family command, an entry modified during the iteration may be
+// returned more than once or not at all.
+type RedisMapScanner[K comparable, V any] struct {
+	m     *RedisMap[K, V]
+	ctx   context.Context
+	match string
+	count int64
+	err   error
+}
+
+// Scan returns a cursor-based iterator over the hash.
+// match is an HSCAN MATCH pattern applied to the encoded field ("" matches everything)
+// and count is the HSCAN COUNT hint (<= 0 uses a default of 100).
+// Check Err after ranging over the scanner.
+func (m *RedisMap[K, V]) Scan(ctx context.Context, match string, count int64) *RedisMapScanner[K, V] {
+	return &RedisMapScanner[K, V]{m: m, ctx: ctx, match: match, count: count}
+}
+
+// All yields every key-value pair. Each call starts a new scan from cursor 0.
+func (s *RedisMapScanner[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.err = nil
+		if s.m.keyCodec == nil {
+			s.err = errors.New("xmap: key codec not set")
+			return
+		}
+		s.err = s.m.scanFields(s.ctx, s.match, s.count, false, func(field string, b []byte) error {
+			k, err := s.m.keyCodec.Decode(field)
+			if err != nil {
+				return err
+			}
+			var v V
+			if err := s.m.codec.Unmarshal(b, &v); err != nil {
+				return err
+			}
+			if !yield(k, v) {
+				return errStopScan
+			}
+			return nil
+		})
+	}
+}
+
+// Keys yields every key. Each call starts a new scan from cursor 0.
+func (s *RedisMapScanner[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values yields every value without decoding the keys. Each call starts a new scan from cursor 0.
+func (s *RedisMapScanner[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		s.err = s.m.scanFields(s.ctx, s.match, s.count, false, func(_ string, b []byte) error {
+			var v V
+			if err := s.m.codec.Unmarshal(b, &v); err != nil {
+				return err
+			}
+			if !yield(v) {
+				return errStopScan
+			}
+			return nil
+		})
+	}
+}
+
+// Err returns the error that ended the last iteration, if any.
+func (s *RedisMapScanner[K, V]) Err() error {
+	return s.err
+}
+
+// scanFields walks the hash with HSCAN and calls fn for every field.
+// When unique is set, fields returned more than once by the server are skipped.
+func (m *RedisMap[K, V]) scanFields(ctx context.Context, match string, count int64, unique bool, fn func(field string, value []byte) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	var seen map[string]struct{}
+	if unique {
+		seen = make(map[string]struct{})
+	}
+	var cursor uint64
+	for {
+		pairs, next, err := m.client.HScan(ctx, m.key, cursor, match, count).Result()
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if unique {
+				if _, ok := seen[pairs[i]]; ok {
+					continue
+				}
+				seen[pairs[i]] = struct{}{}
+			}
+			if err := fn(pairs[i], []byte(pairs[i+1])); err != nil {
+				if errors.Is(err, errStopScan) {
+					return nil
+				}
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
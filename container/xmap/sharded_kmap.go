@@ -0,0 +1,203 @@
+package xmap
+
+import (
+	"sync"
+
+	"github.com/karosown/katool-go/container/stream"
+)
+
+// DefaultShards ShardedKMap 默认分片数
+// DefaultShards is the default number of ShardedKMap shards
+const DefaultShards = 32
+
+// kmapShard 带读写锁的分片
+// kmapShard is a shard guarded by a read-write lock
+type kmapShard[K any, V any] struct {
+	sync.RWMutex
+	m *KMap[K, V]
+}
+
+// ShardedKMap 并发安全的 KMap，按哈希值把键分散到多个带读写锁的分片中以降低锁竞争；
+// 零值可直接使用，首次访问时按 DefaultShards 与默认 Hasher/Equal 初始化
+// ShardedKMap is a concurrency-safe KMap that spreads keys across shards guarded by their own
+// read-write locks to reduce contention. The zero value is ready to use and is set up with
+// DefaultShards and the default Hasher/Equal on first access
+type ShardedKMap[K any, V any] struct {
+	once   sync.Once
+	shards []*kmapShard[K, V]
+	hasher Hasher[K]
+}
+
+// NewShardedKMap 创建 ShardedKMap，shards 会向上取整为2的幂，小于等于0时使用 DefaultShards
+// NewShardedKMap creates a ShardedKMap, shards is rounded up to a power of two and DefaultShards is used when it is not positive
+func NewShardedKMap[K any, V any](shards int, opts ...KMapOption[K, V]) *ShardedKMap[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	m := &ShardedKMap[K, V]{}
+	m.once.Do(func() { m.init(n, opts) })
+	return m
+}
+
+// init 创建n个分片，所有分片共享同一个 Hasher/Equal
+// init creates n shards, all of them share the same Hasher/Equal
+func (m *ShardedKMap[K, V]) init(n int, opts []KMapOption[K, V]) {
+	proto := NewKMap(opts...)
+	m.shards, m.hasher = make([]*kmapShard[K, V], n), proto.hasher
+	for i := range m.shards {
+		m.shards[i] = &kmapShard[K, V]{m: NewKMap(WithHasher[K, V](proto.hasher), WithEqual[K, V](proto.equal))}
+	}
+}
+
+// lazyInit 零值首次使用时按默认配置初始化
+// lazyInit sets up the zero value with the defaults on first use
+func (m *ShardedKMap[K, V]) lazyInit() {
+	m.once.Do(func() { m.init(DefaultShards, nil) })
+}
+
+// shard 返回键所在的分片与哈希值
+// shard returns the shard holding the key and its hash
+func (m *ShardedKMap[K, V]) shard(k K) (*kmapShard[K, V], uint64) {
+	m.lazyInit()
+	h := m.hasher(k)
+	// 高位参与分片选择，避免与桶内哈希的低位相关
+	// mix in the high bits so shard selection does not only depend on the low bits
+	return m.shards[(h^(h>>32))&uint64(len(m.shards)-1)], h
+}
+
+// Set 设置键值对
+// Set sets a key-value pair
+func (m *ShardedKMap[K, V]) Set(k K, v V) {
+	s, h := m.shard(k)
+	s.Lock()
+	defer s.Unlock()
+	s.m.set(h, k, v)
+}
+
+// Get 获取指定键的值
+// Get retrieves the value for the key
+func (m *ShardedKMap[K, V]) Get(k K) (V, bool) {
+	s, h := m.shard(k)
+	s.RLock()
+	defer s.RUnlock()
+	return s.m.get(h, k)
+}
+
+// GetOrSet 键存在时返回已有值与true，否则写入v并返回v与false
+// GetOrSet returns the existing value and true when the key exists, otherwise stores v and returns v and false
+func (m *ShardedKMap[K, V]) GetOrSet(k K, v V) (V, bool) {
+	s, h := m.shard(k)
+	s.Lock()
+	defer s.Unlock()
+	if old, ok := s.m.get(h, k); ok {
+		return old, true
+	}
+	s.m.set(h, k, v)
+	return v, false
+}
+
+// Compute 在持有分片锁的情况下根据旧值计算新值，fn返回的keep为false时删除该键
+// Compute computes the new value from the old one while holding the shard lock, the key is removed when fn returns keep == false
+func (m *ShardedKMap[K, V]) Compute(k K, fn func(old V, exists bool) (value V, keep bool)) (V, bool) {
+	s, h := m.shard(k)
+	s.Lock()
+	defer s.Unlock()
+	old, exists := s.m.get(h, k)
+	value, keep := fn(old, exists)
+	if !keep {
+		s.m.delete(h, k)
+		return *new(V), false
+	}
+	s.m.set(h, k, value)
+	return value, true
+}
+
+// Delete 删除指定键
+// Delete removes the key
+func (m *ShardedKMap[K, V]) Delete(k K) {
+	s, h := m.shard(k)
+	s.Lock()
+	defer s.Unlock()
+	s.m.delete(h, k)
+}
+
+// Has 检查键是否存在
+// Has reports whether the key exists
+func (m *ShardedKMap[K, V]) Has(k K) bool {
+	_, ok := m.Get(k)
+	return ok
+}
+
+// Len 返回键值对数量
+// Len returns the number of entries
+func (m *ShardedKMap[K, V]) Len() int {
+	m.lazyInit()
+	total := 0
+	for _, s := range m.shards {
+		s.RLock()
+		total += s.m.Len()
+		s.RUnlock()
+	}
+	return total
+}
+
+// Entries 返回所有键值对的快照，顺序不保证
+// Entries returns a snapshot of all the entries in no particular order
+func (m *ShardedKMap[K, V]) Entries() []*KV[K, V] {
+	m.lazyInit()
+	entries := make([]*KV[K, V], 0)
+	for _, s := range m.shards {
+		s.RLock()
+		for _, kv := range s.m.Entries() {
+			entries = append(entries, &KV[K, V]{kv.Key, kv.Value})
+		}
+		s.RUnlock()
+	}
+	return entries
+}
+
+// ToStream 转换为键值对快照的流
+// ToStream converts a snapshot of the entries to a stream
+func (m *ShardedKMap[K, V]) ToStream() *stream.Stream[*KV[K, V], []*KV[K, V]] {
+	entries := m.Entries()
+	return stream.Of(&entries)
+}
+
+// Foreach 遍历键值对快照
+// Foreach iterates over a snapshot of the entries
+func (m *ShardedKMap[K, V]) Foreach(f func(k K, v V)) {
+	for _, kv := range m.Entries() {
+		f(kv.Key, kv.Value)
+	}
+}
+
+// Keys 返回所有键
+// Keys returns all the keys
+func (m *ShardedKMap[K, V]) Keys() []K {
+	return stream.Map(m.ToStream(), func(i *KV[K, V]) K {
+		return i.Key
+	}).ToList()
+}
+
+// Values 返回所有值
+// Values returns all the values
+func (m *ShardedKMap[K, V]) Values() []V {
+	return stream.Map(m.ToStream(), func(i *KV[K, V]) V {
+		return i.Value
+	}).ToList()
+}
+
+// Clear 清空所有键值对
+// Clear removes all the entries
+func (m *ShardedKMap[K, V]) Clear() {
+	m.lazyInit()
+	for _, s := range m.shards {
+		s.Lock()
+		s.m.Clear()
+		s.Unlock()
+	}
+}
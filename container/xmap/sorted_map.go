@@ -2,31 +2,61 @@ package xmap
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
-	"sort"
+	"iter"
+	"math/rand/v2"
 
 	"github.com/karosown/katool-go/container/stream"
 	"golang.org/x/exp/constraints"
 )
 
-// SortedMap 是对 Map 类型的包装，同时提供有序遍历的能力。
-// 要求键类型满足 constraints.Ordered 才能排序。
+const (
+	// sortedMapMaxLevel 跳表最大层数 / max level of the skip list
+	sortedMapMaxLevel = 32
+	// sortedMapP 每层晋升概率为 1/sortedMapP / a node is promoted to the next level with probability 1/sortedMapP
+	sortedMapP = 4
+)
+
+// sortedNode 跳表节点，prev 指向第0层的前驱（首节点为nil）
+// sortedNode is a skip list node, prev points to the level-0 predecessor (nil for the first node)
+type sortedNode[K constraints.Ordered, V any] struct {
+	key   K
+	value V
+	prev  *sortedNode[K, V]
+	next  []*sortedNode[K, V]
+}
+
+// SortedMap 基于跳表的有序映射，按键的自然顺序存储，查找、写入、删除与 Floor/Ceiling 等导航操作均为 O(log n)，
+// 有序遍历无需重新排序。零值可直接使用，非并发安全，并发场景请使用 ConcurrentSortedMap
+// SortedMap is an ordered map backed by a skip list. Keys are kept in natural order, lookups, writes,
+// deletes and navigation such as Floor/Ceiling are O(log n) and ordered iteration needs no sorting.
+// The zero value is ready to use; it is not safe for concurrent use, see ConcurrentSortedMap
 type SortedMap[K constraints.Ordered, V any] struct {
-	mapper Map[K, V]
+	head  *sortedNode[K, V]
+	tail  *sortedNode[K, V]
+	level int
+	size  int
 }
 
 // NewSortedMap 创建一个新的 SortedMap 实例
 func NewSortedMap[K constraints.Ordered, V any]() *SortedMap[K, V] {
-	return &SortedMap[K, V]{
-		mapper: NewMap[K, V](),
-	}
+	sm := &SortedMap[K, V]{}
+	sm.init()
+	return sm
 }
+
+// CopySortedMap 复制普通 map 的键值对创建 SortedMap
+// CopySortedMap creates a SortedMap from the pairs of a plain map
 func CopySortedMap[K constraints.Ordered, V any, M ~map[K]V](mp M) *SortedMap[K, V] {
-	return &SortedMap[K, V]{
-		mapper: Map[K, V](mp),
+	sm := NewSortedMap[K, V]()
+	for k, v := range mp {
+		sm.Set(k, v)
 	}
+	return sm
 }
+
 func SortedMapFromAny[K constraints.Ordered, V any, M ~map[any]any](m M) *SortedMap[K, V] {
 	mp := map[K]V{}
 	for k, v := range m {
@@ -43,49 +73,333 @@ func SortedMapFromAny[K constraints.Ordered, V any, M ~map[any]any](m M) *Sorted
 	return CopySortedMap(mp)
 }
 
+func (sm *SortedMap[K, V]) init() {
+	if sm.head == nil {
+		sm.head = &sortedNode[K, V]{next: make([]*sortedNode[K, V], sortedMapMaxLevel)}
+		sm.level = 1
+	}
+}
+
+// search 返回第一个不小于key的节点，update 非nil时记录每层的前驱；只读操作不会初始化零值
+// search returns the first node not less than key and records the predecessor of every level into
+// update; reads never initialise a zero value
+func (sm *SortedMap[K, V]) search(key K, update *[sortedMapMaxLevel]*sortedNode[K, V]) *sortedNode[K, V] {
+	if sm.head == nil {
+		return nil
+	}
+	x := sm.head
+	for i := sm.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && cmp.Less(x.next[i].key, key) {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+func randomLevel() int {
+	level := 1
+	for level < sortedMapMaxLevel && rand.IntN(sortedMapP) == 0 {
+		level++
+	}
+	return level
+}
+
 // Set 添加或更新一个键值对
 func (sm *SortedMap[K, V]) Set(key K, value V) {
-	sm.mapper.Set(key, value)
+	sm.init()
+	var update [sortedMapMaxLevel]*sortedNode[K, V]
+	if n := sm.search(key, &update); n != nil && cmp.Compare(n.key, key) == 0 {
+		n.value = value
+		return
+	}
+	level := randomLevel()
+	if level > sm.level {
+		for i := sm.level; i < level; i++ {
+			update[i] = sm.head
+		}
+		sm.level = level
+	}
+	x := &sortedNode[K, V]{key: key, value: value, next: make([]*sortedNode[K, V], level)}
+	for i := 0; i < level; i++ {
+		x.next[i] = update[i].next[i]
+		update[i].next[i] = x
+	}
+	if update[0] != sm.head {
+		x.prev = update[0]
+	}
+	if x.next[0] != nil {
+		x.next[0].prev = x
+	} else {
+		sm.tail = x
+	}
+	sm.size++
 }
 
 // Get 根据键获取值
 func (sm *SortedMap[K, V]) Get(key K) (V, bool) {
-	return sm.mapper.Get(key)
+	if n := sm.search(key, nil); n != nil && cmp.Compare(n.key, key) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Has 键是否存在
+// Has reports whether the key is present
+func (sm *SortedMap[K, V]) Has(key K) bool {
+	_, ok := sm.Get(key)
+	return ok
 }
 
 // Delete 删除指定键的键值对
 func (sm *SortedMap[K, V]) Delete(key K) {
-	sm.mapper.Delete(key)
+	sm.Remove(key)
+}
+
+// Remove 删除指定键并返回被删除的值
+// Remove deletes the key and returns the removed value
+func (sm *SortedMap[K, V]) Remove(key K) (V, bool) {
+	var update [sortedMapMaxLevel]*sortedNode[K, V]
+	n := sm.search(key, &update)
+	if n == nil || cmp.Compare(n.key, key) != 0 {
+		var zero V
+		return zero, false
+	}
+	sm.unlink(n, &update)
+	return n.value, true
+}
+
+// unlink 从跳表中摘除节点
+// unlink removes the node from the skip list
+func (sm *SortedMap[K, V]) unlink(n *sortedNode[K, V], update *[sortedMapMaxLevel]*sortedNode[K, V]) {
+	for i := range n.next {
+		if update[i].next[i] == n {
+			update[i].next[i] = n.next[i]
+		}
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		sm.tail = n.prev
+	}
+	for sm.level > 1 && sm.head.next[sm.level-1] == nil {
+		sm.level--
+	}
+	sm.size--
 }
 
 // Len 返回 SortedMap 中元素数量
 func (sm *SortedMap[K, V]) Len() int {
-	return sm.mapper.Len()
+	return sm.size
+}
+
+// Clear 清空所有键值对
+// Clear removes every pair
+func (sm *SortedMap[K, V]) Clear() {
+	sm.head, sm.tail, sm.size = nil, nil, 0
+	sm.init()
+}
+
+// first 返回首节点 / first returns the first node
+func (sm *SortedMap[K, V]) first() *sortedNode[K, V] {
+	if sm.head == nil {
+		return nil
+	}
+	return sm.head.next[0]
+}
+
+// ceilingNode 第一个大于等于key的节点 / ceilingNode is the first node >= key
+func (sm *SortedMap[K, V]) ceilingNode(key K) *sortedNode[K, V] {
+	return sm.search(key, nil)
+}
+
+// higherNode 第一个大于key的节点 / higherNode is the first node > key
+func (sm *SortedMap[K, V]) higherNode(key K) *sortedNode[K, V] {
+	n := sm.search(key, nil)
+	if n != nil && cmp.Compare(n.key, key) == 0 {
+		n = n.next[0]
+	}
+	return n
+}
+
+// floorNode 最后一个小于等于key的节点 / floorNode is the last node <= key
+func (sm *SortedMap[K, V]) floorNode(key K) *sortedNode[K, V] {
+	n := sm.search(key, nil)
+	if n != nil && cmp.Compare(n.key, key) == 0 {
+		return n
+	}
+	return sm.before(n)
+}
+
+// lowerNode 最后一个小于key的节点 / lowerNode is the last node < key
+func (sm *SortedMap[K, V]) lowerNode(key K) *sortedNode[K, V] {
+	return sm.before(sm.search(key, nil))
+}
+
+// before 返回n的前驱，n为nil时返回尾节点 / before returns the predecessor of n, the tail when n is nil
+func (sm *SortedMap[K, V]) before(n *sortedNode[K, V]) *sortedNode[K, V] {
+	if n == nil {
+		return sm.tail
+	}
+	return n.prev
+}
+
+func nodeResult[K constraints.Ordered, V any](n *sortedNode[K, V]) (K, V, bool) {
+	if n == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// Floor 小于等于key的最大键值对
+// Floor returns the greatest pair whose key is <= key
+func (sm *SortedMap[K, V]) Floor(key K) (K, V, bool) {
+	return nodeResult(sm.floorNode(key))
+}
+
+// Ceiling 大于等于key的最小键值对
+// Ceiling returns the least pair whose key is >= key
+func (sm *SortedMap[K, V]) Ceiling(key K) (K, V, bool) {
+	return nodeResult(sm.ceilingNode(key))
+}
+
+// Lower 严格小于key的最大键值对
+// Lower returns the greatest pair whose key is strictly < key
+func (sm *SortedMap[K, V]) Lower(key K) (K, V, bool) {
+	return nodeResult(sm.lowerNode(key))
+}
+
+// Higher 严格大于key的最小键值对
+// Higher returns the least pair whose key is strictly > key
+func (sm *SortedMap[K, V]) Higher(key K) (K, V, bool) {
+	return nodeResult(sm.higherNode(key))
+}
+
+// First 最小的键值对
+// First returns the pair with the least key
+func (sm *SortedMap[K, V]) First() (K, V, bool) {
+	return nodeResult(sm.first())
+}
+
+// Last 最大的键值对
+// Last returns the pair with the greatest key
+func (sm *SortedMap[K, V]) Last() (K, V, bool) {
+	return nodeResult(sm.tail)
+}
+
+// PollFirst 删除并返回最小的键值对
+// PollFirst removes and returns the pair with the least key
+func (sm *SortedMap[K, V]) PollFirst() (K, V, bool) {
+	n := sm.first()
+	if n == nil {
+		return nodeResult(n)
+	}
+	// 首节点每一层的前驱都是头节点 / the head precedes the first node on every level
+	var update [sortedMapMaxLevel]*sortedNode[K, V]
+	for i := range n.next {
+		update[i] = sm.head
+	}
+	sm.unlink(n, &update)
+	return nodeResult(n)
+}
+
+// PollLast 删除并返回最大的键值对
+// PollLast removes and returns the pair with the greatest key
+func (sm *SortedMap[K, V]) PollLast() (K, V, bool) {
+	n := sm.tail
+	if n == nil {
+		return nodeResult(n)
+	}
+	var update [sortedMapMaxLevel]*sortedNode[K, V]
+	sm.search(n.key, &update)
+	sm.unlink(n, &update)
+	return nodeResult(n)
+}
+
+// All 按键升序遍历
+// All iterates in ascending key order
+func (sm *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := sm.first(); n != nil; n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward 按键降序遍历
+// Backward iterates in descending key order
+func (sm *SortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := sm.tail; n != nil; n = n.prev {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range 按升序遍历键在 [from, to) 区间内的键值对
+// Range iterates the pairs whose keys are in [from, to) in ascending order
+func (sm *SortedMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return sm.SubMap(from, true, to, false).All()
+}
+
+// Foreach 按键升序遍历
+// Foreach visits every pair in ascending key order
+func (sm *SortedMap[K, V]) Foreach(f func(k K, v V)) {
+	for k, v := range sm.All() {
+		f(k, v)
+	}
 }
 
 // SortedKeys 返回排序后的所有键（按照自然顺序排序）
 func (sm *SortedMap[K, V]) SortedKeys() []K {
-	keys := sm.mapper.Keys()
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i] < keys[j]
-	})
+	keys := make([]K, 0, sm.size)
+	for k := range sm.All() {
+		keys = append(keys, k)
+	}
 	return keys
 }
+
+// Values 按键升序返回所有值
+// Values returns every value in ascending key order
+func (sm *SortedMap[K, V]) Values() []V {
+	values := make([]V, 0, sm.size)
+	for _, v := range sm.All() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// ToStream 按键升序转换为流
+// ToStream converts the pairs to a stream in ascending key order
 func (sm *SortedMap[K, V]) ToStream() *stream.Stream[stream.Entry[K, V], []stream.Entry[K, V]] {
-	return stream.EntrySet(sm.mapper).ToStream()
+	entries := make(stream.Entries[K, V], 0, sm.size)
+	for k, v := range sm.All() {
+		entries = append(entries, stream.Entry[K, V]{Key: k, Value: v})
+	}
+	return entries.ToStream()
 }
 
 // MarshalJSON 实现 json.Marshaler 接口，确保按照 SortedKeys 顺序输出
 // 输出格式为 {"key": value, ...}
 func (sm *SortedMap[K, V]) MarshalJSON() ([]byte, error) {
-	keys := sm.SortedKeys()
 	var buf bytes.Buffer
 
 	buf.WriteByte('{')
-	for i, key := range keys {
+	i := 0
+	for key, value := range sm.All() {
 		if i > 0 {
 			buf.WriteByte(',')
 		}
+		i++
 
 		// 将 key 转换为字符串，
 		// 如果 key 的底层类型是 string 则直接使用，否则使用 fmt.Sprintf 转换
@@ -104,18 +418,12 @@ func (sm *SortedMap[K, V]) MarshalJSON() ([]byte, error) {
 		buf.Write(kBytes)
 		buf.WriteByte(':')
 
-		// 获取并序列化 value
-		value, ok := sm.mapper.Get(key)
-		if !ok {
-			// 如果不存在 value，写入 null
-			buf.WriteString("null")
-		} else {
-			vBytes, err := json.Marshal(value)
-			if err != nil {
-				return nil, err
-			}
-			buf.Write(vBytes)
+		// 序列化 value
+		vBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
 		}
+		buf.Write(vBytes)
 	}
 	buf.WriteByte('}')
 	return buf.Bytes(), nil
@@ -0,0 +1,179 @@
+package xmap
+
+import (
+	"cmp"
+	"iter"
+
+	"github.com/karosown/katool-go/container/stream"
+	"golang.org/x/exp/constraints"
+)
+
+// bound 视图的一端边界 / bound is one end of a view
+type bound[K constraints.Ordered] struct {
+	key       K
+	set       bool
+	inclusive bool
+}
+
+// SortedView SortedMap 按键区间划分的只读实时视图，反映底层映射之后的修改
+// SortedView is a read-only live view of a key range of a SortedMap, later changes to the map are visible
+type SortedView[K constraints.Ordered, V any] struct {
+	m      *SortedMap[K, V]
+	lo, hi bound[K]
+}
+
+// HeadMap 键小于to（inclusive 时小于等于）的视图
+// HeadMap returns a view of the keys < to, or <= to when inclusive
+func (sm *SortedMap[K, V]) HeadMap(to K, inclusive bool) *SortedView[K, V] {
+	return &SortedView[K, V]{m: sm, hi: bound[K]{key: to, set: true, inclusive: inclusive}}
+}
+
+// TailMap 键大于from（inclusive 时大于等于）的视图
+// TailMap returns a view of the keys > from, or >= from when inclusive
+func (sm *SortedMap[K, V]) TailMap(from K, inclusive bool) *SortedView[K, V] {
+	return &SortedView[K, V]{m: sm, lo: bound[K]{key: from, set: true, inclusive: inclusive}}
+}
+
+// SubMap 键在 from 与 to 之间的视图，两端是否包含分别由 fromInclusive 与 toInclusive 决定
+// SubMap returns a view of the keys between from and to, fromInclusive and toInclusive decide whether the
+// ends are included
+func (sm *SortedMap[K, V]) SubMap(from K, fromInclusive bool, to K, toInclusive bool) *SortedView[K, V] {
+	return &SortedView[K, V]{
+		m:  sm,
+		lo: bound[K]{key: from, set: true, inclusive: fromInclusive},
+		hi: bound[K]{key: to, set: true, inclusive: toInclusive},
+	}
+}
+
+// inRange 键是否落在视图区间内 / inRange reports whether the key is inside the view
+func (v *SortedView[K, V]) inRange(key K) bool {
+	if v.lo.set {
+		if c := cmp.Compare(key, v.lo.key); c < 0 || (c == 0 && !v.lo.inclusive) {
+			return false
+		}
+	}
+	if v.hi.set {
+		if c := cmp.Compare(key, v.hi.key); c > 0 || (c == 0 && !v.hi.inclusive) {
+			return false
+		}
+	}
+	return true
+}
+
+// firstNode 视图内的首节点 / firstNode is the first node inside the view
+func (v *SortedView[K, V]) firstNode() *sortedNode[K, V] {
+	var n *sortedNode[K, V]
+	switch {
+	case !v.lo.set:
+		n = v.m.first()
+	case v.lo.inclusive:
+		n = v.m.ceilingNode(v.lo.key)
+	default:
+		n = v.m.higherNode(v.lo.key)
+	}
+	if n == nil || !v.inRange(n.key) {
+		return nil
+	}
+	return n
+}
+
+// lastNode 视图内的尾节点 / lastNode is the last node inside the view
+func (v *SortedView[K, V]) lastNode() *sortedNode[K, V] {
+	var n *sortedNode[K, V]
+	switch {
+	case !v.hi.set:
+		n = v.m.tail
+	case v.hi.inclusive:
+		n = v.m.floorNode(v.hi.key)
+	default:
+		n = v.m.lowerNode(v.hi.key)
+	}
+	if n == nil || !v.inRange(n.key) {
+		return nil
+	}
+	return n
+}
+
+// Get 获取视图内的键 / Get returns the value of a key inside the view
+func (v *SortedView[K, V]) Get(key K) (V, bool) {
+	if !v.inRange(key) {
+		var zero V
+		return zero, false
+	}
+	return v.m.Get(key)
+}
+
+// Has 键是否在视图内 / Has reports whether the key is inside the view
+func (v *SortedView[K, V]) Has(key K) bool {
+	_, ok := v.Get(key)
+	return ok
+}
+
+// First 视图内最小的键值对 / First returns the least pair inside the view
+func (v *SortedView[K, V]) First() (K, V, bool) {
+	return nodeResult(v.firstNode())
+}
+
+// Last 视图内最大的键值对 / Last returns the greatest pair inside the view
+func (v *SortedView[K, V]) Last() (K, V, bool) {
+	return nodeResult(v.lastNode())
+}
+
+// Len 视图内的元素数量，需要遍历区间，复杂度为 O(log n + k)
+// Len counts the pairs inside the view, which walks the range in O(log n + k)
+func (v *SortedView[K, V]) Len() int {
+	n := 0
+	for range v.All() {
+		n++
+	}
+	return n
+}
+
+// All 按键升序遍历视图 / All iterates the view in ascending key order
+func (v *SortedView[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := v.firstNode(); n != nil && v.inRange(n.key); n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward 按键降序遍历视图 / Backward iterates the view in descending key order
+func (v *SortedView[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := v.lastNode(); n != nil && v.inRange(n.key); n = n.prev {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// SortedKeys 按升序返回视图内的键 / SortedKeys returns the keys of the view in ascending order
+func (v *SortedView[K, V]) SortedKeys() []K {
+	var keys []K
+	for k := range v.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ToStream 按键升序转换为流 / ToStream converts the view to a stream in ascending key order
+func (v *SortedView[K, V]) ToStream() *stream.Stream[stream.Entry[K, V], []stream.Entry[K, V]] {
+	var entries stream.Entries[K, V]
+	for k, val := range v.All() {
+		entries = append(entries, stream.Entry[K, V]{Key: k, Value: val})
+	}
+	return entries.ToStream()
+}
+
+// ToSortedMap 把视图复制为独立的 SortedMap / ToSortedMap copies the view into a standalone SortedMap
+func (v *SortedView[K, V]) ToSortedMap() *SortedMap[K, V] {
+	out := NewSortedMap[K, V]()
+	for k, val := range v.All() {
+		out.Set(k, val)
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/karosown/katool-go/container/xmap"
+	"github.com/stretchr/testify/assert"
+)
+
+type compositeKey struct {
+	Tags  []string
+	Attrs map[string]int
+	Ref   *int
+}
+
+// 测试 KMap 按值哈希不可比较的键
+func TestKMap(t *testing.T) {
+	m := xmap.NewKMap[[]int, string]()
+	m.Set([]int{1, 2}, "a")
+	m.Set([]int{2, 1}, "b")
+	m.Set([]int{1, 2}, "c")
+
+	val, ok := m.Get([]int{1, 2})
+	assert.True(t, ok, "相等的切片键应该能取到值")
+	assert.Equal(t, "c", val, "重复写入应覆盖旧值")
+	assert.Equal(t, 2, m.Len())
+
+	one := 1
+	other := 1
+	km := xmap.NewKMap[compositeKey, int]()
+	km.Set(compositeKey{Tags: []string{"x"}, Attrs: map[string]int{"a": 1, "b": 2}, Ref: &one}, 10)
+	got, ok := km.Get(compositeKey{Tags: []string{"x"}, Attrs: map[string]int{"b": 2, "a": 1}, Ref: &other})
+	assert.True(t, ok, "结构相等的键应该能取到值")
+	assert.Equal(t, 10, got)
+
+	m.Delete([]int{2, 1})
+	assert.False(t, m.Has([]int{2, 1}))
+	assert.Equal(t, []string{"c"}, m.Values())
+
+	var zero xmap.KMap[map[string]int, int]
+	zero.Set(map[string]int{"k": 1}, 1)
+	assert.True(t, zero.Has(map[string]int{"k": 1}), "零值 KMap 应可直接使用")
+}
+
+// 测试哈希冲突时的链式存储
+func TestKMapCollision(t *testing.T) {
+	m := xmap.NewKMap(xmap.WithHasher[string, int](func(string) uint64 { return 7 }))
+	for i := 0; i < 10; i++ {
+		m.Set(strconv.Itoa(i), i)
+	}
+	assert.Equal(t, 10, m.Len())
+	for i := 0; i < 10; i++ {
+		v, ok := m.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	m.Delete("3")
+	assert.False(t, m.Has("3"))
+	assert.True(t, m.Has("9"))
+	keys := m.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"0", "1", "2", "4", "5", "6", "7", "8", "9"}, keys)
+	assert.Equal(t, 45-3, m.ToStream().Reduce(0, func(acc any, kv *xmap.KV[string, int]) any { return acc.(int) + kv.Value }, nil))
+}
+
+// 测试 ShardedKMap 的并发读写
+func TestShardedKMapConcurrency(t *testing.T) {
+	m := xmap.NewShardedKMap[[]int, int](8)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				m.Set([]int{g, i}, i)
+				m.Compute([]int{-1}, func(old int, _ bool) (int, bool) { return old + 1, true })
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, 8*200+1, m.Len())
+	count, _ := m.Get([]int{-1})
+	assert.Equal(t, 8*200, count)
+	v, loaded := m.GetOrSet([]int{3, 5}, 100)
+	assert.True(t, loaded)
+	assert.Equal(t, 5, v)
+	m.Delete([]int{3, 5})
+	assert.False(t, m.Has([]int{3, 5}))
+}
+
+// 测试零值 ShardedKMap 可直接并发使用
+func TestShardedKMapZeroValue(t *testing.T) {
+	var m xmap.ShardedKMap[compositeKey, int]
+	assert.Equal(t, 0, m.Len())
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			m.Set(compositeKey{Tags: []string{strconv.Itoa(g)}}, g)
+		}(g)
+	}
+	wg.Wait()
+	assert.Equal(t, 4, m.Len())
+	v, ok := m.Get(compositeKey{Tags: []string{"2"}})
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	m.Clear()
+	assert.Empty(t, m.Entries())
+}
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/xmap"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRedisMap 需要通过 REDIS_ADDR 指定 Redis 地址，否则跳过
+func newTestRedisMap[V any](t *testing.T) *xmap.RedisMap[string, V] {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+	key := "xmap:test:" + t.Name()
+	m := xmap.NewRedisMap[string, V](client, key)
+	assert.NoError(t, m.Clear(context.Background()))
+	t.Cleanup(func() { _ = m.Clear(context.Background()) })
+	return m
+}
+
+// 测试 HSCAN 迭代与批量读写
+func TestRedisMapScanAndBatch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestRedisMap[int](t)
+
+	entries := make(map[string]int, 500)
+	for i := 0; i < 500; i++ {
+		entries["k"+strconv.Itoa(i)] = i
+	}
+	assert.NoError(t, m.MSet(ctx, entries))
+
+	got, err := m.MGet(ctx, "k1", "k2", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"k1": 1, "k2": 2}, got)
+
+	all, err := m.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, all)
+
+	scanner := m.Scan(ctx, "k1*", 50)
+	count := 0
+	for k, v := range scanner.All() {
+		assert.Equal(t, entries[k], v)
+		count++
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, 111, count, "k1, k10-k19, k100-k199")
+
+	keys, err := m.Keys(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 500)
+}
+
+// 测试 CAS、Compute 与 Incr
+func TestRedisMapAtomic(t *testing.T) {
+	ctx := context.Background()
+	m := newTestRedisMap[int](t)
+
+	ok, err := m.SetIfAbsent(ctx, "a", 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = m.CompareAndSet(ctx, "a", 2, 3)
+	assert.NoError(t, err)
+	assert.False(t, ok, "期望值不一致时不应写入")
+	ok, err = m.CompareAndSet(ctx, "a", 1, 3)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := m.Compute(ctx, "counter", func(old int, exists bool) (int, bool) {
+				return old + 1, true
+			})
+			if errors.Is(err, xmap.ErrComputeConflict) {
+				return
+			}
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	n, err := m.Incr(ctx, "counter", 0)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, n, int64(20))
+	assert.Greater(t, n, int64(0))
+
+	n, err = m.Incr(ctx, "counter", 5)
+	assert.NoError(t, err)
+	v, _, _ := m.Get(ctx, "counter")
+	assert.Equal(t, int(n), v, "Incr 应与默认编码兼容")
+
+	_, exists, err := m.Compute(ctx, "a", func(int, bool) (int, bool) { return 0, false })
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	has, _ := m.Has(ctx, "a")
+	assert.False(t, has, "返回 false 应删除键")
+}
+
+// 测试字段级过期，服务端不支持时跳过
+func TestRedisMapFieldTTL(t *testing.T) {
+	ctx := context.Background()
+	m := newTestRedisMap[string](t)
+
+	err := m.SetWithTTL(ctx, "a", "x", 100*time.Millisecond)
+	if errors.Is(err, xmap.ErrFieldTTLUnsupported) {
+		t.Skip(err)
+	}
+	assert.NoError(t, err)
+	assert.NoError(t, m.Set(ctx, "b", "y"))
+
+	ttl, exists, err := m.TTL(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Greater(t, ttl, time.Duration(0))
+	ttl, exists, _ = m.TTL(ctx, "b")
+	assert.True(t, exists)
+	assert.Zero(t, ttl, "未设置过期的字段 TTL 为0")
+
+	assert.Eventually(t, func() bool {
+		has, _ := m.Has(ctx, "a")
+		return !has
+	}, time.Second, 20*time.Millisecond)
+	has, _ := m.Has(ctx, "b")
+	assert.True(t, has, "其他字段不受影响")
+}
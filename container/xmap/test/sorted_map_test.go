@@ -0,0 +1,135 @@
+package test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/karosown/katool-go/container/xmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectKeys[K any, V any](seq func(func(K, V) bool)) []K {
+	var keys []K
+	for k := range seq {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// 测试 SortedMap 的导航操作
+func TestSortedMapNavigation(t *testing.T) {
+	var sm xmap.SortedMap[int, string]
+	_, _, ok := sm.First()
+	assert.False(t, ok, "零值应可直接使用")
+
+	for _, k := range []int{50, 10, 40, 20, 30} {
+		sm.Set(k, "v")
+	}
+	assert.Equal(t, []int{10, 20, 30, 40, 50}, sm.SortedKeys())
+
+	k, _, ok := sm.Floor(35)
+	assert.True(t, ok)
+	assert.Equal(t, 30, k)
+	k, _, _ = sm.Floor(30)
+	assert.Equal(t, 30, k)
+	k, _, _ = sm.Lower(30)
+	assert.Equal(t, 20, k)
+	k, _, _ = sm.Ceiling(35)
+	assert.Equal(t, 40, k)
+	k, _, _ = sm.Higher(40)
+	assert.Equal(t, 50, k)
+	_, _, ok = sm.Higher(50)
+	assert.False(t, ok)
+	_, _, ok = sm.Lower(10)
+	assert.False(t, ok)
+	k, _, _ = sm.Floor(100)
+	assert.Equal(t, 50, k)
+
+	assert.Equal(t, []int{20, 30}, collectKeys(sm.Range(15, 40)))
+	assert.Equal(t, []int{10, 20, 30}, sm.HeadMap(30, true).SortedKeys())
+	assert.Equal(t, []int{40, 50}, sm.TailMap(30, false).SortedKeys())
+	view := sm.SubMap(20, false, 50, false)
+	assert.Equal(t, 2, view.Len())
+	sm.Set(35, "new")
+	assert.Equal(t, []int{30, 35, 40}, view.SortedKeys(), "视图应反映后续修改")
+	assert.False(t, view.Has(50))
+	assert.Equal(t, []int{50, 40, 35, 30, 20, 10}, collectKeys(sm.Backward()))
+
+	k, _, _ = sm.PollFirst()
+	assert.Equal(t, 10, k)
+	k, _, _ = sm.PollLast()
+	assert.Equal(t, 50, k)
+	k, _, _ = sm.First()
+	assert.Equal(t, 20, k)
+	k, _, _ = sm.Last()
+	assert.Equal(t, 40, k)
+	assert.Equal(t, 4, sm.Len())
+}
+
+// 随机操作与排序切片对照
+func TestSortedMapRandomized(t *testing.T) {
+	sm := xmap.NewSortedMap[int, int]()
+	ref := map[int]int{}
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 5000; i++ {
+		k := r.IntN(500)
+		if r.IntN(3) == 0 {
+			sm.Delete(k)
+			delete(ref, k)
+		} else {
+			sm.Set(k, i)
+			ref[k] = i
+		}
+	}
+	keys := make([]int, 0, len(ref))
+	for k := range ref {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	assert.Equal(t, keys, sm.SortedKeys())
+	assert.Equal(t, len(ref), sm.Len())
+	for k, v := range sm.All() {
+		assert.Equal(t, ref[k], v)
+	}
+	for probe := -1; probe <= 501; probe++ {
+		i, found := slices.BinarySearch(keys, probe)
+		k, _, ok := sm.Floor(probe)
+		if found {
+			assert.Equal(t, probe, k)
+		} else if i > 0 {
+			assert.Equal(t, keys[i-1], k)
+		} else {
+			assert.False(t, ok)
+		}
+	}
+}
+
+// 测试并发有序映射
+func TestConcurrentSortedMap(t *testing.T) {
+	m := xmap.NewConcurrentSortedMap[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.Set(g*100+i, i)
+				m.Compute(-1, func(old int, _ bool) (int, bool) { return old + 1, true })
+				m.Floor(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	v, _ := m.Get(-1)
+	assert.Equal(t, 800, v)
+	assert.Equal(t, 801, m.Len())
+
+	for k := range m.All() {
+		if k >= 0 {
+			m.Delete(k)
+		}
+	}
+	assert.Equal(t, 1, m.Len(), "遍历快照时修改不应死锁")
+}
@@ -0,0 +1,195 @@
+package xsketch
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrIncompatible 参数或种子不同的结构不能合并
+// ErrIncompatible is returned when merging structures with different parameters or seeds
+var ErrIncompatible = errors.New("xsketch: incompatible structures")
+
+// BloomFilter 布隆过滤器，可能误判存在但不会漏判；位操作为原子操作，并发安全
+// BloomFilter may report false positives but never false negatives; bit operations are atomic and it
+// is safe for concurrent use
+type BloomFilter[T any] struct {
+	bits []uint64
+	m    uint64
+	k    int
+	opts options[T]
+}
+
+// NewBloomFilter 按预期元素数与误判率创建布隆过滤器
+// NewBloomFilter creates a Bloom filter for the expected number of elements and false positive rate
+func NewBloomFilter[T any](expected uint64, fpRate float64, opts ...Option[T]) *BloomFilter[T] {
+	m, k := OptimalBloom(expected, fpRate)
+	return NewBloomFilterWith(m, k, opts...)
+}
+
+// NewBloomFilterWith 使用指定的位数与哈希函数个数创建布隆过滤器
+// NewBloomFilterWith creates a Bloom filter with m bits and k hash functions
+func NewBloomFilterWith[T any](m uint64, k int, opts ...Option[T]) *BloomFilter[T] {
+	m, k = max(m, 1), max(k, 1)
+	return &BloomFilter[T]{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		opts: newOptions(opts),
+	}
+}
+
+// Add 添加元素，返回该元素此前是否一定不存在，可直接用于去重：if bf.Add(url) { crawl(url) }
+// Add inserts the element and reports whether it was definitely absent before, which makes it usable
+// for deduplication: if bf.Add(url) { crawl(url) }
+func (b *BloomFilter[T]) Add(v T) bool {
+	h1, h2 := b.opts.hash(v)
+	added := false
+	for _, loc := range locations(h1, h2, b.k, b.m) {
+		mask := uint64(1) << (loc % 64)
+		if atomic.OrUint64(&b.bits[loc/64], mask)&mask == 0 {
+			added = true
+		}
+	}
+	return added
+}
+
+// Contains 元素是否可能存在
+// Contains reports whether the element may be present
+func (b *BloomFilter[T]) Contains(v T) bool {
+	h1, h2 := b.opts.hash(v)
+	for _, loc := range locations(h1, h2, b.k, b.m) {
+		if atomic.LoadUint64(&b.bits[loc/64])&(uint64(1)<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ApproxCount 根据已置位的比例估算插入的不同元素数
+// ApproxCount estimates the number of distinct elements from the fraction of set bits
+func (b *BloomFilter[T]) ApproxCount() uint64 {
+	set := 0
+	for i := range b.bits {
+		set += bits.OnesCount64(atomic.LoadUint64(&b.bits[i]))
+	}
+	if uint64(set) >= b.m {
+		return math.MaxUint64
+	}
+	n := -float64(b.m) / float64(b.k) * math.Log(1-float64(set)/float64(b.m))
+	return uint64(math.Round(n))
+}
+
+// Merge 合并另一个参数相同的过滤器（求并集）
+// Merge unions another filter with the same parameters into this one
+func (b *BloomFilter[T]) Merge(other *BloomFilter[T]) error {
+	if other.m != b.m || other.k != b.k || other.opts.seed != b.opts.seed {
+		return ErrIncompatible
+	}
+	for i := range b.bits {
+		atomic.OrUint64(&b.bits[i], atomic.LoadUint64(&other.bits[i]))
+	}
+	return nil
+}
+
+// Reset 清空过滤器
+// Reset clears the filter
+func (b *BloomFilter[T]) Reset() {
+	for i := range b.bits {
+		atomic.StoreUint64(&b.bits[i], 0)
+	}
+}
+
+// M 位数 / M returns the number of bits
+func (b *BloomFilter[T]) M() uint64 { return b.m }
+
+// K 哈希函数个数 / K returns the number of hash functions
+func (b *BloomFilter[T]) K() int { return b.k }
+
+// CountingBloomFilter 计数布隆过滤器，每个位置是一个8位计数器，因此支持删除；并发安全
+// CountingBloomFilter uses an 8-bit counter per position so elements can be removed; it is safe for
+// concurrent use
+type CountingBloomFilter[T any] struct {
+	mu       sync.RWMutex
+	counters []uint8
+	m        uint64
+	k        int
+	opts     options[T]
+}
+
+// NewCountingBloomFilter 按预期元素数与误判率创建计数布隆过滤器
+// NewCountingBloomFilter creates a counting Bloom filter for the expected number of elements and false
+// positive rate
+func NewCountingBloomFilter[T any](expected uint64, fpRate float64, opts ...Option[T]) *CountingBloomFilter[T] {
+	m, k := OptimalBloom(expected, fpRate)
+	return &CountingBloomFilter[T]{counters: make([]uint8, m), m: m, k: k, opts: newOptions(opts)}
+}
+
+// Add 添加元素，返回该元素此前是否一定不存在；计数器到达255后不再增加
+// Add inserts the element and reports whether it was definitely absent before; counters saturate at 255
+func (c *CountingBloomFilter[T]) Add(v T) bool {
+	h1, h2 := c.opts.hash(v)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	added := false
+	for _, loc := range locations(h1, h2, c.k, c.m) {
+		if c.counters[loc] == 0 {
+			added = true
+		}
+		if c.counters[loc] < math.MaxUint8 {
+			c.counters[loc]++
+		}
+	}
+	return added
+}
+
+// Remove 删除元素；元素一定不存在时返回 false 且不做修改。只应删除确实添加过的元素，否则会引入漏判
+// Remove deletes the element; it returns false without changes when the element is definitely absent.
+// Only remove elements that were added, otherwise false negatives appear
+func (c *CountingBloomFilter[T]) Remove(v T) bool {
+	h1, h2 := c.opts.hash(v)
+	locs := locations(h1, h2, c.k, c.m)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, loc := range locs {
+		if c.counters[loc] == 0 {
+			return false
+		}
+	}
+	for _, loc := range locs {
+		// 饱和的计数器无法得知真实值，保持不变 / a saturated counter lost its true value, keep it
+		if c.counters[loc] < math.MaxUint8 {
+			c.counters[loc]--
+		}
+	}
+	return true
+}
+
+// Contains 元素是否可能存在
+// Contains reports whether the element may be present
+func (c *CountingBloomFilter[T]) Contains(v T) bool {
+	return c.Count(v) > 0
+}
+
+// Count 元素被添加次数的上界估计
+// Count returns an upper-bound estimate of how many times the element was added
+func (c *CountingBloomFilter[T]) Count(v T) uint8 {
+	h1, h2 := c.opts.hash(v)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	minimum := uint8(math.MaxUint8)
+	for _, loc := range locations(h1, h2, c.k, c.m) {
+		minimum = min(minimum, c.counters[loc])
+	}
+	return minimum
+}
+
+// Reset 清空过滤器
+// Reset clears the filter
+func (c *CountingBloomFilter[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.counters)
+}
@@ -0,0 +1,177 @@
+package xsketch
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/karosown/katool-go/container/xheap"
+)
+
+// CountMinSketch 频率估计草图，估计值不会小于真实值；计数为原子操作，并发安全
+// CountMinSketch estimates frequencies and never underestimates; counters are atomic and it is safe
+// for concurrent use
+type CountMinSketch[T any] struct {
+	counters []uint64
+	width    int
+	depth    int
+	total    atomic.Uint64
+	opts     options[T]
+}
+
+// NewCountMinSketch 按误差ε与置信度δ创建草图，见 OptimalCountMin
+// NewCountMinSketch creates a sketch for error ε and confidence δ, see OptimalCountMin
+func NewCountMinSketch[T any](epsilon, delta float64, opts ...Option[T]) *CountMinSketch[T] {
+	width, depth := OptimalCountMin(epsilon, delta)
+	return NewCountMinSketchWith(width, depth, opts...)
+}
+
+// NewCountMinSketchWith 使用指定的宽度与深度创建草图
+// NewCountMinSketchWith creates a sketch with the given width and depth
+func NewCountMinSketchWith[T any](width, depth int, opts ...Option[T]) *CountMinSketch[T] {
+	width, depth = max(width, 1), max(depth, 1)
+	return &CountMinSketch[T]{
+		counters: make([]uint64, width*depth),
+		width:    width,
+		depth:    depth,
+		opts:     newOptions(opts),
+	}
+}
+
+// cells 每一行对应的计数器下标 / cells returns the counter index of every row
+func (s *CountMinSketch[T]) cells(v T) []uint64 {
+	h1, h2 := s.opts.hash(v)
+	locs := locations(h1, h2, s.depth, uint64(s.width))
+	for row := range locs {
+		locs[row] += uint64(row * s.width)
+	}
+	return locs
+}
+
+// Add 把元素计数增加n并返回新的估计值
+// Add increases the count of the element by n and returns the new estimate
+func (s *CountMinSketch[T]) Add(v T, n uint64) uint64 {
+	s.total.Add(n)
+	estimate := ^uint64(0)
+	for _, i := range s.cells(v) {
+		estimate = min(estimate, atomic.AddUint64(&s.counters[i], n))
+	}
+	return estimate
+}
+
+// Estimate 元素计数的估计值
+// Estimate returns the estimated count of the element
+func (s *CountMinSketch[T]) Estimate(v T) uint64 {
+	estimate := ^uint64(0)
+	for _, i := range s.cells(v) {
+		estimate = min(estimate, atomic.LoadUint64(&s.counters[i]))
+	}
+	return estimate
+}
+
+// Total 所有元素的计数总和
+// Total returns the sum of all counts
+func (s *CountMinSketch[T]) Total() uint64 {
+	return s.total.Load()
+}
+
+// Merge 合并另一个参数相同的草图
+// Merge adds another sketch with the same parameters into this one
+func (s *CountMinSketch[T]) Merge(other *CountMinSketch[T]) error {
+	if other.width != s.width || other.depth != s.depth || other.opts.seed != s.opts.seed {
+		return ErrIncompatible
+	}
+	for i := range s.counters {
+		atomic.AddUint64(&s.counters[i], atomic.LoadUint64(&other.counters[i]))
+	}
+	s.total.Add(other.total.Load())
+	return nil
+}
+
+// Reset 清空草图
+// Reset clears the sketch
+func (s *CountMinSketch[T]) Reset() {
+	for i := range s.counters {
+		atomic.StoreUint64(&s.counters[i], 0)
+	}
+	s.total.Store(0)
+}
+
+// ItemCount 元素及其估计计数
+// ItemCount is an element with its estimated count
+type ItemCount[T any] struct {
+	Item  T
+	Count uint64
+}
+
+// TopK 基于 Count-Min Sketch 的热点统计，用索引小根堆维护估计计数最大的k个元素；并发安全
+// TopK tracks heavy hitters on top of a Count-Min Sketch, keeping the k elements with the largest
+// estimates in an indexed min-heap; it is safe for concurrent use
+type TopK[T comparable] struct {
+	mu     sync.Mutex
+	k      int
+	sketch *CountMinSketch[T]
+	heap   *xheap.IndexedHeap[T, uint64]
+}
+
+// NewTopK 创建 Top-K 统计，epsilon 与 delta 用于底层草图
+// NewTopK creates a top-K tracker, epsilon and delta configure the underlying sketch
+func NewTopK[T comparable](k int, epsilon, delta float64, opts ...Option[T]) *TopK[T] {
+	return &TopK[T]{
+		k:      max(k, 1),
+		sketch: NewCountMinSketch(epsilon, delta, opts...),
+		heap:   xheap.NewIndexedMinHeap[T, uint64](),
+	}
+}
+
+// Add 把元素计数增加n并返回新的估计值
+// Add increases the count of the element by n and returns the new estimate
+func (t *TopK[T]) Add(v T, n uint64) uint64 {
+	estimate := t.sketch.Add(v, n)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.heap.Contains(v):
+		t.heap.Update(v, estimate)
+	case t.heap.Len() < t.k:
+		t.heap.Push(v, estimate)
+	default:
+		if _, smallest, _ := t.heap.Peek(); estimate > smallest {
+			t.heap.Pop()
+			t.heap.Push(v, estimate)
+		}
+	}
+	return estimate
+}
+
+// Estimate 元素计数的估计值
+// Estimate returns the estimated count of the element
+func (t *TopK[T]) Estimate(v T) uint64 {
+	return t.sketch.Estimate(v)
+}
+
+// List 按估计计数降序返回当前的前k个元素
+// List returns the current top k elements in descending order of estimated count
+func (t *TopK[T]) List() []ItemCount[T] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	items := make([]ItemCount[T], 0, t.heap.Len())
+	for t.heap.Len() > 0 {
+		item, count, _ := t.heap.Pop()
+		items = append(items, ItemCount[T]{Item: item, Count: count})
+	}
+	for _, it := range items {
+		t.heap.Push(it.Item, it.Count)
+	}
+	slices.Reverse(items)
+	return items
+}
+
+// Reset 清空统计
+// Reset clears the tracker
+func (t *TopK[T]) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sketch.Reset()
+	t.heap.Clear()
+}
@@ -0,0 +1,142 @@
+package xsketch
+
+// 概率数据结构：布隆过滤器、计数布隆过滤器、Count-Min Sketch（含 Top-K）与 HyperLogLog，
+// 每种结构都有内存版与基于 Redis 的版本（位图/哈希/PFADD），便于多实例共享去重与基数统计
+//
+// Package xsketch provides probabilistic data structures: Bloom filter, counting Bloom filter,
+// Count-Min Sketch with top-K and HyperLogLog. Each has an in-memory and a Redis-backed version
+// (bitmap/hash/PFADD) so deduplication and cardinality counting can be shared across instances
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/twmb/murmur3"
+)
+
+// Encoder 把元素编码为用于哈希的字节
+// Encoder encodes an element into the bytes that get hashed
+type Encoder[T any] func(T) []byte
+
+// options 通用配置 / options shared by all structures
+type options[T any] struct {
+	encoder Encoder[T]
+	seed    uint64
+}
+
+// Option 函数式选项
+// Option is a functional option
+type Option[T any] func(*options[T])
+
+// WithEncoder 自定义元素编码，默认字符串与字节切片按原样、数字按十进制文本、其他类型按 JSON 编码
+// WithEncoder sets the element encoder. By default strings and byte slices are used as is, numbers
+// as decimal text and anything else as JSON
+func WithEncoder[T any](encoder Encoder[T]) Option[T] {
+	return func(o *options[T]) {
+		if encoder != nil {
+			o.encoder = encoder
+		}
+	}
+}
+
+// WithSeed 设置哈希种子，需要合并的结构必须使用相同的种子
+// WithSeed sets the hash seed, structures that are merged must share it
+func WithSeed[T any](seed uint64) Option[T] {
+	return func(o *options[T]) { o.seed = seed }
+}
+
+func newOptions[T any](opts []Option[T]) options[T] {
+	o := options[T]{encoder: defaultEncoder[T]}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// hash 128位 murmur3 哈希 / hash computes the 128-bit murmur3 hash
+func (o options[T]) hash(v T) (uint64, uint64) {
+	return murmur3.SeedSum128(o.seed, o.seed, o.encoder(v))
+}
+
+// locations 用双重哈希生成 k 个 [0, m) 内的位置
+// locations derives k positions in [0, m) by double hashing
+func locations(h1, h2 uint64, k int, m uint64) []uint64 {
+	locs := make([]uint64, k)
+	for i := range locs {
+		locs[i] = (h1 + uint64(i)*h2) % m
+	}
+	return locs
+}
+
+func defaultEncoder[T any](v T) []byte {
+	switch x := any(v).(type) {
+	case string:
+		return []byte(x)
+	case []byte:
+		return x
+	case int:
+		return strconv.AppendInt(nil, int64(x), 10)
+	case int8:
+		return strconv.AppendInt(nil, int64(x), 10)
+	case int16:
+		return strconv.AppendInt(nil, int64(x), 10)
+	case int32:
+		return strconv.AppendInt(nil, int64(x), 10)
+	case int64:
+		return strconv.AppendInt(nil, x, 10)
+	case uint:
+		return strconv.AppendUint(nil, uint64(x), 10)
+	case uint8:
+		return strconv.AppendUint(nil, uint64(x), 10)
+	case uint16:
+		return strconv.AppendUint(nil, uint64(x), 10)
+	case uint32:
+		return strconv.AppendUint(nil, uint64(x), 10)
+	case uint64:
+		return strconv.AppendUint(nil, x, 10)
+	case float32:
+		return strconv.AppendFloat(nil, float64(x), 'g', -1, 32)
+	case float64:
+		return strconv.AppendFloat(nil, x, 'g', -1, 64)
+	case fmt.Stringer:
+		return []byte(x.String())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Appendf(nil, "%#v", v)
+	}
+	return b
+}
+
+// OptimalBloom 根据预期元素数与误判率计算位数m与哈希函数个数k
+// OptimalBloom computes the number of bits m and hash functions k for the expected number of
+// elements and false positive rate
+func OptimalBloom(expected uint64, fpRate float64) (m uint64, k int) {
+	if expected == 0 {
+		expected = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m = uint64(math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = int(math.Round(float64(m) / float64(expected) * math.Ln2))
+	return max(m, 1), max(k, 1)
+}
+
+// OptimalCountMin 根据误差ε与置信度δ计算 Count-Min Sketch 的宽度与深度：
+// 估计值超出真实值 ε·N 的概率不超过 δ
+// OptimalCountMin computes the width and depth of a Count-Min Sketch for error ε and confidence δ:
+// an estimate exceeds the true count by more than ε·N with probability at most δ
+func OptimalCountMin(epsilon, delta float64) (width, depth int) {
+	if epsilon <= 0 || epsilon >= 1 {
+		epsilon = 0.001
+	}
+	if delta <= 0 || delta >= 1 {
+		delta = 0.01
+	}
+	width = int(math.Ceil(math.E / epsilon))
+	depth = int(math.Ceil(math.Log(1 / delta)))
+	return width, max(depth, 1)
+}
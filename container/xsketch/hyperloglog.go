@@ -0,0 +1,119 @@
+package xsketch
+
+import (
+	"math"
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+const (
+	// MinPrecision HyperLogLog 最小精度 / the minimum HyperLogLog precision
+	MinPrecision = 4
+	// MaxPrecision HyperLogLog 最大精度 / the maximum HyperLogLog precision
+	MaxPrecision = 18
+	// DefaultPrecision 默认精度14，与 Redis 一致，标准误差约0.81%
+	// DefaultPrecision is 14 like Redis, the standard error is about 0.81%
+	DefaultPrecision = 14
+)
+
+// HyperLogLog 基数估计，使用 2^p 个寄存器，标准误差约 1.04/√(2^p)；并发安全
+// HyperLogLog estimates cardinality with 2^p registers and a standard error of about 1.04/√(2^p);
+// it is safe for concurrent use
+type HyperLogLog[T any] struct {
+	mu        sync.RWMutex
+	p         uint8
+	registers []uint8
+	opts      options[T]
+}
+
+// NewHyperLogLog 创建 HyperLogLog，精度超出 [MinPrecision, MaxPrecision] 时使用 DefaultPrecision
+// NewHyperLogLog creates a HyperLogLog, DefaultPrecision is used when precision is outside
+// [MinPrecision, MaxPrecision]
+func NewHyperLogLog[T any](precision uint8, opts ...Option[T]) *HyperLogLog[T] {
+	if precision < MinPrecision || precision > MaxPrecision {
+		precision = DefaultPrecision
+	}
+	return &HyperLogLog[T]{p: precision, registers: make([]uint8, 1<<precision), opts: newOptions(opts)}
+}
+
+// Add 添加元素，返回内部寄存器是否发生变化
+// Add inserts the element and reports whether a register changed
+func (h *HyperLogLog[T]) Add(v T) bool {
+	x, _ := h.opts.hash(v)
+	idx := x >> (64 - h.p)
+	// 末尾补1保证前导零计数有上限 / a trailing sentinel bit bounds the leading-zero count
+	w := x<<h.p | 1<<(h.p-1)
+	rank := uint8(bits.LeadingZeros64(w) + 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+		return true
+	}
+	return false
+}
+
+// Count 估计不同元素的数量
+// Count estimates the number of distinct elements
+func (h *HyperLogLog[T]) Count() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(m) * m * m / sum
+	// 小基数时使用线性计数修正 / linear counting for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
+
+// Merge 合并另一个精度相同的 HyperLogLog（求并集）
+// Merge unions another HyperLogLog with the same precision into this one
+func (h *HyperLogLog[T]) Merge(other *HyperLogLog[T]) error {
+	if other == h {
+		return nil
+	}
+	if other.p != h.p || other.opts.seed != h.opts.seed {
+		return ErrIncompatible
+	}
+	// 先在other的锁内复制寄存器再锁定自身，避免 a.Merge(b) 与 b.Merge(a) 并发时互相等待
+	// copy other's registers under its lock before locking h, so a.Merge(b) and b.Merge(a) cannot deadlock
+	other.mu.RLock()
+	registers := slices.Clone(other.registers)
+	other.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, r := range registers {
+		h.registers[i] = max(h.registers[i], r)
+	}
+	return nil
+}
+
+// Reset 清空
+// Reset clears the registers
+func (h *HyperLogLog[T]) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.registers)
+}
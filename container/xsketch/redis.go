@@ -0,0 +1,427 @@
+package xsketch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 版本的结构都接收 redis.Cmdable，*xredis.Client 可直接传入，键前缀可用 client.Key 生成；
+// 多个实例使用相同的键、参数与种子即可共享同一份数据
+//
+// The Redis-backed structures accept a redis.Cmdable, an *xredis.Client can be passed directly and
+// client.Key applies its prefix; instances that use the same key, parameters and seed share the data
+
+var (
+	// bloomAddScript 置位并返回是否有位由0变1 / sets the bits and reports whether any of them was 0
+	bloomAddScript = redis.NewScript(`
+local added = 0
+for i = 1, #ARGV do
+	if redis.call('SETBIT', KEYS[1], ARGV[i], 1) == 0 then added = 1 end
+end
+return added
+`)
+	// bloomHasScript 所有位是否都为1 / reports whether every bit is set
+	bloomHasScript = redis.NewScript(`
+for i = 1, #ARGV do
+	if redis.call('GETBIT', KEYS[1], ARGV[i]) == 0 then return 0 end
+end
+return 1
+`)
+	// countingAddScript 计数器加1并返回是否有计数器由0变1
+	// countingAddScript increments the counters and reports whether any of them was 0
+	countingAddScript = redis.NewScript(`
+local added = 0
+for i = 1, #ARGV do
+	if redis.call('HINCRBY', KEYS[1], ARGV[i], 1) == 1 then added = 1 end
+end
+return added
+`)
+	// countingRemoveScript 所有计数器都大于0时减1，归零的字段被删除
+	// countingRemoveScript decrements the counters when all of them are positive, zeroed fields are deleted
+	countingRemoveScript = redis.NewScript(`
+for i = 1, #ARGV do
+	if tonumber(redis.call('HGET', KEYS[1], ARGV[i]) or '0') <= 0 then return 0 end
+end
+for i = 1, #ARGV do
+	if redis.call('HINCRBY', KEYS[1], ARGV[i], -1) <= 0 then redis.call('HDEL', KEYS[1], ARGV[i]) end
+end
+return 1
+`)
+	// countMinAddScript 每行计数器加n并返回最小值，KEYS[2] 非空时同时维护 Top-K 有序集合
+	// ARGV: n, k, member, cells...
+	// countMinAddScript adds n to the counter of every row and returns the minimum; when KEYS[2] is
+	// given it also maintains the top-K sorted set. ARGV: n, k, member, cells...
+	countMinAddScript = redis.NewScript(`
+local n = tonumber(ARGV[1])
+local estimate
+for i = 4, #ARGV do
+	local c = redis.call('HINCRBY', KEYS[1], ARGV[i], n)
+	if not estimate or c < estimate then estimate = c end
+end
+redis.call('HINCRBY', KEYS[1], 'total', n)
+if KEYS[2] then
+	local k = tonumber(ARGV[2])
+	redis.call('ZADD', KEYS[2], estimate, ARGV[3])
+	redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -(k + 1))
+end
+return estimate
+`)
+)
+
+func locationArgs(locs []uint64) []any {
+	args := make([]any, len(locs))
+	for i, loc := range locs {
+		args[i] = loc
+	}
+	return args
+}
+
+// RedisBloomFilter 基于 Redis 位图的布隆过滤器，单个位图最多 2^32 位
+// RedisBloomFilter is a Bloom filter on a Redis bitmap, which holds at most 2^32 bits
+type RedisBloomFilter[T any] struct {
+	client redis.Cmdable
+	key    string
+	m      uint64
+	k      int
+	opts   options[T]
+}
+
+// NewRedisBloomFilter 按预期元素数与误判率创建 Redis 布隆过滤器
+// NewRedisBloomFilter creates a Redis Bloom filter for the expected number of elements and false
+// positive rate
+func NewRedisBloomFilter[T any](client redis.Cmdable, key string, expected uint64, fpRate float64, opts ...Option[T]) *RedisBloomFilter[T] {
+	m, k := OptimalBloom(expected, fpRate)
+	return &RedisBloomFilter[T]{client: client, key: key, m: min(m, 1<<32), k: k, opts: newOptions(opts)}
+}
+
+func (b *RedisBloomFilter[T]) locations(v T) []any {
+	h1, h2 := b.opts.hash(v)
+	return locationArgs(locations(h1, h2, b.k, b.m))
+}
+
+// Add 添加元素，返回该元素此前是否一定不存在；置位在 Lua 中原子完成，多实例并发去重时只有一个实例得到 true
+// Add inserts the element and reports whether it was definitely absent before. The bits are set
+// atomically in Lua, so only one of several instances deduplicating concurrently gets true
+func (b *RedisBloomFilter[T]) Add(ctx context.Context, v T) (bool, error) {
+	n, err := bloomAddScript.Run(ctx, b.client, []string{b.key}, b.locations(v)...).Int()
+	return n == 1, err
+}
+
+// Contains 元素是否可能存在
+// Contains reports whether the element may be present
+func (b *RedisBloomFilter[T]) Contains(ctx context.Context, v T) (bool, error) {
+	n, err := bloomHasScript.Run(ctx, b.client, []string{b.key}, b.locations(v)...).Int()
+	return n == 1, err
+}
+
+// Reset 删除位图
+// Reset deletes the bitmap
+func (b *RedisBloomFilter[T]) Reset(ctx context.Context) error {
+	return b.client.Del(ctx, b.key).Err()
+}
+
+// RedisCountingBloomFilter 基于 Redis 哈希的计数布隆过滤器，字段为位置、值为计数
+// RedisCountingBloomFilter is a counting Bloom filter on a Redis hash whose fields are positions and
+// values are counters
+type RedisCountingBloomFilter[T any] struct {
+	client redis.Cmdable
+	key    string
+	m      uint64
+	k      int
+	opts   options[T]
+}
+
+// NewRedisCountingBloomFilter 按预期元素数与误判率创建 Redis 计数布隆过滤器
+// NewRedisCountingBloomFilter creates a Redis counting Bloom filter for the expected number of elements
+// and false positive rate
+func NewRedisCountingBloomFilter[T any](client redis.Cmdable, key string, expected uint64, fpRate float64, opts ...Option[T]) *RedisCountingBloomFilter[T] {
+	m, k := OptimalBloom(expected, fpRate)
+	return &RedisCountingBloomFilter[T]{client: client, key: key, m: m, k: k, opts: newOptions(opts)}
+}
+
+func (c *RedisCountingBloomFilter[T]) fields(v T) []string {
+	h1, h2 := c.opts.hash(v)
+	locs := locations(h1, h2, c.k, c.m)
+	fields := make([]string, len(locs))
+	for i, loc := range locs {
+		fields[i] = strconv.FormatUint(loc, 10)
+	}
+	return fields
+}
+
+func stringArgs(ss []string) []any {
+	args := make([]any, len(ss))
+	for i, s := range ss {
+		args[i] = s
+	}
+	return args
+}
+
+// Add 添加元素，返回该元素此前是否一定不存在
+// Add inserts the element and reports whether it was definitely absent before
+func (c *RedisCountingBloomFilter[T]) Add(ctx context.Context, v T) (bool, error) {
+	n, err := countingAddScript.Run(ctx, c.client, []string{c.key}, stringArgs(c.fields(v))...).Int()
+	return n == 1, err
+}
+
+// Remove 删除元素，元素一定不存在时返回 false 且不做修改
+// Remove deletes the element, it returns false without changes when the element is definitely absent
+func (c *RedisCountingBloomFilter[T]) Remove(ctx context.Context, v T) (bool, error) {
+	n, err := countingRemoveScript.Run(ctx, c.client, []string{c.key}, stringArgs(c.fields(v))...).Int()
+	return n == 1, err
+}
+
+// Count 元素被添加次数的上界估计
+// Count returns an upper-bound estimate of how many times the element was added
+func (c *RedisCountingBloomFilter[T]) Count(ctx context.Context, v T) (uint64, error) {
+	vals, err := c.client.HMGet(ctx, c.key, c.fields(v)...).Result()
+	if err != nil {
+		return 0, err
+	}
+	return minCounter(vals)
+}
+
+// Contains 元素是否可能存在
+// Contains reports whether the element may be present
+func (c *RedisCountingBloomFilter[T]) Contains(ctx context.Context, v T) (bool, error) {
+	n, err := c.Count(ctx, v)
+	return n > 0, err
+}
+
+// Reset 删除哈希
+// Reset deletes the hash
+func (c *RedisCountingBloomFilter[T]) Reset(ctx context.Context) error {
+	return c.client.Del(ctx, c.key).Err()
+}
+
+// minCounter HMGET 结果中的最小计数，缺失字段视为0
+// minCounter returns the smallest counter of an HMGET reply, missing fields count as 0
+func minCounter(vals []any) (uint64, error) {
+	var estimate uint64
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 || n < estimate {
+			estimate = n
+		}
+	}
+	return estimate, nil
+}
+
+// RedisCountMinSketch 基于 Redis 哈希的 Count-Min Sketch
+// RedisCountMinSketch is a Count-Min Sketch on a Redis hash
+type RedisCountMinSketch[T any] struct {
+	client redis.Cmdable
+	key    string
+	width  int
+	depth  int
+	opts   options[T]
+}
+
+// NewRedisCountMinSketch 按误差ε与置信度δ创建 Redis 草图
+// NewRedisCountMinSketch creates a Redis sketch for error ε and confidence δ
+func NewRedisCountMinSketch[T any](client redis.Cmdable, key string, epsilon, delta float64, opts ...Option[T]) *RedisCountMinSketch[T] {
+	width, depth := OptimalCountMin(epsilon, delta)
+	return &RedisCountMinSketch[T]{client: client, key: key, width: width, depth: depth, opts: newOptions(opts)}
+}
+
+func (s *RedisCountMinSketch[T]) fields(v T) []string {
+	h1, h2 := s.opts.hash(v)
+	locs := locations(h1, h2, s.depth, uint64(s.width))
+	fields := make([]string, len(locs))
+	for row, loc := range locs {
+		fields[row] = strconv.FormatUint(loc+uint64(row*s.width), 10)
+	}
+	return fields
+}
+
+func (s *RedisCountMinSketch[T]) add(ctx context.Context, v T, n uint64, topKey string, k int) (uint64, error) {
+	keys := []string{s.key}
+	if topKey != "" {
+		keys = append(keys, topKey)
+	}
+	args := append([]any{n, k, s.opts.encoder(v)}, stringArgs(s.fields(v))...)
+	estimate, err := countMinAddScript.Run(ctx, s.client, keys, args...).Int64()
+	return uint64(estimate), err
+}
+
+// Add 把元素计数增加n并返回新的估计值
+// Add increases the count of the element by n and returns the new estimate
+func (s *RedisCountMinSketch[T]) Add(ctx context.Context, v T, n uint64) (uint64, error) {
+	return s.add(ctx, v, n, "", 0)
+}
+
+// Estimate 元素计数的估计值
+// Estimate returns the estimated count of the element
+func (s *RedisCountMinSketch[T]) Estimate(ctx context.Context, v T) (uint64, error) {
+	vals, err := s.client.HMGet(ctx, s.key, s.fields(v)...).Result()
+	if err != nil {
+		return 0, err
+	}
+	return minCounter(vals)
+}
+
+// Total 所有元素的计数总和
+// Total returns the sum of all counts
+func (s *RedisCountMinSketch[T]) Total(ctx context.Context) (uint64, error) {
+	vals, err := s.client.HMGet(ctx, s.key, "total").Result()
+	if err != nil {
+		return 0, err
+	}
+	return minCounter(vals)
+}
+
+// Reset 删除哈希
+// Reset deletes the hash
+func (s *RedisCountMinSketch[T]) Reset(ctx context.Context) error {
+	return s.client.Del(ctx, s.key).Err()
+}
+
+// RedisTopK 基于 Redis 草图与有序集合的 Top-K 统计，有序集合保存编码后的元素
+// RedisTopK tracks heavy hitters with a Redis sketch and a sorted set holding the encoded elements
+type RedisTopK[T any] struct {
+	sketch  *RedisCountMinSketch[T]
+	key     string
+	k       int
+	decoder func(string) (T, error)
+}
+
+// NewRedisTopK 创建 Redis Top-K 统计，有序集合保存在 {key}，草图保存在 {key}:cms；
+// 两个键带相同的哈希标签，在 Redis Cluster 中位于同一槽位，key 已含哈希标签时按原样使用。
+// 默认解码与默认编码对应：字符串与字节切片按原样，其他类型按 JSON；自定义编码时需要提供 decoder
+// NewRedisTopK creates a Redis top-K tracker with the sorted set at {key} and the sketch at {key}:cms.
+// Both keys carry the same hash tag so they share a slot under Redis Cluster; a key that already has a
+// hash tag is used as is. The default decoder mirrors the default encoder: strings and byte slices as
+// is, anything else as JSON; pass a decoder together with a custom encoder
+func NewRedisTopK[T any](client redis.Cmdable, key string, k int, epsilon, delta float64, decoder func(string) (T, error), opts ...Option[T]) *RedisTopK[T] {
+	if decoder == nil {
+		decoder = defaultDecoder[T]
+	}
+	key = hashTag(key)
+	return &RedisTopK[T]{
+		sketch:  NewRedisCountMinSketch(client, key+":cms", epsilon, delta, opts...),
+		key:     key,
+		k:       max(k, 1),
+		decoder: decoder,
+	}
+}
+
+// Add 把元素计数增加n并返回新的估计值
+// Add increases the count of the element by n and returns the new estimate
+func (t *RedisTopK[T]) Add(ctx context.Context, v T, n uint64) (uint64, error) {
+	return t.sketch.add(ctx, v, n, t.key, t.k)
+}
+
+// Estimate 元素计数的估计值
+// Estimate returns the estimated count of the element
+func (t *RedisTopK[T]) Estimate(ctx context.Context, v T) (uint64, error) {
+	return t.sketch.Estimate(ctx, v)
+}
+
+// List 按估计计数降序返回当前的前k个元素
+// List returns the current top k elements in descending order of estimated count
+func (t *RedisTopK[T]) List(ctx context.Context) ([]ItemCount[T], error) {
+	zs, err := t.sketch.client.ZRevRangeWithScores(ctx, t.key, 0, int64(t.k-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ItemCount[T], 0, len(zs))
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		item, err := t.decoder(member)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, ItemCount[T]{Item: item, Count: uint64(z.Score)})
+	}
+	return items, nil
+}
+
+// Reset 删除草图与有序集合
+// Reset deletes the sketch and the sorted set
+func (t *RedisTopK[T]) Reset(ctx context.Context) error {
+	return t.sketch.client.Del(ctx, t.key, t.sketch.key).Err()
+}
+
+// hashTag 为没有哈希标签的键加上 {}，使以它为前缀的键落在同一个 Cluster 槽位
+// hashTag wraps a key without a hash tag in {}, so keys prefixed with it share a Cluster slot
+func hashTag(key string) string {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if j := strings.IndexByte(key[i+1:], '}'); j > 0 {
+			return key
+		}
+	}
+	return "{" + key + "}"
+}
+
+func defaultDecoder[T any](s string) (T, error) {
+	var out T
+	switch p := any(&out).(type) {
+	case *string:
+		*p = s
+	case *[]byte:
+		*p = []byte(s)
+	default:
+		err := json.Unmarshal([]byte(s), &out)
+		return out, err
+	}
+	return out, nil
+}
+
+// RedisHyperLogLog 基于 Redis PFADD/PFCOUNT 的基数统计，哈希由 Redis 完成，编码器只负责把元素转为字节
+// RedisHyperLogLog counts cardinality with Redis PFADD/PFCOUNT; Redis does the hashing and the encoder
+// only turns elements into bytes
+type RedisHyperLogLog[T any] struct {
+	client redis.Cmdable
+	key    string
+	opts   options[T]
+}
+
+// NewRedisHyperLogLog 创建 Redis HyperLogLog
+// NewRedisHyperLogLog creates a Redis HyperLogLog
+func NewRedisHyperLogLog[T any](client redis.Cmdable, key string, opts ...Option[T]) *RedisHyperLogLog[T] {
+	return &RedisHyperLogLog[T]{client: client, key: key, opts: newOptions(opts)}
+}
+
+// Add 添加元素，返回内部寄存器是否发生变化
+// Add inserts elements and reports whether a register changed
+func (h *RedisHyperLogLog[T]) Add(ctx context.Context, values ...T) (bool, error) {
+	if len(values) == 0 {
+		return false, nil
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = h.opts.encoder(v)
+	}
+	n, err := h.client.PFAdd(ctx, h.key, args...).Result()
+	return n == 1, err
+}
+
+// Count 估计不同元素的数量，传入其他键时返回它们并集的基数
+// Count estimates the number of distinct elements, with other keys it estimates their union
+func (h *RedisHyperLogLog[T]) Count(ctx context.Context, others ...string) (uint64, error) {
+	n, err := h.client.PFCount(ctx, append([]string{h.key}, others...)...).Result()
+	return uint64(n), err
+}
+
+// Merge 把其他 HyperLogLog 键合并到当前键
+// Merge merges other HyperLogLog keys into this one
+func (h *RedisHyperLogLog[T]) Merge(ctx context.Context, others ...string) error {
+	return h.client.PFMerge(ctx, h.key, append([]string{h.key}, others...)...).Err()
+}
+
+// Reset 删除键
+// Reset deletes the key
+func (h *RedisHyperLogLog[T]) Reset(ctx context.Context) error {
+	return h.client.Del(ctx, h.key).Err()
+}
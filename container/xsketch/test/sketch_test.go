@@ -0,0 +1,186 @@
+package test
+
+import (
+	"context"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/container/xsketch"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试布隆过滤器的去重与误判率
+func TestBloomFilter(t *testing.T) {
+	bf := xsketch.NewBloomFilter[string](10000, 0.01)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	firsts := 0
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				if bf.Add("url-" + strconv.Itoa(i)) {
+					mu.Lock()
+					firsts++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, firsts, 10000, "同一元素只应被判定为新元素一次")
+	assert.Greater(t, firsts, 9800)
+	for i := 0; i < 10000; i++ {
+		assert.True(t, bf.Contains("url-"+strconv.Itoa(i)), "不应漏判")
+	}
+	fp := 0
+	for i := 0; i < 10000; i++ {
+		if bf.Contains("other-" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	assert.Less(t, fp, 300, "误判率应接近1%")
+	assert.InDelta(t, 10000, float64(bf.ApproxCount()), 500)
+
+	other := xsketch.NewBloomFilter[string](10000, 0.01)
+	other.Add("merged")
+	assert.NoError(t, bf.Merge(other))
+	assert.True(t, bf.Contains("merged"))
+	assert.ErrorIs(t, bf.Merge(xsketch.NewBloomFilter[string](10, 0.01)), xsketch.ErrIncompatible)
+}
+
+// 测试计数布隆过滤器的删除
+func TestCountingBloomFilter(t *testing.T) {
+	cbf := xsketch.NewCountingBloomFilter[int](1000, 0.01)
+	assert.True(t, cbf.Add(1))
+	assert.False(t, cbf.Add(1))
+	assert.Equal(t, uint8(2), cbf.Count(1))
+	assert.True(t, cbf.Remove(1))
+	assert.True(t, cbf.Contains(1))
+	assert.True(t, cbf.Remove(1))
+	assert.False(t, cbf.Contains(1))
+	assert.False(t, cbf.Remove(1), "不存在的元素不应被删除")
+}
+
+// 测试 Count-Min Sketch 与 Top-K
+func TestCountMinSketchTopK(t *testing.T) {
+	topK := xsketch.NewTopK[string](3, 0.001, 0.01)
+	for i := 0; i < 100; i++ {
+		for j := 0; j <= i%10; j++ {
+			topK.Add("k"+strconv.Itoa(i%10), 1)
+		}
+	}
+	list := topK.List()
+	assert.Len(t, list, 3)
+	assert.Equal(t, []string{"k9", "k8", "k7"}, []string{list[0].Item, list[1].Item, list[2].Item})
+	assert.Equal(t, uint64(100), list[0].Count)
+	assert.GreaterOrEqual(t, topK.Estimate("k1"), uint64(20), "估计值不应小于真实值")
+
+	cms := xsketch.NewCountMinSketch[int](0.01, 0.01)
+	cms.Add(42, 5)
+	cms.Add(42, 2)
+	assert.Equal(t, uint64(7), cms.Estimate(42))
+	assert.Equal(t, uint64(7), cms.Total())
+}
+
+// 测试 HyperLogLog 基数估计
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{100, 10000, 200000} {
+		hll := xsketch.NewHyperLogLog[int](xsketch.DefaultPrecision)
+		for i := 0; i < n; i++ {
+			hll.Add(i)
+			hll.Add(i) // 重复元素不影响结果
+		}
+		errRate := math.Abs(float64(hll.Count())-float64(n)) / float64(n)
+		assert.Less(t, errRate, 0.03, "n=%d count=%d", n, hll.Count())
+	}
+
+	a := xsketch.NewHyperLogLog[string](12)
+	b := xsketch.NewHyperLogLog[string](12)
+	for i := 0; i < 5000; i++ {
+		a.Add("a" + strconv.Itoa(i))
+		b.Add("b" + strconv.Itoa(i))
+	}
+	assert.NoError(t, a.Merge(b))
+	assert.InDelta(t, 10000, float64(a.Count()), 500)
+
+	// 互相合并的两个 HyperLogLog 不会死锁 / two HyperLogLogs merging into each other do not deadlock
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(2)
+			go func() { defer wg.Done(); _ = a.Merge(b) }()
+			go func() { defer wg.Done(); _ = b.Merge(a) }()
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("concurrent Merge deadlocked")
+	}
+	assert.Equal(t, a.Count(), b.Count())
+}
+
+// Redis 版本需要通过 REDIS_ADDR 指定 Redis 地址，否则跳过
+func TestRedisSketches(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	bf := xsketch.NewRedisBloomFilter[string](client, "xsketch:test:bloom", 1000, 0.01)
+	defer bf.Reset(ctx)
+	added, err := bf.Add(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, _ = bf.Add(ctx, "a")
+	assert.False(t, added)
+	ok, _ := bf.Contains(ctx, "b")
+	assert.False(t, ok)
+
+	cbf := xsketch.NewRedisCountingBloomFilter[string](client, "xsketch:test:cbf", 1000, 0.01)
+	defer cbf.Reset(ctx)
+	_, _ = cbf.Add(ctx, "a")
+	removed, err := cbf.Remove(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	ok, _ = cbf.Contains(ctx, "a")
+	assert.False(t, ok)
+
+	topK := xsketch.NewRedisTopK[int](client, "xsketch:test:topk", 2, 0.01, 0.01, nil)
+	defer topK.Reset(ctx)
+	for i := 1; i <= 3; i++ {
+		_, err := topK.Add(ctx, i, uint64(i))
+		assert.NoError(t, err)
+	}
+	list, err := topK.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []xsketch.ItemCount[int]{{Item: 3, Count: 3}, {Item: 2, Count: 2}}, list)
+	// 两个键共用哈希标签，兼容 Redis Cluster / both keys share a hash tag for Redis Cluster
+	n, err := client.Exists(ctx, "{xsketch:test:topk}", "{xsketch:test:topk}:cms").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	hll := xsketch.NewRedisHyperLogLog[int](client, "xsketch:test:hll")
+	defer hll.Reset(ctx)
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = i
+	}
+	_, err = hll.Add(ctx, values...)
+	assert.NoError(t, err)
+	count, _ := hll.Count(ctx)
+	assert.InDelta(t, 1000, float64(count), 30)
+}
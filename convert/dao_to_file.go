@@ -17,6 +17,8 @@ import (
 
 // StructToCSV 将结构体切片导出为CSV文件
 // StructToCSV exports a slice of structs to a CSV file
+// 数据量较大时请使用 streamio.WriteCSVFile 按批次流式写出
+// For large data sets use streamio.WriteCSVFile to write in batches
 func StructToCSV[T any](datas []T, fullPath string) error {
 	defer func() {
 		if err := recover(); err != nil {
@@ -49,6 +49,30 @@ func (c *Collection[T]) InsertOne(ctx context.Context, document *T, opts ...*opt
 	}
 }
 
+// InsertMany 批量插入，before 钩子会对每一条文档各调用一次
+func (c *Collection[T]) InsertMany(ctx context.Context, documents []T, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	docs := make([]any, len(documents))
+	for i := range documents {
+		docs[i] = &documents[i]
+	}
+	if c.before != nil {
+		transaction, err := c.Transaction(ctx, func(stx mongo.SessionContext) (any, error) {
+			var ctx context.Context = stx
+			for i := range documents {
+				next, err := c.before(ctx, "InsertMany", c.coll.Database().Name(), c.coll.Name(), &c.qw, &documents[i])
+				if err != nil {
+					return next, err
+				}
+				ctx = next
+			}
+			return c.coll.InsertMany(ctx, docs, opts...)
+		})
+		result, _ := transaction.(*mongo.InsertManyResult)
+		return result, err
+	}
+	return c.coll.InsertMany(ctx, docs, opts...)
+}
+
 func (c *Collection[T]) FindOne(ctx context.Context, result *T, opts ...*options.FindOneOptions) error {
 	singleResult := c.coll.FindOne(ctx, c.filter(), opts...)
 	if singleResult.Err() != nil {
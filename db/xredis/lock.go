@@ -0,0 +1,409 @@
+package xredis
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLockNotHeld is returned when unlocking a lock that this owner does not hold.
+	ErrLockNotHeld = errors.New("xredis: lock not held")
+	// ErrLockLost is reported to the lost handler when the watchdog finds the lease taken or expired.
+	ErrLockLost = errors.New("xredis: lock lost")
+)
+
+// The lock value is "owner:count", the count tracks reentrant acquisitions by the same owner.
+var (
+	acquireScript = redis.NewScript(`
+local v = redis.call('get', KEYS[1])
+if not v then
+  redis.call('set', KEYS[1], ARGV[1] .. ':1', 'nx', 'px', ARGV[2])
+  return 1
+end
+local owner, n = string.match(v, '^(.*):(%d+)$')
+if ARGV[3] == '1' and owner == ARGV[1] then
+  n = tonumber(n) + 1
+  redis.call('set', KEYS[1], owner .. ':' .. n, 'px', ARGV[2])
+  return n
+end
+return 0`)
+	releaseScript = redis.NewScript(`
+local v = redis.call('get', KEYS[1])
+if not v then return -1 end
+local owner, n = string.match(v, '^(.*):(%d+)$')
+if owner ~= ARGV[1] then return -1 end
+n = tonumber(n) - 1
+if n <= 0 then
+  redis.call('del', KEYS[1])
+  return 0
+end
+local ttl = redis.call('pttl', KEYS[1])
+if ttl <= 0 then ttl = ARGV[2] end
+redis.call('set', KEYS[1], owner .. ':' .. n, 'px', ttl)
+return n`)
+	renewScript = redis.NewScript(`
+local v = redis.call('get', KEYS[1])
+if not v then return 0 end
+local owner = string.match(v, '^(.*):%d+$')
+if owner ~= ARGV[1] then return 0 end
+return redis.call('pexpire', KEYS[1], ARGV[2])`)
+)
+
+// LockOption customizes a Mutex.
+type LockOption func(*lockOptions)
+
+type lockOptions struct {
+	ttl       time.Duration
+	retry     time.Duration
+	watchdog  bool
+	owner     string
+	reentrant bool
+	onLost    func(name string, err error)
+}
+
+// WithLockTTL sets the lease of the lock, 30s by default.
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(o *lockOptions) { o.ttl = ttl }
+}
+
+// WithLockRetryInterval sets how long to wait between acquisition attempts, 50ms by default.
+// A random jitter of up to half the interval is added.
+func WithLockRetryInterval(interval time.Duration) LockOption {
+	return func(o *lockOptions) { o.retry = interval }
+}
+
+// WithLockWatchdog enables or disables lease renewal, enabled by default.
+// The watchdog renews the lease every TTL/3 while the lock is held.
+func WithLockWatchdog(enabled bool) LockOption {
+	return func(o *lockOptions) { o.watchdog = enabled }
+}
+
+// WithReentrantOwner makes the lock reentrant for owner: any handle using the same owner,
+// on any node, may acquire it again while it is held and must unlock once per acquisition.
+func WithReentrantOwner(owner string) LockOption {
+	return func(o *lockOptions) {
+		o.owner = owner
+		o.reentrant = true
+	}
+}
+
+// WithLockLostHandler sets a callback invoked when the watchdog finds the lease lost.
+func WithLockLostHandler(handler func(name string, err error)) LockOption {
+	return func(o *lockOptions) { o.onLost = handler }
+}
+
+type lockInstance struct {
+	cmd redis.Cmdable
+	key string
+}
+
+// Mutex is a distributed lock implementing sync.Locker, so it plugs into lock.Synchronized.
+// It is owned by a random token with SET NX PX, released with a Lua compare-and-delete and kept
+// alive by a watchdog. A handle is safe for concurrent use: goroutines sharing a non-reentrant
+// handle exclude each other locally before contending in Redis. With several instances it runs
+// the Redlock algorithm and needs a majority of them.
+type Mutex struct {
+	name      string
+	instances []lockInstance
+	opts      lockOptions
+
+	sem   chan struct{}
+	mu    sync.Mutex
+	holds int
+	stop  context.CancelFunc
+}
+
+// NewMutex creates a distributed lock on this client, the name is prefixed like other keys.
+func (c *Client) NewMutex(name string, opts ...LockOption) *Mutex {
+	return newMutex(name, []lockInstance{{cmd: c.Client, key: c.Key(name)}}, opts)
+}
+
+// NewRedlock creates a lock over independent Redis instances using the Redlock algorithm.
+// An acquisition succeeds when a majority of the instances grant it within the lease.
+func NewRedlock(name string, clients []*Client, opts ...LockOption) *Mutex {
+	instances := make([]lockInstance, 0, len(clients))
+	for _, c := range clients {
+		instances = append(instances, lockInstance{cmd: c.Client, key: c.Key(name)})
+	}
+	return newMutex(name, instances, opts)
+}
+
+func newMutex(name string, instances []lockInstance, opts []LockOption) *Mutex {
+	o := lockOptions{ttl: 30 * time.Second, retry: 50 * time.Millisecond, watchdog: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.ttl <= 0 {
+		o.ttl = 30 * time.Second
+	}
+	if o.retry <= 0 {
+		o.retry = 50 * time.Millisecond
+	}
+	if o.owner == "" {
+		o.owner = newLockToken()
+	}
+	return &Mutex{name: name, instances: instances, opts: o, sem: make(chan struct{}, 1)}
+}
+
+func newLockToken() string {
+	b := make([]byte, 16)
+	_, _ = crand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Name returns the lock name.
+func (m *Mutex) Name() string {
+	return m.name
+}
+
+// Owner returns the owner token stored in Redis.
+func (m *Mutex) Owner() string {
+	return m.opts.owner
+}
+
+// Lock blocks until the lock is acquired. It panics if Redis cannot be reached,
+// use LockContext to handle errors.
+func (m *Mutex) Lock() {
+	if err := m.LockContext(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// Unlock releases one acquisition. It panics when the handle does not hold the lock, like
+// sync.Mutex; Redis errors and lost leases are ignored, use UnlockContext to observe them.
+func (m *Mutex) Unlock() {
+	m.mu.Lock()
+	held := m.holds > 0
+	m.mu.Unlock()
+	if !held {
+		panic("xredis: unlock of unlocked lock " + m.name)
+	}
+	_ = m.UnlockContext(context.Background())
+}
+
+// TryLock makes a single acquisition attempt.
+func (m *Mutex) TryLock() bool {
+	ok, _ := m.TryLockContext(context.Background(), 0)
+	return ok
+}
+
+// LockContext blocks until the lock is acquired, ctx is done or Redis fails.
+func (m *Mutex) LockContext(ctx context.Context) error {
+	_, err := m.acquire(ctx, -1)
+	return err
+}
+
+// TryLockContext keeps trying for at most wait, or until ctx is done.
+// It returns false without error when the lock is still taken.
+func (m *Mutex) TryLockContext(ctx context.Context, wait time.Duration) (bool, error) {
+	return m.acquire(ctx, wait)
+}
+
+// acquire retries until wait elapses, a negative wait retries until ctx is done.
+func (m *Mutex) acquire(ctx context.Context, wait time.Duration) (bool, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var deadline time.Time
+	if wait >= 0 {
+		deadline = time.Now().Add(wait)
+	}
+	if !m.opts.reentrant {
+		if !m.acquireLocal(ctx, deadline) {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+	for {
+		ok, err := m.attempt(ctx)
+		if ok {
+			m.held()
+			return true, nil
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil || (wait >= 0 && !time.Now().Before(deadline)) {
+			m.releaseLocal()
+			return false, err
+		}
+		delay := m.opts.retry + rand.N(m.opts.retry/2+1)
+		if wait >= 0 {
+			delay = min(delay, time.Until(deadline))
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			m.releaseLocal()
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// acquireLocal serializes goroutines sharing a non-reentrant handle.
+func (m *Mutex) acquireLocal(ctx context.Context, deadline time.Time) bool {
+	select {
+	case m.sem <- struct{}{}:
+		return true
+	default:
+	}
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	select {
+	case m.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (m *Mutex) releaseLocal() {
+	if !m.opts.reentrant {
+		<-m.sem
+	}
+}
+
+func (m *Mutex) quorum() int {
+	return len(m.instances)/2 + 1
+}
+
+// attempt runs one acquisition round on every instance and keeps it only with a quorum
+// granted before the lease runs out.
+func (m *Mutex) attempt(ctx context.Context) (bool, error) {
+	start := time.Now()
+	granted := make([]bool, len(m.instances))
+	var errs []error
+	n := 0
+	for i, in := range m.instances {
+		res, err := acquireScript.Run(ctx, in.cmd, []string{in.key},
+			m.opts.owner, m.opts.ttl.Milliseconds(), flag(m.opts.reentrant)).Int64()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if res > 0 {
+			granted[i] = true
+			n++
+		}
+	}
+	drift := m.opts.ttl/100 + 2*time.Millisecond
+	if n >= m.quorum() && time.Since(start)+drift < m.opts.ttl {
+		return true, nil
+	}
+	for i, in := range m.instances {
+		if granted[i] {
+			releaseScript.Run(context.WithoutCancel(ctx), in.cmd, []string{in.key}, m.opts.owner, m.opts.ttl.Milliseconds())
+		}
+	}
+	if len(errs) > len(m.instances)-m.quorum() {
+		return false, errors.Join(errs...)
+	}
+	return false, nil
+}
+
+// UnlockContext releases one acquisition on every instance, the lease is deleted after the last one.
+// It returns ErrLockNotHeld when the handle does not hold the lock or the lease was lost.
+func (m *Mutex) UnlockContext(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.mu.Lock()
+	if m.holds == 0 {
+		m.mu.Unlock()
+		return ErrLockNotHeld
+	}
+	m.holds--
+	if m.holds == 0 && m.stop != nil {
+		m.stop()
+		m.stop = nil
+	}
+	last := m.holds == 0
+	m.mu.Unlock()
+	if !m.opts.reentrant && last {
+		defer m.releaseLocal()
+	}
+
+	var errs []error
+	released := 0
+	for _, in := range m.instances {
+		res, err := releaseScript.Run(ctx, in.cmd, []string{in.key}, m.opts.owner, m.opts.ttl.Milliseconds()).Int64()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case res >= 0:
+			released++
+		}
+	}
+	if released >= m.quorum() {
+		return nil
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return ErrLockNotHeld
+}
+
+// held records an acquisition and starts the watchdog on the first one.
+func (m *Mutex) held() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.holds++
+	if m.holds == 1 && m.opts.watchdog {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.stop = cancel
+		go m.watchdog(ctx)
+	}
+}
+
+func (m *Mutex) watchdog(ctx context.Context) {
+	ticker := time.NewTicker(max(m.opts.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		renewed, failed := 0, 0
+		for _, in := range m.instances {
+			res, err := renewScript.Run(ctx, in.cmd, []string{in.key}, m.opts.owner, m.opts.ttl.Milliseconds()).Int64()
+			switch {
+			case err != nil:
+				failed++
+			case res == 1:
+				renewed++
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// transient errors are retried on the next tick, the lease is lost once a quorum denies it
+		if renewed < m.quorum() && len(m.instances)-failed-renewed > len(m.instances)-m.quorum() {
+			if m.opts.onLost != nil {
+				m.opts.onLost(m.name, ErrLockLost)
+			}
+			return
+		}
+	}
+}
+
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
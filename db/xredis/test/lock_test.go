@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/db/xredis"
+	"github.com/karosown/katool-go/lock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLockClient(t *testing.T) *xredis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	c := xredis.NewClient(&redis.Options{Addr: addr}, xredis.WithPrefix("katool:test:lock:"))
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// 无法连接 Redis 时返回错误，未持有时 Unlock 会 panic
+func TestMutexUnreachable(t *testing.T) {
+	c := xredis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 50 * time.Millisecond, MaxRetries: -1})
+	defer c.Close()
+	m := c.NewMutex("job")
+	ok, err := m.TryLockContext(context.Background(), 100*time.Millisecond)
+	assert.False(t, ok)
+	assert.Error(t, err)
+	assert.False(t, m.TryLock())
+	assert.Panics(t, m.Unlock)
+	assert.ErrorIs(t, m.UnlockContext(context.Background()), xredis.ErrLockNotHeld)
+}
+
+// 测试互斥与 lock.Synchronized 集成
+func TestMutexExclusion(t *testing.T) {
+	c := newLockClient(t)
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		// 每个协程一个句柄，模拟多个节点 / one handle per goroutine simulates several nodes
+		m := c.NewMutex("counter", xredis.WithLockRetryInterval(5*time.Millisecond))
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				lock.Synchronized(m, func() { counter++ })
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 80, counter)
+}
+
+// 测试 TryLock 超时、ctx 取消与看门狗续期
+func TestMutexTryLockAndWatchdog(t *testing.T) {
+	c := newLockClient(t)
+	holder := c.NewMutex("lease", xredis.WithLockTTL(300*time.Millisecond))
+	other := c.NewMutex("lease", xredis.WithLockRetryInterval(10*time.Millisecond))
+	assert.NoError(t, holder.LockContext(context.Background()))
+
+	ok, err := other.TryLockContext(context.Background(), 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// 超过租期仍被持有 / still held after the lease would have expired
+	time.Sleep(600 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, other.LockContext(ctx), context.DeadlineExceeded)
+
+	assert.NoError(t, holder.UnlockContext(context.Background()))
+	assert.True(t, other.TryLock())
+	other.Unlock()
+}
+
+// 测试同一所有者的可重入
+func TestMutexReentrant(t *testing.T) {
+	c := newLockClient(t)
+	a := c.NewMutex("reentrant", xredis.WithReentrantOwner("node-1"))
+	b := c.NewMutex("reentrant", xredis.WithReentrantOwner("node-1"))
+	stranger := c.NewMutex("reentrant")
+
+	assert.True(t, a.TryLock())
+	assert.True(t, b.TryLock())
+	assert.False(t, stranger.TryLock())
+	a.Unlock()
+	assert.False(t, stranger.TryLock())
+	b.Unlock()
+	assert.True(t, stranger.TryLock())
+	stranger.Unlock()
+}
+
+// 测试 Redlock 多数派
+func TestRedlock(t *testing.T) {
+	newLockClient(t)
+	var clients []*xredis.Client
+	for _, db := range []int{1, 2, 3} {
+		cl := xredis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_ADDR"), DB: db})
+		defer cl.Close()
+		clients = append(clients, cl)
+	}
+	// 其中一个实例上已被他人持有，仍可获得多数派 / one instance is taken, a majority is still reachable
+	taken := clients[0].NewMutex("redlock")
+	assert.True(t, taken.TryLock())
+	defer taken.Unlock()
+
+	rl := xredis.NewRedlock("redlock", clients)
+	assert.True(t, rl.TryLock())
+	assert.False(t, xredis.NewRedlock("redlock", clients).TryLock())
+	rl.Unlock()
+}
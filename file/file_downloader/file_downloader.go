@@ -2,18 +2,24 @@ package file_downloader
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/karosown/katool-go/resilience"
 )
 
 // FileDownloader 是一个文件下载工具类
 // FileDownloader is a file download utility class
 type FileDownloader struct {
 	Client *http.Client
+	// Policy 下载时使用的容错策略（重试、熔断等），为 nil 时直接请求
+	// Policy is the fault-tolerance policy such as retry or circuit breaker used for downloads, nil requests directly
+	Policy resilience.Policy
 }
 
 // NewFileDownloader 创建一个新的 FileDownloader 实例
@@ -24,9 +30,21 @@ func NewFileDownloader() *FileDownloader {
 	}
 }
 
+// WithPolicy 设置容错策略
+// WithPolicy sets the fault-tolerance policy
+func (fd *FileDownloader) WithPolicy(policy resilience.Policy) *FileDownloader {
+	fd.Policy = policy
+	return fd
+}
+
 // DownloadFile 下载单个文件
 // DownloadFile downloads a single file
 func (fd *FileDownloader) DownloadFile(url, destPath string) error {
+	byties, err := fd.DownloadFileBytes(url)
+	if err != nil {
+		return err
+	}
+
 	// 创建目标文件
 	out, err := os.Create(destPath)
 	if err != nil {
@@ -34,8 +52,6 @@ func (fd *FileDownloader) DownloadFile(url, destPath string) error {
 	}
 	defer out.Close()
 
-	byties, err := fd.DownloadFileBytes(url)
-
 	// 将响应体写入文件
 	_, err = io.Copy(out, bytes.NewReader(byties))
 	if err != nil {
@@ -44,16 +60,39 @@ func (fd *FileDownloader) DownloadFile(url, destPath string) error {
 
 	return nil
 }
+
+// DownloadFileBytes 下载文件内容；设置了 Policy 时在策略下执行，除 408、429 外的 4xx 响应不会重试
+// DownloadFileBytes downloads the file content; with a Policy set it runs under the policy, and 4xx
+// responses other than 408 and 429 are not retried
 func (fd *FileDownloader) DownloadFileBytes(url string) ([]byte, error) {
-	resp, err := fd.Client.Get(url)
+	if fd.Policy == nil {
+		return fd.download(context.Background(), url)
+	}
+	return resilience.Do(context.Background(), fd.Policy, func(ctx context.Context) ([]byte, error) {
+		return fd.download(ctx, url)
+	})
+}
+
+// download 执行一次下载 / download performs a single download attempt
+func (fd *FileDownloader) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, resilience.Permanent(fmt.Errorf("下载文件失败: %w", err))
+	}
+	resp, err := fd.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("下载文件失败: %v", err)
+		return nil, fmt.Errorf("下载文件失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("下载文件失败，服务器返回状态码: %d", resp.StatusCode)
+		err := fmt.Errorf("下载文件失败，服务器返回状态码: %d", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, resilience.Permanent(err)
+		}
+		return nil, err
 	}
 
 	return io.ReadAll(resp.Body)
@@ -0,0 +1,134 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// RowError 某一行解析失败，跳过该行后可以继续 Decode
+type RowError struct {
+	Line int64
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("has error in %d line when read line: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder 逐行读取 CSV 并解码为 T，适合无法一次性装入内存的大文件
+// T 必须是结构体，字段映射规则与 Read 一致
+type Decoder[T any] struct {
+	br         *bufio.Reader
+	cr         *csv.Reader
+	tType      reflect.Type
+	fields     map[string]fieldInfo
+	expected   []string
+	colToField []fieldInfo
+	line       int64
+}
+
+// NewDecoder 创建 CSV 解码器，标题行在第一次 Decode 时读取
+func NewDecoder[T any](r io.Reader) *Decoder[T] {
+	tType := reflect.TypeOf((*T)(nil)).Elem()
+	d := &Decoder[T]{br: bufio.NewReader(r), tType: tType}
+	if tType.Kind() == reflect.Struct {
+		d.fields, d.expected = extractFields(tType)
+	}
+	return d
+}
+
+// Decode 读取下一行，数据读完时返回 io.EOF
+// 某一行解析失败时返回已解析的部分与 *RowError，调用方可以选择跳过并继续 Decode；其他错误无法恢复
+func (d *Decoder[T]) Decode() (T, error) {
+	var zero T
+	if d.tType.Kind() != reflect.Struct {
+		return zero, fmt.Errorf("T must be a struct, got %s", d.tType.Kind())
+	}
+	if d.cr == nil {
+		headers, err := findHeader(d.br, d.expected)
+		if err != nil {
+			return zero, err
+		}
+		d.colToField = make([]fieldInfo, len(headers))
+		for i, h := range headers {
+			if fi, ok := d.fields[h]; ok {
+				d.colToField[i] = fi
+			}
+		}
+		d.cr = csv.NewReader(d.br)
+	}
+	rec, err := d.cr.Read()
+	if errors.Is(err, io.EOF) {
+		return zero, io.EOF
+	}
+	d.line++
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return zero, &RowError{Line: d.line, Err: err}
+		}
+		return zero, err
+	}
+	v := reflect.New(d.tType).Elem()
+	var rowErr error
+	for i, raw := range rec {
+		if i >= len(d.colToField) || d.colToField[i].index == -1 {
+			continue
+		}
+		raw = strings.Trim(raw, "\"")
+		if err1 := setFieldValue(v.Field(d.colToField[i].index), raw); err1 != nil {
+			rowErr = errors.Join(rowErr, err1)
+		}
+	}
+	if rowErr != nil {
+		return v.Interface().(T), &RowError{Line: d.line, Err: rowErr}
+	}
+	return v.Interface().(T), nil
+}
+
+// Encoder 逐条把 T 编码为 CSV 行，第一次写入时输出标题行
+// T 必须是结构体，字段映射规则与 Write 一致
+type Encoder[T any] struct {
+	cw      *csv.Writer
+	fields  map[string]fieldInfo
+	headers []string
+}
+
+// NewEncoder 创建 CSV 编码器，写入的数据会缓存在内部，调用 Flush 后才保证落到 w
+func NewEncoder[T any](w io.Writer) *Encoder[T] {
+	return &Encoder[T]{cw: csv.NewWriter(w)}
+}
+
+// Encode 写入一行
+func (e *Encoder[T]) Encode(row T) error {
+	rv := reflect.ValueOf(row)
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("T must be a struct, got %s", rv.Kind())
+	}
+	if e.headers == nil {
+		e.fields, e.headers = extractFields(rv.Type())
+		if err := e.cw.Write(e.headers); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(e.headers))
+	for i, h := range e.headers {
+		record[i] = getFieldString(rv.Field(e.fields[h].index))
+	}
+	return e.cw.Write(record)
+}
+
+// Flush 把缓存的数据写入底层 io.Writer
+func (e *Encoder[T]) Flush() error {
+	e.cw.Flush()
+	return e.cw.Error()
+}
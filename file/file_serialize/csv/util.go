@@ -74,29 +74,9 @@ func Read[T any](r io.Reader) ([]T, error) {
 
 	// 逐行扫描，寻找标题行
 	br := bufio.NewReader(r)
-
-	var headers []string
-	for {
-		line, err := br.ReadString('\n')
-		if err != nil && !errors.Is(err, io.EOF) {
-			return zero, fmt.Errorf("read line: %w", err)
-		}
-		line = strings.TrimSpace(line)
-		if line != "" {
-			rec, err2 := csv.NewReader(strings.NewReader(line)).Read()
-			if err2 == nil {
-				for i := range rec {
-					rec[i] = strings.Trim(rec[i], "\"")
-				}
-				if headerMatch(rec, headersExpected) {
-					headers = rec
-					break
-				}
-			}
-		}
-		if errors.Is(err, io.EOF) {
-			return zero, errors.New("csv header not found")
-		}
+	headers, err := findHeader(br, headersExpected)
+	if err != nil {
+		return zero, err
 	}
 
 	// 继续用同一个缓冲区之后的内容读取数据
@@ -113,7 +93,6 @@ func Read[T any](r io.Reader) ([]T, error) {
 	}
 
 	var out []T
-	var err error
 	var line int64
 	line = 1
 	for {
@@ -199,6 +178,31 @@ func Write[T any](w io.Writer, data []T) error {
 
 // ----------------- 辅助方法与类型 -----------------
 
+// findHeader 逐行扫描，直到找到与期望一致的标题行
+func findHeader(br *bufio.Reader, headersExpected []string) ([]string, error) {
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("read line: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			rec, err2 := csv.NewReader(strings.NewReader(line)).Read()
+			if err2 == nil {
+				for i := range rec {
+					rec[i] = strings.Trim(rec[i], "\"")
+				}
+				if headerMatch(rec, headersExpected) {
+					return rec, nil
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv header not found")
+		}
+	}
+}
+
 type fieldInfo struct {
 	index int
 	name  string // header name
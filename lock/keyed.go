@@ -0,0 +1,250 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// refEntry 带引用计数的锁，引用计数为持有者与等待者之和
+// refEntry is a lock with a reference count of its holders and waiters
+type refEntry[L any] struct {
+	lock L
+	refs int
+}
+
+// refMap 按键引用计数的锁表，引用归零时删除
+// refMap is a table of per-key locks that are removed when their reference count drops to zero
+type refMap[K comparable, L any] struct {
+	mu      sync.Mutex
+	entries map[K]*refEntry[L]
+}
+
+func (m *refMap[K, L]) acquire(key K) *L {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[K]*refEntry[L])
+	}
+	e, ok := m.entries[key]
+	if !ok {
+		e = &refEntry[L]{}
+		m.entries[key] = e
+	}
+	e.refs++
+	return &e.lock
+}
+
+// get 返回已存在的锁，不存在时panic
+// get returns an existing lock and panics when there is none
+func (m *refMap[K, L]) get(key K) *L {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		panic("lock: unlock of unlocked key")
+	}
+	return &e.lock
+}
+
+func (m *refMap[K, L]) release(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		if e.refs--; e.refs == 0 {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func (m *refMap[K, L]) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// KeyedMutex 按键加锁的互斥锁，键在无人持有也无人等待时自动释放，零值可用
+// KeyedMutex locks per key, a key is freed once nobody holds or waits for it; the zero value is ready to use
+type KeyedMutex[K comparable] struct {
+	locks refMap[K, Mutex]
+}
+
+// NewKeyedMutex 创建按键互斥锁
+// NewKeyedMutex creates a per-key mutex
+func NewKeyedMutex[K comparable]() *KeyedMutex[K] {
+	return &KeyedMutex[K]{}
+}
+
+// Lock 对键加锁
+// Lock acquires the lock of the key
+func (k *KeyedMutex[K]) Lock(key K) {
+	k.locks.acquire(key).Lock()
+}
+
+// Unlock 释放键的锁，未加锁时panic
+// Unlock releases the lock of the key, it panics when the key is not locked
+func (k *KeyedMutex[K]) Unlock(key K) {
+	k.locks.get(key).Unlock()
+	k.locks.release(key)
+}
+
+// TryLock 尝试立即对键加锁
+// TryLock tries to lock the key without blocking
+func (k *KeyedMutex[K]) TryLock(key K) bool {
+	if k.locks.acquire(key).TryLock() {
+		return true
+	}
+	k.locks.release(key)
+	return false
+}
+
+// LockContext 对键加锁直到成功或ctx结束
+// LockContext locks the key unless ctx is done first
+func (k *KeyedMutex[K]) LockContext(ctx context.Context, key K) error {
+	if err := k.locks.acquire(key).LockContext(ctx); err != nil {
+		k.locks.release(key)
+		return err
+	}
+	return nil
+}
+
+// LockTimeout 在超时时间内对键加锁，返回是否成功
+// LockTimeout locks the key within the timeout and reports whether it succeeded
+func (k *KeyedMutex[K]) LockTimeout(key K, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.LockContext(ctx, key) == nil
+}
+
+// Locker 返回对键加锁的 sync.Locker，可用于 Synchronized
+// Locker returns a sync.Locker for the key, usable with Synchronized
+func (k *KeyedMutex[K]) Locker(key K) sync.Locker {
+	return keyedLocker[K]{lock: k.Lock, unlock: k.Unlock, key: key}
+}
+
+// Len 当前被持有或等待中的键数量
+// Len returns the number of keys that are held or waited for
+func (k *KeyedMutex[K]) Len() int {
+	return k.locks.len()
+}
+
+// KeyedRWMutex 按键加锁的读写锁，键在无人持有也无人等待时自动释放，零值可用
+// KeyedRWMutex is a per-key reader/writer lock, a key is freed once nobody holds or waits for it; the
+// zero value is ready to use
+type KeyedRWMutex[K comparable] struct {
+	locks refMap[K, RWMutex]
+}
+
+// NewKeyedRWMutex 创建按键读写锁
+// NewKeyedRWMutex creates a per-key reader/writer lock
+func NewKeyedRWMutex[K comparable]() *KeyedRWMutex[K] {
+	return &KeyedRWMutex[K]{}
+}
+
+// Lock 对键加写锁
+// Lock acquires the write lock of the key
+func (k *KeyedRWMutex[K]) Lock(key K) {
+	k.locks.acquire(key).Lock()
+}
+
+// Unlock 释放键的写锁
+// Unlock releases the write lock of the key
+func (k *KeyedRWMutex[K]) Unlock(key K) {
+	k.locks.get(key).Unlock()
+	k.locks.release(key)
+}
+
+// RLock 对键加读锁
+// RLock acquires a read lock of the key
+func (k *KeyedRWMutex[K]) RLock(key K) {
+	k.locks.acquire(key).RLock()
+}
+
+// RUnlock 释放键的读锁
+// RUnlock releases a read lock of the key
+func (k *KeyedRWMutex[K]) RUnlock(key K) {
+	k.locks.get(key).RUnlock()
+	k.locks.release(key)
+}
+
+// TryLock 尝试立即对键加写锁
+// TryLock tries to write-lock the key without blocking
+func (k *KeyedRWMutex[K]) TryLock(key K) bool {
+	if k.locks.acquire(key).TryLock() {
+		return true
+	}
+	k.locks.release(key)
+	return false
+}
+
+// TryRLock 尝试立即对键加读锁
+// TryRLock tries to read-lock the key without blocking
+func (k *KeyedRWMutex[K]) TryRLock(key K) bool {
+	if k.locks.acquire(key).TryRLock() {
+		return true
+	}
+	k.locks.release(key)
+	return false
+}
+
+// LockContext 对键加写锁直到成功或ctx结束
+// LockContext write-locks the key unless ctx is done first
+func (k *KeyedRWMutex[K]) LockContext(ctx context.Context, key K) error {
+	if err := k.locks.acquire(key).LockContext(ctx); err != nil {
+		k.locks.release(key)
+		return err
+	}
+	return nil
+}
+
+// RLockContext 对键加读锁直到成功或ctx结束
+// RLockContext read-locks the key unless ctx is done first
+func (k *KeyedRWMutex[K]) RLockContext(ctx context.Context, key K) error {
+	if err := k.locks.acquire(key).RLockContext(ctx); err != nil {
+		k.locks.release(key)
+		return err
+	}
+	return nil
+}
+
+// LockTimeout 在超时时间内对键加写锁，返回是否成功
+// LockTimeout write-locks the key within the timeout and reports whether it succeeded
+func (k *KeyedRWMutex[K]) LockTimeout(key K, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.LockContext(ctx, key) == nil
+}
+
+// RLockTimeout 在超时时间内对键加读锁，返回是否成功
+// RLockTimeout read-locks the key within the timeout and reports whether it succeeded
+func (k *KeyedRWMutex[K]) RLockTimeout(key K, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.RLockContext(ctx, key) == nil
+}
+
+// Locker 返回对键加写锁的 sync.Locker
+// Locker returns a sync.Locker write-locking the key
+func (k *KeyedRWMutex[K]) Locker(key K) sync.Locker {
+	return keyedLocker[K]{lock: k.Lock, unlock: k.Unlock, key: key}
+}
+
+// RLocker 返回对键加读锁的 sync.Locker
+// RLocker returns a sync.Locker read-locking the key
+func (k *KeyedRWMutex[K]) RLocker(key K) sync.Locker {
+	return keyedLocker[K]{lock: k.RLock, unlock: k.RUnlock, key: key}
+}
+
+// Len 当前被持有或等待中的键数量
+// Len returns the number of keys that are held or waited for
+func (k *KeyedRWMutex[K]) Len() int {
+	return k.locks.len()
+}
+
+type keyedLocker[K comparable] struct {
+	lock, unlock func(K)
+	key          K
+}
+
+func (l keyedLocker[K]) Lock()   { l.lock(l.key) }
+func (l keyedLocker[K]) Unlock() { l.unlock(l.key) }
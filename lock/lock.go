@@ -1,55 +1,79 @@
 package lock
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/karosown/katool-go/container/xmap"
 )
 
-// LockSupport 类似Java的LockSupport，提供park/unpark机制
-// LockSupport provides park/unpark mechanism similar to Java's LockSupport
+// LockSupport 类似Java的LockSupport，基于许可的park/unpark机制：许可最多一个，
+// Unpark 发放许可，Park 消耗许可，没有许可时阻塞；先 Unpark 后 Park 不会阻塞
+// LockSupport provides a permit-based park/unpark mechanism similar to Java's LockSupport: there is at
+// most one permit, Unpark grants it and Park consumes it, blocking while there is none; an Unpark
+// before Park makes that Park return at once
 type LockSupport struct {
-	wt    chan bool
-	state chan bool
+	permit chan struct{}
 }
 
 // NewLockSupport 创建新的LockSupport实例
 // NewLockSupport creates a new LockSupport instance
 func NewLockSupport() *LockSupport {
 	return &LockSupport{
-		wt:    make(chan bool),
-		state: make(chan bool),
+		permit: make(chan struct{}, 1),
 	}
 }
 
-// Park 阻塞当前协程直到被unpark唤醒
-// Park blocks the current goroutine until it's unparked
+// Park 阻塞当前协程直到获得许可
+// Park blocks the current goroutine until a permit is available
 func (l *LockSupport) Park() bool {
-	if len(l.state) >= 1 {
+	<-l.permit
+	return true
+}
+
+// ParkNanos 最多阻塞nanos纳秒，获得许可返回true，超时返回false
+// ParkNanos blocks for at most nanos nanoseconds, it returns true when a permit was consumed and false on timeout
+func (l *LockSupport) ParkNanos(nanos int64) bool {
+	return l.ParkUntil(time.Now().Add(time.Duration(nanos)))
+}
+
+// ParkUntil 阻塞直到获得许可或到达deadline，获得许可返回true
+// ParkUntil blocks until a permit is available or the deadline passes, it returns true when a permit was consumed
+func (l *LockSupport) ParkUntil(deadline time.Time) bool {
+	select {
+	case <-l.permit:
+		return true
+	default:
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-l.permit:
+		return true
+	case <-timer.C:
 		return false
 	}
-	l.state <- true
-	return <-l.wt
 }
 
-// Unpark 唤醒被park阻塞的协程
-// Unpark wakes up the goroutine that was parked
-func (l *LockSupport) Unpark() (err error) {
-	defer func() error {
-		e := recover()
-		if e != nil {
-			err = e.(error)
-		}
-		if err != nil {
-			return err
-		}
-		return nil
-	}()
-	<-l.state
-	if len(l.wt) >= 1 {
+// ParkContext 阻塞直到获得许可或ctx结束
+// ParkContext blocks until a permit is available or ctx is done
+func (l *LockSupport) ParkContext(ctx context.Context) error {
+	select {
+	case <-l.permit:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Unpark 发放许可，已有许可时不累加
+// Unpark grants the permit, permits do not accumulate
+func (l *LockSupport) Unpark() error {
+	select {
+	case l.permit <- struct{}{}:
+	default:
 	}
-	l.wt <- true
 	return nil
 }
 
@@ -85,6 +109,6 @@ func SynchronizedTErr[T any](locker sync.Locker, f func() (T, error)) (T, error)
 	return f()
 }
 
-// LockMap 线程安全的锁映射
-// LockMap is a thread-safe map for locks
+// LockMap 线程安全的锁映射，其中的锁不会被自动清理；按键加锁请使用 KeyedMutex
+// LockMap is a thread-safe map for locks whose entries are never cleaned up; use KeyedMutex for per-key locking
 type LockMap = xmap.SafeMap[string, sync.Locker]
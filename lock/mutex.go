@@ -0,0 +1,233 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Mutex 支持ctx与超时的互斥锁，零值可用，实现 sync.Locker
+// Mutex is a mutual exclusion lock supporting ctx and timeouts; the zero value is ready to use and it
+// implements sync.Locker
+type Mutex struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+func (m *Mutex) sem() chan struct{} {
+	m.once.Do(func() { m.ch = make(chan struct{}, 1) })
+	return m.ch
+}
+
+// Lock 加锁
+// Lock acquires the lock
+func (m *Mutex) Lock() {
+	m.sem() <- struct{}{}
+}
+
+// Unlock 解锁，未加锁时panic
+// Unlock releases the lock, it panics when the lock is not held
+func (m *Mutex) Unlock() {
+	select {
+	case <-m.sem():
+	default:
+		panic("lock: unlock of unlocked Mutex")
+	}
+}
+
+// TryLock 尝试立即加锁
+// TryLock tries to acquire the lock without blocking
+func (m *Mutex) TryLock() bool {
+	select {
+	case m.sem() <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LockContext 加锁直到成功或ctx结束
+// LockContext acquires the lock unless ctx is done first
+func (m *Mutex) LockContext(ctx context.Context) error {
+	select {
+	case m.sem() <- struct{}{}:
+		return nil
+	default:
+	}
+	select {
+	case m.sem() <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LockTimeout 在超时时间内加锁，返回是否成功
+// LockTimeout acquires the lock within the timeout and reports whether it succeeded
+func (m *Mutex) LockTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.LockContext(ctx) == nil
+}
+
+// RWMutex 支持ctx与超时的读写锁，写锁优先（有写者等待时新的读者会等待），零值可用
+// RWMutex is a reader/writer lock supporting ctx and timeouts. Writers are preferred, new readers
+// wait while a writer is waiting. The zero value is ready to use
+type RWMutex struct {
+	mu      sync.Mutex
+	readers int
+	writer  bool
+	writers int // 等待中的写者 / waiting writers
+	changed chan struct{}
+}
+
+// wait 返回状态变化时关闭的通道，调用时需持有 mu
+// wait returns a channel closed on the next state change, mu must be held
+func (rw *RWMutex) wait() chan struct{} {
+	if rw.changed == nil {
+		rw.changed = make(chan struct{})
+	}
+	return rw.changed
+}
+
+// broadcast 唤醒所有等待者，调用时需持有 mu
+// broadcast wakes every waiter, mu must be held
+func (rw *RWMutex) broadcast() {
+	if rw.changed != nil {
+		close(rw.changed)
+		rw.changed = nil
+	}
+}
+
+func (rw *RWMutex) acquire(ctx context.Context, write, block bool) error {
+	rw.mu.Lock()
+	if write {
+		rw.writers++
+	}
+	for {
+		if write && !rw.writer && rw.readers == 0 {
+			rw.writers--
+			rw.writer = true
+			rw.mu.Unlock()
+			return nil
+		}
+		if !write && !rw.writer && rw.writers == 0 {
+			rw.readers++
+			rw.mu.Unlock()
+			return nil
+		}
+		if !block {
+			rw.giveUp(write)
+			return context.DeadlineExceeded
+		}
+		ch := rw.wait()
+		rw.mu.Unlock()
+		select {
+		case <-ch:
+			rw.mu.Lock()
+		case <-ctx.Done():
+			rw.mu.Lock()
+			rw.giveUp(write)
+			return ctx.Err()
+		}
+	}
+}
+
+// giveUp 放弃等待并释放 mu，写者离开后被阻塞的读者可能可以继续
+// giveUp abandons the wait and releases mu, readers blocked by this writer may proceed
+func (rw *RWMutex) giveUp(write bool) {
+	if write {
+		rw.writers--
+		rw.broadcast()
+	}
+	rw.mu.Unlock()
+}
+
+// Lock 加写锁
+// Lock acquires the write lock
+func (rw *RWMutex) Lock() {
+	_ = rw.acquire(context.Background(), true, true)
+}
+
+// Unlock 释放写锁，未加写锁时panic
+// Unlock releases the write lock, it panics when the write lock is not held
+func (rw *RWMutex) Unlock() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if !rw.writer {
+		panic("lock: unlock of unlocked RWMutex")
+	}
+	rw.writer = false
+	rw.broadcast()
+}
+
+// RLock 加读锁
+// RLock acquires a read lock
+func (rw *RWMutex) RLock() {
+	_ = rw.acquire(context.Background(), false, true)
+}
+
+// RUnlock 释放读锁，未加读锁时panic
+// RUnlock releases a read lock, it panics when no read lock is held
+func (rw *RWMutex) RUnlock() {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.readers == 0 {
+		panic("lock: RUnlock of unlocked RWMutex")
+	}
+	rw.readers--
+	if rw.readers == 0 {
+		rw.broadcast()
+	}
+}
+
+// TryLock 尝试立即加写锁
+// TryLock tries to acquire the write lock without blocking
+func (rw *RWMutex) TryLock() bool {
+	return rw.acquire(context.Background(), true, false) == nil
+}
+
+// TryRLock 尝试立即加读锁
+// TryRLock tries to acquire a read lock without blocking
+func (rw *RWMutex) TryRLock() bool {
+	return rw.acquire(context.Background(), false, false) == nil
+}
+
+// LockContext 加写锁直到成功或ctx结束
+// LockContext acquires the write lock unless ctx is done first
+func (rw *RWMutex) LockContext(ctx context.Context) error {
+	return rw.acquire(ctx, true, true)
+}
+
+// RLockContext 加读锁直到成功或ctx结束
+// RLockContext acquires a read lock unless ctx is done first
+func (rw *RWMutex) RLockContext(ctx context.Context) error {
+	return rw.acquire(ctx, false, true)
+}
+
+// LockTimeout 在超时时间内加写锁，返回是否成功
+// LockTimeout acquires the write lock within the timeout and reports whether it succeeded
+func (rw *RWMutex) LockTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return rw.LockContext(ctx) == nil
+}
+
+// RLockTimeout 在超时时间内加读锁，返回是否成功
+// RLockTimeout acquires a read lock within the timeout and reports whether it succeeded
+func (rw *RWMutex) RLockTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return rw.RLockContext(ctx) == nil
+}
+
+// RLocker 返回以读锁实现的 sync.Locker
+// RLocker returns a sync.Locker backed by the read lock
+func (rw *RWMutex) RLocker() sync.Locker {
+	return rlocker{rw}
+}
+
+type rlocker struct{ rw *RWMutex }
+
+func (r rlocker) Lock()   { r.rw.RLock() }
+func (r rlocker) Unlock() { r.rw.RUnlock() }
@@ -0,0 +1,185 @@
+package lock
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Semaphore 计数信号量，等待者按先进先出顺序获得许可
+// Semaphore is a counting semaphore, waiters are granted permits in FIFO order
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int
+	used    int
+	waiters list.List
+}
+
+type semWaiter struct {
+	n     int
+	ready chan struct{}
+}
+
+// NewSemaphore 创建拥有permits个许可的信号量
+// NewSemaphore creates a semaphore with the given number of permits
+func NewSemaphore(permits int) *Semaphore {
+	return &Semaphore{size: permits}
+}
+
+// Acquire 获取n个许可直到成功或ctx结束；n大于总许可数时只会在ctx结束后返回
+// Acquire obtains n permits unless ctx is done first; asking for more than the total only returns once
+// ctx is done
+func (s *Semaphore) Acquire(ctx context.Context, n int) error {
+	s.mu.Lock()
+	if s.used+n <= s.size && s.waiters.Len() == 0 {
+		s.used += n
+		s.mu.Unlock()
+		return nil
+	}
+	if n > s.size {
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := &semWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// 取消与获得许可同时发生时归还许可 / give the permits back when granted concurrently
+			s.used -= n
+			s.notify()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if isFront {
+				s.notify()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// AcquireTimeout 在超时时间内获取n个许可，返回是否成功
+// AcquireTimeout obtains n permits within the timeout and reports whether it succeeded
+func (s *Semaphore) AcquireTimeout(n int, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Acquire(ctx, n) == nil
+}
+
+// TryAcquire 尝试立即获取n个许可
+// TryAcquire obtains n permits without blocking
+func (s *Semaphore) TryAcquire(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used+n <= s.size && s.waiters.Len() == 0 {
+		s.used += n
+		return true
+	}
+	return false
+}
+
+// Release 归还n个许可，归还超过已获取的数量时panic
+// Release returns n permits, it panics when releasing more than were acquired
+func (s *Semaphore) Release(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used -= n
+	if s.used < 0 {
+		panic("lock: semaphore released more than held")
+	}
+	s.notify()
+}
+
+// Available 当前可用的许可数
+// Available returns the number of free permits
+func (s *Semaphore) Available() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size - s.used
+}
+
+// notify 按顺序唤醒能满足的等待者，调用时需持有 mu
+// notify grants waiters in order while permits suffice, mu must be held
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*semWaiter)
+		if s.used+w.n > s.size {
+			return
+		}
+		s.used += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// CountDownLatch 倒计时门闩，计数归零后所有等待者被唤醒
+// CountDownLatch releases every waiter once its count reaches zero
+type CountDownLatch struct {
+	count atomic.Int64
+	done  chan struct{}
+}
+
+// NewCountDownLatch 创建计数为count的门闩
+// NewCountDownLatch creates a latch with the given count
+func NewCountDownLatch(count int) *CountDownLatch {
+	l := &CountDownLatch{done: make(chan struct{})}
+	l.count.Store(int64(count))
+	if count <= 0 {
+		close(l.done)
+	}
+	return l
+}
+
+// CountDown 计数减一，归零时唤醒所有等待者
+// CountDown decrements the count and releases the waiters when it reaches zero
+func (l *CountDownLatch) CountDown() {
+	if l.count.Add(-1) == 0 {
+		close(l.done)
+	}
+}
+
+// Count 当前计数
+// Count returns the current count
+func (l *CountDownLatch) Count() int {
+	return int(max(l.count.Load(), 0))
+}
+
+// Await 等待计数归零或ctx结束
+// Await waits until the count reaches zero or ctx is done
+func (l *CountDownLatch) Await(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AwaitTimeout 在超时时间内等待计数归零，返回是否归零
+// AwaitTimeout waits for the count to reach zero within the timeout and reports whether it did
+func (l *CountDownLatch) AwaitTimeout(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.Await(ctx) == nil
+}
+
+// Done 计数归零时关闭的通道
+// Done returns a channel that is closed when the count reaches zero
+func (l *CountDownLatch) Done() <-chan struct{} {
+	return l.done
+}
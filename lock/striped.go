@@ -0,0 +1,78 @@
+package lock
+
+import (
+	"hash/maphash"
+	"slices"
+	"sync"
+)
+
+// Striped 分段锁：固定数量的锁按键的哈希分配，内存占用恒定，不同的键可能共享同一把锁
+// Striped holds a fixed number of locks assigned by key hash; memory stays constant and different keys
+// may share a lock
+type Striped[K comparable, L sync.Locker] struct {
+	seed  maphash.Seed
+	locks []L
+}
+
+// NewStriped 创建n段互斥锁分段锁
+// NewStriped creates n striped mutexes
+func NewStriped[K comparable](n int) *Striped[K, *Mutex] {
+	return newStriped[K](n, func() *Mutex { return &Mutex{} })
+}
+
+// NewStripedRW 创建n段读写锁分段锁
+// NewStripedRW creates n striped reader/writer locks
+func NewStripedRW[K comparable](n int) *Striped[K, *RWMutex] {
+	return newStriped[K](n, func() *RWMutex { return &RWMutex{} })
+}
+
+func newStriped[K comparable, L sync.Locker](n int, newLock func() L) *Striped[K, L] {
+	n = max(n, 1)
+	s := &Striped[K, L]{seed: maphash.MakeSeed(), locks: make([]L, n)}
+	for i := range s.locks {
+		s.locks[i] = newLock()
+	}
+	return s
+}
+
+func (s *Striped[K, L]) index(key K) int {
+	return int(maphash.Comparable(s.seed, key) % uint64(len(s.locks)))
+}
+
+// Get 返回键对应的锁
+// Get returns the lock of the key
+func (s *Striped[K, L]) Get(key K) L {
+	return s.locks[s.index(key)]
+}
+
+// At 返回第i段锁
+// At returns the i-th stripe
+func (s *Striped[K, L]) At(i int) L {
+	return s.locks[i]
+}
+
+// Len 分段数量
+// Len returns the number of stripes
+func (s *Striped[K, L]) Len() int {
+	return len(s.locks)
+}
+
+// LockKeys 按段序号依次锁住多个键对应的锁（去重），避免多键加锁时的死锁；返回解锁函数
+// LockKeys locks the stripes of several keys, deduplicated and in stripe order so that multi-key
+// locking cannot deadlock; it returns the unlock function
+func (s *Striped[K, L]) LockKeys(keys ...K) (unlock func()) {
+	idx := make([]int, 0, len(keys))
+	for _, k := range keys {
+		idx = append(idx, s.index(k))
+	}
+	slices.Sort(idx)
+	idx = slices.Compact(idx)
+	for _, i := range idx {
+		s.locks[i].Lock()
+	}
+	return func() {
+		for _, i := range slices.Backward(idx) {
+			s.locks[i].Unlock()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/lock"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试许可语义：先 Unpark 后 Park 不阻塞，许可不累加，ParkNanos 超时
+func TestLockSupportPermit(t *testing.T) {
+	support := lock.NewLockSupport()
+	support.Unpark()
+	support.Unpark()
+	assert.True(t, support.Park())
+	assert.False(t, support.ParkNanos(int64(10*time.Millisecond)))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		support.Unpark()
+	}()
+	assert.True(t, support.ParkUntil(time.Now().Add(time.Second)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, support.ParkContext(ctx), context.DeadlineExceeded)
+}
+
+// 测试 Mutex 与 RWMutex 的超时与ctx
+func TestMutexTimeout(t *testing.T) {
+	var m lock.Mutex
+	m.Lock()
+	assert.False(t, m.TryLock())
+	assert.False(t, m.LockTimeout(10*time.Millisecond))
+	m.Unlock()
+	assert.True(t, m.LockTimeout(10*time.Millisecond))
+	m.Unlock()
+	assert.Panics(t, m.Unlock)
+
+	var rw lock.RWMutex
+	rw.RLock()
+	assert.True(t, rw.TryRLock())
+	assert.False(t, rw.TryLock())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, rw.LockContext(ctx), context.DeadlineExceeded)
+	rw.RUnlock()
+	rw.RUnlock()
+	assert.True(t, rw.LockTimeout(10*time.Millisecond))
+	assert.False(t, rw.RLockTimeout(10*time.Millisecond))
+	rw.Unlock()
+}
+
+// 写者等待时新读者需要等待
+func TestRWMutexWriterPreference(t *testing.T) {
+	var rw lock.RWMutex
+	rw.RLock()
+	acquired := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(acquired)
+		rw.Unlock()
+	}()
+	assert.Eventually(t, func() bool {
+		if rw.TryRLock() {
+			rw.RUnlock()
+			return false
+		}
+		return true
+	}, time.Second, time.Millisecond)
+	rw.RUnlock()
+	<-acquired
+	assert.True(t, rw.TryRLock())
+	rw.RUnlock()
+}
+
+// 测试按键锁的互斥与自动清理
+func TestKeyedMutex(t *testing.T) {
+	km := lock.NewKeyedMutex[string]()
+	counters := map[string]int{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			lock.Synchronized(km.Locker(key), func() {
+				mu.Lock()
+				counters[key]++
+				mu.Unlock()
+			})
+		}([]string{"a", "b", "c"}[i%3])
+	}
+	wg.Wait()
+	assert.Equal(t, 50, counters["a"]+counters["b"]+counters["c"])
+	assert.Equal(t, 0, km.Len())
+
+	km.Lock("x")
+	assert.True(t, km.TryLock("y"))
+	assert.False(t, km.TryLock("x"))
+	assert.False(t, km.LockTimeout("x", 10*time.Millisecond))
+	assert.Equal(t, 2, km.Len())
+	km.Unlock("x")
+	km.Unlock("y")
+	assert.Equal(t, 0, km.Len())
+	assert.Panics(t, func() { km.Unlock("z") })
+
+	krw := lock.NewKeyedRWMutex[int]()
+	krw.RLock(1)
+	assert.True(t, krw.TryRLock(1))
+	assert.False(t, krw.TryLock(1))
+	assert.True(t, krw.TryLock(2))
+	krw.RUnlock(1)
+	krw.RUnlock(1)
+	krw.Unlock(2)
+	assert.Equal(t, 0, krw.Len())
+}
+
+// 测试分段锁的多键加锁
+func TestStriped(t *testing.T) {
+	s := lock.NewStriped[string](8)
+	assert.Equal(t, 8, s.Len())
+	assert.Same(t, s.Get("k"), s.Get("k"))
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := s.LockKeys("a", "b", "c", "a")
+			defer unlock()
+			total.Add(1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(20), total.Load())
+
+	rw := lock.NewStripedRW[int](4)
+	rw.Get(1).RLock()
+	assert.True(t, rw.Get(1).TryRLock())
+	rw.Get(1).RUnlock()
+	rw.Get(1).RUnlock()
+}
+
+// 测试信号量的先进先出与取消
+func TestSemaphore(t *testing.T) {
+	sem := lock.NewSemaphore(3)
+	assert.True(t, sem.TryAcquire(2))
+	assert.False(t, sem.TryAcquire(2))
+	assert.False(t, sem.AcquireTimeout(2, 10*time.Millisecond))
+
+	got := make(chan struct{})
+	go func() {
+		assert.NoError(t, sem.Acquire(context.Background(), 3))
+		close(got)
+	}()
+	assert.Eventually(t, func() bool {
+		if sem.TryAcquire(1) {
+			sem.Release(1)
+			return false
+		}
+		return true
+	}, time.Second, time.Millisecond)
+	sem.Release(2)
+	<-got
+	assert.Equal(t, 0, sem.Available())
+	sem.Release(3)
+	assert.Equal(t, 3, sem.Available())
+	assert.Panics(t, func() { sem.Release(1) })
+}
+
+// 测试倒计时门闩
+func TestCountDownLatch(t *testing.T) {
+	latch := lock.NewCountDownLatch(3)
+	assert.False(t, latch.AwaitTimeout(10*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		go latch.CountDown()
+	}
+	assert.NoError(t, latch.Await(context.Background()))
+	assert.Equal(t, 0, latch.Count())
+	<-latch.Done()
+}
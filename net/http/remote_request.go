@@ -1,15 +1,19 @@
 package remote
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	neturl "net/url"
 	"reflect"
 	"strings"
 	"time"
 
 	"github.com/karosown/katool-go/container/optional"
 	"github.com/karosown/katool-go/net/format/baseformat"
+	"github.com/karosown/katool-go/ratelimit"
+	"github.com/karosown/katool-go/resilience"
 	"github.com/karosown/katool-go/xlog"
 
 	"github.com/go-resty/resty/v2"
@@ -62,6 +66,9 @@ type Req struct {
 	decodeHandler format.EnDeCodeFormat // 请求格式化解析器（bing使用的是xml进行请求响应，google采用的是json
 	httpClient    *resty.Client
 	Logger        xlog.Logger
+	limiter       *ratelimit.Limiter
+	limitKey      string
+	policy        resilience.Policy
 }
 
 func NewReq() *Req {
@@ -141,6 +148,36 @@ func (r *Req) HttpClient(client *resty.Client) *Req {
 	return r
 }
 
+// RateLimit 设置限流器，每次 Build 前等待许可；key 为空时按请求URL的主机名限流
+// RateLimit sets a limiter that Build waits on before every request; an empty key limits per URL host
+func (r *Req) RateLimit(limiter *ratelimit.Limiter, key string) *Req {
+	r.limiter = limiter
+	r.limitKey = key
+	return r
+}
+
+// Resilience 设置容错策略（重试、熔断等），Build 的每次尝试都在策略下执行
+// Resilience sets a fault-tolerance policy such as retry or circuit breaker that every Build attempt runs under
+func (r *Req) Resilience(policy resilience.Policy) *Req {
+	r.policy = policy
+	return r
+}
+
+// waitLimit 等待限流许可
+// waitLimit waits for a rate limit permit
+func (r *Req) waitLimit(ctx context.Context) error {
+	if r.limiter == nil {
+		return nil
+	}
+	key := r.limitKey
+	if key == "" {
+		if u, err := neturl.Parse(r.url); err == nil {
+			key = u.Host
+		}
+	}
+	return r.limiter.Wait(ctx, key)
+}
+
 // DecodeHandler 设置编解码处理器
 // DecodeHandler sets the encode/decode handler
 func (r *Req) DecodeHandler(format format.EnDeCodeFormat) *Req {
@@ -169,6 +206,35 @@ func (r *Req) Build(backDao any) (any, *Error) {
 			Err:       errors.New("back must be a pointer"),
 		}
 	}
+	// 客户端在重试之前创建，避免超时后仍在运行的尝试与重试并发初始化
+	// the client is created before any attempt so a timed-out attempt still running never races a retry on it
+	r.prepare()
+	if r.policy == nil {
+		return r.execute(context.Background(), backDao)
+	}
+	res, err := resilience.Do(context.Background(), r.policy, func(ctx context.Context) (any, error) {
+		res, rerr := r.execute(ctx, backDao)
+		if rerr != nil {
+			return res, rerr
+		}
+		return res, nil
+	})
+	if err == nil {
+		return res, nil
+	}
+	var rerr *Error
+	if errors.As(err, &rerr) && err == error(rerr) {
+		return res, rerr
+	}
+	return res, &Error{
+		HttpErr:   nil,
+		DecodeErr: nil,
+		Err:       err,
+	}
+}
+
+// prepare 初始化默认的HTTP客户端与编解码器 / prepare sets up the default HTTP client and decoder
+func (r *Req) prepare() {
 	if r.httpClient == nil {
 		r.httpClient = resty.New()
 		r.httpClient.SetTimeout(30 * time.Second)
@@ -177,9 +243,20 @@ func (r *Req) Build(backDao any) (any, *Error) {
 	if r.decodeHandler == nil {
 		r.decodeHandler = &baseformat.JSONEnDeCodeFormat{}
 	}
+}
+
+// execute 执行一次请求，ctx 取消时请求随之中止 / execute performs a single request attempt, aborted when ctx is cancelled
+func (r *Req) execute(ctx context.Context, backDao any) (any, *Error) {
+	if err := r.waitLimit(ctx); err != nil {
+		return nil, &Error{
+			HttpErr:   nil,
+			DecodeErr: nil,
+			Err:       err,
+		}
+	}
 	url := r.url
 	data := r.data
-	reqAtomic := r.httpClient.R().SetQueryParams(r.queryParams).SetHeaders(r.headers)
+	reqAtomic := r.httpClient.R().SetContext(ctx).SetQueryParams(r.queryParams).SetHeaders(r.headers)
 	switch strings.ToUpper(r.method) {
 	case "GET":
 		fallthrough
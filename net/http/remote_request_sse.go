@@ -3,6 +3,7 @@ package remote
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/karosown/katool-go/container/optional"
 	"github.com/karosown/katool-go/net/format"
+	"github.com/karosown/katool-go/resilience"
 	"github.com/karosown/katool-go/xlog"
 
 	"github.com/go-resty/resty/v2"
@@ -62,6 +64,7 @@ type SSEReq[T any] struct {
 	done             chan struct{}
 	mu               sync.Mutex
 	isDoneClosed     bool
+	policy           resilience.Policy
 }
 
 // 创建新的SSE请求实例
@@ -138,15 +141,24 @@ func (r *SSEReq[T]) OnError(handler func(err error)) *SSEReq[T] {
 	return r
 }
 
+// Resilience 设置建立连接时的容错策略（重试、熔断等），连接建立后的事件流不受影响
+// Resilience sets a fault-tolerance policy such as retry or circuit breaker for establishing the connection;
+// the event stream after connecting is not affected
+func (r *SSEReq[T]) Resilience(policy resilience.Policy) *SSEReq[T] {
+	r.policy = policy
+	return r
+}
+
 // 连接到SSE服务器
 func (r *SSEReq[T]) Connect() error {
 	// 如果已经连接，先断开
+	r.mu.Lock()
 	if r.isConnected {
+		r.mu.Unlock()
 		return errors.New("已经连接到SSE服务器")
 	}
 
 	// 重置状态
-	r.mu.Lock()
 	if r.isDoneClosed {
 		r.done = make(chan struct{})
 		r.isDoneClosed = false
@@ -170,6 +182,106 @@ func (r *SSEReq[T]) Connect() error {
 	r.headers["Accept"] = "text/event-stream"
 	r.headers["Cache-Control"] = "no-cache"
 
+	var resp *http.Response
+	var err error
+	if r.policy != nil {
+		// 超时后仍在后台完成的尝试，其连接不会被使用，需要关闭
+		// an attempt that completes in the background after a timeout is never used, so its connection is closed
+		var mu sync.Mutex
+		var finished bool
+		var attempts []*http.Response
+		resp, err = resilience.Do(context.Background(), r.policy, func(ctx context.Context) (*http.Response, error) {
+			resp, err := r.dial(ctx)
+			if resp != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if finished {
+					resp.Body.Close()
+					return nil, ctx.Err()
+				}
+				attempts = append(attempts, resp)
+			}
+			return resp, err
+		})
+		mu.Lock()
+		finished = true
+		for _, a := range attempts {
+			if a != resp || err != nil {
+				a.Body.Close()
+			}
+		}
+		mu.Unlock()
+	} else {
+		resp, err = r.dial(context.Background())
+	}
+	if err != nil {
+		return err
+	}
+
+	// 保存响应和创建reader
+	r.mu.Lock()
+	r.response = resp
+	r.reader = bufio.NewReader(resp.Body)
+	r.isConnected = true
+	r.mu.Unlock()
+
+	// 通知连接已建立
+	if r.connectedHandler != nil {
+		if err := r.connectedHandler(); err != nil {
+			r.Disconnect()
+			return err
+		}
+	}
+
+	// 启动事件处理循环
+	go r.processEvents()
+
+	return nil
+}
+
+// dial 发起请求并检查响应状态，设置了容错策略时每次尝试都会调用。ctx 只约束建立连接，
+// 连接建立后事件流不再受其取消的影响；4xx（408、429除外）标记为不可重试
+// dial sends the request and checks the response status; with a policy set it runs once per attempt. ctx
+// only bounds establishing the connection, the event stream is not cut off when it is cancelled later;
+// 4xx responses other than 408 and 429 are marked as not retryable
+func (r *SSEReq[T]) dial(ctx context.Context) (*http.Response, error) {
+	// 请求使用与 ctx 脱离的上下文，建立连接期间 ctx 取消时才中止请求
+	// the request runs on a context detached from ctx, which only aborts it while the connection is being established
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	resp, err := r.send(reqCtx)
+	if !stop() {
+		// ctx 已取消 / ctx was cancelled
+		if resp != nil {
+			resp.Body.Close()
+		}
+		cancel()
+		if err == nil {
+			err = context.Cause(ctx)
+		}
+		return nil, err
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody 关闭时同时释放请求的上下文 / cancelBody also releases the request context when closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// send 发送一次请求并检查响应状态 / send sends the request once and checks the response status
+func (r *SSEReq[T]) send(ctx context.Context) (*http.Response, error) {
 	// 创建HTTP请求
 	var httpReq *http.Request
 	var err error
@@ -191,21 +303,21 @@ func (r *SSEReq[T]) Connect() error {
 				if r.errorHandler != nil {
 					r.errorHandler(err)
 				}
-				return fmt.Errorf("无法序列化请求数据: %v", err)
+				return nil, fmt.Errorf("无法序列化请求数据: %v", err)
 			}
 			body = bytes.NewReader(jsonData)
 		}
 
-		httpReq, err = http.NewRequest(r.method, r.url, body)
+		httpReq, err = http.NewRequestWithContext(ctx, r.method, r.url, body)
 	} else {
-		httpReq, err = http.NewRequest(r.method, r.url, nil)
+		httpReq, err = http.NewRequestWithContext(ctx, r.method, r.url, nil)
 	}
 
 	if err != nil {
 		if r.errorHandler != nil {
 			r.errorHandler(err)
 		}
-		return err
+		return nil, err
 	}
 
 	// 添加查询参数
@@ -227,7 +339,7 @@ func (r *SSEReq[T]) Connect() error {
 		if r.errorHandler != nil {
 			r.errorHandler(err)
 		}
-		return err
+		return nil, err
 	}
 
 	// 检查响应状态
@@ -237,38 +349,24 @@ func (r *SSEReq[T]) Connect() error {
 		if r.errorHandler != nil {
 			r.errorHandler(err)
 		}
-		return err
-	}
-
-	// 保存响应和创建reader
-	r.response = resp
-	r.reader = bufio.NewReader(resp.Body)
-	r.isConnected = true
-
-	// 通知连接已建立
-	if r.connectedHandler != nil {
-		if err := r.connectedHandler(); err != nil {
-			r.Disconnect()
-			return err
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return nil, resilience.Permanent(err)
 		}
+		return nil, err
 	}
-
-	// 启动事件处理循环
-	go r.processEvents()
-
-	return nil
+	return resp, nil
 }
 
 // 断开SSE连接
 func (r *SSEReq[T]) Disconnect() error {
+	// 使用互斥锁保护关闭操作，事件循环结束时也会调用
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if !r.isConnected {
 		return nil
 	}
 
-	// 使用互斥锁保护关闭操作
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	// 确保通道只被关闭一次
 	if !r.isDoneClosed {
 		close(r.done)
@@ -301,13 +399,16 @@ func (r *SSEReq[T]) processEvents() {
 	}()
 
 	var event SSEEvent[T]
+	r.mu.Lock()
+	reader, done := r.reader, r.done
+	r.mu.Unlock()
 
 	for {
 		select {
-		case <-r.done:
+		case <-done:
 			return
 		default:
-			line, err := r.reader.ReadString('\n')
+			line, err := reader.ReadString('\n')
 			if err != nil {
 				if err == io.EOF {
 					if r.Logger != nil {
@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"context"
+	"errors"
+)
+
+// Future 异步任务的类型化结果句柄
+// Future is a typed handle to the result of an asynchronous task
+type Future[R any] struct {
+	done  chan struct{}
+	value R
+	err   error
+}
+
+func newFuture[R any]() *Future[R] {
+	return &Future[R]{done: make(chan struct{})}
+}
+
+func (f *Future[R]) complete(value R, err error) {
+	f.value, f.err = value, err
+	close(f.done)
+}
+
+// Get 等待任务完成并返回结果，ctx结束时返回 ctx.Err()，任务本身不会被取消
+// Get waits for the task and returns its result, it returns ctx.Err() when ctx is done first without
+// cancelling the task
+func (f *Future[R]) Get(ctx context.Context) (R, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero R
+		return zero, ctx.Err()
+	}
+}
+
+// Wait 等待任务完成并返回结果
+// Wait blocks until the task finishes and returns its result
+func (f *Future[R]) Wait() (R, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// Done 任务完成时关闭的通道
+// Done returns a channel that is closed when the task finishes
+func (f *Future[R]) Done() <-chan struct{} {
+	return f.done
+}
+
+// IsDone 任务是否已完成
+// IsDone reports whether the task has finished
+func (f *Future[R]) IsDone() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Submit 提交有返回值的任务并返回 Future；panic 以 *PanicError 作为结果错误，
+// 任务在执行前被丢弃（ctx结束或 ShutdownNow）时结果错误为对应原因
+// Submit submits a task with a result and returns its Future. A panic becomes a *PanicError result
+// error, a task discarded before running (ctx done or ShutdownNow) completes with the cause
+func Submit[R any](ctx context.Context, p *Pool, fn func(ctx context.Context) (R, error)) (*Future[R], error) {
+	f, t := futureTask(ctx, fn)
+	if err := p.submit(ctx, t, true); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// TrySubmit 与 Submit 相同，但只在有空闲容量时提交，不阻塞也不应用策略
+// TrySubmit is like Submit but only submits when there is spare capacity, it neither blocks nor
+// applies the policy
+func TrySubmit[R any](ctx context.Context, p *Pool, fn func(ctx context.Context) (R, error)) (*Future[R], bool) {
+	f, t := futureTask(ctx, fn)
+	if p.submit(ctx, t, false) != nil {
+		return nil, false
+	}
+	return f, true
+}
+
+func futureTask[R any](ctx context.Context, fn func(ctx context.Context) (R, error)) (*Future[R], task) {
+	f := newFuture[R]()
+	return f, task{
+		ctx: ctx,
+		fn: func(ctx context.Context) (err error) {
+			var value R
+			defer func() {
+				if r := recover(); r != nil {
+					pe := NewPanicError(r)
+					f.complete(value, pe)
+					panic(pe)
+				}
+				f.complete(value, err)
+			}()
+			value, err = fn(ctx)
+			return err
+		},
+		drop: func(err error) {
+			var zero R
+			f.complete(zero, err)
+		},
+	}
+}
+
+// All 等待全部 Future 完成，按顺序返回结果，并用 errors.Join 合并所有错误
+// All waits for every future and returns the results in order, all errors are combined with errors.Join
+func All[R any](ctx context.Context, futures ...*Future[R]) ([]R, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results := make([]R, len(futures))
+	errs := make([]error, 0)
+	for i, f := range futures {
+		value, err := f.Get(ctx)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+				return results, err
+			}
+			errs = append(errs, err)
+		}
+		results[i] = value
+	}
+	return results, errors.Join(errs...)
+}
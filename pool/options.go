@@ -0,0 +1,90 @@
+package pool
+
+import (
+	"runtime"
+	"time"
+)
+
+// Policy 队列已满时的提交策略
+// Policy decides what happens when the queue is full
+type Policy int
+
+const (
+	// PolicyBlock 阻塞直到队列有空位或提交的ctx结束，默认策略
+	// PolicyBlock blocks until the queue has room or the submit ctx is done; it is the default
+	PolicyBlock Policy = iota
+	// PolicyReject 立即返回 ErrRejected
+	// PolicyReject returns ErrRejected immediately
+	PolicyReject
+	// PolicyCallerRuns 在提交者的协程中直接执行，自然地降低提交速度
+	// PolicyCallerRuns runs the task on the submitting goroutine, which slows submission down
+	PolicyCallerRuns
+)
+
+// Options 协程池配置
+// Options configures the pool
+type Options struct {
+	CoreWorkers  int           // 常驻协程数，至少为1 / resident workers, at least 1
+	MaxWorkers   int           // 最大协程数，大于 CoreWorkers 时为弹性池 / max workers, elastic when greater than CoreWorkers
+	KeepAlive    time.Duration // 弹性协程的空闲存活时间 / how long an idle elastic worker lives
+	QueueSize    int           // 等待队列容量 / capacity of the waiting queue
+	Policy       Policy        // 队列已满时的策略 / policy when the queue is full
+	PanicHandler func(*PanicError)
+}
+
+// Option 函数式选项
+// Option is a functional option
+type Option func(*Options)
+
+// WithWorkers 固定协程数
+// WithWorkers uses a fixed number of workers
+func WithWorkers(n int) Option {
+	return func(o *Options) {
+		o.CoreWorkers, o.MaxWorkers = n, n
+	}
+}
+
+// WithElastic 弹性协程数：常驻core个，繁忙时最多扩展到max个，扩展的协程空闲keepAlive后退出
+// WithElastic keeps core workers resident and grows up to max when busy, extra workers exit after
+// being idle for keepAlive
+func WithElastic(core, max int, keepAlive time.Duration) Option {
+	return func(o *Options) {
+		o.CoreWorkers, o.MaxWorkers, o.KeepAlive = core, max, keepAlive
+	}
+}
+
+// WithQueueSize 设置等待队列容量
+// WithQueueSize sets the capacity of the waiting queue
+func WithQueueSize(n int) Option {
+	return func(o *Options) { o.QueueSize = n }
+}
+
+// WithPolicy 设置队列已满时的策略
+// WithPolicy sets the policy used when the queue is full
+func WithPolicy(policy Policy) Option {
+	return func(o *Options) { o.Policy = policy }
+}
+
+// WithPanicHandler 设置任务panic时的回调，panic总会被恢复并作为任务错误返回
+// WithPanicHandler sets a callback for task panics, panics are always recovered and reported as the task error
+func WithPanicHandler(handler func(*PanicError)) Option {
+	return func(o *Options) { o.PanicHandler = handler }
+}
+
+func newOptions(opts []Option) Options {
+	o := Options{
+		CoreWorkers: runtime.GOMAXPROCS(0),
+		KeepAlive:   time.Minute,
+		QueueSize:   1024,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.CoreWorkers = max(o.CoreWorkers, 1)
+	o.MaxWorkers = max(o.MaxWorkers, o.CoreWorkers)
+	o.QueueSize = max(o.QueueSize, 0)
+	if o.KeepAlive <= 0 {
+		o.KeepAlive = time.Minute
+	}
+	return o
+}
@@ -0,0 +1,346 @@
+package pool
+
+// 通用协程池：固定或弹性协程数、有界等待队列（阻塞/拒绝/调用者执行）、类型化的 Future、
+// 任务 ctx 与 panic 恢复、优雅关闭与立即关闭，以及队列深度与活跃协程等指标
+//
+// Package pool is a generic worker pool with fixed or elastic workers, a bounded queue with
+// block/reject/caller-runs policies, typed futures, task contexts and panic recovery, graceful and
+// immediate shutdown, and metrics such as queue depth and active workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrClosed 池已关闭
+	// ErrClosed is returned when the pool is shut down
+	ErrClosed = errors.New("pool: closed")
+	// ErrRejected 队列已满且策略为 PolicyReject
+	// ErrRejected is returned when the queue is full under PolicyReject
+	ErrRejected = errors.New("pool: rejected")
+)
+
+// PanicError 被恢复的panic，池任务、stream 算子与 optional.Try 共用此类型
+// PanicError is a recovered panic, shared by pool tasks, stream operators and optional.Try
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered: %v", e.Value)
+}
+
+// NewPanicError 用 recover() 的返回值构造 PanicError
+// NewPanicError builds a PanicError from the value returned by recover()
+func NewPanicError(value any) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+// call 执行任务并把panic转换为 *PanicError
+// call runs the task and turns a panic into a *PanicError
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if pe, ok := r.(*PanicError); ok {
+				err = pe
+				return
+			}
+			err = NewPanicError(r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// task 排队中的任务，drop 在任务被丢弃而未执行时调用
+// task is a queued task, drop is called when it is discarded without running
+type task struct {
+	ctx  context.Context
+	fn   func(ctx context.Context) error
+	drop func(err error)
+}
+
+// Stats 协程池指标快照
+// Stats is a snapshot of the pool metrics
+type Stats struct {
+	Workers   int   // 当前协程数 / current workers
+	Idle      int   // 空闲协程数 / idle workers
+	Active    int   // 正在执行任务的数量 / tasks running right now
+	Queued    int   // 队列深度 / queue depth
+	Submitted int64 // 已接受的任务数 / accepted tasks
+	Completed int64 // 成功完成的任务数 / tasks that returned nil
+	Failed    int64 // 返回错误或panic的任务数 / tasks that returned an error or panicked
+	Panics    int64 // panic的任务数 / tasks that panicked
+	Rejected  int64 // 被拒绝的提交数 / rejected submissions
+	Dropped   int64 // 立即关闭时被丢弃的任务数 / tasks dropped by ShutdownNow
+}
+
+// Pool 协程池，并发安全
+// Pool is a worker pool, safe for concurrent use
+type Pool struct {
+	opts  Options
+	queue chan task
+
+	mu     sync.RWMutex
+	closed bool
+	// closing 在 Shutdown 取写锁前关闭，唤醒阻塞中的提交者使其释放读锁
+	// closing is closed before Shutdown takes the write lock, waking blocked submitters so they release the read lock
+	closing   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopped   atomic.Bool
+
+	workers, idle, active                                   atomic.Int64
+	submitted, completed, failed, panics, rejected, dropped atomic.Int64
+}
+
+// New 创建协程池，默认 GOMAXPROCS 个固定协程、容量1024的阻塞队列
+// New creates a pool, by default with GOMAXPROCS fixed workers and a blocking queue of 1024
+func New(opts ...Option) *Pool {
+	o := newOptions(opts)
+	p := &Pool{opts: o, queue: make(chan task, o.QueueSize), closing: make(chan struct{})}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.workers.Store(int64(o.CoreWorkers))
+	p.wg.Add(o.CoreWorkers)
+	for i := 0; i < o.CoreWorkers; i++ {
+		go p.worker(nil, true)
+	}
+	return p
+}
+
+// Execute 提交任务，队列已满时按策略处理；任务收到的ctx在提交的ctx结束或 ShutdownNow 时取消
+// Execute submits a task and applies the policy when the queue is full; the task ctx is cancelled when
+// the submit ctx is done or on ShutdownNow
+func (p *Pool) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	return p.submit(ctx, task{ctx: ctx, fn: fn}, true)
+}
+
+// TryExecute 仅当有空闲容量时提交任务，不阻塞也不应用策略；适合在池内任务中再提交子任务，
+// 返回 false 时调用者可以自行执行以避免死锁
+// TryExecute submits only when there is spare capacity, it neither blocks nor applies the policy.
+// It suits tasks that fan out from inside the pool: on false the caller can run the work itself and
+// avoid a deadlock
+func (p *Pool) TryExecute(ctx context.Context, fn func(ctx context.Context) error) bool {
+	return p.submit(ctx, task{ctx: ctx, fn: fn}, false) == nil
+}
+
+var errFull = errors.New("pool: queue full")
+
+func (p *Pool) submit(ctx context.Context, t task, apply bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+		t.ctx = ctx
+	}
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrClosed
+	}
+	if p.idle.Load() == 0 && p.spawn(&t) {
+		p.mu.RUnlock()
+		p.submitted.Add(1)
+		return nil
+	}
+	select {
+	case p.queue <- t:
+		p.mu.RUnlock()
+		p.submitted.Add(1)
+		return nil
+	default:
+	}
+	if !apply {
+		p.mu.RUnlock()
+		return errFull
+	}
+	switch p.opts.Policy {
+	case PolicyReject:
+		p.mu.RUnlock()
+		p.rejected.Add(1)
+		return ErrRejected
+	case PolicyCallerRuns:
+		p.mu.RUnlock()
+		p.submitted.Add(1)
+		p.run(t)
+		return nil
+	default:
+		// 持有读锁阻塞，保证关闭队列前不会有发送者；Shutdown 先关闭 closing，阻塞的发送者随即退出并释放读锁，
+		// 等待中的写锁因此不会挡住池内任务的嵌套提交
+		// Blocking under the read lock keeps senders off a closed queue. Shutdown closes closing first, so
+		// blocked senders leave and release the read lock, and the pending write lock never stalls nested
+		// submits from running tasks
+		defer p.mu.RUnlock()
+		select {
+		case p.queue <- t:
+			p.submitted.Add(1)
+			return nil
+		case <-p.closing:
+			return ErrClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// spawn 未达到最大协程数时启动一个弹性协程直接执行first
+// spawn starts an elastic worker that runs first directly unless the max is reached
+func (p *Pool) spawn(first *task) bool {
+	for {
+		n := p.workers.Load()
+		if n >= int64(p.opts.MaxWorkers) {
+			return false
+		}
+		if p.workers.CompareAndSwap(n, n+1) {
+			break
+		}
+	}
+	p.wg.Add(1)
+	go p.worker(first, false)
+	return true
+}
+
+// worker 常驻协程一直运行到队列关闭，弹性协程空闲 KeepAlive 后退出
+// worker runs until the queue is closed, elastic workers also exit after being idle for KeepAlive
+func (p *Pool) worker(first *task, core bool) {
+	defer p.wg.Done()
+	defer p.workers.Add(-1)
+	if first != nil {
+		p.run(*first)
+	}
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if !core {
+		timer = time.NewTimer(p.opts.KeepAlive)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		if timer != nil {
+			timer.Reset(p.opts.KeepAlive)
+		}
+		p.idle.Add(1)
+		select {
+		case t, ok := <-p.queue:
+			p.idle.Add(-1)
+			if !ok {
+				return
+			}
+			p.run(t)
+		case <-timeout:
+			p.idle.Add(-1)
+			return
+		}
+	}
+}
+
+// run 执行任务：ShutdownNow 之后丢弃，ctx已结束时不执行
+// run executes a task: it is dropped after ShutdownNow and skipped when its ctx is already done
+func (p *Pool) run(t task) {
+	if p.stopped.Load() {
+		p.dropped.Add(1)
+		if t.drop != nil {
+			t.drop(ErrClosed)
+		}
+		return
+	}
+	p.active.Add(1)
+	defer p.active.Add(-1)
+
+	ctx, cancel := context.WithCancelCause(t.ctx)
+	stop := context.AfterFunc(p.ctx, func() { cancel(ErrClosed) })
+	defer func() {
+		stop()
+		cancel(nil)
+	}()
+
+	var err error
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+		if t.drop != nil {
+			t.drop(err)
+		}
+	} else {
+		err = call(ctx, t.fn)
+	}
+	if err == nil {
+		p.completed.Add(1)
+		return
+	}
+	p.failed.Add(1)
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		p.panics.Add(1)
+		if p.opts.PanicHandler != nil {
+			p.opts.PanicHandler(pe)
+		}
+	}
+}
+
+// Shutdown 停止接受新任务，等待已提交的任务全部完成或ctx结束
+// Shutdown stops accepting tasks and waits until the submitted ones finish or ctx is done
+func (p *Pool) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.closeOnce.Do(func() { close(p.closing) })
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownNow 停止接受新任务，取消正在执行任务的ctx并丢弃排队中的任务（其 Future 以 ErrClosed 完成），
+// 然后等待协程退出或ctx结束
+// ShutdownNow stops accepting tasks, cancels the ctx of running tasks and drops the queued ones (their
+// futures complete with ErrClosed), then waits for the workers to exit or ctx to be done
+func (p *Pool) ShutdownNow(ctx context.Context) error {
+	p.stopped.Store(true)
+	p.cancel()
+	return p.Shutdown(ctx)
+}
+
+// Closed 是否已关闭
+// Closed reports whether the pool is shut down
+func (p *Pool) Closed() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.closed
+}
+
+// Stats 返回指标快照
+// Stats returns a snapshot of the metrics
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   int(p.workers.Load()),
+		Idle:      int(p.idle.Load()),
+		Active:    int(p.active.Load()),
+		Queued:    len(p.queue),
+		Submitted: p.submitted.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Panics:    p.panics.Load(),
+		Rejected:  p.rejected.Load(),
+		Dropped:   p.dropped.Load(),
+	}
+}
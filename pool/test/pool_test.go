@@ -0,0 +1,244 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/collect/lists"
+	"github.com/karosown/katool-go/container/stream"
+	"github.com/karosown/katool-go/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试固定协程池的 Future 与统计
+func TestPoolSubmit(t *testing.T) {
+	p := pool.New(pool.WithWorkers(4))
+	defer p.Shutdown(context.Background())
+
+	futures := make([]*pool.Future[int], 0)
+	for i := 0; i < 100; i++ {
+		f, err := pool.Submit(context.Background(), p, func(ctx context.Context) (int, error) {
+			return i * i, nil
+		})
+		assert.NoError(t, err)
+		futures = append(futures, f)
+	}
+	results, err := pool.All(context.Background(), futures...)
+	assert.NoError(t, err)
+	for i, r := range results {
+		assert.Equal(t, i*i, r)
+	}
+	stats := p.Stats()
+	assert.Equal(t, 4, stats.Workers)
+	assert.Equal(t, int64(100), stats.Submitted)
+	assert.Equal(t, int64(100), stats.Completed)
+}
+
+// 测试panic恢复与错误返回
+func TestPoolPanic(t *testing.T) {
+	var handled atomic.Int32
+	p := pool.New(pool.WithWorkers(1), pool.WithPanicHandler(func(*pool.PanicError) { handled.Add(1) }))
+	defer p.Shutdown(context.Background())
+
+	f, err := pool.Submit(context.Background(), p, func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	assert.NoError(t, err)
+	_, err = f.Wait()
+	var pe *pool.PanicError
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, "boom", pe.Value)
+
+	f, _ = pool.Submit(context.Background(), p, func(ctx context.Context) (int, error) {
+		return 0, errors.New("fail")
+	})
+	_, err = f.Wait()
+	assert.EqualError(t, err, "fail")
+	assert.Equal(t, int32(1), handled.Load())
+	stats := p.Stats()
+	assert.Equal(t, int64(2), stats.Failed)
+	assert.Equal(t, int64(1), stats.Panics)
+}
+
+// 测试队列已满时的三种策略
+func TestPoolPolicies(t *testing.T) {
+	block := make(chan struct{})
+	occupy := func(p *pool.Pool) {
+		started := make(chan struct{})
+		assert.NoError(t, p.Execute(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-block
+			return nil
+		}))
+		<-started
+		// 填满容量为1的队列 / fill the queue of size 1
+		assert.NoError(t, p.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+	}
+	defer close(block)
+
+	reject := pool.New(pool.WithWorkers(1), pool.WithQueueSize(1), pool.WithPolicy(pool.PolicyReject))
+	occupy(reject)
+	assert.ErrorIs(t, reject.Execute(context.Background(), func(ctx context.Context) error { return nil }), pool.ErrRejected)
+	assert.Equal(t, int64(1), reject.Stats().Rejected)
+	assert.Equal(t, 1, reject.Stats().Queued)
+	assert.Equal(t, 1, reject.Stats().Active)
+
+	callerRuns := pool.New(pool.WithWorkers(1), pool.WithQueueSize(1), pool.WithPolicy(pool.PolicyCallerRuns))
+	occupy(callerRuns)
+	ran := false
+	assert.NoError(t, callerRuns.Execute(context.Background(), func(ctx context.Context) error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+
+	blocking := pool.New(pool.WithWorkers(1), pool.WithQueueSize(1))
+	occupy(blocking)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, blocking.Execute(ctx, func(ctx context.Context) error { return nil }), context.DeadlineExceeded)
+}
+
+// 测试弹性协程的扩容与空闲回收
+func TestPoolElastic(t *testing.T) {
+	p := pool.New(pool.WithElastic(1, 4, 20*time.Millisecond), pool.WithQueueSize(0))
+	defer p.Shutdown(context.Background())
+
+	release := make(chan struct{})
+	var started atomic.Int32
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, p.Execute(context.Background(), func(ctx context.Context) error {
+			started.Add(1)
+			<-release
+			return nil
+		}))
+	}
+	assert.Eventually(t, func() bool { return started.Load() == 4 }, time.Second, time.Millisecond)
+	assert.Equal(t, 4, p.Stats().Workers)
+	assert.Equal(t, 4, p.Stats().Active)
+	close(release)
+	assert.Eventually(t, func() bool { return p.Stats().Workers == 1 }, time.Second, 5*time.Millisecond)
+}
+
+// 测试优雅关闭与立即关闭
+func TestPoolShutdown(t *testing.T) {
+	p := pool.New(pool.WithWorkers(2))
+	var done atomic.Int32
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, p.Execute(context.Background(), func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return nil
+		}))
+	}
+	assert.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, int32(10), done.Load())
+	assert.ErrorIs(t, p.Execute(context.Background(), func(ctx context.Context) error { return nil }), pool.ErrClosed)
+
+	p = pool.New(pool.WithWorkers(1))
+	started := make(chan struct{})
+	running, _ := pool.Submit(context.Background(), p, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	})
+	<-started
+	queued, _ := pool.Submit(context.Background(), p, func(ctx context.Context) (int, error) { return 1, nil })
+	assert.NoError(t, p.ShutdownNow(context.Background()))
+	_, err := running.Wait()
+	assert.ErrorIs(t, err, pool.ErrClosed)
+	_, err = queued.Wait()
+	assert.ErrorIs(t, err, pool.ErrClosed)
+	assert.Equal(t, int64(1), p.Stats().Dropped)
+}
+
+// 测试关闭时阻塞的提交者不会让池内任务的嵌套提交死锁
+func TestPoolShutdownWithBlockedSubmitter(t *testing.T) {
+	p := pool.New(pool.WithWorkers(1), pool.WithQueueSize(1))
+	gate := make(chan struct{})
+	nested := make(chan bool, 1)
+	assert.NoError(t, p.Execute(context.Background(), func(ctx context.Context) error {
+		<-gate
+		nested <- p.TryExecute(ctx, func(context.Context) error { return nil })
+		return nil
+	}))
+	assert.NoError(t, p.Execute(context.Background(), func(context.Context) error { return nil }))
+
+	// 队列已满，该提交者持有读锁阻塞 / the queue is full, so this submitter blocks under the read lock
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- p.Execute(context.Background(), func(context.Context) error { return nil })
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	close(gate)
+
+	select {
+	case err := <-shutdown:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown deadlocked")
+	}
+	assert.ErrorIs(t, <-blocked, pool.ErrClosed)
+	assert.False(t, <-nested)
+}
+
+// 测试 RunWorker 与 RunBackErrWorker
+func TestRunWorker(t *testing.T) {
+	p := pool.New(pool.WithWorkers(3))
+	defer p.Shutdown(context.Background())
+
+	w := &pool.RunWorker[int, string, []int]{Pool: p}
+	w.Reader([]int{1, 2, 3}).Work(func(a int) string { return string(rune('a' + a - 1)) })
+	assert.NoError(t, w.Err())
+	assert.Equal(t, []string{"a", "b", "c"}, w.Results())
+
+	one, two := 1, 2
+	e := &pool.RunBackErrWorker[int, int, []*int]{}
+	e.Reader([]*int{&one, &two, nil}).Work(func(a int) (int, error) {
+		if a == 0 {
+			return 0, errors.New("zero")
+		}
+		return a * 10, nil
+	})
+	assert.EqualError(t, e.Err(), "zero")
+	assert.Equal(t, []int{10, 20, 0}, e.Results())
+}
+
+// 测试分片在协程池中执行，以及并行流使用公共协程池
+func TestBatchAndStreamWithPool(t *testing.T) {
+	p := pool.New(pool.WithWorkers(2), pool.WithQueueSize(1))
+	defer p.Shutdown(context.Background())
+
+	datas := make([]int, 100)
+	for i := range datas {
+		datas[i] = i
+	}
+	var sum atomic.Int64
+	err := lists.Partition(datas, 7).ForEachWithPool(context.Background(), p, func(pos int, part []int) error {
+		for _, v := range part {
+			sum.Add(int64(v))
+		}
+		if pos == 3 {
+			return errors.New("batch 3")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "batch 3")
+	assert.Equal(t, int64(4950), sum.Load())
+
+	stream.SetCommonPool(p)
+	defer stream.SetCommonPool(nil)
+	res := stream.ToStream(&datas).Parallel().Map(func(i int) any { return i * 2 }).ToList()
+	expected := make([]any, 0, len(datas))
+	for _, v := range datas {
+		expected = append(expected, v*2)
+	}
+	assert.ElementsMatch(t, expected, res)
+}
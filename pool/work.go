@@ -1,26 +1,150 @@
 package pool
 
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Worker 批量任务：Reader 读入数据，Work 用协程池并发处理每个元素并阻塞到全部完成
+// Worker is a batch job: Reader loads the data and Work processes every element on a pool, blocking
+// until all of them finish
 type Worker[T any, R any, F func(t T) (R, error) | func(t T) R, IN []T | []*T] interface {
 	Reader(IN) Worker[T, R, F, IN]
 	Work(F)
 }
 
+// RunWorker 并发处理每个元素并按输入顺序收集结果；Pool 为空时使用临时的 GOMAXPROCS 协程池，
+// []*T 输入中的 nil 元素按零值处理
+// RunWorker processes every element concurrently and collects the results in input order. A temporary
+// GOMAXPROCS pool is used when Pool is nil, nil elements of a []*T input are treated as zero values
 type RunWorker[T any, R any, IN []T | []*T] struct {
-}
+	Pool *Pool
+	Ctx  context.Context
 
-func (d *RunWorker[T, R, IN]) Work(func(a T) R) {
+	datas   []T
+	results []R
+	err     error
+}
 
+func (d *RunWorker[T, R, IN]) Work(fn func(a T) R) {
+	d.results, d.err = work(d.Ctx, d.Pool, d.datas, func(a T) (R, error) { return fn(a), nil })
 }
+
 func (d *RunWorker[T, R, IN]) Reader(a IN) Worker[T, R, func(a T) R, IN] {
+	d.datas = deref[T](a)
 	return d
 }
 
-type RunBackErrWorker[T any, R any, IN []T | []*T] struct {
+// Results 按输入顺序返回结果
+// Results returns the results in input order
+func (d *RunWorker[T, R, IN]) Results() []R {
+	return d.results
 }
 
-func (d *RunBackErrWorker[T, R, IN]) Work(func(a T) (R, error)) {
+// Err 返回任务panic或提交失败等错误
+// Err returns errors such as task panics or failed submissions
+func (d *RunWorker[T, R, IN]) Err() error {
+	return d.err
+}
+
+// RunBackErrWorker 与 RunWorker 相同，但处理函数可以返回错误，所有错误用 errors.Join 合并
+// RunBackErrWorker is like RunWorker but the function may return an error, all errors are combined
+// with errors.Join
+type RunBackErrWorker[T any, R any, IN []T | []*T] struct {
+	Pool *Pool
+	Ctx  context.Context
+
+	datas   []T
+	results []R
+	err     error
+}
 
+func (d *RunBackErrWorker[T, R, IN]) Work(fn func(a T) (R, error)) {
+	d.results, d.err = work(d.Ctx, d.Pool, d.datas, fn)
 }
+
 func (d *RunBackErrWorker[T, R, IN]) Reader(a IN) Worker[T, R, func(a T) (R, error), IN] {
+	d.datas = deref[T](a)
 	return d
 }
+
+// Results 按输入顺序返回结果，失败元素为零值
+// Results returns the results in input order, failed elements hold zero values
+func (d *RunBackErrWorker[T, R, IN]) Results() []R {
+	return d.results
+}
+
+// Err 返回合并后的错误
+// Err returns the combined error
+func (d *RunBackErrWorker[T, R, IN]) Err() error {
+	return d.err
+}
+
+func deref[T any, IN []T | []*T](in IN) []T {
+	switch v := any(in).(type) {
+	case []T:
+		return v
+	case []*T:
+		res := make([]T, len(v))
+		for i, p := range v {
+			if p != nil {
+				res[i] = *p
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func work[T, R any](ctx context.Context, p *Pool, datas []T, fn func(a T) (R, error)) ([]R, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p == nil {
+		p = New()
+		defer p.Shutdown(context.Background())
+	}
+	results := make([]R, len(datas))
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	addErr := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	for i, data := range datas {
+		wg.Add(1)
+		err := p.submit(ctx, task{
+			ctx: ctx,
+			fn: func(ctx context.Context) error {
+				defer wg.Done()
+				err := call(ctx, func(context.Context) error {
+					res, err := fn(data)
+					if err == nil {
+						results[i] = res
+					}
+					return err
+				})
+				if err != nil {
+					addErr(err)
+				}
+				return err
+			},
+			drop: func(err error) {
+				addErr(err)
+				wg.Done()
+			},
+		}, true)
+		if err != nil {
+			wg.Done()
+			addErr(err)
+			break
+		}
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
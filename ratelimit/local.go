@@ -0,0 +1,302 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery 每多少次获取清理一次空闲键
+// sweepEvery is how many takes happen between sweeps of idle keys
+const sweepEvery = 1024
+
+// state 单个key的算法状态
+// state is the algorithm state of one key
+type state interface {
+	take(now time.Time, n int, maxWait time.Duration) Reservation
+	// idle 状态已等价于初始状态，可以删除 / the state equals a fresh one and can be dropped
+	idle(now time.Time) bool
+}
+
+// stateCanceler 可以归还许可的算法状态
+// stateCanceler is an algorithm state that can give permits back
+type stateCanceler interface {
+	cancel(now time.Time, n int)
+}
+
+// local 内存存储，空闲的key会被定期清理
+// local is the in-memory backend, idle keys are swept periodically
+type local struct {
+	mu       sync.Mutex
+	states   map[string]state
+	newState func() state
+	now      func() time.Time
+	ops      int
+}
+
+func newLocal(opts []Option, newState func() state) *Limiter {
+	o := newOptions(opts)
+	return New(&local{states: make(map[string]state), newState: newState, now: o.now})
+}
+
+func (l *local) Take(_ context.Context, key string, n int, maxWait time.Duration) (Reservation, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if l.ops++; l.ops%sweepEvery == 0 {
+		for k, s := range l.states {
+			if s.idle(now) {
+				delete(l.states, k)
+			}
+		}
+	}
+	s, ok := l.states[key]
+	if !ok {
+		s = l.newState()
+		l.states[key] = s
+	}
+	return s.take(now, n, maxWait), nil
+}
+
+func (l *local) Cancel(_ context.Context, key string, n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.states[key].(stateCanceler); ok {
+		s.cancel(l.now(), n)
+	}
+	return nil
+}
+
+// accepts maxWait为负数时不限制等待 / a negative maxWait accepts any wait
+func accepts(wait, maxWait time.Duration) bool {
+	return maxWait < 0 || wait <= maxWait
+}
+
+// NewTokenBucket 内存令牌桶：以rate补充令牌，最多积攒burst个，允许突发；等待时可以预支令牌
+// NewTokenBucket creates an in-memory token bucket refilled at rate and holding at most burst tokens,
+// so bursts are allowed; waiting callers may borrow future tokens
+func NewTokenBucket(rate Rate, burst int, opts ...Option) *Limiter {
+	rate, burst = rate.valid(), max(burst, 1)
+	perToken := rate.interval()
+	return newLocal(opts, func() state {
+		return &tokenBucket{perToken: perToken, burst: burst, tokens: float64(burst)}
+	})
+}
+
+type tokenBucket struct {
+	perToken time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens = min(float64(b.burst), b.tokens+float64(now.Sub(b.last))/float64(b.perToken))
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) take(now time.Time, n int, maxWait time.Duration) Reservation {
+	b.refill(now)
+	if n > b.burst {
+		return Reservation{RetryAfter: -1, Remaining: int(max(b.tokens, 0)), Limit: b.burst}
+	}
+	left := b.tokens - float64(n)
+	var wait time.Duration
+	if left < 0 {
+		wait = time.Duration(math.Ceil(-left * float64(b.perToken)))
+	}
+	if !accepts(wait, maxWait) {
+		return Reservation{RetryAfter: wait - maxWait, Remaining: int(max(b.tokens, 0)), Limit: b.burst, ExceedsWait: true}
+	}
+	b.tokens = left
+	return Reservation{OK: true, Delay: wait, Remaining: int(max(left, 0)), Limit: b.burst}
+}
+
+func (b *tokenBucket) cancel(now time.Time, n int) {
+	b.refill(now)
+	b.tokens = min(float64(b.burst), b.tokens+float64(n))
+}
+
+func (b *tokenBucket) idle(now time.Time) bool {
+	return now.Sub(b.last) >= time.Duration((float64(b.burst)-b.tokens)*float64(b.perToken))
+}
+
+// NewLeakyBucket 内存漏桶：请求以恒定的rate流出，没有突发；最多capacity个请求排队等待，
+// 因此 Allow 只在队列为空时放行，Wait 会排队
+// NewLeakyBucket creates an in-memory leaky bucket that lets events out at a constant rate without
+// bursts; at most capacity events queue up, so Allow passes only when the queue is empty and Wait queues
+func NewLeakyBucket(rate Rate, capacity int, opts ...Option) *Limiter {
+	rate, capacity = rate.valid(), max(capacity, 1)
+	return newLocal(opts, func() state {
+		return &leakyBucket{interval: rate.interval(), capacity: capacity}
+	})
+}
+
+type leakyBucket struct {
+	interval time.Duration
+	capacity int
+	next     time.Time // 下一个请求可以流出的时间 / when the next event may leave
+}
+
+func (b *leakyBucket) take(now time.Time, n int, maxWait time.Duration) Reservation {
+	start := now
+	if b.next.After(now) {
+		start = b.next
+	}
+	delay := start.Sub(now)
+	queued := int((delay + b.interval - 1) / b.interval)
+	if n > b.capacity {
+		return Reservation{RetryAfter: -1, Remaining: b.capacity - queued, Limit: b.capacity}
+	}
+	limit := time.Duration(b.capacity) * b.interval
+	if maxWait >= 0 {
+		limit = min(limit, maxWait)
+	}
+	if delay > limit {
+		return Reservation{
+			RetryAfter:  delay - limit,
+			Remaining:   max(b.capacity-queued, 0),
+			Limit:       b.capacity,
+			ExceedsWait: maxWait >= 0 && delay > maxWait,
+		}
+	}
+	b.next = start.Add(time.Duration(n) * b.interval)
+	return Reservation{OK: true, Delay: delay, Remaining: max(b.capacity-queued-n, 0), Limit: b.capacity}
+}
+
+// cancel 让排在后面的请求提前 / cancel moves the events queued behind forward
+func (b *leakyBucket) cancel(now time.Time, n int) {
+	b.next = b.next.Add(-time.Duration(n) * b.interval)
+	if b.next.Before(now) {
+		b.next = now
+	}
+}
+
+func (b *leakyBucket) idle(now time.Time) bool {
+	return !b.next.After(now)
+}
+
+// NewFixedWindow 内存固定窗口：每个对齐的 Period 窗口内最多 Limit 次
+// NewFixedWindow creates an in-memory fixed window allowing Limit events per aligned Period window
+func NewFixedWindow(rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newLocal(opts, func() state { return &fixedWindow{rate: rate} })
+}
+
+type fixedWindow struct {
+	rate  Rate
+	start time.Time
+	count int
+}
+
+func (w *fixedWindow) take(now time.Time, n int, _ time.Duration) Reservation {
+	if start := now.Truncate(w.rate.Period); !start.Equal(w.start) {
+		w.start, w.count = start, 0
+	}
+	if n > w.rate.Limit {
+		return Reservation{RetryAfter: -1, Remaining: w.rate.Limit - w.count, Limit: w.rate.Limit}
+	}
+	if w.count+n > w.rate.Limit {
+		return Reservation{RetryAfter: w.start.Add(w.rate.Period).Sub(now), Remaining: w.rate.Limit - w.count, Limit: w.rate.Limit}
+	}
+	w.count += n
+	return Reservation{OK: true, Remaining: w.rate.Limit - w.count, Limit: w.rate.Limit}
+}
+
+func (w *fixedWindow) idle(now time.Time) bool {
+	return !now.Before(w.start.Add(w.rate.Period))
+}
+
+// NewSlidingLog 内存滑动日志：记录每次的时间，任意 Period 长度的区间内最多 Limit 次；精确但占用 O(Limit) 内存
+// NewSlidingLog creates an in-memory sliding log recording every event, allowing Limit events in any
+// interval of length Period; it is exact but keeps O(Limit) timestamps per key
+func NewSlidingLog(rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newLocal(opts, func() state { return &slidingLog{rate: rate} })
+}
+
+type slidingLog struct {
+	rate Rate
+	log  []time.Time
+}
+
+func (s *slidingLog) prune(now time.Time) {
+	cut := 0
+	for cut < len(s.log) && !s.log[cut].Add(s.rate.Period).After(now) {
+		cut++
+	}
+	s.log = s.log[cut:]
+}
+
+func (s *slidingLog) take(now time.Time, n int, _ time.Duration) Reservation {
+	s.prune(now)
+	if n > s.rate.Limit {
+		return Reservation{RetryAfter: -1, Remaining: s.rate.Limit - len(s.log), Limit: s.rate.Limit}
+	}
+	if over := len(s.log) + n - s.rate.Limit; over > 0 {
+		return Reservation{RetryAfter: s.log[over-1].Add(s.rate.Period).Sub(now), Remaining: s.rate.Limit - len(s.log), Limit: s.rate.Limit}
+	}
+	for i := 0; i < n; i++ {
+		s.log = append(s.log, now)
+	}
+	return Reservation{OK: true, Remaining: s.rate.Limit - len(s.log), Limit: s.rate.Limit}
+}
+
+func (s *slidingLog) idle(now time.Time) bool {
+	s.prune(now)
+	return len(s.log) == 0
+}
+
+// NewSlidingWindow 内存滑动计数器：用上一窗口计数按时间加权近似滑动窗口，内存 O(1)
+// NewSlidingWindow creates an in-memory sliding counter that approximates a sliding window by weighting
+// the previous window's count by time, using O(1) memory
+func NewSlidingWindow(rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newLocal(opts, func() state { return &slidingWindow{rate: rate} })
+}
+
+type slidingWindow struct {
+	rate        Rate
+	start       time.Time
+	prev, count int
+}
+
+func (s *slidingWindow) roll(now time.Time) {
+	start := now.Truncate(s.rate.Period)
+	switch {
+	case start.Equal(s.start):
+	case start.Equal(s.start.Add(s.rate.Period)):
+		s.start, s.prev, s.count = start, s.count, 0
+	default:
+		s.start, s.prev, s.count = start, 0, 0
+	}
+}
+
+func (s *slidingWindow) take(now time.Time, n int, _ time.Duration) Reservation {
+	s.roll(now)
+	elapsed := now.Sub(s.start)
+	weight := 1 - float64(elapsed)/float64(s.rate.Period)
+	estimate := float64(s.prev)*weight + float64(s.count)
+	remaining := max(s.rate.Limit-int(math.Ceil(estimate)), 0)
+	if n > s.rate.Limit {
+		return Reservation{RetryAfter: -1, Remaining: remaining, Limit: s.rate.Limit}
+	}
+	if estimate+float64(n) > float64(s.rate.Limit) {
+		retry := s.start.Add(s.rate.Period).Sub(now)
+		if room := s.rate.Limit - s.count - n; room >= 0 && s.prev > 0 {
+			// 上一窗口的权重降到足够低的时刻 / when the previous window's weight has decayed enough
+			at := time.Duration(math.Ceil(float64(s.rate.Period) * (1 - float64(room)/float64(s.prev))))
+			retry = at - elapsed
+		}
+		return Reservation{RetryAfter: max(retry, 0), Remaining: remaining, Limit: s.rate.Limit}
+	}
+	s.count += n
+	return Reservation{OK: true, Remaining: max(s.rate.Limit-int(math.Ceil(estimate))-n, 0), Limit: s.rate.Limit}
+}
+
+func (s *slidingWindow) idle(now time.Time) bool {
+	return !now.Before(s.start.Add(2 * s.rate.Period))
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/karosown/katool-go/mq"
+)
+
+// MessageKey 从消息中取限流键
+// MessageKey extracts the limiting key from a message
+type MessageKey func(ctx context.Context, msg mq.Message) string
+
+// ByTopic 按主题限流
+// ByTopic limits per topic
+func ByTopic(_ context.Context, msg mq.Message) string {
+	return msg.GetMetadata().Topic
+}
+
+// MQHandler 包装消费者回调：处理前等待许可，消费速度因此被限制；等待失败（如ctx结束）时返回错误且不调用next。
+// key 为 nil 时按主题限流
+// MQHandler wraps a consumer handler so it waits for a permit before handling, throttling consumption.
+// When waiting fails, for example because ctx is done, the error is returned without calling next.
+// A nil key limits per topic
+func MQHandler(l *Limiter, key MessageKey, next mq.Handler) mq.Handler {
+	if key == nil {
+		key = ByTopic
+	}
+	return func(ctx context.Context, msg mq.Message) error {
+		if err := l.Wait(ctx, key(ctx, msg)); err != nil {
+			return err
+		}
+		return next(ctx, msg)
+	}
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+// 限流：令牌桶、漏桶、固定窗口、滑动日志与滑动计数器，均提供内存与 Redis(Lua) 两种实现，
+// 支持按键限流以及 Allow/Wait/Reserve 三种用法
+//
+// Package ratelimit provides token bucket, leaky bucket, fixed window, sliding log and sliding counter
+// limiters, each with in-memory and Redis (Lua) implementations, per-key limiting and Allow/Wait/Reserve APIs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrTooLarge 请求的数量超过了限流器一次能放行的上限，永远无法满足
+	// ErrTooLarge is returned when n exceeds what the limiter can ever grant at once
+	ErrTooLarge = errors.New("ratelimit: n exceeds the limit")
+	// ErrWouldExceedDeadline 等待时间会超过ctx的截止时间
+	// ErrWouldExceedDeadline is returned when waiting would outlast the ctx deadline
+	ErrWouldExceedDeadline = errors.New("ratelimit: wait would exceed context deadline")
+)
+
+// Rate 速率：每个 Period 允许 Limit 次
+// Rate allows Limit events per Period
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Per 每period允许limit次
+// Per allows limit events per period
+func Per(limit int, period time.Duration) Rate {
+	return Rate{Limit: limit, Period: period}
+}
+
+// PerSecond 每秒允许n次
+// PerSecond allows n events per second
+func PerSecond(n int) Rate {
+	return Per(n, time.Second)
+}
+
+// PerMinute 每分钟允许n次
+// PerMinute allows n events per minute
+func PerMinute(n int) Rate {
+	return Per(n, time.Minute)
+}
+
+// interval 两次之间的平均间隔
+// interval is the average gap between two events
+func (r Rate) interval() time.Duration {
+	return r.Period / time.Duration(r.Limit)
+}
+
+func (r Rate) valid() Rate {
+	r.Limit = max(r.Limit, 1)
+	if r.Period <= 0 {
+		r.Period = time.Second
+	}
+	return r
+}
+
+// Reservation 一次获取许可的结果
+// Reservation is the outcome of taking permits
+type Reservation struct {
+	OK         bool          // 是否获得许可 / whether the permits were granted
+	Delay      time.Duration // 获得许可后还需等待多久才能执行 / how long to wait before acting on granted permits
+	RetryAfter time.Duration // 未获得许可时建议的重试间隔，负数表示永远无法满足 / when to retry if denied, negative means never
+	Remaining  int           // 剩余许可数 / permits left
+	Limit      int           // 许可上限 / the permit limit
+	// ExceedsWait 因需要的等待超过maxWait被拒绝，此时等待只会与maxWait同步缩短，重试无法满足
+	// ExceedsWait reports a denial because the needed wait is longer than maxWait; the wait shrinks
+	// along with maxWait, so retrying cannot succeed
+	ExceedsWait bool
+}
+
+// Backend 限流算法的存储实现：以原子方式为key获取n个许可，
+// 允许的最长等待为maxWait（负数表示不限），只有令牌桶与漏桶会返回 Delay>0 的预约
+// Backend is the storage of an algorithm: it atomically takes n permits for key, accepting a reservation
+// that needs at most maxWait of waiting (negative means unlimited); only token and leaky buckets return
+// reservations with Delay > 0
+type Backend interface {
+	Take(ctx context.Context, key string, n int, maxWait time.Duration) (Reservation, error)
+}
+
+// Canceler 可以归还已预约但未使用的许可的存储实现，令牌桶与漏桶实现了该接口
+// Canceler is a backend that can give back permits reserved but not used, implemented by the token and
+// leaky buckets
+type Canceler interface {
+	Cancel(ctx context.Context, key string, n int) error
+}
+
+// Limiter 限流器，所有方法按key独立限流
+// Limiter rate-limits every key independently
+type Limiter struct {
+	backend Backend
+}
+
+// New 用自定义存储实现创建限流器
+// New creates a limiter from a custom backend
+func New(backend Backend) *Limiter {
+	return &Limiter{backend: backend}
+}
+
+// Allow 是否可以立即执行一次
+// Allow reports whether one event may happen now
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 是否可以立即执行n次
+// AllowN reports whether n events may happen now
+func (l *Limiter) AllowN(ctx context.Context, key string, n int) (bool, error) {
+	r, err := l.backend.Take(ctx, key, n, 0)
+	return r.OK, err
+}
+
+// Reserve 预约一次许可，调用者需要等待 Delay 后再执行；窗口类算法不支持预约未来的许可，
+// 此时 OK 为 false 并给出 RetryAfter
+// Reserve reserves one permit, the caller must wait Delay before acting. Window algorithms cannot reserve
+// future permits, they return OK false with RetryAfter instead
+func (l *Limiter) Reserve(ctx context.Context, key string) (Reservation, error) {
+	return l.ReserveN(ctx, key, 1, -1)
+}
+
+// ReserveN 预约n个许可，只接受等待不超过maxWait的预约（负数表示不限）
+// ReserveN reserves n permits, accepting only a reservation that waits at most maxWait (negative means unlimited)
+func (l *Limiter) ReserveN(ctx context.Context, key string, n int, maxWait time.Duration) (Reservation, error) {
+	return l.backend.Take(ctx, key, n, maxWait)
+}
+
+// CancelN 归还通过 ReserveN 预约但不再使用的n个许可，存储不支持归还时什么也不做
+// CancelN gives back n permits reserved with ReserveN that will not be used, it does nothing when the
+// backend cannot give permits back
+func (l *Limiter) CancelN(ctx context.Context, key string, n int) error {
+	if c, ok := l.backend.(Canceler); ok {
+		return c.Cancel(ctx, key, n)
+	}
+	return nil
+}
+
+// Wait 阻塞直到可以执行一次或ctx结束
+// Wait blocks until one event may happen or ctx is done
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	return l.WaitN(ctx, key, 1)
+}
+
+// WaitN 阻塞直到可以执行n次或ctx结束；确定会超过ctx截止时间时立即返回 ErrWouldExceedDeadline，
+// 等待预约期间ctx结束时归还已预约的许可
+// WaitN blocks until n events may happen or ctx is done; it returns ErrWouldExceedDeadline at once when
+// the wait is known to outlast the ctx deadline, and gives the reserved permits back when ctx is done
+// while waiting for a reservation
+func (l *Limiter) WaitN(ctx context.Context, key string, n int) error {
+	for {
+		maxWait := time.Duration(-1)
+		deadline, hasDeadline := ctx.Deadline()
+		if hasDeadline {
+			maxWait = max(time.Until(deadline), 0)
+		}
+		r, err := l.backend.Take(ctx, key, n, maxWait)
+		if err != nil {
+			return err
+		}
+		if r.OK {
+			if err := sleep(ctx, r.Delay); err != nil {
+				return errors.Join(err, l.CancelN(context.WithoutCancel(ctx), key, n))
+			}
+			return nil
+		}
+		if r.RetryAfter < 0 {
+			return ErrTooLarge
+		}
+		if r.ExceedsWait || hasDeadline && time.Until(deadline) < r.RetryAfter {
+			return ErrWouldExceedDeadline
+		}
+		if err := sleep(ctx, max(r.RetryAfter, time.Millisecond)); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Option 限流器选项
+// Option configures a limiter
+type Option func(*options)
+
+type options struct {
+	now    func() time.Time
+	prefix string
+}
+
+// WithClock 设置内存实现使用的时钟，便于测试
+// WithClock sets the clock of in-memory limiters, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+// WithPrefix 设置 Redis 实现的键前缀，默认 "ratelimit:"
+// WithPrefix sets the key prefix of Redis limiters, "ratelimit:" by default
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+func newOptions(opts []Option) options {
+	o := options{now: time.Now, prefix: "ratelimit:"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,235 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 所有脚本使用 Redis 服务器时间（微秒），返回 {ok, delay, retryAfter, remaining}，时间单位为微秒，
+// 令牌桶与漏桶拒绝时追加 exceedsWait；写入的数字用 string.format 格式化，避免 Lua 数字转字符串时丢失精度
+// Every script uses the Redis server clock in microseconds and returns {ok, delay, retryAfter, remaining}
+// in microseconds, the buckets append exceedsWait to a denial; numbers are written with string.format so
+// Lua does not lose precision
+const nowLua = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+`
+
+var (
+	tokenBucketScript = redis.NewScript(nowLua + `
+local per, burst, n, maxwait = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+local s = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(s[1]) or burst
+local ts = tonumber(s[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / per)
+if n > burst then return {0, 0, -1, math.floor(math.max(tokens, 0))} end
+local left = tokens - n
+local wait = 0
+if left < 0 then wait = math.ceil(-left * per) end
+if maxwait >= 0 and wait > maxwait then
+  return {0, 0, wait - maxwait, math.floor(math.max(tokens, 0)), 1}
+end
+redis.call('HSET', KEYS[1], 'tokens', string.format('%.6f', left), 'ts', string.format('%.0f', now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - left) * per / 1000) + 1000)
+return {1, wait, 0, math.floor(math.max(left, 0))}`)
+
+	// tokenBucketCancelScript 归还n个令牌 / gives n tokens back
+	tokenBucketCancelScript = redis.NewScript(nowLua + `
+local per, burst, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local s = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+if not s[1] then return 0 end
+local ts = tonumber(s[2]) or now
+local tokens = math.min(burst, tonumber(s[1]) + math.max(0, now - ts) / per + n)
+redis.call('HSET', KEYS[1], 'tokens', string.format('%.6f', tokens), 'ts', string.format('%.0f', now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * per / 1000) + 1000)
+return 1`)
+
+	leakyBucketScript = redis.NewScript(nowLua + `
+local interval, cap, n, maxwait = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+local nxt = tonumber(redis.call('GET', KEYS[1])) or now
+local start = math.max(nxt, now)
+local delay = start - now
+local queued = math.ceil(delay / interval)
+if n > cap then return {0, 0, -1, math.max(cap - queued, 0)} end
+local limit = cap * interval
+if maxwait >= 0 then limit = math.min(limit, maxwait) end
+if delay > limit then
+  local exceeds = 0
+  if maxwait >= 0 and delay > maxwait then exceeds = 1 end
+  return {0, 0, delay - limit, math.max(cap - queued, 0), exceeds}
+end
+nxt = start + n * interval
+redis.call('SET', KEYS[1], string.format('%.0f', nxt), 'PX', math.ceil((nxt - now) / 1000) + 1000)
+return {1, delay, 0, math.max(cap - queued - n, 0)}`)
+
+	// leakyBucketCancelScript 让排在后面的请求提前n个间隔 / moves the queued events n intervals forward
+	leakyBucketCancelScript = redis.NewScript(nowLua + `
+local interval, n = tonumber(ARGV[1]), tonumber(ARGV[3])
+local nxt = tonumber(redis.call('GET', KEYS[1]))
+if not nxt then return 0 end
+nxt = nxt - n * interval
+if nxt <= now then
+  redis.call('DEL', KEYS[1])
+  return 1
+end
+redis.call('SET', KEYS[1], string.format('%.0f', nxt), 'PX', math.ceil((nxt - now) / 1000) + 1000)
+return 1`)
+
+	fixedWindowScript = redis.NewScript(nowLua + `
+local period, limit, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local ws = now - (now % period)
+local s = redis.call('HMGET', KEYS[1], 'ws', 'count')
+local count = 0
+if tonumber(s[1]) == ws then count = tonumber(s[2]) end
+if n > limit then return {0, 0, -1, limit - count} end
+if count + n > limit then return {0, 0, ws + period - now, limit - count} end
+redis.call('HSET', KEYS[1], 'ws', string.format('%.0f', ws), 'count', count + n)
+redis.call('PEXPIRE', KEYS[1], math.ceil((ws + period - now) / 1000) + 1000)
+return {1, 0, 0, limit - count - n}`)
+
+	slidingLogScript = redis.NewScript(nowLua + `
+local period, limit, n, id = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3]), ARGV[4]
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', string.format('%.0f', now - period))
+local count = redis.call('ZCARD', KEYS[1])
+if n > limit then return {0, 0, -1, limit - count} end
+local over = count + n - limit
+if over > 0 then
+  local oldest = redis.call('ZRANGE', KEYS[1], over - 1, over - 1, 'WITHSCORES')
+  return {0, 0, tonumber(oldest[2]) + period - now, limit - count}
+end
+local score = string.format('%.0f', now)
+for i = 1, n do
+  redis.call('ZADD', KEYS[1], score, score .. ':' .. id .. ':' .. i)
+end
+redis.call('PEXPIRE', KEYS[1], math.ceil(period / 1000) + 1000)
+return {1, 0, 0, limit - count - n}`)
+
+	slidingWindowScript = redis.NewScript(nowLua + `
+local period, limit, n = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local ws = now - (now % period)
+local s = redis.call('HMGET', KEYS[1], 'ws', 'count', 'prev')
+local sws, count, prev = tonumber(s[1]), tonumber(s[2]) or 0, tonumber(s[3]) or 0
+if sws ~= ws then
+  if sws == ws - period then prev = count else prev = 0 end
+  count = 0
+end
+local elapsed = now - ws
+local estimate = prev * (1 - elapsed / period) + count
+local remaining = math.max(limit - math.ceil(estimate), 0)
+if n > limit then return {0, 0, -1, remaining} end
+if estimate + n > limit then
+  local retry = ws + period - now
+  local room = limit - count - n
+  if room >= 0 and prev > 0 then
+    retry = math.ceil(period * (1 - room / prev)) - elapsed
+  end
+  return {0, 0, math.max(retry, 0), remaining}
+end
+redis.call('HSET', KEYS[1], 'ws', string.format('%.0f', ws), 'count', count + n, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], math.ceil((ws + 2 * period - now) / 1000) + 1000)
+return {1, 0, 0, math.max(limit - math.ceil(estimate) - n, 0)}`)
+)
+
+// remote Redis 存储，每次获取执行一次 Lua 脚本
+// remote is the Redis backend, every take runs one Lua script
+type remote struct {
+	cmd    redis.Cmdable
+	prefix string
+	script *redis.Script
+	cancel *redis.Script // 归还许可的脚本，nil 表示不支持 / gives permits back, nil when unsupported
+	limit  int
+	args   []any // 算法参数 / algorithm parameters
+	unique bool  // 是否需要为每次调用追加唯一标识 / whether a per-call unique id is appended
+}
+
+func newRemote(cmd redis.Cmdable, opts []Option, script, cancel *redis.Script, limit int, unique bool, args ...any) *Limiter {
+	o := newOptions(opts)
+	return New(&remote{cmd: cmd, prefix: o.prefix, script: script, cancel: cancel, limit: limit, args: args, unique: unique})
+}
+
+func (r *remote) Take(ctx context.Context, key string, n int, maxWait time.Duration) (Reservation, error) {
+	args := append(append(make([]any, 0, len(r.args)+3), r.args...), n)
+	if r.unique {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		args = append(args, hex.EncodeToString(b))
+	} else {
+		args = append(args, micros(maxWait))
+	}
+	res, err := r.script.Run(ctx, r.cmd, []string{r.prefix + key}, args...).Int64Slice()
+	if err != nil {
+		return Reservation{}, err
+	}
+	retry := time.Duration(res[2]) * time.Microsecond
+	if res[2] < 0 {
+		retry = -1
+	}
+	return Reservation{
+		OK:          res[0] == 1,
+		Delay:       time.Duration(res[1]) * time.Microsecond,
+		RetryAfter:  retry,
+		Remaining:   int(res[3]),
+		Limit:       r.limit,
+		ExceedsWait: len(res) > 4 && res[4] == 1,
+	}, nil
+}
+
+func (r *remote) Cancel(ctx context.Context, key string, n int) error {
+	if r.cancel == nil {
+		return nil
+	}
+	args := append(append(make([]any, 0, len(r.args)+1), r.args...), n)
+	return r.cancel.Run(ctx, r.cmd, []string{r.prefix + key}, args...).Err()
+}
+
+// micros 转换为微秒，负数保持为-1 / micros converts to microseconds, negatives stay -1
+func micros(d time.Duration) int64 {
+	if d < 0 {
+		return -1
+	}
+	return d.Microseconds()
+}
+
+func microsFloat(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Microsecond), 'f', -1, 64)
+}
+
+// NewRedisTokenBucket Redis 令牌桶，语义同 NewTokenBucket，多个节点共享配额
+// NewRedisTokenBucket is a Redis token bucket with the semantics of NewTokenBucket, shared across nodes
+func NewRedisTokenBucket(cmd redis.Cmdable, rate Rate, burst int, opts ...Option) *Limiter {
+	rate, burst = rate.valid(), max(burst, 1)
+	return newRemote(cmd, opts, tokenBucketScript, tokenBucketCancelScript, burst, false, microsFloat(rate.interval()), burst)
+}
+
+// NewRedisLeakyBucket Redis 漏桶，语义同 NewLeakyBucket
+// NewRedisLeakyBucket is a Redis leaky bucket with the semantics of NewLeakyBucket
+func NewRedisLeakyBucket(cmd redis.Cmdable, rate Rate, capacity int, opts ...Option) *Limiter {
+	rate, capacity = rate.valid(), max(capacity, 1)
+	return newRemote(cmd, opts, leakyBucketScript, leakyBucketCancelScript, capacity, false, microsFloat(rate.interval()), capacity)
+}
+
+// NewRedisFixedWindow Redis 固定窗口，语义同 NewFixedWindow
+// NewRedisFixedWindow is a Redis fixed window with the semantics of NewFixedWindow
+func NewRedisFixedWindow(cmd redis.Cmdable, rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newRemote(cmd, opts, fixedWindowScript, nil, rate.Limit, false, rate.Period.Microseconds(), rate.Limit)
+}
+
+// NewRedisSlidingLog Redis 滑动日志（有序集合），语义同 NewSlidingLog
+// NewRedisSlidingLog is a Redis sliding log on a sorted set with the semantics of NewSlidingLog
+func NewRedisSlidingLog(cmd redis.Cmdable, rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newRemote(cmd, opts, slidingLogScript, nil, rate.Limit, true, rate.Period.Microseconds(), rate.Limit)
+}
+
+// NewRedisSlidingWindow Redis 滑动计数器，语义同 NewSlidingWindow
+// NewRedisSlidingWindow is a Redis sliding counter with the semantics of NewSlidingWindow
+func NewRedisSlidingWindow(cmd redis.Cmdable, rate Rate, opts ...Option) *Limiter {
+	rate = rate.valid()
+	return newRemote(cmd, opts, slidingWindowScript, nil, rate.Limit, false, rate.Period.Microseconds(), rate.Limit)
+}
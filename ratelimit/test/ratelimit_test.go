@@ -0,0 +1,258 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/mq"
+	"github.com/karosown/katool-go/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// clock 可手动推进的时钟
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func allowed(t *testing.T, l *ratelimit.Limiter, key string, times int) int {
+	n := 0
+	for i := 0; i < times; i++ {
+		ok, err := l.Allow(context.Background(), key)
+		assert.NoError(t, err)
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// 测试令牌桶的突发与补充
+func TestTokenBucket(t *testing.T) {
+	c := newClock()
+	l := ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 5, ratelimit.WithClock(c.Now))
+	assert.Equal(t, 5, allowed(t, l, "a", 10))
+	assert.Equal(t, 5, allowed(t, l, "b", 10))
+
+	c.Advance(300 * time.Millisecond)
+	assert.Equal(t, 3, allowed(t, l, "a", 10))
+
+	r, err := l.Reserve(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.True(t, r.OK)
+	assert.Equal(t, 100*time.Millisecond, r.Delay)
+
+	r, _ = l.ReserveN(context.Background(), "a", 1, 50*time.Millisecond)
+	assert.False(t, r.OK)
+	assert.Equal(t, 150*time.Millisecond, r.RetryAfter)
+
+	r, _ = l.ReserveN(context.Background(), "a", 6, -1)
+	assert.False(t, r.OK)
+	assert.Less(t, r.RetryAfter, time.Duration(0))
+}
+
+// 测试漏桶的匀速流出与排队
+func TestLeakyBucket(t *testing.T) {
+	c := newClock()
+	l := ratelimit.NewLeakyBucket(ratelimit.PerSecond(10), 3, ratelimit.WithClock(c.Now))
+	assert.Equal(t, 1, allowed(t, l, "a", 5))
+	c.Advance(100 * time.Millisecond)
+	assert.Equal(t, 1, allowed(t, l, "a", 5))
+
+	delays := make([]time.Duration, 0)
+	for i := 0; i < 4; i++ {
+		r, _ := l.Reserve(context.Background(), "a")
+		if r.OK {
+			delays = append(delays, r.Delay)
+		}
+	}
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, delays)
+}
+
+// 测试固定窗口与滑动日志在窗口边界的差异
+func TestWindows(t *testing.T) {
+	c := newClock()
+	fixed := ratelimit.NewFixedWindow(ratelimit.PerSecond(4), ratelimit.WithClock(c.Now))
+	log := ratelimit.NewSlidingLog(ratelimit.PerSecond(4), ratelimit.WithClock(c.Now))
+	counter := ratelimit.NewSlidingWindow(ratelimit.PerSecond(4), ratelimit.WithClock(c.Now))
+
+	c.Advance(900 * time.Millisecond)
+	assert.Equal(t, 4, allowed(t, fixed, "k", 6))
+	assert.Equal(t, 4, allowed(t, log, "k", 6))
+	assert.Equal(t, 4, allowed(t, counter, "k", 6))
+
+	r, _ := fixed.Reserve(context.Background(), "k")
+	assert.Equal(t, 100*time.Millisecond, r.RetryAfter)
+	r, _ = log.Reserve(context.Background(), "k")
+	assert.Equal(t, time.Second, r.RetryAfter)
+
+	// 跨过窗口边界：固定窗口立即重置，滑动算法仍记得之前的请求
+	c.Advance(200 * time.Millisecond)
+	assert.Equal(t, 4, allowed(t, fixed, "k", 6))
+	assert.Equal(t, 0, allowed(t, log, "k", 6))
+	assert.Equal(t, 0, allowed(t, counter, "k", 6))
+
+	c.Advance(800 * time.Millisecond)
+	assert.Equal(t, 4, allowed(t, log, "k", 6))
+	// 上一窗口权重为 0.1，估计值 0.4 / the previous window weighs 0.1, the estimate is 0.4
+	assert.Equal(t, 3, allowed(t, counter, "k", 6))
+}
+
+// 测试 Wait 的阻塞与截止时间
+func TestWait(t *testing.T) {
+	l := ratelimit.NewTokenBucket(ratelimit.PerSecond(50), 1)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, l.Wait(context.Background(), "k"))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 55*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.WaitN(ctx, "k", 1), ratelimit.ErrWouldExceedDeadline)
+	assert.ErrorIs(t, l.WaitN(context.Background(), "k", 2), ratelimit.ErrTooLarge)
+
+	w := ratelimit.NewFixedWindow(ratelimit.Per(2, 30*time.Millisecond))
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, w.Wait(context.Background(), "k"))
+	}
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// 测试桶算法的等待超过截止时间时 Wait 立即返回
+func TestWaitExceedsDeadline(t *testing.T) {
+	limiters := map[string]*ratelimit.Limiter{
+		"token": ratelimit.NewTokenBucket(ratelimit.PerSecond(1), 1),
+		"leaky": ratelimit.NewLeakyBucket(ratelimit.PerSecond(1), 3),
+	}
+	for name, l := range limiters {
+		assert.Equal(t, 1, allowed(t, l, "k", 1), name)
+		ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+		start := time.Now()
+		assert.ErrorIs(t, l.Wait(ctx, "k"), ratelimit.ErrWouldExceedDeadline, name)
+		assert.Less(t, time.Since(start), 100*time.Millisecond, name)
+		cancel()
+	}
+
+	// 漏桶队列已满但截止时间足够时仍会等待 / a full leaky queue still waits when the deadline allows it
+	leaky := ratelimit.NewLeakyBucket(ratelimit.Per(1, 20*time.Millisecond), 1)
+	assert.Equal(t, 1, allowed(t, leaky, "k", 1))
+	r, _ := leaky.Reserve(context.Background(), "k")
+	assert.True(t, r.OK)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, leaky.Wait(ctx, "k"))
+}
+
+// 测试 Wait 被取消时归还预约的许可
+func TestWaitCancelReturnsPermits(t *testing.T) {
+	c := newClock()
+	limiters := map[string]*ratelimit.Limiter{
+		"token": ratelimit.NewTokenBucket(ratelimit.PerSecond(10), 1, ratelimit.WithClock(c.Now)),
+		"leaky": ratelimit.NewLeakyBucket(ratelimit.PerSecond(10), 3, ratelimit.WithClock(c.Now)),
+	}
+	for name, l := range limiters {
+		assert.Equal(t, 1, allowed(t, l, "k", 1), name)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		assert.ErrorIs(t, l.Wait(ctx, "k"), context.Canceled, name)
+
+		// 被取消的预约不再占用许可 / the cancelled reservation no longer holds a permit
+		r, err := l.Reserve(context.Background(), "k")
+		assert.NoError(t, err)
+		assert.True(t, r.OK, name)
+		assert.Equal(t, 100*time.Millisecond, r.Delay, name)
+	}
+
+	// 不支持归还的算法忽略 CancelN / algorithms that cannot give permits back ignore CancelN
+	fixed := ratelimit.NewFixedWindow(ratelimit.PerSecond(1), ratelimit.WithClock(c.Now))
+	assert.Equal(t, 1, allowed(t, fixed, "k", 2))
+	assert.NoError(t, fixed.CancelN(context.Background(), "k", 1))
+	assert.Equal(t, 0, allowed(t, fixed, "k", 1))
+}
+
+type message struct{ topic string }
+
+func (m message) Payload() []byte          { return nil }
+func (m message) GetMetadata() mq.Metadata { return mq.Metadata{Topic: m.topic} }
+func (m message) Ack() error               { return nil }
+func (m message) Nack(requeue bool) error  { return nil }
+
+// 测试 mq 消费者限流
+func TestMQHandler(t *testing.T) {
+	l := ratelimit.NewFixedWindow(ratelimit.Per(1, time.Hour))
+	handled := 0
+	h := ratelimit.MQHandler(l, nil, func(ctx context.Context, msg mq.Message) error {
+		handled++
+		return nil
+	})
+	assert.NoError(t, h(context.Background(), message{topic: "a"}))
+	assert.NoError(t, h(context.Background(), message{topic: "b"}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h(ctx, message{topic: "a"}), ratelimit.ErrWouldExceedDeadline)
+	assert.Equal(t, 2, handled)
+}
+
+// 测试 Redis 实现（需要 REDIS_ADDR）
+func TestRedisLimiters(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	prefix := ratelimit.WithPrefix("katool:test:ratelimit:" + strconv.FormatInt(time.Now().UnixNano(), 36) + ":")
+
+	limiters := map[string]*ratelimit.Limiter{
+		"token":   ratelimit.NewRedisTokenBucket(client, ratelimit.PerMinute(5), 5, prefix),
+		"fixed":   ratelimit.NewRedisFixedWindow(client, ratelimit.Per(5, time.Hour), prefix),
+		"log":     ratelimit.NewRedisSlidingLog(client, ratelimit.PerMinute(5), prefix),
+		"sliding": ratelimit.NewRedisSlidingWindow(client, ratelimit.Per(5, time.Hour), prefix),
+	}
+	for name, l := range limiters {
+		assert.Equal(t, 5, allowed(t, l, name, 8), name)
+		r, err := l.Reserve(context.Background(), name)
+		assert.NoError(t, err)
+		if !r.OK {
+			assert.Greater(t, r.RetryAfter, time.Duration(0), name)
+		}
+	}
+
+	leaky := ratelimit.NewRedisLeakyBucket(client, ratelimit.PerSecond(10), 3, prefix)
+	assert.Equal(t, 1, allowed(t, leaky, "leaky", 3))
+	r, err := leaky.Reserve(context.Background(), "leaky")
+	assert.NoError(t, err)
+	assert.True(t, r.OK)
+	assert.InDelta(t, float64(100*time.Millisecond), float64(r.Delay), float64(20*time.Millisecond))
+	assert.NoError(t, leaky.CancelN(context.Background(), "leaky", 1))
+	r, err = leaky.Reserve(context.Background(), "leaky")
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(100*time.Millisecond), float64(r.Delay), float64(20*time.Millisecond))
+
+	token := limiters["token"]
+	assert.NoError(t, token.CancelN(context.Background(), "token", 1))
+	assert.Equal(t, 1, allowed(t, token, "token", 2))
+}
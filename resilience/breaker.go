@@ -0,0 +1,326 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen 熔断器打开（或半开状态的试探名额已满），调用未被执行
+// ErrOpen is returned when the breaker is open, or half-open with all trial calls taken, and the call was not run
+var ErrOpen = errors.New("resilience: circuit breaker is open")
+
+// State 熔断器状态
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed 正常放行并统计结果 / calls pass and are recorded
+	StateClosed State = iota
+	// StateOpen 拒绝所有调用，等待冷却 / calls are rejected until the open timeout passes
+	StateOpen
+	// StateHalfOpen 放行少量试探调用以决定关闭还是重新打开 / a few trial calls decide between closing and reopening
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOption 熔断器选项
+// BreakerOption configures a Breaker
+type BreakerOption func(*Breaker)
+
+// WithWindowSize 滑动窗口记录最近多少次调用，默认100
+// WithWindowSize sets how many recent calls the sliding window keeps, 100 by default
+func WithWindowSize(n int) BreakerOption {
+	return func(b *Breaker) { b.windowSize = max(n, 1) }
+}
+
+// WithMinimumCalls 窗口内至少多少次调用后才计算比率，默认10
+// WithMinimumCalls sets how many calls the window needs before rates are evaluated, 10 by default
+func WithMinimumCalls(n int) BreakerOption {
+	return func(b *Breaker) { b.minCalls = max(n, 1) }
+}
+
+// WithFailureRate 失败率达到阈值（0~1）时打开，默认0.5
+// WithFailureRate opens the breaker when the failure rate reaches threshold (0 to 1), 0.5 by default
+func WithFailureRate(threshold float64) BreakerOption {
+	return func(b *Breaker) { b.failureRate = threshold }
+}
+
+// WithSlowCall 耗时不小于 duration 的调用记为慢调用，慢调用率达到阈值时打开；默认不统计慢调用
+// WithSlowCall counts calls taking at least duration as slow and opens the breaker when the slow-call rate
+// reaches threshold; slow calls are not tracked by default
+func WithSlowCall(duration time.Duration, threshold float64) BreakerOption {
+	return func(b *Breaker) {
+		b.slowDuration = duration
+		b.slowRate = threshold
+	}
+}
+
+// WithOpenTimeout 打开后多久转为半开，默认30s
+// WithOpenTimeout sets how long the breaker stays open before going half-open, 30s by default
+func WithOpenTimeout(d time.Duration) BreakerOption {
+	return func(b *Breaker) { b.openTimeout = d }
+}
+
+// WithHalfOpenCalls 半开状态允许的试探调用数，默认3
+// WithHalfOpenCalls sets how many trial calls the half-open state permits, 3 by default
+func WithHalfOpenCalls(n int) BreakerOption {
+	return func(b *Breaker) { b.halfOpenCalls = max(n, 1) }
+}
+
+// WithFailureIf 设置哪些错误计为失败，默认除 context.Canceled 外的所有错误
+// WithFailureIf sets which errors count as failures, by default every error but context.Canceled
+func WithFailureIf(isFailure func(err error) bool) BreakerOption {
+	return func(b *Breaker) { b.isFailure = isFailure }
+}
+
+// WithOnStateChange 状态变化回调，在锁外调用
+// WithOnStateChange sets a listener for state changes, called outside the lock
+func WithOnStateChange(listener func(name string, from, to State)) BreakerOption {
+	return func(b *Breaker) { b.onStateChange = listener }
+}
+
+// WithBreakerClock 设置时钟，便于测试
+// WithBreakerClock sets the clock, mainly for tests
+func WithBreakerClock(now func() time.Time) BreakerOption {
+	return func(b *Breaker) { b.now = now }
+}
+
+// BreakerMetrics 熔断器当前窗口的统计
+// BreakerMetrics is a snapshot of the breaker's current window
+type BreakerMetrics struct {
+	State        State
+	Calls        int
+	Failures     int
+	SlowCalls    int
+	FailureRate  float64
+	SlowCallRate float64
+}
+
+const (
+	outcomeFailed uint8 = 1 << iota
+	outcomeSlow
+)
+
+// Breaker 基于计数滑动窗口的熔断器
+// Breaker is a circuit breaker over a count-based sliding window
+type Breaker struct {
+	name          string
+	windowSize    int
+	minCalls      int
+	failureRate   float64
+	slowDuration  time.Duration
+	slowRate      float64
+	openTimeout   time.Duration
+	halfOpenCalls int
+	isFailure     func(err error) bool
+	onStateChange func(name string, from, to State)
+	now           func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	gen      uint64
+	openedAt time.Time
+	ring     []uint8
+	pos      int
+	calls    int
+	failures int
+	slows    int
+	// trials 半开状态已放行的试探数 / trial calls let through while half-open
+	trials int
+	// 半开状态试探调用的结果，与滑动窗口分开统计，窗口小于试探数时也能结束半开
+	// outcomes of the half-open trials, counted apart from the sliding window so half-open ends even when
+	// the window is smaller than the number of trials
+	trialCalls, trialFailures, trialSlows int
+}
+
+// NewBreaker 创建熔断器，name 用于回调中区分
+// NewBreaker creates a circuit breaker, name identifies it in listeners
+func NewBreaker(name string, opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		name:          name,
+		windowSize:    100,
+		minCalls:      10,
+		failureRate:   0.5,
+		openTimeout:   30 * time.Second,
+		halfOpenCalls: 3,
+		isFailure:     func(err error) bool { return !errors.Is(err, context.Canceled) },
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.ring = make([]uint8, b.windowSize)
+	return b
+}
+
+// Name 熔断器名称
+// Name returns the breaker's name
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State 当前状态；打开超时已过时报告为半开
+// State returns the current state, reporting half-open once the open timeout has passed
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Metrics 当前窗口的统计
+// Metrics returns statistics of the current window
+func (b *Breaker) Metrics() BreakerMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := BreakerMetrics{State: b.state, Calls: b.calls, Failures: b.failures, SlowCalls: b.slows}
+	if b.calls > 0 {
+		m.FailureRate = float64(b.failures) / float64(b.calls)
+		m.SlowCallRate = float64(b.slows) / float64(b.calls)
+	}
+	return m
+}
+
+// Reset 强制回到关闭状态并清空窗口
+// Reset forces the breaker closed and clears the window
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	notify := b.transition(StateClosed)
+	b.mu.Unlock()
+	notify()
+}
+
+// Execute 放行时执行调用并记录结果，否则返回 ErrOpen；fn panic 时记为失败后继续 panic
+// Execute runs the call and records its outcome when permitted, otherwise it returns ErrOpen.
+// A panic in fn is recorded as a failure and then propagated
+func (b *Breaker) Execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	gen, err := b.acquire()
+	if err != nil {
+		return err
+	}
+	start := b.now()
+	panicked := true
+	defer func() {
+		if panicked {
+			b.record(gen, outcomeFailed)
+		}
+	}()
+	err = fn(ctx)
+	panicked = false
+
+	var o uint8
+	if err != nil && b.isFailure(err) {
+		o |= outcomeFailed
+	}
+	if b.slowDuration > 0 && b.now().Sub(start) >= b.slowDuration {
+		o |= outcomeSlow
+	}
+	b.record(gen, o)
+	return err
+}
+
+// acquire 判断是否放行，返回当前代数以忽略跨状态的过期结果
+// acquire decides whether to permit a call and returns the generation so stale outcomes can be ignored
+func (b *Breaker) acquire() (uint64, error) {
+	b.mu.Lock()
+	notify := func() {}
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return 0, ErrOpen
+		}
+		notify = b.transition(StateHalfOpen)
+	}
+	if b.state == StateHalfOpen {
+		if b.trials >= b.halfOpenCalls {
+			return 0, ErrOpen
+		}
+		b.trials++
+	}
+	return b.gen, nil
+}
+
+// record 记录一次调用结果并在需要时切换状态
+// record stores an outcome and switches state when a threshold is crossed
+func (b *Breaker) record(gen uint64, o uint8) {
+	b.mu.Lock()
+	notify := func() {}
+	defer func() {
+		b.mu.Unlock()
+		notify()
+	}()
+	if gen != b.gen {
+		return
+	}
+	if b.state == StateHalfOpen {
+		b.trialCalls++
+		b.trialFailures += int(o & outcomeFailed)
+		b.trialSlows += int(o&outcomeSlow) >> 1
+		if b.trialCalls >= b.halfOpenCalls {
+			if b.exceeded(b.trialCalls, b.trialFailures, b.trialSlows) {
+				notify = b.transition(StateOpen)
+			} else {
+				notify = b.transition(StateClosed)
+			}
+		}
+		return
+	}
+	old := b.ring[b.pos]
+	if b.calls == b.windowSize {
+		b.failures -= int(old & outcomeFailed)
+		b.slows -= int(old&outcomeSlow) >> 1
+	} else {
+		b.calls++
+	}
+	b.ring[b.pos] = o
+	b.pos = (b.pos + 1) % b.windowSize
+	b.failures += int(o & outcomeFailed)
+	b.slows += int(o&outcomeSlow) >> 1
+
+	if b.state == StateClosed && b.calls >= b.minCalls && b.exceeded(b.calls, b.failures, b.slows) {
+		notify = b.transition(StateOpen)
+	}
+}
+
+func (b *Breaker) exceeded(calls, failures, slows int) bool {
+	if b.failureRate > 0 && float64(failures) >= b.failureRate*float64(calls) {
+		return true
+	}
+	return b.slowDuration > 0 && b.slowRate > 0 && float64(slows) >= b.slowRate*float64(calls)
+}
+
+// transition 切换状态并清空窗口，返回需要在锁外调用的通知
+// transition switches state and clears the window; the returned notification must run outside the lock
+func (b *Breaker) transition(to State) func() {
+	from := b.state
+	b.state = to
+	b.gen++
+	clear(b.ring)
+	b.pos, b.calls, b.failures, b.slows, b.trials = 0, 0, 0, 0, 0
+	b.trialCalls, b.trialFailures, b.trialSlows = 0, 0, 0
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+	if b.onStateChange == nil || from == to {
+		return func() {}
+	}
+	return func() { b.onStateChange(b.name, from, to) }
+}
@@ -0,0 +1,114 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/karosown/katool-go/lock"
+)
+
+// ErrBulkheadFull 舱壁已满，调用未被执行
+// ErrBulkheadFull is returned when the bulkhead has no free slot and the call was not run
+var ErrBulkheadFull = errors.New("resilience: bulkhead is full")
+
+// ErrTimeout 调用超过 Timeout 策略设定的时间
+// ErrTimeout is returned when a call exceeds the Timeout policy's duration
+var ErrTimeout = errors.New("resilience: call timed out")
+
+// Bulkhead 舱壁隔离：限制同时进行的调用数，避免一个慢依赖耗尽所有资源
+// Bulkhead limits concurrent calls so one slow dependency cannot exhaust every resource
+type Bulkhead struct {
+	sem     *lock.Semaphore
+	maxWait time.Duration
+}
+
+// NewBulkhead 创建舱壁，maxWait 为等待空位的最长时间：0 不等待，负数一直等到ctx结束
+// NewBulkhead creates a bulkhead; maxWait bounds the wait for a free slot, 0 means no waiting and a
+// negative value waits until ctx is done
+func NewBulkhead(maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{sem: lock.NewSemaphore(max(maxConcurrent, 1)), maxWait: maxWait}
+}
+
+// Available 当前空闲的名额
+// Available returns the number of free slots
+func (b *Bulkhead) Available() int {
+	return b.sem.Available()
+}
+
+// Execute 占用一个名额执行调用，等待超时返回 ErrBulkheadFull，ctx结束返回ctx的错误
+// Execute runs the call in a slot; it returns ErrBulkheadFull when the wait times out and ctx's error
+// when ctx is done
+func (b *Bulkhead) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if b.maxWait == 0 {
+		if !b.sem.TryAcquire(1) {
+			return ErrBulkheadFull
+		}
+	} else {
+		wctx := ctx
+		if b.maxWait > 0 {
+			var cancel context.CancelFunc
+			wctx, cancel = context.WithTimeout(ctx, b.maxWait)
+			defer cancel()
+		}
+		if err := b.sem.Acquire(wctx, 1); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return ErrBulkheadFull
+		}
+	}
+	defer b.sem.Release(1)
+	return fn(ctx)
+}
+
+// Timeout 超时策略：到时立即返回 ErrTimeout 并取消调用的ctx，不响应ctx的调用会在后台跑完
+// Timeout returns ErrTimeout as soon as the duration passes and cancels the call's ctx; a call that
+// ignores ctx finishes in the background
+type Timeout struct {
+	d time.Duration
+}
+
+// NewTimeout 创建超时策略
+// NewTimeout creates a timeout policy
+func NewTimeout(d time.Duration) *Timeout {
+	return &Timeout{d: d}
+}
+
+func (t *Timeout) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	tctx, cancel := context.WithTimeoutCause(ctx, t.d, ErrTimeout)
+	defer cancel()
+
+	type result struct {
+		err   error
+		panic any
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() {
+			if v := recover(); v != nil {
+				r.panic = v
+			}
+			done <- r
+		}()
+		r.err = fn(tctx)
+	}()
+
+	select {
+	case r := <-done:
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		if r.err != nil && ctx.Err() == nil && errors.Is(context.Cause(tctx), ErrTimeout) {
+			return fmt.Errorf("%w: %w", ErrTimeout, r.err)
+		}
+		return r.err
+	case <-tctx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrTimeout
+	}
+}
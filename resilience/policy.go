@@ -0,0 +1,125 @@
+package resilience
+
+// 外部调用的容错策略：重试、熔断、舱壁隔离与超时，可以自由组合
+//
+// Package resilience provides fault-tolerance policies for outbound calls: retry, circuit breaker,
+// bulkhead and timeout, which compose freely
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Policy 容错策略，包装一次调用
+// Policy wraps a call with fault-tolerance behaviour
+type Policy interface {
+	Execute(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// PolicyFunc 函数形式的策略
+// PolicyFunc adapts a function to a Policy
+type PolicyFunc func(ctx context.Context, fn func(ctx context.Context) error) error
+
+func (f PolicyFunc) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	return f(ctx, fn)
+}
+
+// Wrap 组合多个策略，第一个在最外层：Wrap(retry, breaker, timeout) 即每次重试都经过熔断器，且每次尝试单独计时
+// Wrap composes policies with the first one outermost: Wrap(retry, breaker, timeout) sends every retry
+// through the breaker and times every attempt separately
+func Wrap(policies ...Policy) Policy {
+	return PolicyFunc(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		call := fn
+		for i := len(policies) - 1; i >= 0; i-- {
+			p, next := policies[i], call
+			if p == nil {
+				continue
+			}
+			call = func(ctx context.Context) error { return p.Execute(ctx, next) }
+		}
+		return call(ctx)
+	})
+}
+
+// Do 在策略下执行带返回值的调用，只返回决定最终结果的那次尝试的值；策略提前返回（如超时）后
+// 仍在运行的调用，其结果会被丢弃
+// Do runs a call with a result under the policy and returns only the value of the attempt whose error
+// decided the outcome; results of calls still running after the policy returned early, for example on
+// timeout, are discarded
+func Do[T any](ctx context.Context, p Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	var (
+		mu       sync.Mutex
+		last     chan result
+		returned bool
+	)
+	err := p.Execute(ctx, func(ctx context.Context) error {
+		// 每次尝试使用自己的通道，被放弃的尝试无法覆盖之后尝试的结果
+		// every attempt owns its channel, so an abandoned attempt cannot overwrite a later one's result
+		ch := make(chan result, 1)
+		mu.Lock()
+		if !returned {
+			last = ch
+		}
+		mu.Unlock()
+		v, err := fn(ctx)
+		ch <- result{v: v, err: err}
+		return err
+	})
+	mu.Lock()
+	returned = true
+	ch := last
+	mu.Unlock()
+
+	// 策略按顺序发起尝试，最后一次尝试的错误与最终错误一致时才是它决定了结果
+	// policies start attempts one after another, the last one decided the outcome only when its error
+	// matches the returned one
+	var zero T
+	if ch == nil {
+		return zero, err
+	}
+	select {
+	case r := <-ch:
+		if errors.Is(err, r.err) || errors.Is(r.err, err) {
+			return r.v, err
+		}
+	default:
+	}
+	return zero, err
+}
+
+// permanentError 标记为不可重试的错误
+// permanentError marks an error as not retryable
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 把错误标记为不可重试，Retry 会立即返回原错误
+// Permanent marks err as not retryable, Retry returns the original error at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 错误是否被标记为不可重试
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// unwrapPermanent 去掉 Permanent 标记 / unwrapPermanent strips the Permanent marker
+func unwrapPermanent(err error) error {
+	var p *permanentError
+	if errors.As(err, &p) && err == error(p) {
+		return p.err
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff 第attempt次失败后（从1开始）等待多久再重试
+// Backoff returns how long to wait after the attempt-th failure, counting from 1
+type Backoff func(attempt int) time.Duration
+
+// Constant 固定间隔
+// Constant waits the same duration every time
+func Constant(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// Linear 线性增长：initial, initial+step, initial+2*step...
+// Linear grows linearly: initial, initial+step, initial+2*step...
+func Linear(initial, step time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return initial + time.Duration(attempt-1)*step
+	}
+}
+
+// Exponential 指数增长：initial*multiplier^(attempt-1)，不超过maxDelay（为0时不限）
+// Exponential grows as initial*multiplier^(attempt-1), capped at maxDelay unless it is 0
+func Exponential(initial, maxDelay time.Duration, multiplier float64) Backoff {
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	return func(attempt int) time.Duration {
+		d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+		if maxDelay > 0 && d > float64(maxDelay) {
+			return maxDelay
+		}
+		return time.Duration(d)
+	}
+}
+
+// Jitter 在退避时间上加入 ±factor 比例的随机抖动，避免大量客户端同时重试
+// Jitter randomizes the backoff by ±factor so that many clients do not retry in lockstep
+func Jitter(b Backoff, factor float64) Backoff {
+	factor = min(max(factor, 0), 1)
+	return func(attempt int) time.Duration {
+		d := float64(b(attempt))
+		return time.Duration(d * (1 - factor + 2*factor*rand.Float64()))
+	}
+}
+
+// RetryOption 重试选项
+// RetryOption configures a Retry
+type RetryOption func(*Retry)
+
+// WithMaxAttempts 最多尝试次数（含第一次），默认3
+// WithMaxAttempts sets the maximum attempts including the first, 3 by default
+func WithMaxAttempts(n int) RetryOption {
+	return func(r *Retry) { r.maxAttempts = max(n, 1) }
+}
+
+// WithBackoff 设置退避策略，默认 100ms 起、最多 10s 的指数退避并带 20% 抖动
+// WithBackoff sets the backoff, by default exponential from 100ms up to 10s with 20% jitter
+func WithBackoff(b Backoff) RetryOption {
+	return func(r *Retry) { r.backoff = b }
+}
+
+// WithRetryIf 设置可重试错误的判断，默认除 ErrOpen 外都重试；Permanent 错误与ctx结束时始终不重试
+// WithRetryIf sets the retryable-error classifier, by default everything but ErrOpen is retried;
+// Permanent errors and a done ctx always stop retrying
+func WithRetryIf(retryable func(err error) bool) RetryOption {
+	return func(r *Retry) { r.retryable = retryable }
+}
+
+// WithOnRetry 每次重试前的回调
+// WithOnRetry sets a listener called before every retry
+func WithOnRetry(listener func(attempt int, err error, delay time.Duration)) RetryOption {
+	return func(r *Retry) { r.onRetry = listener }
+}
+
+// Retry 重试策略
+// Retry retries failed calls
+type Retry struct {
+	maxAttempts int
+	backoff     Backoff
+	retryable   func(err error) bool
+	onRetry     func(attempt int, err error, delay time.Duration)
+}
+
+// NewRetry 创建重试策略
+// NewRetry creates a retry policy
+func NewRetry(opts ...RetryOption) *Retry {
+	r := &Retry{
+		maxAttempts: 3,
+		backoff:     Jitter(Exponential(100*time.Millisecond, 10*time.Second, 2), 0.2),
+		retryable:   DefaultRetryable,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRetryable 默认的可重试判断
+// DefaultRetryable is the default retryable-error classifier
+func DefaultRetryable(err error) bool {
+	return !errors.Is(err, ErrOpen)
+}
+
+// Execute 执行调用，失败且可重试时按退避等待后重试；返回最后一次的错误
+// Execute runs the call and retries retryable failures after the backoff; it returns the last error
+func (r *Retry) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if IsPermanent(err) {
+			return unwrapPermanent(err)
+		}
+		if attempt >= r.maxAttempts || !r.retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		delay := r.backoff(attempt)
+		if r.onRetry != nil {
+			r.onRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
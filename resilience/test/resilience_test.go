@@ -0,0 +1,465 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/file/file_downloader"
+	remote "github.com/karosown/katool-go/net/http"
+	"github.com/karosown/katool-go/resilience"
+	"github.com/stretchr/testify/assert"
+)
+
+var errBoom = errors.New("boom")
+
+// 测试退避策略
+func TestBackoff(t *testing.T) {
+	exp := resilience.Exponential(10*time.Millisecond, 50*time.Millisecond, 2)
+	assert.Equal(t, 10*time.Millisecond, exp(1))
+	assert.Equal(t, 40*time.Millisecond, exp(3))
+	assert.Equal(t, 50*time.Millisecond, exp(4))
+	assert.Equal(t, 30*time.Millisecond, resilience.Linear(10*time.Millisecond, 10*time.Millisecond)(3))
+
+	j := resilience.Jitter(resilience.Constant(100*time.Millisecond), 0.2)
+	for i := 0; i < 100; i++ {
+		d := j(1)
+		assert.GreaterOrEqual(t, d, 80*time.Millisecond)
+		assert.LessOrEqual(t, d, 120*time.Millisecond)
+	}
+}
+
+// 测试重试次数、监听器、分类器与 Permanent
+func TestRetry(t *testing.T) {
+	var retries []int
+	r := resilience.NewRetry(
+		resilience.WithMaxAttempts(4),
+		resilience.WithBackoff(resilience.Constant(time.Millisecond)),
+		resilience.WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			retries = append(retries, attempt)
+		}),
+	)
+	calls := 0
+	err := r.Execute(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errBoom
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	calls = 0
+	err = r.Execute(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, 4, calls)
+
+	calls = 0
+	err = r.Execute(context.Background(), func(ctx context.Context) error {
+		calls++
+		return resilience.Permanent(errBoom)
+	})
+	assert.Equal(t, errBoom, err)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	onlyBoom := resilience.NewRetry(resilience.WithBackoff(resilience.Constant(0)),
+		resilience.WithRetryIf(func(err error) bool { return errors.Is(err, errBoom) }))
+	other := errors.New("other")
+	err = onlyBoom.Execute(context.Background(), func(ctx context.Context) error {
+		calls++
+		return other
+	})
+	assert.Equal(t, other, err)
+	assert.Equal(t, 1, calls)
+
+	// ctx结束时停止等待 / stops waiting once ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	slow := resilience.NewRetry(resilience.WithMaxAttempts(10), resilience.WithBackoff(resilience.Constant(time.Hour)))
+	start := time.Now()
+	assert.ErrorIs(t, slow.Execute(ctx, func(ctx context.Context) error { return errBoom }), errBoom)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func run(b *resilience.Breaker, err error) error {
+	return b.Execute(context.Background(), func(ctx context.Context) error { return err })
+}
+
+// 测试熔断器的状态流转与监听器
+func TestBreaker(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	var changes []string
+	b := resilience.NewBreaker("svc",
+		resilience.WithWindowSize(10),
+		resilience.WithMinimumCalls(4),
+		resilience.WithFailureRate(0.5),
+		resilience.WithOpenTimeout(time.Second),
+		resilience.WithHalfOpenCalls(2),
+		resilience.WithBreakerClock(c.Now),
+		resilience.WithOnStateChange(func(name string, from, to resilience.State) {
+			changes = append(changes, name+":"+from.String()+"->"+to.String())
+		}),
+	)
+
+	assert.NoError(t, run(b, nil))
+	assert.NoError(t, run(b, nil))
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	assert.Equal(t, resilience.StateClosed, b.State())
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	assert.Equal(t, resilience.StateOpen, b.State())
+
+	called := false
+	err := b.Execute(context.Background(), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, resilience.ErrOpen)
+	assert.False(t, called)
+
+	// 半开试探失败后重新打开 / a failed trial reopens the breaker
+	c.Advance(time.Second)
+	assert.Equal(t, resilience.StateHalfOpen, b.State())
+	assert.NoError(t, run(b, nil))
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	assert.Equal(t, resilience.StateOpen, b.State())
+
+	// 半开试探全部成功后关闭 / successful trials close the breaker
+	c.Advance(time.Second)
+	assert.NoError(t, run(b, nil))
+	assert.NoError(t, run(b, nil))
+	assert.Equal(t, resilience.StateClosed, b.State())
+	assert.Equal(t, []string{
+		"svc:closed->open",
+		"svc:open->half-open",
+		"svc:half-open->open",
+		"svc:open->half-open",
+		"svc:half-open->closed",
+	}, changes)
+
+	// 取消不计为失败 / cancellation does not count as a failure
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, run(b, context.Canceled), context.Canceled)
+	}
+	assert.Equal(t, resilience.StateClosed, b.State())
+	assert.Equal(t, 0, b.Metrics().Failures)
+}
+
+// 测试半开状态只放行有限的试探调用
+func TestBreakerHalfOpenLimit(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	b := resilience.NewBreaker("svc", resilience.WithMinimumCalls(1), resilience.WithHalfOpenCalls(1),
+		resilience.WithOpenTimeout(time.Second), resilience.WithBreakerClock(c.Now))
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	c.Advance(time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = b.Execute(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	assert.ErrorIs(t, run(b, nil), resilience.ErrOpen)
+	close(release)
+	assert.Eventually(t, func() bool { return b.State() == resilience.StateClosed }, time.Second, time.Millisecond)
+}
+
+// 测试窗口小于半开试探数时半开状态仍能结束
+func TestBreakerHalfOpenLargerThanWindow(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	b := resilience.NewBreaker("svc", resilience.WithWindowSize(2), resilience.WithMinimumCalls(1),
+		resilience.WithHalfOpenCalls(5), resilience.WithOpenTimeout(time.Second), resilience.WithBreakerClock(c.Now))
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	assert.Equal(t, resilience.StateOpen, b.State())
+
+	c.Advance(time.Second)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, run(b, nil))
+		assert.Equal(t, resilience.StateHalfOpen, b.State())
+	}
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	assert.Equal(t, resilience.StateClosed, b.State(), "1/5 的失败率低于阈值")
+
+	assert.ErrorIs(t, run(b, errBoom), errBoom)
+	c.Advance(time.Second)
+	for i := 0; i < 5; i++ {
+		assert.ErrorIs(t, run(b, errBoom), errBoom)
+	}
+	assert.Equal(t, resilience.StateOpen, b.State())
+}
+
+// 测试慢调用率阈值
+func TestBreakerSlowCalls(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	b := resilience.NewBreaker("svc",
+		resilience.WithMinimumCalls(2),
+		resilience.WithSlowCall(100*time.Millisecond, 1),
+		resilience.WithBreakerClock(c.Now),
+	)
+	slow := func(ctx context.Context) error {
+		c.Advance(200 * time.Millisecond)
+		return nil
+	}
+	assert.NoError(t, b.Execute(context.Background(), slow))
+	assert.NoError(t, b.Execute(context.Background(), slow))
+	assert.Equal(t, resilience.StateOpen, b.State())
+
+	b.Reset()
+	assert.Equal(t, resilience.StateClosed, b.State())
+	assert.Equal(t, resilience.BreakerMetrics{State: resilience.StateClosed}, b.Metrics())
+}
+
+// 测试舱壁限制并发
+func TestBulkhead(t *testing.T) {
+	b := resilience.NewBulkhead(2, 0)
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = b.Execute(context.Background(), func(ctx context.Context) error {
+				<-release
+				return nil
+			})
+		}()
+	}
+	assert.Eventually(t, func() bool { return b.Available() == 0 }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, b.Execute(context.Background(), func(ctx context.Context) error { return nil }), resilience.ErrBulkheadFull)
+
+	waiting := resilience.NewBulkhead(1, 20*time.Millisecond)
+	_ = waiting.Execute(context.Background(), func(ctx context.Context) error {
+		assert.ErrorIs(t, waiting.Execute(context.Background(), func(ctx context.Context) error { return nil }),
+			resilience.ErrBulkheadFull)
+		return nil
+	})
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 2, b.Available())
+}
+
+// 测试超时策略
+func TestTimeout(t *testing.T) {
+	to := resilience.NewTimeout(20 * time.Millisecond)
+	start := time.Now()
+	err := to.Execute(context.Background(), func(ctx context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	assert.ErrorIs(t, err, resilience.ErrTimeout)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	err = to.Execute(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, resilience.ErrTimeout)
+	assert.NoError(t, to.Execute(context.Background(), func(ctx context.Context) error { return nil }))
+	assert.Panics(t, func() {
+		_ = to.Execute(context.Background(), func(ctx context.Context) error { panic("oops") })
+	})
+}
+
+// 测试组合顺序：每次重试都单独计时并经过熔断器
+func TestWrap(t *testing.T) {
+	b := resilience.NewBreaker("svc", resilience.WithMinimumCalls(2), resilience.WithFailureRate(1))
+	p := resilience.Wrap(
+		resilience.NewRetry(resilience.WithMaxAttempts(5), resilience.WithBackoff(resilience.Constant(0))),
+		b,
+		resilience.NewTimeout(10*time.Millisecond),
+	)
+	var calls atomic.Int32
+	err := p.Execute(context.Background(), func(ctx context.Context) error {
+		calls.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	// 两次超时后熔断器打开，重试遇到 ErrOpen 停止 / the breaker opens after two timeouts and retry stops at ErrOpen
+	assert.ErrorIs(t, err, resilience.ErrOpen)
+	assert.Equal(t, int32(2), calls.Load())
+
+	res, err := resilience.Do(context.Background(), resilience.Wrap(), func(ctx context.Context) (string, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res)
+}
+
+// 测试被超时放弃的慢尝试不会覆盖之后成功的结果
+func TestDoAbandonedAttempt(t *testing.T) {
+	slowDone := make(chan struct{})
+	// 最外层等慢尝试结束后才返回，让它在 Do 取结果前完成 / the outer policy returns only after the slow attempt finished
+	waitSlow := resilience.PolicyFunc(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		err := fn(ctx)
+		<-slowDone
+		return err
+	})
+	p := resilience.Wrap(
+		waitSlow,
+		resilience.NewRetry(resilience.WithMaxAttempts(2), resilience.WithBackoff(resilience.Constant(0))),
+		resilience.NewTimeout(20*time.Millisecond),
+	)
+	var calls atomic.Int32
+	fast := make(chan struct{})
+	res, err := resilience.Do(context.Background(), p, func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			defer close(slowDone)
+			<-fast
+			return "slow", nil
+		}
+		close(fast)
+		return "fast", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", res)
+
+	// 决定结果的尝试失败时返回它的值 / the value of the failing attempt that decided the outcome is returned
+	res, err = resilience.Do(context.Background(), resilience.NewRetry(resilience.WithMaxAttempts(2), resilience.WithBackoff(resilience.Constant(0))),
+		func(ctx context.Context) (string, error) { return "partial", resilience.Permanent(errBoom) })
+	assert.ErrorIs(t, err, errBoom)
+	assert.Equal(t, "partial", res)
+}
+
+func flakyServer(failures int32) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"katool"}`))
+	})), &hits
+}
+
+// 测试 Req 与 FileDownloader 接入策略
+func TestOptIn(t *testing.T) {
+	retry := resilience.NewRetry(resilience.WithBackoff(resilience.Constant(time.Millisecond)))
+
+	srv, hits := flakyServer(2)
+	defer srv.Close()
+	var back struct {
+		Name string `json:"name"`
+	}
+	_, rerr := remote.NewReq().Url(srv.URL).Method(http.MethodGet).Resilience(retry).Build(&back)
+	assert.Nil(t, rerr)
+	assert.Equal(t, "katool", back.Name)
+	assert.Equal(t, int32(3), hits.Load())
+
+	srv2, hits2 := flakyServer(1)
+	defer srv2.Close()
+	data, err := file_downloader.NewFileDownloader().WithPolicy(retry).DownloadFileBytes(srv2.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"katool"}`, string(data))
+	assert.Equal(t, int32(2), hits2.Load())
+
+	// 404 不重试 / 404 is not retried
+	var notFound atomic.Int32
+	srv3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notFound.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv3.Close()
+	_, err = file_downloader.NewFileDownloader().WithPolicy(retry).DownloadFileBytes(srv3.URL)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), notFound.Load())
+}
+
+// 测试超时的尝试会被取消，SSE 连接建立后不受策略的ctx影响且 4xx 不重试
+func TestOptInContext(t *testing.T) {
+	var hits, cancelled atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				cancelled.Add(1)
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"katool"}`))
+	}))
+	defer srv.Close()
+	policy := resilience.Wrap(
+		resilience.NewRetry(resilience.WithBackoff(resilience.Constant(0))),
+		resilience.NewTimeout(50*time.Millisecond),
+	)
+	var back struct {
+		Name string `json:"name"`
+	}
+	_, rerr := remote.NewReq().Url(srv.URL).Method(http.MethodGet).Resilience(policy).Build(&back)
+	assert.Nil(t, rerr)
+	assert.Equal(t, "katool", back.Name)
+	assert.Eventually(t, func() bool { return cancelled.Load() == 1 }, time.Second, 5*time.Millisecond, "超时的尝试应被取消")
+
+	events := make(chan string, 2)
+	sse := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			hits.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		// 超过超时策略的时长后再发送 / sent after the timeout policy's duration has passed
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("data: second\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer sse.Close()
+	req := remote.NewSSEReq[string]().Url(sse.URL).Resilience(policy).
+		BeforeEvent(func(e remote.SSEEvent[string]) (*string, error) { return &e.Data, nil }).
+		OnEvent(func(data string) error {
+			events <- data
+			return nil
+		})
+	assert.NoError(t, req.Connect())
+	defer req.Disconnect()
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-events:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("missing event %q", want)
+		}
+	}
+
+	hits.Store(0)
+	err := remote.NewSSEReq[string]().Url(sse.URL + "/missing").Resilience(policy).Connect()
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), hits.Load(), "4xx 不应重试")
+}
@@ -0,0 +1,323 @@
+package ruleengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/karosown/katool-go/ruleengine/expr"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition 声明式规则定义，可从 JSON 或 YAML 加载。Rules 注册为可复用的命名规则，Chains 编译为规则链
+// Definition is a declarative rule definition loadable from JSON or YAML. Rules are registered as reusable
+// named rules and Chains are compiled into rule chains
+type Definition struct {
+	Rules  []RuleDef  `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Chains []ChainDef `json:"chains,omitempty" yaml:"chains,omitempty"`
+}
+
+// ChainDef 规则链定义。Rules 构成根节点下的第一层，同层规则都基于上一层的结果判断条件，依赖前一条规则结果的规则应放在其 Children 中
+// ChainDef defines a rule chain. Its Rules form the first layer under the root; rules of one layer all see
+// the previous layer's output, so a rule depending on another one's effects belongs in its Children
+type ChainDef struct {
+	Name  string    `json:"name" yaml:"name"`
+	Rules []RuleDef `json:"rules" yaml:"rules"`
+}
+
+// RuleDef 规则定义，条件与动作使用 expr 表达式语言，事实数据的字段可直接作为标识符使用
+// RuleDef defines a rule whose condition and actions are written in the expr language, with the fact's
+// fields usable directly as identifiers
+//
+//	name: vip_discount
+//	when: "customer.level == 'vip' && amount >= 100"
+//	then: ["discount = amount * 0.1", "tags = tags + ['vip']"]
+//	result: "amount - discount"
+//	children: [...]
+type RuleDef struct {
+	// Name 规则名称，用于错误信息与注册 / Name is used in errors and for registration
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// When 条件表达式，为空表示总是执行 / When is the condition, empty means always
+	When string `json:"when,omitempty" yaml:"when,omitempty"`
+	// Then 条件成立时依次执行的语句，通常为赋值 / Then lists the statements, usually assignments, run when the condition holds
+	Then []string `json:"then,omitempty" yaml:"then,omitempty"`
+	// Else 条件不成立时执行的语句 / Else lists the statements run when the condition fails
+	Else []string `json:"else,omitempty" yaml:"else,omitempty"`
+	// Result 结果表达式，为空时传递上一层的结果，也可在 Then 中对 result 赋值 / Result is the result
+	// expression; when empty the previous layer's result is passed on, unless Then assigns result
+	Result string `json:"result,omitempty" yaml:"result,omitempty"`
+	// Control 执行后的控制：stop 终止整棵规则树（EOF），fallthrough 跳过子规则继续执行（FALLTHROUGH）/
+	// Control after running: stop ends the whole tree (EOF), fallthrough skips the children and goes on (FALLTHROUGH)
+	Control string `json:"control,omitempty" yaml:"control,omitempty"`
+	// Children 条件成立并执行后进入的下一层规则 / Children is the next layer, entered after the rule ran
+	Children []RuleDef `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+const (
+	// ControlStop 终止整棵规则树 / ends the whole rule tree
+	ControlStop = "stop"
+	// ControlFallthrough 跳过子规则继续执行 / skips the children and goes on
+	ControlFallthrough = "fallthrough"
+)
+
+// ParseJSON 解析 JSON 规则定义
+// ParseJSON parses a JSON rule definition
+func ParseJSON(data []byte) (*Definition, error) {
+	def := &Definition{}
+	if err := json.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("ruleengine: parse json: %w", err)
+	}
+	return def, nil
+}
+
+// ParseYAML 解析 YAML 规则定义
+// ParseYAML parses a YAML rule definition
+func ParseYAML(data []byte) (*Definition, error) {
+	def := &Definition{}
+	if err := yaml.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("ruleengine: parse yaml: %w", err)
+	}
+	return def, nil
+}
+
+// LoadJSON 加载 JSON 规则定义，opts 用于注册表达式中可调用的函数
+// LoadJSON loads a JSON rule definition, opts register the functions expressions may call
+func (e *RuleEngine[T]) LoadJSON(data []byte, opts ...expr.Option) error {
+	def, err := ParseJSON(data)
+	if err != nil {
+		return err
+	}
+	return e.Load(def, opts...)
+}
+
+// LoadYAML 加载 YAML 规则定义，opts 用于注册表达式中可调用的函数
+// LoadYAML loads a YAML rule definition, opts register the functions expressions may call
+func (e *RuleEngine[T]) LoadYAML(data []byte, opts ...expr.Option) error {
+	def, err := ParseYAML(data)
+	if err != nil {
+		return err
+	}
+	return e.Load(def, opts...)
+}
+
+// Load 编译规则定义并注册到引擎；任何一条规则编译失败时不注册任何内容，同名的规则与规则链被替换
+// Load compiles a definition and registers it with the engine. Nothing is registered if any rule fails to
+// compile; rules and chains with existing names are replaced
+func (e *RuleEngine[T]) Load(def *Definition, opts ...expr.Option) error {
+	rules := make(map[string]*RuleNode[T], len(def.Rules))
+	for _, r := range def.Rules {
+		if r.Name == "" {
+			return errors.New("ruleengine: a named rule needs a name")
+		}
+		if len(r.Else) > 0 {
+			return fmt.Errorf("ruleengine: rule %q: else is only supported inside chains", r.Name)
+		}
+		node, err := CompileRule[T](r, opts...)
+		if err != nil {
+			return err
+		}
+		rules[r.Name] = node
+	}
+	chains := make(map[string]*RuleTree[T], len(def.Chains))
+	for _, c := range def.Chains {
+		if _, ok := chains[c.Name]; ok {
+			return fmt.Errorf("ruleengine: duplicate chain %q", c.Name)
+		}
+		tree, err := CompileChain[T](c, opts...)
+		if err != nil {
+			return err
+		}
+		chains[c.Name] = tree
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for name, node := range rules {
+		e.rules[name] = node
+	}
+	for name, tree := range chains {
+		e.chains[name] = tree
+	}
+	return nil
+}
+
+// RegisterChain 注册已构建的规则树，同名的规则链被替换
+// RegisterChain registers a built rule tree, replacing a chain with the same name
+func (e *RuleEngine[T]) RegisterChain(name string, tree *RuleTree[T]) *RuleEngine[T] {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.chains[name] = tree
+	return e
+}
+
+// CompileChain 把规则链定义编译为规则树，根节点总是执行并原样传递数据，定义中的规则为其下一层
+// CompileChain compiles a chain definition into a rule tree whose root always runs and passes the data on,
+// with the defined rules as its next layer
+func CompileChain[T any](def ChainDef, opts ...expr.Option) (*RuleTree[T], error) {
+	if def.Name == "" {
+		return nil, errors.New("ruleengine: a chain needs a name")
+	}
+	if len(def.Rules) == 0 {
+		return nil, fmt.Errorf("ruleengine: chain %q has no rules", def.Name)
+	}
+	layer, err := CompileRules[T](def.Rules, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ruleengine: chain %q: %w", def.Name, err)
+	}
+	root := NewRuleNode(func(T, any) bool { return true }, func(data T, _ any) (T, any, error) {
+		return data, nil, nil
+	}, layer...)
+	root.Name = def.Name
+	return NewRuleTree(root), nil
+}
+
+// CompileRules 编译同一层的规则，带 Else 的规则额外生成一个条件相反的节点
+// CompileRules compiles the rules of one layer; a rule with Else adds a node with the opposite condition
+func CompileRules[T any](defs []RuleDef, opts ...expr.Option) (RuleLayer[T], error) {
+	var layer RuleLayer[T]
+	for i, d := range defs {
+		node, err := CompileRule[T](d, opts...)
+		if err != nil {
+			return nil, err
+		}
+		layer = append(layer, node)
+		if len(d.Else) > 0 {
+			stmts, err := compileStatements(d.Else, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s else: %w", ruleLabel(d, i), err)
+			}
+			elseNode := node.Else(nil)
+			if d.Name != "" {
+				elseNode.Name = d.Name + " else"
+			}
+			exec, label := execFunc(elseNode, stmts, nil, nil), ruleLabel(d, i)+" else"
+			elseNode.Exec = func(data T, cvt any) (T, any, error) {
+				data, res, err := exec(data, cvt)
+				if err != nil {
+					err = fmt.Errorf("%s: %w", label, err)
+				}
+				return data, res, err
+			}
+			layer = append(layer, elseNode)
+		}
+	}
+	return layer, nil
+}
+
+// CompileRule 把规则定义编译为规则节点（不含 Else）。条件出错时节点仍会被执行并返回该错误
+// CompileRule compiles a rule definition into a rule node, Else excluded. When the condition fails to
+// evaluate the node still runs and returns that error
+func CompileRule[T any](def RuleDef, opts ...expr.Option) (*RuleNode[T], error) {
+	label := ruleLabel(def, -1)
+	var when *expr.Program
+	if def.When != "" {
+		var err error
+		if when, err = expr.Compile(def.When, opts...); err != nil {
+			return nil, fmt.Errorf("%s when: %w", label, err)
+		}
+	}
+	stmts, err := compileStatements(def.Then, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s then: %w", label, err)
+	}
+	var result *expr.Program
+	if def.Result != "" {
+		if result, err = expr.Compile(def.Result, opts...); err != nil {
+			return nil, fmt.Errorf("%s result: %w", label, err)
+		}
+	}
+	var control error
+	switch def.Control {
+	case "":
+	case ControlStop:
+		control = EOF
+	case ControlFallthrough:
+		control = FALLTHROUGH
+	default:
+		return nil, fmt.Errorf("%s: unknown control %q", label, def.Control)
+	}
+	children, err := CompileRules[T](def.Children, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// condErr 记录条件求值的错误，由 Exec 返回；同一棵规则树本就不能并发执行
+	// condErr keeps the condition's evaluation error for Exec to return; a rule tree is not run concurrently anyway
+	var condErr error
+	node := NewRuleNode[T](func(data T, cvt any) bool {
+		condErr = nil
+		if when == nil {
+			return true
+		}
+		ok, err := when.EvalBool(env(&data, cvt))
+		if err != nil {
+			condErr = fmt.Errorf("%s when: %w", label, err)
+			return true
+		}
+		return ok
+	}, nil, children...)
+	node.Name = def.Name
+	exec := execFunc(node, stmts, result, control)
+	node.Exec = func(data T, cvt any) (T, any, error) {
+		if condErr != nil {
+			return data, node.ConvertData, condErr
+		}
+		data, res, err := exec(data, cvt)
+		if err != nil && !errors.Is(err, EOF) && !errors.Is(err, FALLTHROUGH) {
+			err = fmt.Errorf("%s: %w", label, err)
+		}
+		return data, res, err
+	}
+	return node, nil
+}
+
+// execFunc 依次执行语句并计算结果；result 变量的初值为该节点从上一层得到的结果
+// execFunc runs the statements and computes the result; the result variable starts as the result the node
+// received from the previous layer
+func execFunc[T any](node *RuleNode[T], stmts []*expr.Program, result *expr.Program, control error) func(T, any) (T, any, error) {
+	return func(data T, _ any) (T, any, error) {
+		ev := env(&data, node.ConvertData)
+		for _, s := range stmts {
+			if _, err := s.Eval(ev); err != nil {
+				return data, node.ConvertData, err
+			}
+		}
+		if result != nil {
+			v, err := result.Eval(ev)
+			if err != nil {
+				return data, node.ConvertData, err
+			}
+			ev.Vars["result"] = v
+		}
+		return data, ev.Vars["result"], control
+	}
+}
+
+// env 表达式环境：fact 为事实数据，result 为当前结果，事实的字段可直接访问
+// env builds the expression environment: fact is the data, result the current result, and the fact's
+// fields are accessible directly
+func env[T any](data *T, result any) expr.Env {
+	return expr.Env{Vars: map[string]any{"fact": data, "result": result}, Root: data}
+}
+
+func compileStatements(src []string, opts []expr.Option) ([]*expr.Program, error) {
+	stmts := make([]*expr.Program, 0, len(src))
+	for _, s := range src {
+		p, err := expr.CompileStatement(s, opts...)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, p)
+	}
+	return stmts, nil
+}
+
+func ruleLabel(def RuleDef, i int) string {
+	switch {
+	case def.Name != "":
+		return fmt.Sprintf("rule %q", def.Name)
+	case i >= 0:
+		return fmt.Sprintf("rule #%d", i)
+	}
+	return "rule"
+}
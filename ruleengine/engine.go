@@ -3,6 +3,9 @@ package ruleengine
 import (
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/karosown/katool-go/container/optional"
 )
 
 // RuleEngine 规则引擎管理器
@@ -24,6 +27,7 @@ type RuleBuilder[T any] struct {
 	engine *RuleEngine[T]
 	nodes  []*RuleNode[T]
 	name   string
+	labels map[*RuleNode[T]]string
 }
 
 // ExecuteResult 执行结果
@@ -33,6 +37,15 @@ type ExecuteResult[T any] struct {
 	Result any
 	Error  error
 	Chain  string
+	// Trace 执行轨迹，仅在使用 WithTrace 或 WithSnapshot 时记录
+	// Trace is the execution trace, recorded only with WithTrace or WithSnapshot
+	Trace *Trace
+}
+
+// ToResult 以 optional.Result 返回执行后的数据与错误
+// ToResult returns the resulting data and error as an optional.Result
+func (r *ExecuteResult[T]) ToResult() optional.Result[T] {
+	return optional.ResultOf(r.Data, r.Error)
 }
 
 // NewRuleEngine 创建新的规则引擎
@@ -77,14 +90,19 @@ func (e *RuleEngine[T]) NewBuilder(chainName string) *RuleBuilder[T] {
 		engine: e,
 		nodes:  make([]*RuleNode[T], 0),
 		name:   chainName,
+		labels: make(map[*RuleNode[T]]string),
 	}
 }
 
-// AddRule 向构建器添加规则（通过名称）
-// AddRule adds a rule to the builder (by name)
+// AddRule 向构建器添加规则（通过名称），节点没有 Name 时轨迹与导出使用注册名
+// AddRule adds a rule to the builder (by name), traces and exports label a node without a Name by its
+// registered name
 func (b *RuleBuilder[T]) AddRule(ruleName string) *RuleBuilder[T] {
 	if rule, exists := b.engine.GetRule(ruleName); exists {
 		b.nodes = append(b.nodes, rule)
+		if _, ok := b.labels[rule]; !ok {
+			b.labels[rule] = ruleName
+		}
 	}
 	return b
 }
@@ -151,6 +169,7 @@ func (b *RuleBuilder[T]) Build() (*RuleTree[T], error) {
 	}
 
 	tree := NewRuleTree(root)
+	tree.labels = b.labels
 
 	// 注册到引擎
 	b.engine.mutex.Lock()
@@ -160,9 +179,19 @@ func (b *RuleBuilder[T]) Build() (*RuleTree[T], error) {
 	return tree, nil
 }
 
-// Execute 执行指定的规则链
-// Execute executes the specified rule chain
-func (e *RuleEngine[T]) Execute(chainName string, data T) *ExecuteResult[T] {
+// GetChain 获取已构建的规则链
+// GetChain gets a built rule chain
+func (e *RuleEngine[T]) GetChain(name string) (*RuleTree[T], bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	chain, exists := e.chains[name]
+	return chain, exists
+}
+
+// Execute 执行指定的规则链，可通过 WithTrace 记录执行轨迹
+// Execute executes the specified rule chain; WithTrace records the execution trace
+func (e *RuleEngine[T]) Execute(chainName string, data T, opts ...ExecuteOption) *ExecuteResult[T] {
 	e.mutex.RLock()
 	chain, exists := e.chains[chainName]
 	e.mutex.RUnlock()
@@ -175,22 +204,29 @@ func (e *RuleEngine[T]) Execute(chainName string, data T) *ExecuteResult[T] {
 		}
 	}
 
+	tr := newTracer(chainName, chain, traceOptions(opts))
+	start := time.Now()
 	// 应用中间件
-	finalData, result, err := e.applyMiddleware(data, func(d T) (T, any, error) {
-		return chain.Run(d)
+	finalData, result, err := e.applyMiddleware(data, tr, func(d T) (T, any, error) {
+		return chain.run(d, tr)
 	})
 
-	return &ExecuteResult[T]{
+	res := &ExecuteResult[T]{
 		Data:   finalData,
 		Result: result,
 		Error:  err,
 		Chain:  chainName,
 	}
+	if tr != nil {
+		tr.trace.Duration, tr.trace.Err = time.Since(start), err
+		res.Trace = tr.trace
+	}
+	return res
 }
 
 // ExecuteAll 执行所有规则链
 // ExecuteAll executes all rule chains
-func (e *RuleEngine[T]) ExecuteAll(data T) map[string]*ExecuteResult[T] {
+func (e *RuleEngine[T]) ExecuteAll(data T, opts ...ExecuteOption) map[string]*ExecuteResult[T] {
 	e.mutex.RLock()
 	chains := make(map[string]*RuleTree[T])
 	for name, chain := range e.chains {
@@ -201,7 +237,7 @@ func (e *RuleEngine[T]) ExecuteAll(data T) map[string]*ExecuteResult[T] {
 	results := make(map[string]*ExecuteResult[T])
 
 	for name := range chains {
-		results[name] = e.Execute(name, data)
+		results[name] = e.Execute(name, data, opts...)
 	}
 
 	return results
@@ -209,7 +245,7 @@ func (e *RuleEngine[T]) ExecuteAll(data T) map[string]*ExecuteResult[T] {
 
 // BatchExecute 批量执行规则链（并发）
 // BatchExecute executes rule chains in batch (concurrent)
-func (e *RuleEngine[T]) BatchExecute(chainNames []string, data T) map[string]*ExecuteResult[T] {
+func (e *RuleEngine[T]) BatchExecute(chainNames []string, data T, opts ...ExecuteOption) map[string]*ExecuteResult[T] {
 	results := make(map[string]*ExecuteResult[T])
 	var mutex sync.Mutex
 	var wg sync.WaitGroup
@@ -218,7 +254,7 @@ func (e *RuleEngine[T]) BatchExecute(chainNames []string, data T) map[string]*Ex
 		wg.Add(1)
 		go func(name string) {
 			defer wg.Done()
-			result := e.Execute(name, data)
+			result := e.Execute(name, data, opts...)
 
 			mutex.Lock()
 			results[name] = result
@@ -240,9 +276,9 @@ func (e *RuleEngine[T]) AddMiddleware(middleware MiddlewareFunc[T]) *RuleEngine[
 	return e
 }
 
-// applyMiddleware 应用中间件
-// applyMiddleware applies middleware
-func (e *RuleEngine[T]) applyMiddleware(data T, final func(T) (T, any, error)) (T, any, error) {
+// applyMiddleware 应用中间件，tr 不为 nil 时记录每一跳
+// applyMiddleware applies middleware, recording each hop when tr is not nil
+func (e *RuleEngine[T]) applyMiddleware(data T, tr *tracer[T], final func(T) (T, any, error)) (T, any, error) {
 	if len(e.middleware) == 0 {
 		return final(data)
 	}
@@ -254,9 +290,12 @@ func (e *RuleEngine[T]) applyMiddleware(data T, final func(T) (T, any, error)) (
 			return final(d)
 		}
 
-		return e.middleware[index](d, func(nextData T) (T, any, error) {
+		done := tr.hop(index, d)
+		nd, res, err := e.middleware[index](d, func(nextData T) (T, any, error) {
 			return applyNext(index+1, nextData)
 		})
+		done(nd, res, err)
+		return nd, res, err
 	}
 
 	return applyNext(0, data)
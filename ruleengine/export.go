@@ -0,0 +1,183 @@
+package ruleengine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// nodeState 节点在一次执行中的状态，用于导出图的着色
+// nodeState is a node's state in one execution, used to colour the exported graphs
+type nodeState int
+
+const (
+	stateUnvisited nodeState = iota
+	stateFailed
+	statePassed
+	stateExecuted
+	stateControl
+	stateError
+)
+
+// graph 规则树与执行轨迹合并后的图，DOT 与 Mermaid 共用
+// graph is the rule tree merged with a trace, shared by DOT and Mermaid
+type graph struct {
+	names  []string
+	notes  []string
+	states []nodeState
+	edges  [][2]int
+	taken  map[[2]int]bool
+}
+
+func newGraph[T any](tree *RuleTree[T], trace *Trace) *graph {
+	ids := nodeIDs(tree.Root)
+	nodes := make([]*RuleNode[T], len(ids))
+	for node, id := range ids {
+		nodes[id] = node
+	}
+	g := &graph{
+		names:  make([]string, len(nodes)),
+		notes:  make([]string, len(nodes)),
+		states: make([]nodeState, len(nodes)),
+		taken:  make(map[[2]int]bool),
+	}
+	for id, node := range nodes {
+		g.names[id] = nodeName(node, tree.labels, id)
+		for _, nxt := range node.NxtLayer {
+			if nxt != nil {
+				g.edges = append(g.edges, [2]int{id, ids[nxt]})
+			}
+		}
+	}
+	if trace == nil {
+		return g
+	}
+	for _, s := range trace.Steps {
+		if s.NodeID < 0 || s.NodeID >= len(nodes) {
+			continue
+		}
+		state := stateFailed
+		switch {
+		case s.Err != nil:
+			state, g.notes[s.NodeID] = stateError, s.Err.Error()
+		case s.Control != "":
+			state, g.notes[s.NodeID] = stateControl, s.Control
+		case s.Executed:
+			state = stateExecuted
+		case s.Passed:
+			state = statePassed
+		}
+		// 同一节点被多次检查时保留最显著的状态 / keep the most notable state of a node checked more than once
+		g.states[s.NodeID] = max(g.states[s.NodeID], state)
+		if s.Executed && s.Parent >= 0 {
+			g.taken[[2]int{s.Parent, s.NodeID}] = true
+		}
+	}
+	return g
+}
+
+// DOT 以 Graphviz DOT 格式导出规则树；trace 不为 nil 时标出执行路径：
+// 执行的节点为绿色，条件不成立为灰色，EOF/FALLTHROUGH 为黄色，出错为红色，走过的边加粗
+// DOT exports the rule tree in Graphviz DOT format. With a trace it marks the path taken: executed nodes
+// are green, failed conditions grey, EOF/FALLTHROUGH yellow, errors red, and taken edges bold
+func (r *RuleTree[T]) DOT(trace *Trace) string {
+	g := newGraph(r, trace)
+	var sb strings.Builder
+	sb.WriteString("digraph RuleTree {\n")
+	sb.WriteString("  rankdir=TB;\n  node [shape=box, style=rounded];\n")
+	for id, name := range g.names {
+		label := name
+		if g.notes[id] != "" {
+			label += "\n" + g.notes[id]
+		}
+		fmt.Fprintf(&sb, "  n%d [label=\"%s\"", id, dotEscape(label))
+		if c := dotColors[g.states[id]]; c != "" {
+			fmt.Fprintf(&sb, ", style=\"rounded,filled\", fillcolor=\"%s\"", c)
+		}
+		sb.WriteString("];\n")
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&sb, "  n%d -> n%d", e[0], e[1])
+		if g.taken[e] {
+			sb.WriteString(" [penwidth=2.5, color=\"#2e7d32\"]")
+		} else if trace != nil {
+			sb.WriteString(" [style=dashed, color=\"#9e9e9e\"]")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid 以 Mermaid flowchart 格式导出规则树，着色规则与 DOT 相同
+// Mermaid exports the rule tree as a Mermaid flowchart, coloured as DOT does
+func (r *RuleTree[T]) Mermaid(trace *Trace) string {
+	g := newGraph(r, trace)
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	classes := make(map[string][]string)
+	for id, name := range g.names {
+		label := mermaidEscape(name)
+		if g.notes[id] != "" {
+			label += "<br/>" + mermaidEscape(g.notes[id])
+		}
+		fmt.Fprintf(&sb, "  n%d[\"%s\"]\n", id, label)
+		if c := mermaidClasses[g.states[id]]; c != "" {
+			classes[c] = append(classes[c], fmt.Sprintf("n%d", id))
+		}
+	}
+	for _, e := range g.edges {
+		arrow := "-->"
+		if g.taken[e] {
+			arrow = "==>"
+		} else if trace != nil {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&sb, "  n%d %s n%d\n", e[0], arrow, e[1])
+	}
+	names := make([]string, 0, len(classes))
+	for c := range classes {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	for _, c := range names {
+		fmt.Fprintf(&sb, "  classDef %s %s\n  class %s %s\n", c, mermaidStyles[c], strings.Join(classes[c], ","), c)
+	}
+	return sb.String()
+}
+
+var dotColors = map[nodeState]string{
+	stateFailed:   "#eeeeee",
+	statePassed:   "#e3f2fd",
+	stateExecuted: "#c8e6c9",
+	stateControl:  "#fff59d",
+	stateError:    "#ffcdd2",
+}
+
+var mermaidClasses = map[nodeState]string{
+	stateFailed:   "failed",
+	statePassed:   "passed",
+	stateExecuted: "executed",
+	stateControl:  "control",
+	stateError:    "error",
+}
+
+var mermaidStyles = map[string]string{
+	"failed":   "fill:#eeeeee,stroke:#9e9e9e,color:#616161",
+	"passed":   "fill:#e3f2fd,stroke:#1e88e5",
+	"executed": "fill:#c8e6c9,stroke:#2e7d32",
+	"control":  "fill:#fff59d,stroke:#f9a825",
+	"error":    "fill:#ffcdd2,stroke:#c62828",
+}
+
+var dotReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+func dotEscape(s string) string {
+	return dotReplacer.Replace(s)
+}
+
+var mermaidReplacer = strings.NewReplacer("#", "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>", "\r", "")
+
+func mermaidEscape(s string) string {
+	return mermaidReplacer.Replace(s)
+}
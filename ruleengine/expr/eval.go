@@ -0,0 +1,419 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// node 语法树节点
+// node is a syntax tree node
+type node interface {
+	eval(e *Env) (any, error)
+}
+
+type literal struct{ v any }
+
+type ident struct{ name string }
+
+type member struct {
+	x    node
+	name string
+}
+
+type index struct{ x, i node }
+
+type call struct {
+	name string
+	fn   Func
+	args []node
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+type binary struct {
+	op          string
+	left, right node
+	re          *regexp.Regexp
+}
+
+type ternary struct{ cond, a, b node }
+
+type list struct{ elems []node }
+
+func (n *literal) eval(*Env) (any, error) {
+	return n.v, nil
+}
+
+func (n *ident) eval(e *Env) (any, error) {
+	v, err := identRef(e, n.name)
+	if err != nil {
+		return nil, err
+	}
+	return valueOf(v), nil
+}
+
+func identRef(e *Env, name string) (reflect.Value, error) {
+	if v, ok := e.Vars[name]; ok {
+		return reflect.ValueOf(v), nil
+	}
+	if e.Root != nil {
+		v, found, err := fieldRef(reflect.ValueOf(e.Root), name)
+		if err != nil || found {
+			return v, err
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown identifier %q", name)
+}
+
+func (n *member) eval(e *Env) (any, error) {
+	v, err := ref(e, n)
+	if err != nil {
+		return nil, err
+	}
+	return valueOf(v), nil
+}
+
+func (n *index) eval(e *Env) (any, error) {
+	v, err := ref(e, n)
+	if err != nil {
+		return nil, err
+	}
+	return valueOf(v), nil
+}
+
+// ref 取可寻址的值，使对嵌套结构体字段的赋值能写回原数据
+// ref resolves to an addressable value where possible so assignments to nested struct fields reach the data
+func ref(e *Env, n node) (reflect.Value, error) {
+	switch n := n.(type) {
+	case *ident:
+		return identRef(e, n.name)
+	case *member:
+		base, err := ref(e, n.x)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v, found, err := fieldRef(base, n.name)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("no field %q in %v", n.name, indirect(base).Type())
+		}
+		return v, nil
+	case *index:
+		base, err := ref(e, n.x)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		key, err := n.i.eval(e)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return indexRef(base, key)
+	}
+	v, err := n.eval(e)
+	return reflect.ValueOf(v), err
+}
+
+func (n *call) eval(e *Env) (res any, err error) {
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		if args[i], err = a.eval(e); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: panic: %v", n.name, r)
+		}
+	}()
+	if res, err = n.fn(args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return res, nil
+}
+
+func (n *unary) eval(e *Env) (any, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, err := truthy(v)
+		return !b, err
+	}
+	i, f, isFloat, ok := toNumber(v)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("cannot negate %T", v)
+	case isFloat:
+		return -f, nil
+	}
+	return -i, nil
+}
+
+func (n *ternary) eval(e *Env) (any, error) {
+	v, err := n.cond.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(v)
+	if err != nil {
+		return nil, err
+	}
+	if b {
+		return n.a.eval(e)
+	}
+	return n.b.eval(e)
+}
+
+func (n *list) eval(e *Env) (any, error) {
+	res := make([]any, len(n.elems))
+	for i, x := range n.elems {
+		v, err := x.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+func (n *binary) eval(e *Env) (any, error) {
+	l, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		lb, err := truthy(l)
+		if err != nil {
+			return nil, err
+		}
+		if lb == (n.op == "||") {
+			return lb, nil
+		}
+		r, err := n.right.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r)
+	}
+	r, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "in":
+		return contains(r, l)
+	case "not in":
+		ok, err := contains(r, l)
+		return !ok, err
+	case "matches":
+		return n.matches(l, r)
+	}
+	return arithmetic(n.op, l, r)
+}
+
+func (n *binary) matches(l, r any) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	s, ok := toString(l)
+	if !ok {
+		return false, fmt.Errorf("matches expects a string, got %T", l)
+	}
+	re := n.re
+	if re == nil {
+		pattern, ok := toString(r)
+		if !ok {
+			return false, fmt.Errorf("matches expects a string pattern, got %T", r)
+		}
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false, err
+		}
+	}
+	return re.MatchString(s), nil
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// arithmetic 算术运算，整数之间的 + - * % 保持整数，/ 总是得到浮点数
+// arithmetic does the arithmetic; + - * % keep integers integral while / always yields a float
+func arithmetic(op string, l, r any) (any, error) {
+	if op == "+" {
+		if ls, ok := toString(l); ok {
+			if rs, ok := toString(r); ok {
+				return ls + rs, nil
+			}
+		}
+		lv, rv := reflect.ValueOf(l), reflect.ValueOf(r)
+		if lv.Kind() == reflect.Slice && rv.Kind() == reflect.Slice {
+			res := make([]any, 0, lv.Len()+rv.Len())
+			for _, v := range []reflect.Value{lv, rv} {
+				for i := 0; i < v.Len(); i++ {
+					res = append(res, valueOf(v.Index(i)))
+				}
+			}
+			return res, nil
+		}
+	}
+	li, lf, lFloat, lok := toNumber(l)
+	ri, rf, rFloat, rok := toNumber(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("invalid operation %T %s %T", l, op, r)
+	}
+	isFloat := lFloat || rFloat
+	switch op {
+	case "+":
+		if isFloat {
+			return lf + rf, nil
+		}
+		return li + ri, nil
+	case "-":
+		if isFloat {
+			return lf - rf, nil
+		}
+		return li - ri, nil
+	case "*":
+		if isFloat {
+			return lf * rf, nil
+		}
+		return li * ri, nil
+	case "/":
+		if rf == 0 {
+			return nil, errDivisionByZero
+		}
+		return lf / rf, nil
+	case "%":
+		if isFloat {
+			return nil, fmt.Errorf("%% expects integers, got %T %% %T", l, r)
+		}
+		if ri == 0 {
+			return nil, errDivisionByZero
+		}
+		return li % ri, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// assign 对标识符、字段、映射键或切片元素赋值
+// assign sets an identifier, field, map entry or slice element
+func assign(e *Env, target node, v any) error {
+	switch t := target.(type) {
+	case *ident:
+		if _, ok := e.Vars[t.name]; ok || e.Root == nil {
+			if e.Vars == nil {
+				return fmt.Errorf("cannot assign %q: no variables", t.name)
+			}
+			e.Vars[t.name] = v
+			return nil
+		}
+		return setField(reflect.ValueOf(e.Root), t.name, v)
+	case *member:
+		base, err := ref(e, t.x)
+		if err != nil {
+			return err
+		}
+		return setField(base, t.name, v)
+	case *index:
+		base, err := ref(e, t.x)
+		if err != nil {
+			return err
+		}
+		key, err := t.i.eval(e)
+		if err != nil {
+			return err
+		}
+		return setIndex(base, key, v)
+	}
+	return fmt.Errorf("cannot assign to %T", target)
+}
+
+func setField(base reflect.Value, name string, v any) error {
+	base = indirect(base)
+	if !base.IsValid() {
+		return fmt.Errorf("cannot assign %q on nil", name)
+	}
+	switch base.Kind() {
+	case reflect.Map:
+		return setIndex(base, name, v)
+	case reflect.Struct:
+		idx, ok := fieldIndex(base.Type(), name)
+		if !ok {
+			return fmt.Errorf("no field %q in %v", name, base.Type())
+		}
+		f, err := base.FieldByIndexErr(idx)
+		if err != nil || !f.CanSet() {
+			return fmt.Errorf("cannot assign %v.%s, the data must be passed by pointer", base.Type(), name)
+		}
+		cv, err := convert(v, f.Type())
+		if err != nil {
+			return err
+		}
+		f.Set(cv)
+		return nil
+	}
+	return fmt.Errorf("cannot assign %q on %v", name, base.Type())
+}
+
+func setIndex(base reflect.Value, key, v any) error {
+	base = indirect(base)
+	if !base.IsValid() {
+		return fmt.Errorf("cannot assign [%v] on nil", key)
+	}
+	switch base.Kind() {
+	case reflect.Map:
+		if base.IsNil() {
+			return fmt.Errorf("cannot assign [%v] on a nil map", key)
+		}
+		k, err := convert(key, base.Type().Key())
+		if err != nil {
+			return err
+		}
+		cv, err := convert(v, base.Type().Elem())
+		if err != nil {
+			return err
+		}
+		base.SetMapIndex(k, cv)
+		return nil
+	case reflect.Slice, reflect.Array:
+		elem, err := indexRef(base, key)
+		if err != nil {
+			return err
+		}
+		if !elem.CanSet() {
+			return fmt.Errorf("cannot assign [%v] on %v, the data must be passed by pointer", key, base.Type())
+		}
+		cv, err := convert(v, elem.Type())
+		if err != nil {
+			return err
+		}
+		elem.Set(cv)
+		return nil
+	}
+	return fmt.Errorf("cannot assign [%v] on %v", key, base.Type())
+}
@@ -0,0 +1,182 @@
+// Package expr 为声明式规则提供的安全表达式语言：只能读取给定的数据、调用注册的函数，
+// 不能访问方法、包或任意代码，且没有循环，求值必然终止。
+//
+// 支持字面量（数字、'字符串'、"字符串"、true、false、nil、[列表]）、字段与下标访问（a.b、a[0]、m["k"]，
+// 对 nil 的访问得到 nil）、算术 + - * / %、比较 == != < <= > >=、逻辑 && || !（及 and or not）、
+// in / not in（列表元素、映射键、子串）、matches（正则）、三元 c ? a : b 以及函数调用。
+// 语句形式 target = expr 用于对字段、映射键或变量赋值。
+//
+// Package expr is the safe expression language behind declarative rules: it can only read the data it is
+// given and call registered functions, with no access to methods, packages or arbitrary code, and without
+// loops every evaluation terminates.
+//
+// It supports literals (numbers, 'strings', "strings", true, false, nil, [lists]), field and index access
+// (a.b, a[0], m["k"], where access on nil yields nil), arithmetic + - * / %, comparisons == != < <= > >=,
+// logic && || ! (or and, or, not), in / not in (list elements, map keys, substrings), matches (regular
+// expressions), the conditional c ? a : b and function calls. The statement form target = expr assigns to a
+// field, map key or variable.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// Env 求值环境。标识符先在 Vars 中查找，再作为 Root 的字段（字段名、json 标签或首字母小写形式）或映射键查找；
+// 对不在 Vars 中的标识符赋值会写入 Root，因此需要修改结构体时 Root 应为指针
+// Env is what an expression is evaluated against. Identifiers are looked up in Vars first, then as fields
+// of Root (by name, json tag or lower-camel name) or keys of a Root map. Assigning to an identifier missing
+// from Vars writes to Root, so Root must be a pointer for structs to be modified
+type Env struct {
+	Vars map[string]any
+	Root any
+}
+
+// Program 编译后的表达式或赋值语句，可并发使用
+// Program is a compiled expression or assignment, safe for concurrent use
+type Program struct {
+	src    string
+	root   node
+	target node
+}
+
+// Option 编译选项
+// Option configures compilation
+type Option func(*config)
+
+type config struct {
+	funcs map[string]Func
+	err   error
+}
+
+// WithFunction 注册可在表达式中调用的函数。fn 可以是 Func，也可以是任意返回 R 或 (R, error) 的 Go 函数，
+// 参数会按形参类型转换；与内置函数同名时覆盖内置函数
+// WithFunction registers a function callable from expressions. fn is either a Func or any Go function
+// returning R or (R, error), whose arguments are converted to the parameter types; it overrides a builtin
+// of the same name
+func WithFunction(name string, fn any) Option {
+	return func(c *config) {
+		f, err := wrapFunc(fn)
+		if err != nil {
+			c.err = errors.Join(c.err, fmt.Errorf("expr: function %q: %w", name, err))
+			return
+		}
+		c.funcs[name] = f
+	}
+}
+
+// WithFunctions 批量注册函数，规则同 WithFunction
+// WithFunctions registers several functions, as WithFunction does
+func WithFunctions(funcs map[string]any) Option {
+	return func(c *config) {
+		for name, fn := range funcs {
+			WithFunction(name, fn)(c)
+		}
+	}
+}
+
+// Compile 编译表达式
+// Compile compiles an expression
+func Compile(src string, opts ...Option) (*Program, error) {
+	p, err := parse(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	if p.target != nil {
+		return nil, &SyntaxError{Src: src, Pos: 0, Msg: "assignment is not allowed in an expression"}
+	}
+	return p, nil
+}
+
+// CompileStatement 编译表达式或 target = expr 赋值语句
+// CompileStatement compiles an expression or a target = expr assignment
+func CompileStatement(src string, opts ...Option) (*Program, error) {
+	return parse(src, opts)
+}
+
+// MustCompile 同 Compile，出错时 panic
+// MustCompile is like Compile but panics on error
+func MustCompile(src string, opts ...Option) *Program {
+	p, err := Compile(src, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func parse(src string, opts []Option) (*Program, error) {
+	c := config{funcs: maps.Clone(builtins)}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &parser{src: src, tokens: tokens, funcs: c.funcs}
+	x, err := ps.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	prog := &Program{src: src, root: x}
+	if t := ps.peek(); t.kind == tokOp && t.text == "=" {
+		switch x.(type) {
+		case *ident, *member, *index:
+		default:
+			return nil, ps.errorf(t.pos, "cannot assign to this expression")
+		}
+		ps.next()
+		value, err := ps.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		prog.target, prog.root = x, value
+	}
+	if t := ps.peek(); t.kind != tokEOF {
+		return nil, ps.errorf(t.pos, "unexpected %s", describe(t))
+	}
+	return prog, nil
+}
+
+// String 返回源码
+// String returns the source
+func (p *Program) String() string {
+	return p.src
+}
+
+// IsAssignment 是否为赋值语句
+// IsAssignment reports whether the program is an assignment
+func (p *Program) IsAssignment() bool {
+	return p.target != nil
+}
+
+// Eval 求值；赋值语句执行赋值并返回所赋的值
+// Eval evaluates the program; an assignment is performed and the assigned value returned
+func (p *Program) Eval(env Env) (any, error) {
+	v, err := p.root.eval(&env)
+	if err == nil && p.target != nil {
+		err = assign(&env, p.target, v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", p.src, err)
+	}
+	return v, nil
+}
+
+// EvalBool 求值并要求结果为布尔值，nil 视为 false
+// EvalBool evaluates the program and requires a boolean result, treating nil as false
+func (p *Program) EvalBool(env Env) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, err := truthy(v)
+	if err != nil {
+		return false, fmt.Errorf("expr %q: %w", p.src, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,147 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Func 可在表达式中调用的函数
+// Func is a function callable from expressions
+type Func func(args ...any) (any, error)
+
+var errType = reflect.TypeFor[error]()
+
+// wrapFunc 把任意 Go 函数包装为 Func，参数按形参类型转换
+// wrapFunc wraps any Go function as a Func, converting arguments to the parameter types
+func wrapFunc(fn any) (Func, error) {
+	switch f := fn.(type) {
+	case Func:
+		return f, nil
+	case func(args ...any) (any, error):
+		return f, nil
+	}
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("expected a function, got %T", fn)
+	}
+	ft := v.Type()
+	if ft.NumOut() == 0 || ft.NumOut() > 2 || (ft.NumOut() == 2 && ft.Out(1) != errType) {
+		return nil, fmt.Errorf("%v must return R or (R, error)", ft)
+	}
+	return func(args ...any) (any, error) {
+		n := ft.NumIn()
+		if len(args) < n-1 || (!ft.IsVariadic() && len(args) != n) {
+			return nil, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, a := range args {
+			t := ft.In(min(i, n-1))
+			if ft.IsVariadic() && i >= n-1 {
+				t = t.Elem()
+			}
+			cv, err := convert(a, t)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %w", i+1, err)
+			}
+			in[i] = cv
+		}
+		out := v.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			return nil, out[1].Interface().(error)
+		}
+		return valueOf(out[0]), nil
+	}, nil
+}
+
+func mustWrap(fn any) Func {
+	f, err := wrapFunc(fn)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// builtins 内置函数
+// builtins are the functions available to every expression
+var builtins = map[string]Func{
+	"len":        length,
+	"lower":      mustWrap(strings.ToLower),
+	"upper":      mustWrap(strings.ToUpper),
+	"trim":       mustWrap(strings.TrimSpace),
+	"contains":   mustWrap(strings.Contains),
+	"startsWith": mustWrap(strings.HasPrefix),
+	"endsWith":   mustWrap(strings.HasSuffix),
+	"abs":        mustWrap(math.Abs),
+	"floor":      mustWrap(math.Floor),
+	"ceil":       mustWrap(math.Ceil),
+	"round":      mustWrap(math.Round),
+	"min":        func(args ...any) (any, error) { return extreme(args, -1) },
+	"max":        func(args ...any) (any, error) { return extreme(args, 1) },
+	"int":        toInt,
+	"float":      toFloat,
+	"string":     func(args ...any) (any, error) { return fmt.Sprint(args...), nil },
+}
+
+func length(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	rv := indirect(reflect.ValueOf(args[0]))
+	if !rv.IsValid() {
+		return int64(0), nil
+	}
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return int64(rv.Len()), nil
+	}
+	return nil, fmt.Errorf("cannot take the length of %T", args[0])
+}
+
+// extreme 返回参数中的最小值（sign 为 -1）或最大值（sign 为 1）
+// extreme returns the smallest (sign -1) or largest (sign 1) argument
+func extreme(args []any, sign int) (any, error) {
+	if len(args) == 0 {
+		return nil, errors.New("expected at least 1 argument")
+	}
+	res := args[0]
+	for _, a := range args[1:] {
+		c, err := compare(a, res)
+		if err != nil {
+			return nil, err
+		}
+		if c == sign {
+			res = a
+		}
+	}
+	return res, nil
+}
+
+func toInt(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	if i, _, _, ok := toNumber(args[0]); ok {
+		return i, nil
+	}
+	if s, ok := toString(args[0]); ok {
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	}
+	return nil, fmt.Errorf("cannot convert %T to int", args[0])
+}
+
+func toFloat(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	if _, f, _, ok := toNumber(args[0]); ok {
+		return f, nil
+	}
+	if s, ok := toString(args[0]); ok {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	}
+	return nil, fmt.Errorf("cannot convert %T to float", args[0])
+}
@@ -0,0 +1,165 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError 表达式语法错误，Pos 为出错位置的字节偏移
+// SyntaxError is a syntax error in an expression, Pos is the byte offset where it occurred
+type SyntaxError struct {
+	Src string
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("expr: %s at position %d in %q", e.Msg, e.Pos, e.Src)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	val  any
+	pos  int
+}
+
+// operators 按长度降序排列，保证先匹配双字符运算符
+// operators are sorted longest first so two-character operators win
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", ".", "?", ":", "=",
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		case isDigit(c):
+			tok, n, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = n
+		case c == '"' || c == '\'':
+			tok, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = n
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Src: src, Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func lexNumber(src string, i int) (token, int, error) {
+	start := i
+	isFloat := false
+	for i < len(src) && isDigit(src[i]) {
+		i++
+	}
+	if i+1 < len(src) && src[i] == '.' && isDigit(src[i+1]) {
+		isFloat = true
+		i++
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && isDigit(src[j]) {
+			isFloat = true
+			for i = j; i < len(src) && isDigit(src[i]); i++ {
+			}
+		}
+	}
+	text := src[start:i]
+	if isFloat {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, 0, &SyntaxError{Src: src, Pos: start, Msg: "invalid number " + text}
+		}
+		return token{kind: tokNumber, text: text, val: f, pos: start}, i, nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return token{}, 0, &SyntaxError{Src: src, Pos: start, Msg: "invalid number " + text}
+	}
+	return token{kind: tokNumber, text: text, val: n, pos: start}, i, nil
+}
+
+func lexString(src string, i int) (token, int, error) {
+	quote := src[i]
+	start := i
+	var sb strings.Builder
+	for i++; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return token{kind: tokString, text: src[start : i+1], val: sb.String(), pos: start}, i + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '\\', '\'', '"':
+				sb.WriteByte(src[i])
+			default:
+				return token{}, 0, &SyntaxError{Src: src, Pos: i - 1, Msg: fmt.Sprintf("unknown escape \\%c", src[i])}
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return token{}, 0, &SyntaxError{Src: src, Pos: start, Msg: "unterminated string"}
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,286 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// maxDepth 最大嵌套深度，防止恶意输入耗尽栈
+// maxDepth bounds nesting so hostile input cannot exhaust the stack
+const maxDepth = 128
+
+// 二元运算符的优先级，数值越大结合越紧
+// binary operator precedence, higher binds tighter
+var precedence = map[string]int{
+	"||": 1, "or": 1,
+	"&&": 2, "and": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3, "in": 3, "not in": 3, "matches": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	depth  int
+	funcs  map[string]Func
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) error {
+	return &SyntaxError{Src: p.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(op string) error {
+	if t := p.next(); t.kind != tokOp || t.text != op {
+		return p.errorf(t.pos, "expected %q, found %s", op, describe(t))
+	}
+	return nil
+}
+
+func describe(t token) string {
+	if t.kind == tokEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// binaryOp 当前记号若为二元运算符则返回它，"not in" 占两个记号
+// binaryOp returns the binary operator at the current token, "not in" spans two tokens
+func (p *parser) binaryOp() (string, int) {
+	t := p.peek()
+	switch t.kind {
+	case tokOp:
+		if _, ok := precedence[t.text]; ok {
+			return t.text, 1
+		}
+	case tokIdent:
+		if t.text == "not" && p.tokens[p.pos+1].kind == tokIdent && p.tokens[p.pos+1].text == "in" {
+			return "not in", 2
+		}
+		if _, ok := precedence[t.text]; ok && t.text != "not in" {
+			return t.text, 1
+		}
+	}
+	return "", 0
+}
+
+// parseExpr 解析三元表达式
+// parseExpr parses a conditional expression
+func (p *parser) parseExpr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf(p.peek().pos, "expression nested too deeply")
+	}
+	cond, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokOp || t.text != "?" {
+		return cond, nil
+	}
+	p.next()
+	a, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &ternary{cond: cond, a: a, b: b}, nil
+}
+
+func (p *parser) parseBinary(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, n := p.binaryOp()
+		prec := precedence[op]
+		if n == 0 || prec < minPrec {
+			return left, nil
+		}
+		pos := p.peek().pos
+		p.pos += n
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		b := &binary{op: normalizeOp(op), left: left, right: right}
+		if b.op == "matches" {
+			if lit, ok := right.(*literal); ok {
+				s, ok := lit.v.(string)
+				if !ok {
+					return nil, p.errorf(pos, "matches expects a string pattern")
+				}
+				re, err := regexp.Compile(s)
+				if err != nil {
+					return nil, p.errorf(pos, "invalid pattern: %v", err)
+				}
+				b.re = re
+			}
+		}
+		left = b
+	}
+}
+
+func normalizeOp(op string) string {
+	switch op {
+	case "and":
+		return "&&"
+	case "or":
+		return "||"
+	}
+	return op
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if (t.kind == tokOp && (t.text == "!" || t.text == "-")) || (t.kind == tokIdent && t.text == "not") {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, p.errorf(t.pos, "expression nested too deeply")
+		}
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		op := t.text
+		if op == "not" {
+			op = "!"
+		}
+		return &unary{op: op, x: x}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp {
+			return x, nil
+		}
+		switch t.text {
+		case ".":
+			p.next()
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, p.errorf(name.pos, "expected field name, found %s", describe(name))
+			}
+			x = &member{x: x, name: name.text}
+		case "[":
+			p.next()
+			i, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &index{x: x, i: i}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber, tokString:
+		return &literal{v: t.val}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{v: true}, nil
+		case "false":
+			return &literal{v: false}, nil
+		case "nil", "null":
+			return &literal{v: nil}, nil
+		}
+		if _, ok := precedence[t.text]; ok {
+			return nil, p.errorf(t.pos, "unexpected %q", t.text)
+		}
+		if n := p.peek(); n.kind == tokOp && n.text == "(" {
+			return p.parseCall(t)
+		}
+		return &ident{name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			elems, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &list{elems: elems}, nil
+		}
+	}
+	return nil, p.errorf(t.pos, "unexpected %s", describe(t))
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := p.funcs[name.text]
+	if !ok {
+		return nil, p.errorf(name.pos, "unknown function %q", name.text)
+	}
+	p.next()
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	return &call{name: name.text, fn: fn, args: args}, nil
+}
+
+// parseList 解析逗号分隔的表达式直到 end
+// parseList parses comma separated expressions up to end
+func (p *parser) parseList(end string) ([]node, error) {
+	var elems []node
+	if t := p.peek(); t.kind == tokOp && t.text == end {
+		p.next()
+		return elems, nil
+	}
+	for {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, x)
+		t := p.next()
+		if t.kind == tokOp && t.text == end {
+			return elems, nil
+		}
+		if t.kind != tokOp || t.text != "," {
+			return nil, p.errorf(t.pos, "expected \",\" or %q, found %s", end, describe(t))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/karosown/katool-go/ruleengine/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer struct {
+	Name  string
+	Level string `json:"tier"`
+	Tags  []string
+}
+
+type Order struct {
+	ID       string
+	Amount   float64
+	Quantity int
+	Customer Customer
+	Owner    *Customer
+	Extra    map[string]any
+	Discount float64
+}
+
+func order() *Order {
+	return &Order{
+		ID:       "A-1001",
+		Amount:   150,
+		Quantity: 3,
+		Customer: Customer{Name: "bob", Level: "vip", Tags: []string{"new", "mobile"}},
+		Extra:    map[string]any{"channel": "web", "score": 7},
+	}
+}
+
+func eval(t *testing.T, src string, env expr.Env, opts ...expr.Option) any {
+	t.Helper()
+	p, err := expr.Compile(src, opts...)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	v, err := p.Eval(env)
+	assert.NoError(t, err, src)
+	return v
+}
+
+func stmt(t *testing.T, src string) *expr.Program {
+	t.Helper()
+	p, err := expr.CompileStatement(src)
+	assert.NoError(t, err)
+	return p
+}
+
+// 测试运算符、字段访问与优先级
+func TestEval(t *testing.T) {
+	env := expr.Env{Root: order(), Vars: map[string]any{"limit": 100}}
+	cases := map[string]any{
+		"1 + 2 * 3":                          int64(7),
+		"(1 + 2) * 3":                        int64(9),
+		"7 / 2":                              3.5,
+		"7 % 4":                              int64(3),
+		"-quantity + 1":                      int64(-2),
+		"amount > limit && quantity >= 3":    true,
+		"amount > 200 || !(quantity < 3)":    true,
+		"customer.level == 'vip'":            true,
+		"customer.tier == \"vip\"":           true,
+		"Customer.Name + '!'":                "bob!",
+		"customer.tags[1]":                   "mobile",
+		"'mobile' in customer.tags":          true,
+		"'tv' not in customer.tags":          true,
+		"'channel' in extra":                 true,
+		"'ob' in customer.name":              true,
+		"extra.score * 2":                    int64(14),
+		"extra['channel']":                   "web",
+		"extra.missing == nil":               true,
+		"owner.name":                         nil,
+		"id matches '^A-\\\\d+$'":            true,
+		"quantity in [1, 2, 3]":              true,
+		"amount >= 100 ? 'big' : 'small'":    "big",
+		"not (amount < 100) and true":        true,
+		"len(customer.tags) + len('abc')":    int64(5),
+		"upper(customer.name)":               "BOB",
+		"max(1, 2.5, 2)":                     2.5,
+		"round(amount / 7)":                  21.0,
+		"int('42') + 1":                      int64(43),
+		"startsWith(id, 'A-') && 1e2 == 100": true,
+	}
+	for src, want := range cases {
+		assert.Equal(t, want, eval(t, src, env), src)
+	}
+}
+
+// 测试赋值语句
+func TestAssign(t *testing.T) {
+	o := order()
+	vars := map[string]any{"result": nil}
+	env := expr.Env{Root: o, Vars: vars}
+	for _, src := range []string{
+		"discount = amount * 0.1",
+		"customer.level = 'gold'",
+		"customer.tags[0] = 'old'",
+		"extra.channel = 'app'",
+		"result = discount + 1",
+		"quantity = 4.0",
+	} {
+		p, err := expr.CompileStatement(src)
+		assert.NoError(t, err)
+		assert.True(t, p.IsAssignment())
+		_, err = p.Eval(env)
+		assert.NoError(t, err, src)
+	}
+	assert.Equal(t, 15.0, o.Discount)
+	assert.Equal(t, "gold", o.Customer.Level)
+	assert.Equal(t, "old", o.Customer.Tags[0])
+	assert.Equal(t, "app", o.Extra["channel"])
+	assert.Equal(t, 16.0, vars["result"])
+	assert.Equal(t, 4, o.Quantity)
+
+	_, err := expr.MustCompile("1").Eval(env)
+	assert.NoError(t, err)
+	_, err = expr.Compile("a = 1")
+	assert.Error(t, err)
+	for _, src := range []string{"quantity = 1.5", "quantity = 'x'", "owner.name = 'x'", "nope = 1"} {
+		_, err = stmt(t, src).Eval(env)
+		assert.Error(t, err, src)
+	}
+
+	// 按值传入的结构体不能修改 / a struct passed by value cannot be modified
+	_, err = stmt(t, "amount = 1").Eval(expr.Env{Root: *o})
+	assert.ErrorContains(t, err, "passed by pointer")
+}
+
+// 测试注册函数
+func TestFunctions(t *testing.T) {
+	env := expr.Env{Root: order()}
+	opts := []expr.Option{
+		expr.WithFunction("tax", func(amount float64, rate float64) float64 { return amount * rate }),
+		expr.WithFunction("lookup", func(id string) (string, error) {
+			if id == "" {
+				return "", errors.New("empty id")
+			}
+			return "found " + id, nil
+		}),
+		expr.WithFunction("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) }),
+		expr.WithFunction("raw", expr.Func(func(args ...any) (any, error) { return len(args), nil })),
+	}
+	assert.Equal(t, 15.0, eval(t, "tax(amount, 0.1)", env, opts...))
+	assert.Equal(t, "found A-1001", eval(t, "lookup(id)", env, opts...))
+	assert.Equal(t, "a-b-c", eval(t, "join('-', 'a', 'b', 'c')", env, opts...))
+	assert.Equal(t, 3, eval(t, "raw(1, 'x', nil)", env, opts...))
+
+	p, err := expr.Compile("lookup('')", opts...)
+	assert.NoError(t, err)
+	_, err = p.Eval(env)
+	assert.ErrorContains(t, err, "lookup: empty id")
+
+	_, err = expr.Compile("missing(1)")
+	assert.ErrorContains(t, err, `unknown function "missing"`)
+	_, err = expr.Compile("1", expr.WithFunction("bad", 42))
+	assert.Error(t, err)
+}
+
+// 测试语法错误与运行时错误
+func TestErrors(t *testing.T) {
+	for _, src := range []string{"1 +", "(1", "a.", "'abc", "a ? b", "1 2", "a[1", "x matches '('", "#", strings.Repeat("(", 500) + "1" + strings.Repeat(")", 500)} {
+		_, err := expr.Compile(src)
+		var se *expr.SyntaxError
+		assert.ErrorAs(t, err, &se, src)
+	}
+	env := expr.Env{Root: order()}
+	for _, src := range []string{"unknown > 1", "amount / 0", "id > 1", "amount && true", "customer.tags[5]", "customer.nope", "-id"} {
+		p, err := expr.Compile(src)
+		assert.NoError(t, err, src)
+		_, err = p.Eval(env)
+		assert.Error(t, err, src)
+	}
+	ok, err := expr.MustCompile("owner").EvalBool(env)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, err = expr.MustCompile("amount").EvalBool(env)
+	assert.ErrorContains(t, err, "expected a boolean")
+}
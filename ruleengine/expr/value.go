@@ -0,0 +1,322 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// fieldCache 结构体类型到可访问名称与字段下标的映射
+// fieldCache maps struct types to their accessible names and field indexes
+var fieldCache sync.Map
+
+// fieldIndex 按字段名、json 标签或首字母小写的字段名查找导出字段
+// fieldIndex finds an exported field by name, json tag or lower-camel name
+func fieldIndex(t reflect.Type, name string) ([]int, bool) {
+	if cached, ok := fieldCache.Load(t); ok {
+		idx, ok := cached.(map[string][]int)[name]
+		return idx, ok
+	}
+	names := make(map[string][]int)
+	fields := reflect.VisibleFields(t)
+	for _, f := range fields {
+		if f.IsExported() {
+			names[f.Name] = f.Index
+		}
+	}
+	for _, f := range fields {
+		if !f.IsExported() {
+			continue
+		}
+		alias := []string{lowerFirst(f.Name)}
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			alias = append([]string{tag}, alias...)
+		}
+		for _, a := range alias {
+			if _, ok := names[a]; !ok {
+				names[a] = f.Index
+			}
+		}
+	}
+	fieldCache.Store(t, names)
+	idx, ok := names[name]
+	return idx, ok
+}
+
+// lowerFirst 首字母小写，开头的缩写整体小写，如 ID -> id、URLPath -> urlPath
+// lowerFirst lowers the first letter, or a leading initialism as a whole: ID -> id, URLPath -> urlPath
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsUpper(r) || (i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			break
+		}
+		runes[i] = unicode.ToLower(r)
+	}
+	return string(runes)
+}
+
+// indirect 解开指针与接口，nil 时返回无效值
+// indirect follows pointers and interfaces, returning an invalid value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldRef 取字段或映射键；found 为 false 表示结构体没有该字段，对 nil 的访问得到无效值
+// fieldRef gets a field or map entry; found is false when a struct has no such field, and access on nil
+// yields an invalid value
+func fieldRef(v reflect.Value, name string) (res reflect.Value, found bool, err error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return reflect.Value{}, true, nil
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false, fmt.Errorf("cannot access %q on %v, use an index", name, v.Type())
+		}
+		return v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())), true, nil
+	case reflect.Struct:
+		idx, ok := fieldIndex(v.Type(), name)
+		if !ok {
+			return reflect.Value{}, false, nil
+		}
+		f, err := v.FieldByIndexErr(idx)
+		if err != nil {
+			return reflect.Value{}, true, nil
+		}
+		return f, true, nil
+	}
+	return reflect.Value{}, false, nil
+}
+
+// indexRef 取切片、数组、字符串或映射的元素
+// indexRef gets an element of a slice, array, string or map
+func indexRef(v reflect.Value, key any) (reflect.Value, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return reflect.Value{}, nil
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		k, err := convert(key, v.Type().Key())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return v.MapIndex(k), nil
+	case reflect.Slice, reflect.Array, reflect.String:
+		i, _, isFloat, ok := toNumber(key)
+		if !ok || isFloat {
+			return reflect.Value{}, fmt.Errorf("index must be an integer, got %T", key)
+		}
+		if i < 0 || i >= int64(v.Len()) {
+			return reflect.Value{}, fmt.Errorf("index %d out of range [0, %d)", i, v.Len())
+		}
+		if v.Kind() == reflect.String {
+			return reflect.ValueOf(string(v.String()[i])), nil
+		}
+		return v.Index(int(i)), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot index %v", v.Type())
+}
+
+func valueOf(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// toNumber 把整数、无符号整数与浮点数统一为 int64 或 float64
+// toNumber unifies integers, unsigned integers and floats as int64 or float64
+func toNumber(v any) (i int64, f float64, isFloat bool, ok bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), float64(rv.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(rv.Uint()), float64(rv.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return int64(rv.Float()), rv.Float(), true, true
+	}
+	return 0, 0, false, false
+}
+
+func toString(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func truthy(v any) (bool, error) {
+	if isNil(v) {
+		return false, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Bool {
+		return rv.Bool(), nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %T", v)
+}
+
+func equal(a, b any) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) && isNil(b)
+	}
+	if ai, af, aFloat, ok := toNumber(a); ok {
+		bi, bf, bFloat, ok := toNumber(b)
+		if !ok {
+			return false
+		}
+		if aFloat || bFloat {
+			return af == bf
+		}
+		return ai == bi
+	}
+	if as, ok := toString(a); ok {
+		bs, ok := toString(b)
+		return ok && as == bs
+	}
+	if ab, ok := a.(bool); ok {
+		bb, ok := b.(bool)
+		return ok && ab == bb
+	}
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare 比较数字、字符串或时间，返回 -1、0、1
+// compare orders numbers, strings or times, returning -1, 0 or 1
+func compare(a, b any) (int, error) {
+	if ai, af, aFloat, ok := toNumber(a); ok {
+		if bi, bf, bFloat, ok := toNumber(b); ok {
+			if aFloat || bFloat {
+				return cmpOrdered(af, bf), nil
+			}
+			return cmpOrdered(ai, bi), nil
+		}
+	}
+	if as, ok := toString(a); ok {
+		if bs, ok := toString(b); ok {
+			return strings.Compare(as, bs), nil
+		}
+	}
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Compare(bt), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T and %T", a, b)
+}
+
+func cmpOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// contains in 运算：列表元素、映射键或子串
+// contains implements in: list elements, map keys or substrings
+func contains(coll, item any) (bool, error) {
+	rv := indirect(reflect.ValueOf(coll))
+	if !rv.IsValid() {
+		return false, nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		s, ok := toString(item)
+		if !ok {
+			return false, fmt.Errorf("in on a string expects a string, got %T", item)
+		}
+		return strings.Contains(rv.String(), s), nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if equal(valueOf(rv.Index(i)), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			if equal(valueOf(iter.Key()), item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("in expects a list, map or string, got %T", coll)
+}
+
+// convert 把表达式的值转换为 Go 类型 t，用于赋值与调用注册的函数
+// convert turns an expression value into Go type t, for assignments and calls to registered functions
+func convert(v any, t reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %v", t)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(t) {
+		return rv, nil
+	}
+	if i, f, isFloat, ok := toNumber(v); ok {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if isFloat && f != math.Trunc(f) {
+				return reflect.Value{}, fmt.Errorf("cannot use %v as %v without losing the fraction", f, t)
+			}
+			return reflect.ValueOf(i).Convert(t), nil
+		case reflect.Float32, reflect.Float64:
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	}
+	if s, ok := toString(v); ok && t.Kind() == reflect.String {
+		return reflect.ValueOf(s).Convert(t), nil
+	}
+	if rv.Kind() == reflect.Slice && t.Kind() == reflect.Slice {
+		res := reflect.MakeSlice(t, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			e, err := convert(valueOf(rv.Index(i)), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			res.Index(i).Set(e)
+		}
+		return res, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %v", v, t)
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/karosown/katool-go/ruleengine"
+	"github.com/karosown/katool-go/ruleengine/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+type Order struct {
+	ID       string
+	Amount   float64
+	Level    string
+	Country  string
+	Discount float64
+	Tags     []string
+	Reviewed bool
+}
+
+const pricingYAML = `
+rules:
+  - name: flag_review
+    when: "amount > 1000"
+    then: ["reviewed = true"]
+chains:
+  - name: pricing
+    rules:
+      - name: vip
+        when: "level == 'vip' && amount >= 100"
+        then:
+          - "discount = amount * rate('vip')"
+          - "tags = tags + ['vip']"
+        else:
+          - "tags = tags + ['regular']"
+        result: "amount - discount"
+        children:
+          - name: big_vip
+            when: "amount >= 500"
+            then: ["discount = discount + 20"]
+            result: "amount - discount"
+      - name: blocked
+        when: "country in ['XX', 'YY']"
+        then: ["result = 'blocked'"]
+        control: stop
+`
+
+// 测试从 YAML 加载规则并执行
+func TestLoadYAML(t *testing.T) {
+	engine := ruleengine.NewRuleEngine[*Order]()
+	rates := map[string]float64{"vip": 0.1}
+	err := engine.LoadYAML([]byte(pricingYAML), expr.WithFunction("rate", func(level string) float64 { return rates[level] }))
+	assert.NoError(t, err)
+	assert.Contains(t, engine.ListChains(), "pricing")
+	assert.Contains(t, engine.ListRules(), "flag_review")
+
+	o := &Order{Amount: 200, Level: "vip"}
+	res := engine.Execute("pricing", o)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, 20.0, o.Discount)
+	assert.Equal(t, []string{"vip"}, o.Tags)
+	assert.Equal(t, 180.0, res.Result)
+
+	o = &Order{Amount: 600, Level: "vip"}
+	res = engine.Execute("pricing", o)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, 80.0, o.Discount)
+	assert.Equal(t, 520.0, res.Result)
+
+	o = &Order{Amount: 50, Level: "basic"}
+	res = engine.Execute("pricing", o)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, []string{"regular"}, o.Tags)
+
+	o = &Order{Amount: 50, Country: "XX"}
+	res = engine.Execute("pricing", o)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, "blocked", res.Result)
+
+	// 命名规则可在构建器中复用 / named rules can be reused by the builder
+	_, err = engine.NewBuilder("review").AddRule("flag_review").Build()
+	assert.NoError(t, err)
+	o = &Order{Amount: 5000}
+	assert.NoError(t, engine.Execute("review", o).Error)
+	assert.True(t, o.Reviewed)
+}
+
+// 测试 JSON 定义、按值传递的数据与映射数据
+func TestLoadJSON(t *testing.T) {
+	def := `{"chains": [{"name": "score", "rules": [
+		{"when": "score >= 90", "then": ["grade = 'A'"], "result": "grade"},
+		{"when": "score < 90 && name matches '^[a-z]+$'", "then": ["grade = upper(name)"], "result": "grade"}
+	]}]}`
+	engine := ruleengine.NewRuleEngine[map[string]any]()
+	assert.NoError(t, engine.LoadJSON([]byte(def)))
+
+	data := map[string]any{"score": 95, "name": "amy"}
+	res := engine.Execute("score", data)
+	assert.NoError(t, res.Error)
+	assert.Equal(t, "A", res.Result)
+	assert.Equal(t, "A", data["grade"])
+
+	res = engine.Execute("score", map[string]any{"score": 10, "name": "bob"})
+	assert.Equal(t, "BOB", res.Result)
+
+	type Item struct{ Price, Total float64 }
+	tree, err := ruleengine.CompileChain[Item](ruleengine.ChainDef{Name: "total", Rules: []ruleengine.RuleDef{
+		{Then: []string{"total = price * 2"}},
+	}})
+	assert.NoError(t, err)
+	item, _, err := tree.Run(Item{Price: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, item.Total)
+}
+
+// 测试编译错误与执行错误
+func TestDeclarativeErrors(t *testing.T) {
+	engine := ruleengine.NewRuleEngine[*Order]()
+	bad := []string{
+		`{"chains": [{"name": "c", "rules": [{"name": "r", "when": "amount >"}]}]}`,
+		`{"chains": [{"name": "c", "rules": [{"name": "r", "then": ["1 = 2"]}]}]}`,
+		`{"chains": [{"name": "c", "rules": [{"name": "r", "control": "jump"}]}]}`,
+		`{"chains": [{"name": "c", "rules": []}]}`,
+		`{"chains": [{"name": "c", "rules": [{"when": "nope(1)"}]}]}`,
+		`{"rules": [{"when": "true"}]}`,
+		`{"chains": [`,
+	}
+	for _, def := range bad {
+		assert.Error(t, engine.LoadJSON([]byte(def)), def)
+	}
+	assert.Empty(t, engine.ListChains())
+
+	err := engine.LoadJSON([]byte(`{"chains": [{"name": "c", "rules": [{"name": "r", "when": "amount >"}]}]}`))
+	var se *expr.SyntaxError
+	assert.ErrorAs(t, err, &se)
+	assert.True(t, strings.Contains(err.Error(), `chain "c"`) && strings.Contains(err.Error(), `rule "r" when`), err.Error())
+
+	assert.NoError(t, engine.LoadJSON([]byte(`{"chains": [{"name": "c", "rules": [
+		{"name": "div", "when": "amount / discount > 1"}
+	]}]}`)))
+	res := engine.Execute("c", &Order{Amount: 1})
+	assert.ErrorContains(t, res.Error, `rule "div" when`)
+	assert.ErrorContains(t, res.Error, "division by zero")
+
+	assert.NoError(t, engine.LoadYAML([]byte("chains:\n  - name: f\n    rules:\n      - then: ['fail()']\n"),
+		expr.WithFunction("fail", func() (bool, error) { return false, errors.New("boom") })))
+	assert.ErrorContains(t, engine.Execute("f", &Order{}).Error, "fail: boom")
+}
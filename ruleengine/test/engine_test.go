@@ -244,13 +244,13 @@ func TestRemoveRule(t *testing.T) {
 // TestExample 运行基础示例
 func TestExample(t *testing.T) {
 	fmt.Println("运行基础示例...")
-	ExampleUsage()
+	Example_usage()
 }
 
 // TestAdvancedExample 运行高级示例
 func TestAdvancedExample(t *testing.T) {
 	fmt.Println("运行高级示例...")
-	ExampleAdvanced()
+	Example_advanced()
 }
 
 // BenchmarkRuleExecution 性能测试
@@ -18,8 +18,8 @@ type User struct {
 	Balance  float64
 }
 
-// ExampleUsage 演示规则引擎的使用方法
-func ExampleUsage() {
+// Example_usage 演示规则引擎的使用方法
+func Example_usage() {
 	// 创建规则引擎
 	engine := ruleengine.NewRuleEngine[User]()
 
@@ -195,8 +195,8 @@ func ExampleUsage() {
 	fmt.Printf("添加新规则后: %v\n", engine.ListRules())
 }
 
-// ExampleAdvanced 高级用法示例
-func ExampleAdvanced() {
+// Example_advanced 高级用法示例
+func Example_advanced() {
 	engine := ruleengine.NewRuleEngine[map[string]interface{}]()
 
 	// 数据转换规则
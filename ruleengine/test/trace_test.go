@@ -0,0 +1,173 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/karosown/katool-go/ruleengine"
+	"github.com/stretchr/testify/assert"
+)
+
+const traceYAML = `
+chains:
+  - name: pricing
+    rules:
+      - name: vip
+        when: "level == 'vip'"
+        then: ["discount = 10"]
+        children:
+          - name: big_vip
+            when: "amount >= 500"
+            then: ["discount = discount + 20"]
+      - name: blocked
+        when: "country == 'XX'"
+        control: stop
+`
+
+// 测试执行轨迹记录节点路径、条件结果与快照
+func TestExecuteTrace(t *testing.T) {
+	engine := ruleengine.NewRuleEngine[*Order]()
+	assert.NoError(t, engine.LoadYAML([]byte(traceYAML)))
+
+	res := engine.Execute("pricing", &Order{Amount: 100, Level: "vip"})
+	assert.NoError(t, res.Error)
+	assert.Nil(t, res.Trace)
+
+	snapshot := func(v any) any {
+		if o, ok := v.(*Order); ok {
+			return *o
+		}
+		return v
+	}
+	res = engine.Execute("pricing", &Order{Amount: 100, Level: "vip"}, ruleengine.WithSnapshot(snapshot))
+	tr := res.Trace
+	if !assert.NotNil(t, tr) {
+		return
+	}
+	assert.Equal(t, "pricing", tr.Chain)
+	assert.Equal(t, []string{"pricing", "vip"}, tr.Path())
+	assert.False(t, tr.Stopped)
+
+	steps := map[string]ruleengine.TraceStep{}
+	for _, s := range tr.Steps {
+		steps[s.Node] = s
+	}
+	assert.Len(t, steps, 4)
+	assert.True(t, steps["vip"].Passed)
+	assert.Equal(t, 1, steps["vip"].Depth)
+	assert.Equal(t, steps["pricing"].NodeID, steps["vip"].Parent)
+	assert.Equal(t, 0.0, steps["vip"].Input.(Order).Discount)
+	assert.Equal(t, 10.0, steps["vip"].Output.(Order).Discount)
+	assert.False(t, steps["blocked"].Passed)
+	assert.False(t, steps["big_vip"].Passed)
+	assert.Equal(t, 2, steps["big_vip"].Depth)
+
+	explain := tr.Explain()
+	assert.Contains(t, explain, `chain "pricing"`)
+	assert.Contains(t, explain, "✗ blocked: condition failed")
+	assert.Contains(t, explain, "    ✗ big_vip")
+
+	// EOF 提前结束 / EOF ends the walk early
+	res = engine.Execute("pricing", &Order{Country: "XX"}, ruleengine.WithTrace())
+	assert.True(t, res.Trace.Stopped)
+	assert.Equal(t, []string{"pricing", "blocked"}, res.Trace.Path())
+	assert.Contains(t, res.Trace.Explain(), "[EOF]")
+}
+
+// 测试轨迹中的中间件、FALLTHROUGH 与错误
+func TestTraceMiddlewareAndErrors(t *testing.T) {
+	engine := ruleengine.NewRuleEngine[int]()
+	engine.AddMiddleware(func(data int, next func(int) (int, any, error)) (int, any, error) {
+		return next(data * 2)
+	})
+	engine.AddMiddleware(func(data int, next func(int) (int, any, error)) (int, any, error) {
+		d, res, err := next(data + 1)
+		return d, res, err
+	})
+	engine.RegisterRule("skip", func(int, any) bool { return true }, func(d int, _ any) (int, any, error) {
+		return d + 100, nil, ruleengine.FALLTHROUGH
+	})
+	engine.RegisterRule("fail", func(int, any) bool { return true }, func(d int, _ any) (int, any, error) {
+		return d, nil, errors.New("boom")
+	})
+	engine.RegisterRule("pass", func(int, any) bool { return true }, func(d int, _ any) (int, any, error) {
+		return d + 1, nil, nil
+	})
+	_, err := engine.NewBuilder("c").AddRule("skip").AddRule("fail").Build()
+	assert.NoError(t, err)
+	_, err = engine.NewBuilder("e").AddRule("pass").AddRule("fail").Build()
+	assert.NoError(t, err)
+
+	// FALLTHROUGH 跳过了后续节点 / FALLTHROUGH skipped the nodes below it
+	res := engine.Execute("c", 1, ruleengine.WithTrace())
+	assert.NoError(t, res.Error)
+	tr := res.Trace
+	assert.Len(t, tr.Middleware, 2)
+	assert.Equal(t, 0, tr.Middleware[0].Index)
+	assert.Equal(t, "1", tr.Middleware[0].Input)
+	assert.Equal(t, "2", tr.Middleware[1].Input)
+	assert.Equal(t, "103", tr.Middleware[0].Output)
+	assert.Equal(t, []string{"skip"}, tr.Path())
+	assert.Equal(t, "FALLTHROUGH", tr.Steps[0].Control)
+	assert.Equal(t, "3", tr.Steps[0].Input)
+
+	res = engine.Execute("e", 1, ruleengine.WithTrace())
+	assert.ErrorContains(t, res.Error, "boom")
+	tr = res.Trace
+	assert.Equal(t, res.Error, tr.Err)
+	assert.ErrorContains(t, tr.Middleware[0].Err, "boom")
+	assert.Equal(t, []string{"pass", "fail"}, tr.Path())
+	assert.Equal(t, "4", tr.Steps[1].Input)
+	assert.ErrorContains(t, tr.Steps[1].Err, "boom")
+	assert.Contains(t, tr.Explain(), "error: boom")
+
+	batch := engine.BatchExecute([]string{"c"}, 1, ruleengine.WithTrace())
+	assert.NotNil(t, batch["c"].Trace)
+	assert.NotNil(t, engine.ExecuteAll(1, ruleengine.WithTrace())["c"].Trace)
+
+	// 注册名只用于轨迹与导出，不写回调用者的节点 / the registered name labels traces and exports without touching the caller's node
+	node := ruleengine.NewRuleNode(func(int, any) bool { return true }, func(d int, _ any) (int, any, error) { return d, nil, nil })
+	engine.RegisterRuleNode("keyed", node)
+	tree, err := engine.NewBuilder("k").AddRule("keyed").Build()
+	assert.NoError(t, err)
+	assert.Empty(t, node.Name)
+	assert.Equal(t, []string{"keyed"}, engine.Execute("k", 1, ruleengine.WithTrace()).Trace.Path())
+	assert.Contains(t, tree.Mermaid(nil), "keyed")
+}
+
+// 测试导出 DOT 与 Mermaid
+func TestExportGraph(t *testing.T) {
+	engine := ruleengine.NewRuleEngine[*Order]()
+	assert.NoError(t, engine.LoadYAML([]byte(traceYAML)))
+	tree, ok := engine.GetChain("pricing")
+	assert.True(t, ok)
+
+	dot := tree.DOT(nil)
+	assert.True(t, strings.HasPrefix(dot, "digraph RuleTree {"))
+	assert.Contains(t, dot, `n0 [label="pricing"]`)
+	assert.Contains(t, dot, "n0 -> n1;")
+	assert.NotContains(t, dot, "fillcolor")
+
+	_, _, tr, err := tree.RunWithTrace(&Order{Country: "XX"})
+	assert.NoError(t, err)
+	dot = tree.DOT(tr)
+	assert.Contains(t, dot, `n2 [label="blocked\nEOF", style="rounded,filled", fillcolor="#fff59d"]`)
+	assert.Contains(t, dot, `n0 -> n2 [penwidth=2.5`)
+	assert.Contains(t, dot, `n0 -> n1 [style=dashed`)
+
+	mermaid := tree.Mermaid(tr)
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart TD\n"))
+	assert.Contains(t, mermaid, `n2["blocked<br/>EOF"]`)
+	assert.Contains(t, mermaid, "n0 ==> n2")
+	assert.Contains(t, mermaid, "n0 -.-> n1")
+	assert.Contains(t, mermaid, "class n1 failed")
+	assert.Contains(t, mermaid, "class n2 control")
+
+	// 标签转义 / labels are escaped
+	node := ruleengine.NewRuleNode[int](func(int, any) bool { return true }, func(d int, _ any) (int, any, error) { return d, nil, nil })
+	node.Name = `say "hi" <now>`
+	odd := ruleengine.NewRuleTree(node)
+	assert.Contains(t, odd.DOT(nil), `label="say \"hi\" <now>"`)
+	assert.Contains(t, odd.Mermaid(nil), `n0["say #quot;hi#quot; #lt;now#gt;"]`)
+}
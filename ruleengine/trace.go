@@ -0,0 +1,288 @@
+package ruleengine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecuteOption 执行选项
+// ExecuteOption configures an execution
+type ExecuteOption func(*executeOptions)
+
+type executeOptions struct {
+	trace    bool
+	snapshot func(v any) any
+}
+
+// WithTrace 记录执行轨迹到 ExecuteResult.Trace
+// WithTrace records the execution trace in ExecuteResult.Trace
+func WithTrace() ExecuteOption {
+	return func(o *executeOptions) { o.trace = true }
+}
+
+// WithSnapshot 设置轨迹中输入输出快照的生成方式并开启轨迹，默认为 fmt.Sprintf("%+v")；
+// 数据为指针时快照需要复制内容，否则只能看到最终状态
+// WithSnapshot sets how the trace snapshots inputs and outputs, and turns tracing on. The default is
+// fmt.Sprintf("%+v"); for pointer data a snapshot must copy the content or it only shows the final state
+func WithSnapshot(snapshot func(v any) any) ExecuteOption {
+	return func(o *executeOptions) {
+		o.trace = true
+		o.snapshot = snapshot
+	}
+}
+
+func traceOptions(opts []ExecuteOption) executeOptions {
+	var o executeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func defaultSnapshot(v any) any {
+	return fmt.Sprintf("%+v", v)
+}
+
+// Trace 一次执行的轨迹
+// Trace is the trace of one execution
+type Trace struct {
+	Chain string
+	// Steps 按条件判断的顺序记录每个被检查的节点 / Steps holds every node checked, in the order conditions were evaluated
+	Steps []TraceStep
+	// Middleware 按中间件注册顺序记录每一跳 / Middleware holds every hop, in registration order
+	Middleware []MiddlewareHop
+	// Stopped 是否被 EOF 提前终止 / Stopped reports whether EOF ended the walk early
+	Stopped  bool
+	Duration time.Duration
+	Err      error
+}
+
+// TraceStep 一个节点的条件判断与执行
+// TraceStep is one node's condition check and execution
+type TraceStep struct {
+	// NodeID 节点在规则树中按广度优先的编号，与导出图中的编号一致 / NodeID numbers the node breadth first in
+	// the tree, as the exported graphs do
+	NodeID int
+	Node   string
+	// Parent 父节点编号，根节点为 -1 / Parent is the parent's NodeID, -1 for the root
+	Parent int
+	Depth  int
+	// Passed 条件是否成立，不成立的节点不会执行 / Passed is the condition outcome; nodes that failed are not executed
+	Passed            bool
+	ConditionDuration time.Duration
+	Executed          bool
+	Input             any
+	Output            any
+	Result            any
+	// Control 节点返回的 EOF 或 FALLTHROUGH / Control is the EOF or FALLTHROUGH the node returned
+	Control  string
+	Err      error
+	Duration time.Duration
+}
+
+// MiddlewareHop 一个中间件的调用
+// MiddlewareHop is one middleware call
+type MiddlewareHop struct {
+	Index    int
+	Input    any
+	Output   any
+	Result   any
+	Err      error
+	Duration time.Duration
+}
+
+// tracer 在执行过程中收集轨迹，nil 表示不追踪
+// tracer collects the trace during execution, nil means tracing is off
+type tracer[T any] struct {
+	trace    *Trace
+	snapshot func(v any) any
+	ids      map[*RuleNode[T]]int
+	labels   map[*RuleNode[T]]string
+	depth    map[*RuleNode[T]]int
+	pending  []int
+}
+
+func newTracer[T any](chain string, tree *RuleTree[T], o executeOptions) *tracer[T] {
+	if !o.trace {
+		return nil
+	}
+	if o.snapshot == nil {
+		o.snapshot = defaultSnapshot
+	}
+	t := &tracer[T]{trace: &Trace{Chain: chain}, snapshot: o.snapshot, depth: make(map[*RuleNode[T]]int)}
+	if tree != nil {
+		t.ids, t.labels = nodeIDs(tree.Root), tree.labels
+	}
+	return t
+}
+
+// nodeIDs 按广度优先为节点编号
+// nodeIDs numbers the nodes breadth first
+func nodeIDs[T any](root *RuleNode[T]) map[*RuleNode[T]]int {
+	ids := map[*RuleNode[T]]int{root: 0}
+	queue := []*RuleNode[T]{root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, nxt := range node.NxtLayer {
+			if _, ok := ids[nxt]; nxt != nil && !ok {
+				ids[nxt] = len(ids)
+				queue = append(queue, nxt)
+			}
+		}
+	}
+	return ids
+}
+
+// nodeName 节点的显示名：Name、labels 中的名称或编号
+// nodeName is the display name of a node: its Name, its label or its number
+func nodeName[T any](node *RuleNode[T], labels map[*RuleNode[T]]string, id int) string {
+	if node.Name != "" {
+		return node.Name
+	}
+	if label, ok := labels[node]; ok {
+		return label
+	}
+	return fmt.Sprintf("node#%d", id)
+}
+
+// enqueue 判断条件并在成立时入队，与 ToQueue 相同但会记录判断结果
+// enqueue checks the condition and queues the node when it holds, as ToQueue does, recording the outcome
+func (t *tracer[T]) enqueue(node, parent *RuleNode[T], queue chan *RuleNode[T]) {
+	if t == nil {
+		node.ToQueue(queue)
+		return
+	}
+	start := time.Now()
+	passed := node.Valid(node.SourceTypeData, node.ConvertData)
+	step := TraceStep{NodeID: t.ids[node], Parent: -1, Passed: passed, ConditionDuration: time.Since(start)}
+	step.Node = nodeName(node, t.labels, step.NodeID)
+	if parent != nil {
+		step.Parent = t.ids[parent]
+		step.Depth = t.depth[parent] + 1
+	}
+	t.depth[node] = step.Depth
+	t.trace.Steps = append(t.trace.Steps, step)
+	if passed {
+		t.pending = append(t.pending, len(t.trace.Steps)-1)
+		queue <- node
+	}
+}
+
+// layerToQueue 判断下一层各节点的条件并入队，与 LayerToQueue 相同
+// layerToQueue checks and queues the nodes of the next layer, as LayerToQueue does
+func (t *tracer[T]) layerToQueue(node *RuleNode[T], queue chan *RuleNode[T]) {
+	if t == nil {
+		node.LayerToQueue(queue)
+		return
+	}
+	for _, item := range node.NxtLayer {
+		t.enqueue(item, node, queue)
+	}
+}
+
+// exec 执行出队的节点并记录输入、输出与耗时
+// exec runs a dequeued node, recording its input, output and duration
+func (t *tracer[T]) exec(node *RuleNode[T], cvt any) (T, any, error) {
+	if t == nil {
+		return node.Exec(node.SourceTypeData, cvt)
+	}
+	i := t.pending[0]
+	t.pending = t.pending[1:]
+	input := t.snapshot(node.SourceTypeData)
+	start := time.Now()
+	data, res, err := node.Exec(node.SourceTypeData, cvt)
+	step := &t.trace.Steps[i]
+	step.Executed, step.Duration, step.Input = true, time.Since(start), input
+	step.Output, step.Result = t.snapshot(data), t.snapshot(res)
+	switch {
+	case err == nil:
+	case errors.Is(err, EOF):
+		step.Control = "EOF"
+		t.trace.Stopped = true
+	case errors.Is(err, FALLTHROUGH):
+		step.Control = "FALLTHROUGH"
+	default:
+		step.Err = err
+	}
+	return data, res, err
+}
+
+// hop 开始记录一次中间件调用，返回结束时的回调
+// hop starts recording a middleware call and returns the callback completing it
+func (t *tracer[T]) hop(index int, input T) func(T, any, error) {
+	if t == nil {
+		return func(T, any, error) {}
+	}
+	i := len(t.trace.Middleware)
+	t.trace.Middleware = append(t.trace.Middleware, MiddlewareHop{Index: index, Input: t.snapshot(input)})
+	start := time.Now()
+	return func(data T, res any, err error) {
+		h := &t.trace.Middleware[i]
+		h.Duration, h.Output, h.Result, h.Err = time.Since(start), t.snapshot(data), t.snapshot(res), err
+	}
+}
+
+// Path 实际执行的节点名称，按执行顺序
+// Path returns the names of the executed nodes in execution order
+func (t *Trace) Path() []string {
+	var path []string
+	for _, s := range t.executed() {
+		path = append(path, s.Node)
+	}
+	return path
+}
+
+func (t *Trace) executed() []TraceStep {
+	var steps []TraceStep
+	for _, s := range t.Steps {
+		if s.Executed {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// Explain 以缩进文本说明执行过程：每个节点的条件结果、耗时、输入输出以及终止原因
+// Explain describes the execution as indented text: each node's condition outcome, duration, input and
+// output, and what ended the walk
+func (t *Trace) Explain() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "chain %q took %v", t.Chain, t.Duration)
+	if t.Stopped {
+		sb.WriteString(", stopped by EOF")
+	}
+	if t.Err != nil {
+		fmt.Fprintf(&sb, ", error: %v", t.Err)
+	}
+	sb.WriteByte('\n')
+	for _, h := range t.Middleware {
+		fmt.Fprintf(&sb, "middleware #%d %v: %v -> %v", h.Index, h.Duration, h.Input, h.Output)
+		if h.Err != nil {
+			fmt.Fprintf(&sb, " error: %v", h.Err)
+		}
+		sb.WriteByte('\n')
+	}
+	for _, s := range t.Steps {
+		sb.WriteString(strings.Repeat("  ", s.Depth))
+		if !s.Passed {
+			fmt.Fprintf(&sb, "✗ %s: condition failed (%v)\n", s.Node, s.ConditionDuration)
+			continue
+		}
+		if !s.Executed {
+			fmt.Fprintf(&sb, "✓ %s: condition passed, not executed\n", s.Node)
+			continue
+		}
+		fmt.Fprintf(&sb, "✓ %s (%v): %v -> %v, result %v", s.Node, s.Duration, s.Input, s.Output, s.Result)
+		if s.Control != "" {
+			sb.WriteString(" [" + s.Control + "]")
+		}
+		if s.Err != nil {
+			fmt.Fprintf(&sb, " error: %v", s.Err)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
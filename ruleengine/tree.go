@@ -2,6 +2,7 @@ package ruleengine
 
 import (
 	"errors"
+	"time"
 
 	"github.com/karosown/katool-go/container/cutil"
 	"github.com/karosown/katool-go/container/optional"
@@ -92,6 +93,9 @@ func (r *RuleLayer[T]) Len() int {
 // Each rule node represents an execution unit in the rule tree
 type RuleNode[T any] struct {
 	RuleNodeMeta[T]
+	// Name 节点名称，用于执行轨迹与导出的图，可为空
+	// Name names the node in execution traces and exported graphs, may be empty
+	Name string
 	// NxtLayer 下一层规则节点，形成树状结构
 	// NxtLayer contains the next layer rule nodes, forming a tree structure
 	NxtLayer RuleLayer[T]
@@ -176,6 +180,10 @@ type RuleTree[T any] struct {
 	// waitQueue 等待执行的节点队列，用于异步处理规则节点
 	// waitQueue is a queue of nodes waiting to be executed, used for asynchronous processing of rule nodes
 	waitQueue chan *RuleNode[T]
+
+	// labels 节点没有 Name 时在轨迹与导出中使用的名称，如注册名
+	// labels names nodes without a Name in traces and exports, such as their registered names
+	labels map[*RuleNode[T]]string
 }
 
 // NewRuleNode 创建新的规则节点
@@ -212,13 +220,29 @@ func NewRuleTree[T any](root *RuleNode[T]) *RuleTree[T] {
 // Run executes the rule tree and processes data flow
 // This is the main execution method of the rule tree, using queues and select statements for asynchronous processing
 func (r *RuleTree[T]) Run(data T) (T, any, error) {
+	return r.run(data, nil)
+}
+
+// RunWithTrace 执行规则树并返回执行轨迹，opts 可设置快照方式
+// RunWithTrace executes the rule tree and returns its trace; opts may set how snapshots are taken
+func (r *RuleTree[T]) RunWithTrace(data T, opts ...ExecuteOption) (T, any, *Trace, error) {
+	tr := newTracer[T]("", r, traceOptions(append([]ExecuteOption{WithTrace()}, opts...)))
+	start := time.Now()
+	res, cvt, err := r.run(data, tr)
+	tr.trace.Duration, tr.trace.Err = time.Since(start), err
+	return res, cvt, tr.trace, err
+}
+
+// run 执行规则树，tr 不为 nil 时记录轨迹
+// run executes the rule tree, recording the trace when tr is not nil
+func (r *RuleTree[T]) run(data T, tr *tracer[T]) (T, any, error) {
 	// 初始化根节点的数据
 	// Initialize the root node's data
 	r.Root.SourceTypeData, r.Root.ConvertData = data, data
 
 	// 将根节点加入执行队列
 	// Add the root node to the execution queue
-	r.Root.ToQueue(r.waitQueue)
+	tr.enqueue(r.Root, nil, r.waitQueue)
 
 	// 定义执行过程中的状态变量
 	// Define state variables during execution
@@ -235,7 +259,7 @@ func (r *RuleTree[T]) Run(data T) (T, any, error) {
 		case node := <-r.waitQueue:
 			// 执行节点的业务逻辑
 			// Execute the node's business logic
-			orginData, cvtDara, err = node.Exec(node.SourceTypeData, r.Root.ConvertData)
+			orginData, cvtDara, err = tr.exec(node, r.Root.ConvertData)
 
 			// 处理执行过程中的错误
 			// Handle errors during execution
@@ -272,7 +296,7 @@ func (r *RuleTree[T]) Run(data T) (T, any, error) {
 
 			// 将下一层的节点加入执行队列
 			// Add nodes from the next layer to the execution queue
-			node.LayerToQueue(r.waitQueue)
+			tr.layerToQueue(node, r.waitQueue)
 
 		// 队列为空时，表示所有节点都已处理完毕
 		// When the queue is empty, it indicates all nodes have been processed
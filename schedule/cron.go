@@ -0,0 +1,309 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// starBit 字段为 * 或 ? 时置位，用于日与星期的匹配规则
+// starBit is set when a field is * or ?, which changes how day-of-month and day-of-week combine
+const starBit = 1 << 63
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondBounds = bounds{0, 59, nil}
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// CronSchedule 解析后的 cron 表达式
+// CronSchedule is a parsed cron expression
+type CronSchedule struct {
+	expr                                  string
+	second, minute, hour, dom, month, dow uint64
+	loc                                   *time.Location
+}
+
+// Cron 按本地时区解析 cron 表达式，见 CronIn
+// Cron parses a cron expression in the local time zone, see CronIn
+func Cron(expr string) (Trigger, error) {
+	return CronIn(expr, time.Local)
+}
+
+// MustCron 同 Cron，解析失败时 panic
+// MustCron is like Cron but panics when the expression is invalid
+func MustCron(expr string) Trigger {
+	t, err := Cron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// CronIn 解析 cron 表达式，支持：
+//   - 6 个字段「秒 分 时 日 月 星期」或省略秒的 5 个字段
+//   - * ? , - / 以及月份、星期的英文缩写（JAN、MON）；星期 0 和 7 都表示周日
+//   - @yearly @monthly @weekly @daily @hourly 等描述符，以及 @every 1h30m（等同 FixedRate）
+//   - 以 TZ=Asia/Shanghai 或 CRON_TZ=... 开头指定时区，否则使用 loc
+//
+// 日与星期都被限定时，任一匹配即触发（与标准 cron 一致）
+//
+// CronIn parses a cron expression. It supports:
+//   - 6 fields "second minute hour day-of-month month day-of-week", or 5 fields without seconds
+//   - * ? , - / and English month and weekday abbreviations (JAN, MON); both 0 and 7 mean Sunday
+//   - descriptors such as @yearly @monthly @weekly @daily @hourly, and @every 1h30m (same as FixedRate)
+//   - a leading TZ=Asia/Shanghai or CRON_TZ=... for the time zone, loc is used otherwise
+//
+// When both day-of-month and day-of-week are restricted, matching either one fires, as in standard cron
+func CronIn(expr string, loc *time.Location) (Trigger, error) {
+	spec := strings.TrimSpace(expr)
+	if loc == nil {
+		loc = time.Local
+	}
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("schedule: missing fields in %q", expr)
+		}
+		name := spec[strings.Index(spec, "=")+1 : i]
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("schedule: bad time zone %q: %w", name, err)
+		}
+		loc, spec = l, strings.TrimSpace(spec[i:])
+	}
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("schedule: bad duration in %q", expr)
+		}
+		return FixedRate(d), nil
+	}
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("schedule: expected 5 or 6 fields in %q, found %d", expr, len(fields))
+	}
+	s := &CronSchedule{expr: expr, loc: loc}
+	var err error
+	for i, p := range []struct {
+		dst *uint64
+		b   bounds
+	}{
+		{&s.second, secondBounds}, {&s.minute, minuteBounds}, {&s.hour, hourBounds},
+		{&s.dom, domBounds}, {&s.month, monthBounds}, {&s.dow, dowBounds},
+	} {
+		if *p.dst, err = parseField(fields[i], p.b); err != nil {
+			return nil, fmt.Errorf("schedule: %q: %w", expr, err)
+		}
+	}
+	// 7 与 0 都表示周日 / 7 and 0 both mean Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseField 解析一个字段为位图
+// parseField parses one field into a bitmask
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		m, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		mask |= m
+	}
+	return mask, nil
+}
+
+func parseRange(part string, b bounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	var lo, hi int
+	var extra uint64
+	switch rangePart {
+	case "*", "?":
+		lo, hi = b.min, b.max
+		if b.names != nil && b.max == 7 {
+			hi = 6
+		}
+		if !hasStep {
+			extra = starBit
+		}
+	default:
+		loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+		var err error
+		if lo, err = parseValue(loPart, b); err != nil {
+			return 0, err
+		}
+		switch {
+		case isRange:
+			if hi, err = parseValue(hiPart, b); err != nil {
+				return 0, err
+			}
+		case hasStep:
+			hi = b.max
+		default:
+			hi = lo
+		}
+	}
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, fmt.Errorf("bad step %q", part)
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("range start %d is beyond end %d in %q", lo, hi, part)
+	}
+	var mask uint64
+	for v := lo; v <= hi; v += step {
+		mask |= 1 << uint(v)
+	}
+	return mask | extra, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// String 原始表达式
+// String returns the original expression
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Location 表达式使用的时区
+// Location returns the time zone the expression is evaluated in
+func (s *CronSchedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next 严格晚于 prev 的下一次触发时间，5 年内无匹配时返回零值；夏令时跳过的时刻不会触发
+// Next returns the first fire time strictly after prev, or the zero time when nothing matches within
+// 5 years; wall-clock times skipped by daylight saving do not fire
+func (s *CronSchedule) Next(prev time.Time) time.Time {
+	orig := prev.Location()
+	t := prev.In(s.loc)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	// added 记录是否已经进位，进位后低位字段从最小值开始
+	// added records whether a field has moved on, after which lower fields restart from their minimum
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// 夏令时切换可能让午夜落在 23 点或 1 点 / DST may move midnight to 23:00 or 01:00
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t.In(orig)
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom != 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow != 0
+	if s.dom&starBit != 0 || s.dow&starBit != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
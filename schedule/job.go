@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrOverlap 上一次执行尚未结束，本次被跳过
+	// ErrOverlap marks a run skipped because the previous one was still in progress
+	ErrOverlap = errors.New("schedule: previous run still in progress")
+	// ErrLeaseHeld 本次执行已被集群中的其他实例抢占
+	// ErrLeaseHeld marks a run claimed by another instance in the cluster
+	ErrLeaseHeld = errors.New("schedule: execution claimed by another instance")
+	// ErrMissed 错过的执行按 MisfireSkip 被丢弃
+	// ErrMissed marks missed runs dropped under MisfireSkip
+	ErrMissed = errors.New("schedule: missed run skipped")
+)
+
+// JobFunc 任务函数，ctx 在任务超时或调度器强制停止时取消
+// JobFunc is a job; ctx is cancelled on the job timeout or when the scheduler is stopped forcibly
+type JobFunc func(ctx context.Context) error
+
+// Overlap 上一次执行未结束时又到了触发时间的处理方式
+// Overlap decides what happens when a run is due while the previous one is still in progress
+type Overlap int
+
+const (
+	// OverlapSkip 跳过本次（默认） / skip the new run (default)
+	OverlapSkip Overlap = iota
+	// OverlapQueue 排队，上一次结束后依次执行 / queue it to run after the previous one finishes
+	OverlapQueue
+	// OverlapAllow 并发执行 / run concurrently
+	OverlapAllow
+)
+
+// Misfire 错过触发时间（如进程休眠、调度延迟或从 CatchUpFrom 恢复）时的补跑方式
+// Misfire decides how missed runs are caught up, for example after the process slept, the scheduler
+// lagged, or when resuming from WithCatchUpFrom
+type Misfire int
+
+const (
+	// MisfireRunOnce 合并为一次立即执行（默认） / run once now for all missed runs (default)
+	MisfireRunOnce Misfire = iota
+	// MisfireSkip 丢弃错过的执行，等待下一次 / drop missed runs and wait for the next one
+	MisfireSkip
+	// MisfireRunAll 依次补跑每一次错过的执行 / run every missed run in order
+	MisfireRunAll
+)
+
+// Status 执行结果
+// Status is the outcome of an execution
+type Status int
+
+const (
+	StatusSucceeded Status = iota
+	StatusFailed
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+// Execution 一次执行的记录，被跳过时 Err 说明原因（ErrOverlap、ErrLeaseHeld、ErrMissed）
+// Execution records one run; for skipped runs Err tells why (ErrOverlap, ErrLeaseHeld, ErrMissed)
+type Execution struct {
+	Job       string
+	Scheduled time.Time
+	Started   time.Time
+	Finished  time.Time
+	Status    Status
+	Err       error
+}
+
+// Duration 执行耗时
+// Duration returns how long the run took
+func (e Execution) Duration() time.Duration {
+	return e.Finished.Sub(e.Started)
+}
+
+// JobInfo 任务的当前状态
+// JobInfo is a snapshot of a job
+type JobInfo struct {
+	Name    string
+	Prev    time.Time
+	Next    time.Time
+	Running int
+	Pending int
+}
+
+// JobOption 任务选项
+// JobOption configures a job
+type JobOption func(*job)
+
+// WithOverlap 设置重叠策略
+// WithOverlap sets the overlap policy
+func WithOverlap(o Overlap) JobOption {
+	return func(j *job) { j.overlap = o }
+}
+
+// WithTimeout 单次执行的超时时间，到时取消任务的ctx
+// WithTimeout cancels the job's ctx when a run takes longer than d
+func WithTimeout(d time.Duration) JobOption {
+	return func(j *job) { j.timeout = d }
+}
+
+// WithMisfire 设置错过执行的补跑策略
+// WithMisfire sets how missed runs are caught up
+func WithMisfire(m Misfire) JobOption {
+	return func(j *job) { j.misfire = m }
+}
+
+// WithMisfireThreshold 晚于计划时间多久算错过，默认1s
+// WithMisfireThreshold sets how late a run may start before it counts as missed, 1s by default
+func WithMisfireThreshold(d time.Duration) JobOption {
+	return func(j *job) { j.threshold = d }
+}
+
+// WithMaxCatchUp MisfireRunAll 时最多补跑的次数，默认100
+// WithMaxCatchUp caps how many runs MisfireRunAll catches up, 100 by default
+func WithMaxCatchUp(n int) JobOption {
+	return func(j *job) { j.maxCatchUp = max(n, 1) }
+}
+
+// WithCatchUpFrom 把 t 当作上一次触发时间，t 之后错过的执行按补跑策略处理；常与持久化的历史配合用于重启后补跑
+// WithCatchUpFrom treats t as the previous fire time, so runs missed since t are caught up by the misfire
+// policy; pair it with persisted history to catch up after a restart
+func WithCatchUpFrom(t time.Time) JobOption {
+	return func(j *job) { j.from = t }
+}
+
+type scheduledKey struct{}
+
+// ScheduledTime 当前执行的计划时间
+// ScheduledTime returns the scheduled time of the current run
+func ScheduledTime(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(scheduledKey{}).(time.Time)
+	return t, ok
+}
@@ -0,0 +1,76 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Lease 执行租约：同一（任务名, 计划时间）在 ttl 内只有一个调用方能抢到
+// Lease claims executions: within ttl only one caller wins a given (job name, scheduled time)
+type Lease interface {
+	Acquire(ctx context.Context, job string, scheduled time.Time, ttl time.Duration) (bool, error)
+}
+
+// memoryLease 进程内租约
+// memoryLease is an in-process lease
+type memoryLease struct {
+	mu     sync.Mutex
+	claims map[string]time.Time
+}
+
+// NewMemoryLease 进程内租约，用于同一进程中的多个调度器或测试
+// NewMemoryLease returns an in-process lease for several schedulers in one process, or for tests
+func NewMemoryLease() Lease {
+	return &memoryLease{claims: make(map[string]time.Time)}
+}
+
+func (l *memoryLease) Acquire(_ context.Context, job string, scheduled time.Time, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	key := leaseKey("", job, scheduled)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if exp, ok := l.claims[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	for k, exp := range l.claims {
+		if !now.Before(exp) {
+			delete(l.claims, k)
+		}
+	}
+	l.claims[key] = now.Add(ttl)
+	return true, nil
+}
+
+// redisLease 基于 SET NX 的租约
+// redisLease is a lease on SET NX
+type redisLease struct {
+	client redis.Cmdable
+	prefix string
+	owner  string
+}
+
+// NewRedisLease Redis 租约，键为 prefix+任务名+":"+计划时间毫秒数，prefix 为空时使用 "schedule:"；
+// 键在 ttl 后过期，不会主动释放，以免其他实例重复执行
+// NewRedisLease returns a Redis lease keyed by prefix+job+":"+scheduled milliseconds, with "schedule:" as the
+// default prefix. Keys expire after ttl and are never released early so other instances cannot rerun them
+func NewRedisLease(client redis.Cmdable, prefix string) Lease {
+	if prefix == "" {
+		prefix = "schedule:"
+	}
+	host, _ := os.Hostname()
+	return &redisLease{client: client, prefix: prefix, owner: fmt.Sprintf("%s:%d", host, os.Getpid())}
+}
+
+func (l *redisLease) Acquire(ctx context.Context, job string, scheduled time.Time, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(ctx, leaseKey(l.prefix, job, scheduled), l.owner, ttl).Result()
+}
+
+func leaseKey(prefix, job string, scheduled time.Time) string {
+	return prefix + job + ":" + strconv.FormatInt(scheduled.UnixMilli(), 10)
+}
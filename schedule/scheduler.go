@@ -0,0 +1,457 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karosown/katool-go/pool"
+)
+
+var (
+	ErrDuplicateJob = errors.New("schedule: duplicate job name")
+	ErrJobNotFound  = errors.New("schedule: job not found")
+	ErrStopped      = errors.New("schedule: scheduler stopped")
+)
+
+// Option 调度器选项
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithLocation AddCron 未指定 TZ 时使用的时区，默认本地时区
+// WithLocation sets the time zone AddCron uses when the expression has no TZ, the local zone by default
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) { s.loc = loc }
+}
+
+// WithLease 设置分布式租约，每次执行前按（任务名, 计划时间）抢占，保证集群中只有一个实例执行；
+// ttl 需大于实例间的时钟偏差，默认10分钟
+// WithLease sets a distributed lease claimed per (job name, scheduled time) before every run so only one
+// instance in the cluster executes it; ttl must exceed the clock skew between instances, 10 minutes by default
+func WithLease(lease Lease, ttl time.Duration) Option {
+	return func(s *Scheduler) {
+		s.lease = lease
+		if ttl > 0 {
+			s.leaseTTL = ttl
+		}
+	}
+}
+
+// WithHistoryLimit 每个任务保留的执行记录数，默认100，不大于0时不保留
+// WithHistoryLimit sets how many executions each job keeps, 100 by default; non-positive keeps none
+func WithHistoryLimit(n int) Option {
+	return func(s *Scheduler) { s.historyLimit = n }
+}
+
+// WithListener 每次执行（含跳过）结束后的回调
+// WithListener sets a callback invoked after every execution, skipped ones included
+func WithListener(listener func(Execution)) Option {
+	return func(s *Scheduler) { s.listener = listener }
+}
+
+// Scheduler 定时任务调度器
+// Scheduler runs jobs on their triggers
+type Scheduler struct {
+	loc          *time.Location
+	lease        Lease
+	leaseTTL     time.Duration
+	historyLimit int
+	listener     func(Execution)
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+	stopped bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	loops   sync.WaitGroup
+	running sync.WaitGroup
+}
+
+// New 创建调度器，调用 Start 后开始触发
+// New creates a scheduler; jobs fire after Start
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		loc:          time.Local,
+		leaseTTL:     10 * time.Minute,
+		historyLimit: 100,
+		jobs:         make(map[string]*job),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Add 添加任务，调度器已启动时立即开始调度
+// Add registers a job, scheduling it at once when the scheduler is running
+func (s *Scheduler) Add(name string, trigger Trigger, fn JobFunc, opts ...JobOption) error {
+	j := &job{
+		name:       name,
+		trigger:    trigger,
+		fn:         fn,
+		s:          s,
+		threshold:  time.Second,
+		maxCatchUp: 100,
+		stop:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+	}
+	s.jobs[name] = j
+	if s.started && !s.stopped {
+		s.startLoop(j)
+	}
+	return nil
+}
+
+// AddCron 以 cron 表达式添加任务，表达式语法见 CronIn
+// AddCron registers a job on a cron expression, see CronIn for the syntax
+func (s *Scheduler) AddCron(name, expr string, fn JobFunc, opts ...JobOption) error {
+	trigger, err := CronIn(expr, s.loc)
+	if err != nil {
+		return err
+	}
+	return s.Add(name, trigger, fn, opts...)
+}
+
+// Remove 移除任务，已开始的执行会继续完成
+// Remove unregisters a job; runs already started are left to finish
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if ok {
+		if !s.stopped {
+			close(j.stop)
+		}
+		delete(s.jobs, name)
+	}
+	return ok
+}
+
+// Start 开始调度，重复调用或 Stop 之后调用无效果
+// Start begins scheduling; calling it again or after Stop has no effect
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, j := range s.jobs {
+		s.startLoop(j)
+	}
+}
+
+func (s *Scheduler) startLoop(j *job) {
+	s.loops.Add(1)
+	go j.loop()
+}
+
+// Stop 停止触发新的执行并丢弃排队的执行，等待进行中的执行结束；ctx 结束时取消任务的ctx并返回 ctx 的错误
+// Stop stops firing, drops queued runs and waits for running ones; when ctx is done first it cancels the
+// jobs' ctx and returns ctx's error
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		for _, j := range s.jobs {
+			close(j.stop)
+		}
+	}
+	s.mu.Unlock()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	defer s.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunNow 立即执行一次任务，仍遵循重叠策略与租约；无需先调用 Start
+// RunNow runs a job at once, still subject to its overlap policy and the lease; Start is not required
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return ErrStopped
+	}
+	j, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	// 在锁内登记，保证 Stop 会等待本次分发 / registered under the lock so Stop waits for this dispatch
+	s.running.Add(1)
+	s.mu.Unlock()
+	defer s.running.Done()
+	j.dispatch(time.Now(), false)
+	return nil
+}
+
+// Jobs 按名称排序的任务状态
+// Jobs returns snapshots of all jobs sorted by name
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		infos = append(infos, JobInfo{Name: j.name, Prev: j.prev, Next: j.next, Running: j.running, Pending: len(j.pending)})
+		j.mu.Unlock()
+	}
+	slices.SortFunc(infos, func(a, b JobInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// History 任务的执行记录，按时间先后排列
+// History returns a job's executions, oldest first
+func (s *Scheduler) History(name string) []Execution {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Clone(j.history)
+}
+
+// job 一个已注册的任务
+// job is a registered job
+type job struct {
+	name       string
+	trigger    Trigger
+	fn         JobFunc
+	s          *Scheduler
+	overlap    Overlap
+	timeout    time.Duration
+	misfire    Misfire
+	threshold  time.Duration
+	maxCatchUp int
+	from       time.Time
+	stop       chan struct{}
+
+	mu      sync.Mutex
+	running int
+	pending []time.Time
+	prev    time.Time
+	next    time.Time
+	history []Execution
+}
+
+func (j *job) stopped() bool {
+	select {
+	case <-j.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep 等到 t，任务停止时返回 false
+// sleep waits until t, returning false when the job is stopped
+func (j *job) sleep(t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-j.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (j *job) loop() {
+	defer j.s.loops.Done()
+	_, delay := j.trigger.(fixedDelay)
+	last := j.from
+	if last.IsZero() {
+		last = time.Now()
+	}
+	for {
+		next := j.trigger.Next(last)
+		j.mu.Lock()
+		j.next = next
+		j.mu.Unlock()
+		if next.IsZero() || !j.sleep(next) {
+			return
+		}
+		if delay {
+			if done := j.dispatch(next, false); done != nil {
+				select {
+				case <-done:
+				case <-j.stop:
+					return
+				}
+			}
+			last = time.Now()
+			continue
+		}
+		last = j.fire(next)
+	}
+}
+
+// fire 处理所有已到期的触发时间并按补跑策略分发，返回最后处理的触发时间
+// fire handles every due fire time by the misfire policy and returns the last one handled
+func (j *job) fire(next time.Time) time.Time {
+	now := time.Now()
+	dues := []time.Time{next}
+	last := next
+	for {
+		t := j.trigger.Next(last)
+		if t.IsZero() || t.After(now) {
+			break
+		}
+		if len(dues) == j.maxCatchUp {
+			// 超出补跑上限的部分直接跳过 / runs beyond the cap are skipped outright
+			last = now
+			break
+		}
+		dues = append(dues, t)
+		last = t
+	}
+	latest := dues[len(dues)-1]
+	if len(dues) == 1 && now.Sub(next) <= j.threshold {
+		j.dispatch(next, false)
+		return last
+	}
+	switch j.misfire {
+	case MisfireSkip:
+		if now.Sub(latest) <= j.threshold {
+			j.dispatch(latest, false)
+		} else {
+			j.record(Execution{Job: j.name, Scheduled: latest, Status: StatusSkipped, Err: ErrMissed})
+		}
+	case MisfireRunAll:
+		for _, t := range dues {
+			j.dispatch(t, true)
+		}
+	default:
+		j.dispatch(latest, false)
+	}
+	return last
+}
+
+// dispatch 按重叠策略启动一次执行，返回执行结束时关闭的通道，被跳过或排队时返回 nil；queue 强制排队
+// dispatch starts a run under the overlap policy and returns a channel closed when it finishes, or nil
+// when the run was skipped or queued; queue forces queueing
+func (j *job) dispatch(at time.Time, queue bool) <-chan struct{} {
+	j.mu.Lock()
+	if j.running > 0 {
+		switch {
+		case queue || j.overlap == OverlapQueue:
+			j.pending = append(j.pending, at)
+			j.mu.Unlock()
+			return nil
+		case j.overlap == OverlapSkip:
+			j.mu.Unlock()
+			j.record(Execution{Job: j.name, Scheduled: at, Status: StatusSkipped, Err: ErrOverlap})
+			return nil
+		}
+	}
+	j.running++
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	j.s.running.Add(1)
+	go func() {
+		defer j.s.running.Done()
+		defer close(done)
+		for {
+			j.execute(at)
+			j.mu.Lock()
+			if len(j.pending) == 0 || j.stopped() {
+				j.pending = nil
+				j.running--
+				j.mu.Unlock()
+				return
+			}
+			at = j.pending[0]
+			j.pending = j.pending[1:]
+			j.mu.Unlock()
+		}
+	}()
+	return done
+}
+
+// execute 抢占租约并执行一次任务
+// execute claims the lease and runs the job once
+func (j *job) execute(at time.Time) {
+	e := Execution{Job: j.name, Scheduled: at}
+	ctx := j.s.ctx
+	if j.s.lease != nil {
+		ok, err := j.s.lease.Acquire(ctx, j.name, at, j.s.leaseTTL)
+		if err != nil || !ok {
+			e.Status, e.Err = StatusSkipped, ErrLeaseHeld
+			if err != nil {
+				e.Status, e.Err = StatusFailed, fmt.Errorf("schedule: acquire lease: %w", err)
+			}
+			j.record(e)
+			return
+		}
+	}
+	ctx = context.WithValue(ctx, scheduledKey{}, at)
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+	e.Started = time.Now()
+	e.Err = j.call(ctx)
+	e.Finished = time.Now()
+	if e.Err != nil {
+		e.Status = StatusFailed
+	}
+	j.mu.Lock()
+	j.prev = at
+	j.mu.Unlock()
+	j.record(e)
+}
+
+func (j *job) call(ctx context.Context) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = pool.NewPanicError(v)
+		}
+	}()
+	return j.fn(ctx)
+}
+
+func (j *job) record(e Execution) {
+	if limit := j.s.historyLimit; limit > 0 {
+		j.mu.Lock()
+		if len(j.history) >= limit {
+			j.history = slices.Delete(j.history, 0, len(j.history)-limit+1)
+		}
+		j.history = append(j.history, e)
+		j.mu.Unlock()
+	}
+	if j.s.listener != nil {
+		j.s.listener(e)
+	}
+}
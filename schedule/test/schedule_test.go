@@ -0,0 +1,316 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/karosown/katool-go/pool"
+	"github.com/karosown/katool-go/schedule"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d, h, min, sec int) time.Time {
+	return time.Date(y, m, d, h, min, sec, 0, time.UTC)
+}
+
+// 测试 cron 表达式解析与下一次触发时间
+func TestCron(t *testing.T) {
+	cases := []struct {
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{"*/15 * * * * *", date(2024, 1, 1, 0, 0, 7), []time.Time{date(2024, 1, 1, 0, 0, 15), date(2024, 1, 1, 0, 0, 30)}},
+		{"0 30 9 * * MON-FRI", date(2024, 1, 5, 10, 0, 0), []time.Time{date(2024, 1, 8, 9, 30, 0), date(2024, 1, 9, 9, 30, 0)}},
+		{"0 0 1 * *", date(2024, 1, 15, 0, 0, 0), []time.Time{date(2024, 2, 1, 0, 0, 0), date(2024, 3, 1, 0, 0, 0)}},
+		{"0 0 0 13 * FRI", date(2024, 1, 1, 0, 0, 0), []time.Time{date(2024, 1, 5, 0, 0, 0), date(2024, 1, 12, 0, 0, 0), date(2024, 1, 13, 0, 0, 0)}},
+		{"0 0 0 * * 7", date(2024, 1, 1, 0, 0, 0), []time.Time{date(2024, 1, 7, 0, 0, 0)}},
+		{"0 0 12 29 feb ?", date(2024, 3, 1, 0, 0, 0), []time.Time{date(2028, 2, 29, 12, 0, 0)}},
+		{"@hourly", date(2024, 1, 1, 0, 59, 59), []time.Time{date(2024, 1, 1, 1, 0, 0)}},
+		{"TZ=Asia/Shanghai 0 0 8 * * *", date(2023, 12, 31, 23, 0, 0), []time.Time{date(2024, 1, 1, 0, 0, 0)}},
+		{"0 0 0 30 2 *", date(2024, 1, 1, 0, 0, 0), []time.Time{{}}},
+	}
+	for _, c := range cases {
+		trigger, err := schedule.CronIn(c.expr, time.UTC)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		prev := c.from
+		for _, want := range c.want {
+			next := trigger.Next(prev)
+			assert.True(t, want.Equal(next), "%s: want %v, got %v", c.expr, want, next)
+			prev = next
+		}
+	}
+
+	for _, expr := range []string{"61 * * * * *", "* * *", "a b c d e", "5-1 * * * *", "*/0 * * * *", "TZ=Nowhere/City * * * * *"} {
+		_, err := schedule.Cron(expr)
+		assert.Error(t, err, expr)
+	}
+	assert.Panics(t, func() { schedule.MustCron("bad") })
+
+	every, err := schedule.Cron("@every 90m")
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.FixedRate(90*time.Minute), every)
+}
+
+// 测试夏令时跳过的时刻
+func TestCronDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	trigger, err := schedule.CronIn("0 30 2 * * *", ny)
+	assert.NoError(t, err)
+	next := trigger.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, ny))
+	assert.True(t, time.Date(2024, 3, 11, 2, 30, 0, 0, ny).Equal(next), next)
+}
+
+// 测试固定频率对齐与 Once
+func TestTriggers(t *testing.T) {
+	r := schedule.FixedRate(5 * time.Minute)
+	assert.True(t, date(2024, 1, 1, 10, 5, 0).Equal(r.Next(date(2024, 1, 1, 10, 3, 12))))
+	assert.True(t, date(2024, 1, 1, 10, 10, 0).Equal(r.Next(date(2024, 1, 1, 10, 5, 0))))
+
+	at := date(2024, 1, 1, 0, 0, 0)
+	once := schedule.Once(at)
+	assert.True(t, at.Equal(once.Next(at.Add(-time.Second))))
+	assert.True(t, once.Next(at).IsZero())
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	execs []schedule.Execution
+}
+
+func (r *recorder) add(e schedule.Execution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execs = append(r.execs, e)
+}
+
+func (r *recorder) count(status schedule.Status, err error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, e := range r.execs {
+		if e.Status == status && (err == nil || errors.Is(e.Err, err)) {
+			n++
+		}
+	}
+	return n
+}
+
+// 测试基本调度、历史与任务状态
+func TestSchedulerRuns(t *testing.T) {
+	s := schedule.New(schedule.WithHistoryLimit(3))
+	var runs atomic.Int32
+	assert.NoError(t, s.Add("tick", schedule.FixedRate(10*time.Millisecond), func(ctx context.Context) error {
+		at, ok := schedule.ScheduledTime(ctx)
+		assert.True(t, ok)
+		assert.Zero(t, at.UnixNano()%int64(10*time.Millisecond))
+		runs.Add(1)
+		return nil
+	}))
+	assert.ErrorIs(t, s.Add("tick", schedule.FixedRate(time.Second), nil), schedule.ErrDuplicateJob)
+	s.Start()
+	assert.Eventually(t, func() bool { return runs.Load() >= 5 }, 2*time.Second, time.Millisecond)
+	assert.NoError(t, s.Stop(context.Background()))
+
+	history := s.History("tick")
+	assert.Len(t, history, 3)
+	for _, e := range history {
+		assert.Equal(t, schedule.StatusSucceeded, e.Status)
+	}
+	jobs := s.Jobs()
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "tick", jobs[0].Name)
+	assert.False(t, jobs[0].Prev.IsZero())
+	assert.ErrorIs(t, s.RunNow("tick"), schedule.ErrStopped)
+}
+
+// 测试重叠策略
+func TestOverlap(t *testing.T) {
+	rec := &recorder{}
+	s := schedule.New(schedule.WithListener(rec.add))
+	release := make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+	assert.NoError(t, s.Add("skip", schedule.FixedRate(time.Hour), block))
+	assert.NoError(t, s.Add("queue", schedule.FixedRate(time.Hour), block, schedule.WithOverlap(schedule.OverlapQueue)))
+	assert.NoError(t, s.Add("allow", schedule.FixedRate(time.Hour), block, schedule.WithOverlap(schedule.OverlapAllow)))
+	for _, name := range []string{"skip", "queue", "allow"} {
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, s.RunNow(name))
+		}
+	}
+	assert.ErrorIs(t, s.RunNow("missing"), schedule.ErrJobNotFound)
+
+	info := map[string]schedule.JobInfo{}
+	for _, j := range s.Jobs() {
+		info[j.Name] = j
+	}
+	assert.Equal(t, 1, info["skip"].Running)
+	assert.Equal(t, 1, info["queue"].Running)
+	assert.Equal(t, 2, info["queue"].Pending)
+	assert.Equal(t, 3, info["allow"].Running)
+	assert.Equal(t, 2, rec.count(schedule.StatusSkipped, schedule.ErrOverlap))
+
+	close(release)
+	assert.Eventually(t, func() bool { return len(s.History("queue")) == 3 }, time.Second, time.Millisecond)
+	assert.NoError(t, s.Stop(context.Background()))
+	assert.Equal(t, 1+3+3, rec.count(schedule.StatusSucceeded, nil))
+}
+
+// 测试错过执行的补跑策略
+func TestMisfire(t *testing.T) {
+	now := time.Now()
+	from := now.Add(-5*time.Hour - 30*time.Minute)
+	missed := 0
+	for b := schedule.FixedRate(time.Hour).Next(from); !b.After(now); b = b.Add(time.Hour) {
+		missed++
+	}
+
+	for _, c := range []struct {
+		misfire schedule.Misfire
+		runs    int
+	}{
+		{schedule.MisfireRunAll, missed},
+		{schedule.MisfireRunOnce, 1},
+		{schedule.MisfireSkip, 0},
+	} {
+		rec := &recorder{}
+		s := schedule.New(schedule.WithListener(rec.add))
+		assert.NoError(t, s.Add("job", schedule.FixedRate(time.Hour), func(ctx context.Context) error { return nil },
+			schedule.WithCatchUpFrom(from), schedule.WithMisfire(c.misfire), schedule.WithMisfireThreshold(time.Nanosecond)))
+		s.Start()
+		assert.Eventually(t, func() bool {
+			return rec.count(schedule.StatusSucceeded, nil)+rec.count(schedule.StatusSkipped, nil) >= max(c.runs, 1)
+		}, time.Second, time.Millisecond)
+		assert.NoError(t, s.Stop(context.Background()))
+		assert.Equal(t, c.runs, rec.count(schedule.StatusSucceeded, nil), c.misfire)
+		if c.misfire == schedule.MisfireSkip {
+			assert.Equal(t, 1, rec.count(schedule.StatusSkipped, schedule.ErrMissed))
+		}
+		if c.misfire == schedule.MisfireRunAll {
+			h := s.History("job")
+			for i := 1; i < len(h); i++ {
+				assert.Equal(t, time.Hour, h[i].Scheduled.Sub(h[i-1].Scheduled))
+			}
+		}
+	}
+}
+
+// 测试固定延迟不会重叠
+func TestFixedDelay(t *testing.T) {
+	s := schedule.New()
+	var running, overlapped, runs atomic.Int32
+	assert.NoError(t, s.Add("delay", schedule.FixedDelay(5*time.Millisecond), func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		time.Sleep(15 * time.Millisecond)
+		running.Add(-1)
+		runs.Add(1)
+		return nil
+	}))
+	s.Start()
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, 2*time.Second, time.Millisecond)
+	assert.NoError(t, s.Stop(context.Background()))
+	assert.Zero(t, overlapped.Load())
+}
+
+// 测试任务超时、panic 与强制停止
+func TestJobContext(t *testing.T) {
+	rec := &recorder{}
+	s := schedule.New(schedule.WithListener(rec.add))
+	assert.NoError(t, s.Add("timeout", schedule.FixedRate(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, schedule.WithTimeout(10*time.Millisecond)))
+	assert.NoError(t, s.Add("panic", schedule.FixedRate(time.Hour), func(ctx context.Context) error {
+		panic("boom")
+	}))
+	cancelled := make(chan struct{})
+	assert.NoError(t, s.Add("stuck", schedule.FixedRate(time.Hour), func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return nil
+	}))
+	assert.NoError(t, s.RunNow("timeout"))
+	assert.NoError(t, s.RunNow("panic"))
+	assert.NoError(t, s.RunNow("stuck"))
+	assert.Eventually(t, func() bool { return rec.count(schedule.StatusFailed, nil) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, rec.count(schedule.StatusFailed, context.DeadlineExceeded))
+	var pe *pool.PanicError
+	assert.ErrorAs(t, s.History("panic")[0].Err, &pe)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("job ctx not cancelled")
+	}
+}
+
+// 测试租约保证每次执行只在一个调度器上运行
+func TestLease(t *testing.T) {
+	lease := schedule.NewMemoryLease()
+	rec := &recorder{}
+	var mu sync.Mutex
+	seen := map[time.Time]int{}
+	job := func(ctx context.Context) error {
+		at, _ := schedule.ScheduledTime(ctx)
+		mu.Lock()
+		seen[at]++
+		mu.Unlock()
+		return nil
+	}
+	a := schedule.New(schedule.WithLease(lease, time.Minute), schedule.WithListener(rec.add))
+	b := schedule.New(schedule.WithLease(lease, time.Minute), schedule.WithListener(rec.add))
+	for _, s := range []*schedule.Scheduler{a, b} {
+		assert.NoError(t, s.Add("job", schedule.FixedRate(10*time.Millisecond), job))
+		s.Start()
+	}
+	assert.Eventually(t, func() bool { return rec.count(schedule.StatusSucceeded, nil) >= 5 }, 2*time.Second, time.Millisecond)
+	assert.NoError(t, a.Stop(context.Background()))
+	assert.NoError(t, b.Stop(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	for at, n := range seen {
+		assert.Equal(t, 1, n, at)
+	}
+	assert.Positive(t, rec.count(schedule.StatusSkipped, schedule.ErrLeaseHeld))
+}
+
+// 测试 Redis 租约（需要 REDIS_ADDR）
+func TestRedisLease(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	lease := schedule.NewRedisLease(client, "katool:test:schedule:"+strconv.FormatInt(time.Now().UnixNano(), 36)+":")
+	at := time.Now()
+	ok, err := lease.Acquire(context.Background(), "job", at, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = lease.Acquire(context.Background(), "job", at, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, _ = lease.Acquire(context.Background(), "job", at.Add(time.Second), time.Minute)
+	assert.True(t, ok)
+}
@@ -0,0 +1,77 @@
+package schedule
+
+// 定时任务调度：cron 表达式（含秒与时区）、固定频率与固定延迟触发，支持重叠策略、错过补跑、执行历史与分布式租约
+//
+// Package schedule runs periodic jobs on cron expressions (with seconds and time zones), fixed-rate and
+// fixed-delay triggers, with overlap policies, missed-run catch-up, execution history and distributed leases
+
+import "time"
+
+// Trigger 计算下一次触发时间，没有下一次时返回零值
+// Trigger computes the next fire time, returning the zero time when there is none
+type Trigger interface {
+	Next(prev time.Time) time.Time
+}
+
+// TriggerFunc 函数形式的触发器
+// TriggerFunc adapts a function to a Trigger
+type TriggerFunc func(prev time.Time) time.Time
+
+func (f TriggerFunc) Next(prev time.Time) time.Time {
+	return f(prev)
+}
+
+// fixedRate 固定频率触发器
+// fixedRate fires at a fixed rate
+type fixedRate struct {
+	interval time.Duration
+}
+
+// FixedRate 固定频率触发，触发时间对齐到 interval 的整数倍（自 Unix 纪元起），因此集群中各实例算出的时间一致
+// FixedRate fires at a fixed rate; fire times are aligned to multiples of interval since the Unix epoch
+// so every instance in a cluster computes the same times
+func FixedRate(interval time.Duration) Trigger {
+	if interval <= 0 {
+		panic("schedule: non-positive interval for FixedRate")
+	}
+	return fixedRate{interval: interval}
+}
+
+func (r fixedRate) Next(prev time.Time) time.Time {
+	n := prev.UnixNano()
+	i := int64(r.interval)
+	next := n - n%i + i
+	return time.Unix(0, next).In(prev.Location())
+}
+
+// fixedDelay 固定延迟触发器
+// fixedDelay fires a fixed delay after the previous run finished
+type fixedDelay struct {
+	delay time.Duration
+}
+
+// FixedDelay 上一次执行结束后等待 delay 再触发，同一任务不会重叠；各实例的触发时间不同，租约无法跨实例去重
+// FixedDelay fires delay after the previous run finished, so runs never overlap. Fire times differ per
+// instance, so leases cannot deduplicate them across a cluster
+func FixedDelay(delay time.Duration) Trigger {
+	if delay <= 0 {
+		panic("schedule: non-positive delay for FixedDelay")
+	}
+	return fixedDelay{delay: delay}
+}
+
+// Next prev 为上一次执行的结束时间 / prev is when the previous run finished
+func (d fixedDelay) Next(prev time.Time) time.Time {
+	return prev.Add(d.delay)
+}
+
+// Once 只在 at 触发一次
+// Once fires a single time at at
+func Once(at time.Time) Trigger {
+	return TriggerFunc(func(prev time.Time) time.Time {
+		if prev.Before(at) {
+			return at
+		}
+		return time.Time{}
+	})
+}
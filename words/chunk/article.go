@@ -0,0 +1,24 @@
+package chunk
+
+import (
+	"github.com/karosown/katool-go/web_crawler"
+)
+
+// FromArticle 把爬取到的文章转换为Document，url作为引用来源
+// FromArticle converts a crawled article into a Document, url is used as the citation source
+func FromArticle(url string, article web_crawler.Article) Document {
+	return Document{
+		Source: url,
+		Title:  article.Title,
+		Text:   article.TextContent,
+	}
+}
+
+// SplitArticle 切分爬取到的文章；文章读取失败时返回空结果和对应错误
+// SplitArticle splits a crawled article, returns the reading error when the article failed
+func SplitArticle(url string, article web_crawler.Article, splitter Splitter) (Chunks, error) {
+	if article.IsErr() {
+		return nil, article.WebReaderError
+	}
+	return splitter.Split(FromArticle(url, article)), nil
+}
@@ -0,0 +1,243 @@
+package chunk
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/karosown/katool-go/words/split"
+)
+
+// Document 待切分的文档
+// Document is the source document to be split
+type Document struct {
+	Source string // 来源（通常是URL）/ Source, usually the URL
+	Title  string // 文档标题 / Document title
+	Text   string // 文档正文 / Document body
+}
+
+// Metadata 分块元数据，用于引用溯源
+// Metadata carries chunk metadata used for citation
+type Metadata struct {
+	Source      string   `json:"source,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	HeadingPath []string `json:"heading_path,omitempty"`
+	Index       int      `json:"index"`
+	Start       int      `json:"start"` // 在Document.Text中的起始字节偏移 / start byte offset in Document.Text
+	End         int      `json:"end"`   // 在Document.Text中的结束字节偏移 / end byte offset in Document.Text
+	Tokens      int      `json:"tokens"`
+}
+
+// Chunk 文本块
+// Chunk is a piece of text with its metadata
+type Chunk struct {
+	Text     string   `json:"text"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Chunks 文本块集合
+// Chunks is a collection of chunks
+type Chunks []Chunk
+
+// Texts 返回所有块的文本
+// Texts returns the text of every chunk
+func (c Chunks) Texts() []string {
+	res := make([]string, 0, len(c))
+	for _, item := range c {
+		res = append(res, item.Text)
+	}
+	return res
+}
+
+// Splitter 文本切分器
+// Splitter splits a document into chunks
+type Splitter interface {
+	Split(doc Document) Chunks
+}
+
+// TokenCounter token计数函数
+// TokenCounter counts tokens of a text
+type TokenCounter func(text string) int
+
+// Cutter 分词器接口，jieba.Client 与 cgojieba.Client 均满足该接口
+// Cutter is a word segmenter, satisfied by jieba.Client and cgojieba.Client
+type Cutter interface {
+	Cut(text string) split.SplitStrings
+}
+
+// RuneCounter 按字符数计数
+// RuneCounter counts runes
+func RuneCounter(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// WordCounter 按单词计数（中文每个汉字算一个词，其余按空白分隔）
+// WordCounter counts words, every Han rune counts as one word and the rest are separated by spaces
+func WordCounter(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			count++
+			inWord = false
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			inWord = false
+		default:
+			if !inWord {
+				count++
+			}
+			inWord = true
+		}
+	}
+	return count
+}
+
+// CutterCounter 使用分词器计数，忽略空白与标点
+// CutterCounter counts tokens produced by a Cutter, ignoring spaces and punctuation
+func CutterCounter(cutter Cutter) TokenCounter {
+	return func(text string) int {
+		count := 0
+		for _, token := range cutter.Cut(text) {
+			if strings.TrimFunc(token, func(r rune) bool {
+				return unicode.IsSpace(r) || unicode.IsPunct(r)
+			}) != "" {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+// Config 切分配置
+// Config is the splitting configuration
+type Config struct {
+	ChunkSize int          // 每块最大token数 / max tokens per chunk
+	Overlap   int          // 相邻块重叠token数 / overlapping tokens between adjacent chunks
+	Counter   TokenCounter // token计数函数 / token counter
+}
+
+// Option 切分配置选项
+// Option customizes Config
+type Option func(*Config)
+
+// WithChunkSize 设置每块最大token数
+// WithChunkSize sets the max tokens per chunk
+func WithChunkSize(size int) Option {
+	return func(c *Config) {
+		if size > 0 {
+			c.ChunkSize = size
+		}
+	}
+}
+
+// WithOverlap 设置相邻块重叠token数
+// WithOverlap sets the overlapping tokens between adjacent chunks
+func WithOverlap(overlap int) Option {
+	return func(c *Config) {
+		if overlap >= 0 {
+			c.Overlap = overlap
+		}
+	}
+}
+
+// WithCounter 设置token计数函数
+// WithCounter sets the token counter
+func WithCounter(counter TokenCounter) Option {
+	return func(c *Config) {
+		if counter != nil {
+			c.Counter = counter
+		}
+	}
+}
+
+// newConfig 创建默认配置并应用选项
+// newConfig creates the default config and applies options
+func newConfig(opts ...Option) Config {
+	cfg := Config{
+		ChunkSize: 512,
+		Overlap:   64,
+		Counter:   RuneCounter,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.Overlap >= cfg.ChunkSize {
+		cfg.Overlap = cfg.ChunkSize / 2
+	}
+	return cfg
+}
+
+// span 文本中的一个区间 [start, end)
+// span is a [start, end) range in the text
+type span struct {
+	start, end int
+	tokens     int
+}
+
+// mergeSpans 将连续的小区间合并为不超过ChunkSize的块，并保留Overlap个token的重叠
+// mergeSpans merges consecutive spans into chunks no larger than ChunkSize, keeping Overlap tokens of overlap
+func mergeSpans(spans []span, cfg Config) []span {
+	res := make([]span, 0)
+	window := make([]span, 0)
+	total := 0
+	flush := func() {
+		if len(window) == 0 {
+			return
+		}
+		res = append(res, span{start: window[0].start, end: window[len(window)-1].end, tokens: total})
+	}
+	for _, sp := range spans {
+		if total+sp.tokens > cfg.ChunkSize && len(window) > 0 {
+			flush()
+			// 从头部丢弃，直到剩余部分不超过overlap且能容纳新区间
+			// drop from the head until the tail fits the overlap and the next span
+			for len(window) > 0 && (total > cfg.Overlap || total+sp.tokens > cfg.ChunkSize) {
+				total -= window[0].tokens
+				window = window[1:]
+			}
+		}
+		window = append(window, sp)
+		total += sp.tokens
+	}
+	if len(window) > 0 && (len(res) == 0 || window[len(window)-1].end > res[len(res)-1].end) {
+		flush()
+	}
+	return res
+}
+
+// toChunks 把区间转换为Chunk，去除首尾空白并修正偏移
+// toChunks converts spans into chunks, trimming surrounding spaces and adjusting offsets
+func toChunks(doc Document, text string, base int, spans []span, headingPath []string, cfg Config) Chunks {
+	res := make(Chunks, 0, len(spans))
+	for _, sp := range spans {
+		raw := text[sp.start:sp.end]
+		trimmedLeft := strings.TrimLeftFunc(raw, unicode.IsSpace)
+		start := sp.start + len(raw) - len(trimmedLeft)
+		trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+		if trimmed == "" {
+			continue
+		}
+		res = append(res, Chunk{
+			Text: trimmed,
+			Metadata: Metadata{
+				Source:      doc.Source,
+				Title:       doc.Title,
+				HeadingPath: append([]string(nil), headingPath...),
+				Start:       base + start,
+				End:         base + start + len(trimmed),
+				Tokens:      cfg.Counter(trimmed),
+			},
+		})
+	}
+	return res
+}
+
+// reindex 重新设置块序号
+// reindex resets chunk indexes
+func reindex(chunks Chunks) Chunks {
+	for i := range chunks {
+		chunks[i].Metadata.Index = i
+	}
+	return chunks
+}
@@ -0,0 +1,88 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/karosown/katool-go/util/markdown"
+)
+
+// reHeading 与 markdown.ToTree 使用相同的标题规则
+// reHeading uses the same heading rule as markdown.ToTree
+var reHeading = regexp.MustCompile(`(?m)^#{1,6}\s*.+`)
+
+// MarkdownSplitter 按Markdown标题切分，每个块携带所在的标题路径；过长的章节交给Inner继续切分
+// MarkdownSplitter splits on markdown headings, every chunk carries its heading path; oversized sections are split by Inner
+type MarkdownSplitter struct {
+	Inner *RecursiveSplitter
+	// IncludeHeading 为true时块文本以标题行开头，便于模型理解上下文
+	// IncludeHeading prefixes the chunk text with the heading line so the model keeps the context
+	IncludeHeading bool
+	cfg            Config
+}
+
+// NewMarkdownSplitter 创建Markdown切分器
+// NewMarkdownSplitter creates a markdown splitter
+func NewMarkdownSplitter(opts ...Option) *MarkdownSplitter {
+	return &MarkdownSplitter{
+		Inner: NewRecursiveSplitter(nil, opts...),
+		cfg:   newConfig(opts...),
+	}
+}
+
+// Split 切分文档
+// Split splits the document
+func (s *MarkdownSplitter) Split(doc Document) Chunks {
+	res := make(Chunks, 0)
+	text := doc.Text
+	cursor := 0
+	// markdown.ToTree 会丢弃第一个标题之前的内容，这里单独处理
+	// markdown.ToTree drops the content before the first heading, handle it here
+	if loc := reHeading.FindStringIndex(text); loc == nil {
+		return s.Inner.Split(doc)
+	} else if loc[0] > 0 {
+		res = append(res, s.Inner.splitText(doc, text[:loc[0]], 0, nil)...)
+		cursor = loc[0]
+	}
+	var walk func(tree markdown.Tree, path []string)
+	walk = func(tree markdown.Tree, path []string) {
+		for _, node := range tree {
+			nodePath := append(append([]string(nil), path...), node.Title)
+			headerAt := strings.Index(text[cursor:], node.Title)
+			if headerAt < 0 {
+				continue
+			}
+			cursor += headerAt + len(node.Title)
+			if nl := strings.IndexByte(text[cursor:], '\n'); nl >= 0 {
+				cursor += nl + 1
+			} else {
+				cursor = len(text)
+			}
+			if node.Content != "" {
+				contentAt := strings.Index(text[cursor:], node.Content)
+				if contentAt >= 0 {
+					start := cursor + contentAt
+					res = append(res, s.section(doc, node, start, nodePath)...)
+					cursor = start + len(node.Content)
+				}
+			}
+			walk(node.Children, nodePath)
+		}
+	}
+	walk(markdown.ToTree(text), nil)
+	return reindex(res)
+}
+
+// section 切分单个章节的正文
+// section splits the body of a single section
+func (s *MarkdownSplitter) section(doc Document, node *markdown.Node, start int, path []string) Chunks {
+	chunks := s.Inner.splitText(doc, node.Content, start, path)
+	if s.IncludeHeading {
+		heading := strings.Repeat("#", node.Level) + " " + node.Title + "\n"
+		for i := range chunks {
+			chunks[i].Text = heading + chunks[i].Text
+			chunks[i].Metadata.Tokens = s.cfg.Counter(chunks[i].Text)
+		}
+	}
+	return chunks
+}
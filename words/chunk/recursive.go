@@ -0,0 +1,95 @@
+package chunk
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSeparators 默认的递归分隔符，从段落到字符逐级细化
+// DefaultSeparators are the default recursive separators, from paragraphs down to runes
+var DefaultSeparators = []string{"\n\n", "\n", "。", "！", "？", ". ", "! ", "? ", "；", "; ", "，", ", ", " ", ""}
+
+// RecursiveSplitter 按分隔符递归切分，优先使用粒度更粗的分隔符
+// RecursiveSplitter splits recursively by separators, preferring coarser separators first
+type RecursiveSplitter struct {
+	Separators []string
+	cfg        Config
+}
+
+// NewRecursiveSplitter 创建递归切分器，separators为空时使用DefaultSeparators
+// NewRecursiveSplitter creates a recursive splitter, DefaultSeparators is used when separators is empty
+func NewRecursiveSplitter(separators []string, opts ...Option) *RecursiveSplitter {
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	return &RecursiveSplitter{
+		Separators: separators,
+		cfg:        newConfig(opts...),
+	}
+}
+
+// Split 切分文档
+// Split splits the document
+func (s *RecursiveSplitter) Split(doc Document) Chunks {
+	return reindex(s.splitText(doc, doc.Text, 0, nil))
+}
+
+// splitText 切分文档中的一段文本，base为该段在Document.Text中的偏移
+// splitText splits a piece of the document text, base is its offset in Document.Text
+func (s *RecursiveSplitter) splitText(doc Document, text string, base int, headingPath []string) Chunks {
+	spans := s.split(text, 0, len(text), s.Separators)
+	return toChunks(doc, text, base, mergeSpans(spans, s.cfg), headingPath, s.cfg)
+}
+
+// split 递归切分text[start:end]，返回每个都不超过ChunkSize的最小区间
+// split recursively splits text[start:end] into spans that each fit in ChunkSize
+func (s *RecursiveSplitter) split(text string, start, end int, separators []string) []span {
+	res := make([]span, 0)
+	segment := text[start:end]
+	if tokens := s.cfg.Counter(segment); tokens <= s.cfg.ChunkSize {
+		return append(res, span{start: start, end: end, tokens: tokens})
+	}
+	sep, rest := "", []string(nil)
+	for i, item := range separators {
+		if item == "" || strings.Contains(segment, item) {
+			sep, rest = item, separators[i+1:]
+			break
+		}
+	}
+	for _, piece := range cutKeepSeparator(segment, sep) {
+		pieceStart, pieceEnd := start+piece.start, start+piece.end
+		tokens := s.cfg.Counter(text[pieceStart:pieceEnd])
+		if tokens <= s.cfg.ChunkSize || sep == "" {
+			res = append(res, span{start: pieceStart, end: pieceEnd, tokens: tokens})
+			continue
+		}
+		res = append(res, s.split(text, pieceStart, pieceEnd, rest)...)
+	}
+	return res
+}
+
+// cutKeepSeparator 按分隔符切分，分隔符保留在前一段末尾，sep为空时按字符切分
+// cutKeepSeparator cuts by separator keeping it at the end of the previous piece, cuts by rune when sep is empty
+func cutKeepSeparator(text, sep string) []span {
+	res := make([]span, 0)
+	if sep == "" {
+		for i := 0; i < len(text); {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			res = append(res, span{start: i, end: i + size})
+			i += size
+		}
+		return res
+	}
+	begin := 0
+	for begin < len(text) {
+		idx := strings.Index(text[begin:], sep)
+		if idx < 0 {
+			res = append(res, span{start: begin, end: len(text)})
+			break
+		}
+		end := begin + idx + len(sep)
+		res = append(res, span{start: begin, end: end})
+		begin = end
+	}
+	return res
+}
@@ -0,0 +1,149 @@
+package chunk
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// sentenceEnds 句末标点（中英文）
+// sentenceEnds are sentence-ending punctuations (Chinese and English)
+var sentenceEnds = map[string]bool{
+	"。": true, "！": true, "？": true, "；": true, "…": true,
+	".": true, "!": true, "?": true, ";": true, "\n": true,
+}
+
+// closingMarks 句末标点后可能紧跟的右引号/右括号，应归入同一句
+// closingMarks may follow a sentence end and belong to the same sentence
+var closingMarks = map[string]bool{
+	"”": true, "’": true, "」": true, "』": true, "）": true, ")": true, "\"": true, "'": true,
+}
+
+// SentenceSplitter 按句子切分后再合并到ChunkSize，重叠以整句为单位
+// SentenceSplitter splits by sentence then merges up to ChunkSize, the overlap is made of whole sentences
+type SentenceSplitter struct {
+	// Cutter 为空时按字符扫描标点；设置后使用分词结果识别句末，避免切断英文缩写与数字
+	// Cutter is optional; when set, sentence ends are detected on its tokens so abbreviations and numbers stay intact
+	Cutter Cutter
+	cfg    Config
+}
+
+// NewSentenceSplitter 创建句子切分器，cutter可以是 jieba.New() 返回的客户端
+// NewSentenceSplitter creates a sentence splitter, cutter can be the client returned by jieba.New()
+func NewSentenceSplitter(cutter Cutter, opts ...Option) *SentenceSplitter {
+	return &SentenceSplitter{
+		Cutter: cutter,
+		cfg:    newConfig(opts...),
+	}
+}
+
+// Split 切分文档
+// Split splits the document
+func (s *SentenceSplitter) Split(doc Document) Chunks {
+	return reindex(s.splitText(doc, doc.Text, 0, nil))
+}
+
+// Sentences 返回文本中每个句子的区间
+// Sentences returns the sentences of the text
+func (s *SentenceSplitter) Sentences(text string) []string {
+	spans := s.sentences(text)
+	res := make([]string, 0, len(spans))
+	for _, sp := range spans {
+		if sentence := strings.TrimSpace(text[sp.start:sp.end]); sentence != "" {
+			res = append(res, sentence)
+		}
+	}
+	return res
+}
+
+// splitText 切分文档中的一段文本
+// splitText splits a piece of the document text
+func (s *SentenceSplitter) splitText(doc Document, text string, base int, headingPath []string) Chunks {
+	// 超长的句子再按分隔符递归切开
+	// oversized sentences are split further by separators
+	fallback := &RecursiveSplitter{Separators: DefaultSeparators, cfg: s.cfg}
+	spans := make([]span, 0)
+	for _, sp := range s.sentences(text) {
+		spans = append(spans, fallback.split(text, sp.start, sp.end, fallback.Separators)...)
+	}
+	return toChunks(doc, text, base, mergeSpans(spans, s.cfg), headingPath, s.cfg)
+}
+
+// sentences 计算句子区间
+// sentences computes sentence spans
+func (s *SentenceSplitter) sentences(text string) []span {
+	tokens := s.tokens(text)
+	res := make([]span, 0)
+	begin := 0
+	ended := false
+	for i, tk := range tokens {
+		if ended && !closingMarks[text[tk.start:tk.end]] && !sentenceEnds[text[tk.start:tk.end]] {
+			res = append(res, span{start: begin, end: tokens[i-1].end})
+			begin = tokens[i-1].end
+			ended = false
+		}
+		if sentenceEnds[text[tk.start:tk.end]] && !s.isInlineDot(text, tk) {
+			ended = true
+		}
+	}
+	if begin < len(text) {
+		res = append(res, span{start: begin, end: len(text)})
+	}
+	return res
+}
+
+// isInlineDot 英文句点后若不是空白或文本结尾（如 3.14、example.com），则不视为句末
+// isInlineDot reports whether an English dot is inside a word such as 3.14 or example.com
+func (s *SentenceSplitter) isInlineDot(text string, tk span) bool {
+	if text[tk.start:tk.end] != "." || tk.end >= len(text) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(text[tk.end:])
+	return next != ' ' && next != '\n' && next != '\t' && next != '\r' && !closingMarks[string(next)]
+}
+
+// tokens 将文本拆为token区间；有分词器时使用分词结果，否则按字符
+// tokens splits the text into token spans, using the Cutter when present and runes otherwise
+func (s *SentenceSplitter) tokens(text string) []span {
+	res := make([]span, 0)
+	if s.Cutter == nil {
+		return cutKeepSeparator(text, "")
+	}
+	cursor := 0
+	for _, word := range s.Cutter.Cut(text) {
+		idx := strings.Index(text[cursor:], word)
+		if word == "" || idx < 0 {
+			continue
+		}
+		// 分词结果之间遗漏的字符按单字符补齐
+		// runes skipped by the cutter are added one by one
+		for _, gap := range cutKeepSeparator(text[cursor:cursor+idx], "") {
+			res = append(res, span{start: cursor + gap.start, end: cursor + gap.end})
+		}
+		start := cursor + idx
+		// 句末标点可能与其它字符粘连在同一个词中，拆开处理
+		// a sentence end may be glued to other runes in one token, split them apart
+		if utf8.RuneCountInString(word) > 1 && containsSentenceEnd(word) {
+			for _, r := range cutKeepSeparator(word, "") {
+				res = append(res, span{start: start + r.start, end: start + r.end})
+			}
+		} else {
+			res = append(res, span{start: start, end: start + len(word)})
+		}
+		cursor = start + len(word)
+	}
+	for _, gap := range cutKeepSeparator(text[cursor:], "") {
+		res = append(res, span{start: cursor + gap.start, end: cursor + gap.end})
+	}
+	return res
+}
+
+// containsSentenceEnd 判断词中是否包含句末标点
+// containsSentenceEnd reports whether the word contains a sentence end
+func containsSentenceEnd(word string) bool {
+	for _, r := range word {
+		if sentenceEnds[string(r)] {
+			return true
+		}
+	}
+	return false
+}
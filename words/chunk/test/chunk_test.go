@@ -0,0 +1,51 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/karosown/katool-go/words/chunk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecursiveSplitter(t *testing.T) {
+	text := strings.Repeat("第一段内容，包含一些文字。", 5) + "\n\n" + strings.Repeat("Second paragraph with words. ", 5)
+	doc := chunk.Document{Source: "https://example.com/a", Title: "A", Text: text}
+	chunks := chunk.NewRecursiveSplitter(nil, chunk.WithChunkSize(40), chunk.WithOverlap(15)).Split(doc)
+	assert.True(t, len(chunks) > 1)
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Metadata.Index)
+		assert.Equal(t, "https://example.com/a", c.Metadata.Source)
+		assert.LessOrEqual(t, c.Metadata.Tokens, 40)
+		assert.Equal(t, c.Text, text[c.Metadata.Start:c.Metadata.End])
+	}
+	// 相邻块之间存在重叠
+	assert.Less(t, chunks[1].Metadata.Start, chunks[0].Metadata.End)
+}
+
+func TestMarkdownSplitter(t *testing.T) {
+	md := "前言部分\n# 标题一\n内容一\n## 子标题\n子内容\n# 标题二\n内容二"
+	chunks := chunk.NewMarkdownSplitter(chunk.WithChunkSize(100)).Split(chunk.Document{Text: md})
+	assert.Equal(t, 4, len(chunks))
+	assert.Equal(t, "前言部分", chunks[0].Text)
+	assert.Nil(t, chunks[0].Metadata.HeadingPath)
+	assert.Equal(t, []string{"标题一", "子标题"}, chunks[2].Metadata.HeadingPath)
+	assert.Equal(t, "子内容", chunks[2].Text)
+	for _, c := range chunks {
+		assert.Equal(t, c.Text, md[c.Metadata.Start:c.Metadata.End])
+	}
+}
+
+func TestSentenceSplitter(t *testing.T) {
+	splitter := chunk.NewSentenceSplitter(nil, chunk.WithChunkSize(12), chunk.WithOverlap(0))
+	sentences := splitter.Sentences("今天天气很好。“我们去散步吧！”他说。Pi is 3.14. Done")
+	assert.Equal(t, []string{"今天天气很好。", "“我们去散步吧！”", "他说。", "Pi is 3.14.", "Done"}, sentences)
+
+	text := "今天天气很好。我们去散步吧！公园里人很多。"
+	chunks := splitter.Split(chunk.Document{Text: text})
+	assert.Equal(t, []string{"今天天气很好。", "我们去散步吧！", "公园里人很多。"}, chunks.Texts())
+}
+
+func TestWordCounter(t *testing.T) {
+	assert.Equal(t, 4, chunk.WordCounter("hello, world 你好"))
+}